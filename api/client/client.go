@@ -0,0 +1,280 @@
+// Package client is a Go client for the angee operator's HTTP API. It has a
+// typed method for each endpoint, authenticates with a bearer token,
+// retries reads that fail transiently, and follows the log and event
+// streams.
+//
+//	c := client.New("http://127.0.0.1:9000", client.WithToken(os.Getenv("ANGEE_OPERATOR_TOKEN")))
+//	status, err := c.StackStatus(ctx)
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fyltr/angee/api"
+)
+
+const (
+	// DefaultRetries is how many times a read is retried after a transient
+	// failure.
+	DefaultRetries = 2
+	// DefaultBackoff is the wait before the first retry; it doubles for
+	// each one after.
+	DefaultBackoff = 250 * time.Millisecond
+)
+
+// Client calls an operator. It is safe for concurrent use.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	token      func(context.Context) string
+	caller     string
+	retries    int
+	backoff    time.Duration
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sends requests with c instead of http.DefaultClient.
+func WithHTTPClient(c *http.Client) Option {
+	return func(client *Client) { client.httpClient = c }
+}
+
+// WithToken authenticates requests with the operator token, or any other
+// bearer token the operator accepts.
+func WithToken(token string) Option {
+	return WithTokenFunc(func(context.Context) string { return token })
+}
+
+// WithTokenFunc authenticates each request with the bearer token f
+// returns, for tokens that expire and are refreshed, such as an OIDC ID
+// token. An empty token sends no Authorization header.
+func WithTokenFunc(f func(context.Context) string) Option {
+	return func(client *Client) { client.token = f }
+}
+
+// WithCaller names who is making requests, such as "ci:deploy-bot"; the
+// operator records it as the caller in the deploy ledger.
+func WithCaller(caller string) Option {
+	return func(client *Client) { client.caller = caller }
+}
+
+// WithRetries sets how many times a read is retried and the backoff before
+// the first retry. Zero retries turns retrying off.
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(client *Client) { client.retries, client.backoff = retries, backoff }
+}
+
+// New returns a client for the operator at baseURL, such as
+// http://127.0.0.1:9000.
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		retries:    DefaultRetries,
+		backoff:    DefaultBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Error is an error response from the operator. Body.Code is one of the
+// api.ErrorCode values when the operator set one; clients branch on it
+// rather than on the message.
+type Error struct {
+	Status int
+	Body   api.ErrorResponse
+	// RequestID identifies the failed request in the operator's logs.
+	RequestID string
+}
+
+func (e *Error) Error() string {
+	message := e.Body.Error
+	if message == "" {
+		message = http.StatusText(e.Status)
+	}
+	if e.RequestID != "" {
+		return fmt.Sprintf("operator returned HTTP %d: %s (request %s)", e.Status, message, e.RequestID)
+	}
+	return fmt.Sprintf("operator returned HTTP %d: %s", e.Status, message)
+}
+
+func responseError(resp *http.Response) error {
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	status, requestID := resp.StatusCode, resp.Header.Get(api.RequestIDHeader)
+	var body api.ErrorResponse
+	if err := json.Unmarshal(data, &body); err == nil && body.Error != "" {
+		return &Error{Status: status, Body: body, RequestID: requestID}
+	}
+	text := strings.TrimSpace(string(data))
+	if text == "" {
+		text = http.StatusText(status)
+	}
+	return &Error{Status: status, Body: api.ErrorResponse{Error: text}, RequestID: requestID}
+}
+
+// HistoryQuery selects a page of a history such as the deploy ledger; see
+// GET /deploys. Zero values do not filter.
+type HistoryQuery struct {
+	Limit  int
+	Cursor string
+	Since  time.Time
+	Until  time.Time
+}
+
+func (q HistoryQuery) values() url.Values {
+	query := url.Values{}
+	if q.Limit > 0 {
+		query.Set("limit", strconv.Itoa(q.Limit))
+	}
+	if q.Cursor != "" {
+		query.Set("cursor", q.Cursor)
+	}
+	if !q.Since.IsZero() {
+		query.Set("since", q.Since.Format(time.RFC3339))
+	}
+	if !q.Until.IsZero() {
+		query.Set("until", q.Until.Format(time.RFC3339))
+	}
+	return query
+}
+
+// LogLimits bounds a log stream to its last Tail lines and MaxBytes bytes.
+// Zero values use the operator's defaults.
+type LogLimits struct {
+	Tail     int
+	MaxBytes int
+}
+
+func (l LogLimits) values() url.Values {
+	query := url.Values{}
+	if l.Tail > 0 {
+		query.Set("tail", strconv.Itoa(l.Tail))
+	}
+	if l.MaxBytes > 0 {
+		query.Set("max_bytes", strconv.Itoa(l.MaxBytes))
+	}
+	return query
+}
+
+// doJSON sends in as the JSON body, if not nil, and decodes the response
+// into out, if not nil. It returns the response headers.
+func (c *Client) doJSON(ctx context.Context, method, path string, query url.Values, in, out any) (http.Header, error) {
+	resp, err := c.send(ctx, method, path, query, in)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if out == nil || len(bytes.TrimSpace(data)) == 0 {
+		return resp.Header, nil
+	}
+	return resp.Header, json.Unmarshal(data, out)
+}
+
+func (c *Client) doBytes(ctx context.Context, method, path string, query url.Values, in any) ([]byte, error) {
+	resp, err := c.send(ctx, method, path, query, in)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// send makes a request and returns the response when it succeeds. A GET
+// that fails to connect or is answered 429, 502, 503, or 504 is retried
+// with backoff; other requests change state and are sent once. Every
+// attempt carries the same request ID, so the operator's logs tie them
+// together.
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, in any) (*http.Response, error) {
+	var body []byte
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		body = data
+	}
+	requestID := newRequestID()
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, c.endpoint(path, query), bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		if in != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		c.setHeaders(req, requestID)
+		resp, err := c.httpClient.Do(req)
+		if err == nil && resp.StatusCode < 300 {
+			return resp, nil
+		}
+		retry := method == http.MethodGet && attempt < c.retries && (err != nil || retryable(resp.StatusCode))
+		if err == nil {
+			err = responseError(resp)
+		}
+		if !retry {
+			return nil, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(c.backoff << attempt):
+		}
+	}
+}
+
+func retryable(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func (c *Client) setHeaders(req *http.Request, requestID string) {
+	req.Header.Set(api.RequestIDHeader, requestID)
+	if c.caller != "" {
+		req.Header.Set(api.CallerHeader, c.caller)
+	}
+	if c.token == nil {
+		return
+	}
+	if token := c.token(req.Context()); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+}
+
+func (c *Client) endpoint(path string, query url.Values) string {
+	endpoint := c.baseURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	return endpoint
+}
+
+func newRequestID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,174 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fyltr/angee/api"
+)
+
+func TestClientSendsAuthCallerAndRequestID(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v1/stack/status" {
+			t.Errorf("request = %s %s, want GET /v1/stack/status", r.Method, r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q", got)
+		}
+		if got := r.Header.Get(api.CallerHeader); got != "ci:bot" {
+			t.Errorf("%s = %q", api.CallerHeader, got)
+		}
+		if r.Header.Get(api.RequestIDHeader) == "" {
+			t.Errorf("%s is not set", api.RequestIDHeader)
+		}
+		_ = json.NewEncoder(w).Encode(api.StackStatusResponse{Name: "demo"})
+	}))
+	defer server.Close()
+
+	c := New(server.URL+"/", WithToken("secret"), WithCaller("ci:bot"))
+	status, err := c.StackStatus(context.Background())
+	if err != nil {
+		t.Fatalf("StackStatus() error = %v", err)
+	}
+	if status.Name != "demo" {
+		t.Fatalf("StackStatus() = %#v", status)
+	}
+}
+
+func TestClientRetriesReadsButNotWrites(t *testing.T) {
+	var gets, posts int
+	var requestIDs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			posts++
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		gets++
+		requestIDs = append(requestIDs, r.Header.Get(api.RequestIDHeader))
+		if gets < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode([]api.ServiceState{{Name: "web"}})
+	}))
+	defer server.Close()
+
+	c := New(server.URL, WithRetries(2, time.Millisecond))
+	services, err := c.ServiceList(context.Background())
+	if err != nil {
+		t.Fatalf("ServiceList() error = %v", err)
+	}
+	if len(services) != 1 || gets != 3 {
+		t.Fatalf("ServiceList() = %#v after %d attempts, want the third to succeed", services, gets)
+	}
+	if requestIDs[0] != requestIDs[2] {
+		t.Fatalf("request IDs = %v, want one ID across retries", requestIDs)
+	}
+	if err := c.StackDown(context.Background()); err == nil || posts != 1 {
+		t.Fatalf("StackDown() error = %v after %d attempts, want one failed attempt", err, posts)
+	}
+}
+
+func TestClientReturnsOperatorErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(api.RequestIDHeader, "req-42")
+		if r.URL.Path == "/v1/workspaces/plain" {
+			http.Error(w, "bad gateway", http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(api.ErrorResponse{Code: api.ErrorCodeNotFound, Kind: "workspace", Name: "missing", Error: `workspace "missing" is not declared`})
+	}))
+	defer server.Close()
+
+	c := New(server.URL, WithRetries(0, 0))
+	_, err := c.WorkspaceGet(context.Background(), "missing")
+	var opErr *Error
+	if !errors.As(err, &opErr) {
+		t.Fatalf("WorkspaceGet() error = %T, want *Error", err)
+	}
+	if opErr.Status != http.StatusNotFound || opErr.Body.Code != api.ErrorCodeNotFound || opErr.Body.Name != "missing" {
+		t.Fatalf("Error = %#v", opErr)
+	}
+	if got := err.Error(); !strings.Contains(got, "HTTP 404") || !strings.Contains(got, `workspace "missing" is not declared`) || !strings.Contains(got, "request req-42") {
+		t.Fatalf("error string = %q, want status, message, and request id", got)
+	}
+
+	_, err = c.WorkspaceGet(context.Background(), "plain")
+	if !errors.As(err, &opErr) || opErr.Body.Error != "bad gateway" {
+		t.Fatalf("WorkspaceGet(plain) error = %v, want the response text", err)
+	}
+}
+
+func TestClientPagesDeploys(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("limit") != "1" || query.Get("cursor") != "3" || query.Get("since") != "2026-01-02T00:00:00Z" {
+			t.Errorf("query = %s", r.URL.RawQuery)
+		}
+		w.Header().Set(api.NextCursorHeader, "2")
+		_ = json.NewEncoder(w).Encode([]api.Deploy{{Manifest: "d3"}})
+	}))
+	defer server.Close()
+
+	since := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	deploys, next, err := New(server.URL).DeployPage(context.Background(), HistoryQuery{Limit: 1, Cursor: "3", Since: since})
+	if err != nil {
+		t.Fatalf("DeployPage() error = %v", err)
+	}
+	if len(deploys) != 1 || deploys[0].Manifest != "d3" || next != "2" {
+		t.Fatalf("DeployPage() = %#v, %q", deploys, next)
+	}
+}
+
+func TestClientStreamsLogsAndEvents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/stack/logs":
+			if got := r.URL.Query()["service"]; len(got) != 2 || r.URL.Query().Get("tail") != "10" {
+				t.Errorf("query = %s", r.URL.RawQuery)
+			}
+			_, _ = io.WriteString(w, "web | up\napi | up\n")
+		case "/v1/events":
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = io.WriteString(w, ": keepalive\n\nevent: ready\ndata: {}\n\nid: 7\ndata: {\"a\":\ndata: 1}\n\n")
+		}
+	}))
+	defer server.Close()
+	c := New(server.URL)
+
+	logs, err := c.StackLogs(context.Background(), []string{"web", "api"}, LogLimits{Tail: 10})
+	if err != nil {
+		t.Fatalf("StackLogs() error = %v", err)
+	}
+	var lines []string
+	for line := range logs {
+		lines = append(lines, line)
+	}
+	if strings.Join(lines, "") != "web | up\napi | up\n" {
+		t.Fatalf("StackLogs() lines = %q", lines)
+	}
+
+	events, err := c.Events(context.Background())
+	if err != nil {
+		t.Fatalf("Events() error = %v", err)
+	}
+	var got []Event
+	for event := range events {
+		got = append(got, event)
+	}
+	if len(got) != 2 || got[0].Name != "ready" || string(got[0].Data) != "{}" {
+		t.Fatalf("Events() = %#v", got)
+	}
+	if got[1].Name != "message" || got[1].ID != "7" || string(got[1].Data) != "{\"a\":\n1}" {
+		t.Fatalf("second event = %#v", got[1])
+	}
+}
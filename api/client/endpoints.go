@@ -0,0 +1,430 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/fyltr/angee/api"
+)
+
+// Health reports whether the operator is up and its angee.yaml loads.
+func (c *Client) Health(ctx context.Context) (api.HealthResponse, error) {
+	var health api.HealthResponse
+	_, err := c.doJSON(ctx, http.MethodGet, "/healthz", nil, nil, &health)
+	return health, err
+}
+
+// OIDCConfig returns the OpenID provider users sign in with, for a device
+// flow such as `angee login`.
+func (c *Client) OIDCConfig(ctx context.Context) (api.OIDCConfig, error) {
+	var config api.OIDCConfig
+	_, err := c.doJSON(ctx, http.MethodGet, api.PathPrefix+"/auth/oidc", nil, nil, &config)
+	return config, err
+}
+
+func (c *Client) StackStatus(ctx context.Context) (api.StackStatusResponse, error) {
+	var status api.StackStatusResponse
+	_, err := c.doJSON(ctx, http.MethodGet, api.PathPrefix+"/stack/status", nil, nil, &status)
+	return status, err
+}
+
+func (c *Client) StackInit(ctx context.Context, req api.StackInitRequest) (api.StackInitResponse, error) {
+	var resp api.StackInitResponse
+	_, err := c.doJSON(ctx, http.MethodPost, api.PathPrefix+"/stack/init", nil, req, &resp)
+	return resp, err
+}
+
+func (c *Client) StackUpdate(ctx context.Context) error {
+	_, err := c.doJSON(ctx, http.MethodPost, api.PathPrefix+"/stack/update", nil, nil, nil)
+	return err
+}
+
+// StackPrepare compiles the stack and returns the compiled document: the
+// compose file, the process-compose file, and the secret environment
+// variables they reference.
+func (c *Client) StackPrepare(ctx context.Context) (json.RawMessage, error) {
+	var compiled json.RawMessage
+	_, err := c.doJSON(ctx, http.MethodPost, api.PathPrefix+"/stack/prepare", nil, nil, &compiled)
+	return compiled, err
+}
+
+func (c *Client) StackBuild(ctx context.Context, req api.StackRuntimeRequest) error {
+	_, err := c.doJSON(ctx, http.MethodPost, api.PathPrefix+"/stack/build", nil, req, nil)
+	return err
+}
+
+func (c *Client) StackUp(ctx context.Context, req api.StackRuntimeRequest) error {
+	_, err := c.doJSON(ctx, http.MethodPost, api.PathPrefix+"/stack/up", nil, req, nil)
+	return err
+}
+
+func (c *Client) StackDev(ctx context.Context, req api.StackRuntimeRequest) error {
+	_, err := c.doJSON(ctx, http.MethodPost, api.PathPrefix+"/stack/dev", nil, req, nil)
+	return err
+}
+
+func (c *Client) StackDown(ctx context.Context) error {
+	_, err := c.doJSON(ctx, http.MethodPost, api.PathPrefix+"/stack/down", nil, nil, nil)
+	return err
+}
+
+func (c *Client) StackDestroy(ctx context.Context, purge bool) error {
+	_, err := c.doJSON(ctx, http.MethodPost, api.PathPrefix+"/stack/destroy", flag("purge", purge), nil, nil)
+	return err
+}
+
+// StackPrune removes the resources the stack no longer declares. With only,
+// the resources from an earlier dry run, it removes nothing unless it finds
+// exactly those.
+func (c *Client) StackPrune(ctx context.Context, dryRun bool, only []api.PruneResource) (api.PruneResponse, error) {
+	var in any
+	if only != nil {
+		in = api.PruneRequest{Only: only}
+	}
+	var resp api.PruneResponse
+	_, err := c.doJSON(ctx, http.MethodPost, api.PathPrefix+"/stack/prune", flag("dry_run", dryRun), in, &resp)
+	return resp, err
+}
+
+// StackLockImages records image digests in angee.lock; with refresh every
+// image is resolved again.
+func (c *Client) StackLockImages(ctx context.Context, refresh bool) (api.ImageLockResponse, error) {
+	var resp api.ImageLockResponse
+	_, err := c.doJSON(ctx, http.MethodPost, api.PathPrefix+"/stack/lock-images", flag("refresh", refresh), nil, &resp)
+	return resp, err
+}
+
+// StackOutdated reports the images, template, and sources with newer
+// versions upstream.
+func (c *Client) StackOutdated(ctx context.Context) (api.OutdatedResponse, error) {
+	var resp api.OutdatedResponse
+	_, err := c.doJSON(ctx, http.MethodGet, api.PathPrefix+"/stack/outdated", nil, nil, &resp)
+	return resp, err
+}
+
+// StackPlan compiles the stack without applying it and returns the
+// compiled files with a plan ID. StackUp needs the ID approved with
+// `angee plan approve` on the stack's host.
+func (c *Client) StackPlan(ctx context.Context) (api.Plan, error) {
+	var plan api.Plan
+	_, err := c.doJSON(ctx, http.MethodPost, api.PathPrefix+"/stack/plan", nil, nil, &plan)
+	return plan, err
+}
+
+// StackLogs streams the logs of services, or of every service when none
+// are named.
+func (c *Client) StackLogs(ctx context.Context, services []string, limits LogLimits) (<-chan string, error) {
+	query := limits.values()
+	for _, service := range services {
+		query.Add("service", service)
+	}
+	return c.lines(ctx, api.PathPrefix+"/stack/logs", query)
+}
+
+// TemplateList lists the templates of kind, or of every kind when kind is
+// empty.
+func (c *Client) TemplateList(ctx context.Context, kind string) ([]api.TemplateInfo, error) {
+	var templates []api.TemplateInfo
+	_, err := c.doJSON(ctx, http.MethodGet, api.PathPrefix+"/templates", kindQuery(nil, kind), nil, &templates)
+	return templates, err
+}
+
+func (c *Client) TemplateInfo(ctx context.Context, ref, kind string) (api.TemplateInfo, error) {
+	var info api.TemplateInfo
+	_, err := c.doJSON(ctx, http.MethodGet, api.PathPrefix+"/templates/info", kindQuery(url.Values{"ref": {ref}}, kind), nil, &info)
+	return info, err
+}
+
+func (c *Client) TemplateStatus(ctx context.Context) (api.TemplateStatus, error) {
+	var status api.TemplateStatus
+	_, err := c.doJSON(ctx, http.MethodGet, api.PathPrefix+"/templates/status", nil, nil, &status)
+	return status, err
+}
+
+func (c *Client) JobList(ctx context.Context) ([]api.JobState, error) {
+	var jobs []api.JobState
+	_, err := c.doJSON(ctx, http.MethodGet, api.PathPrefix+"/jobs", nil, nil, &jobs)
+	return jobs, err
+}
+
+// JobRun runs a job to completion and returns its output.
+func (c *Client) JobRun(ctx context.Context, name string, inputs map[string]string) ([]byte, error) {
+	return c.doBytes(ctx, http.MethodPost, api.PathPrefix+"/jobs/"+url.PathEscape(name)+"/run", nil, api.JobRunRequest{Inputs: inputs})
+}
+
+// JobStart starts a job and returns the operation to poll with
+// OperationGet.
+func (c *Client) JobStart(ctx context.Context, name string, inputs map[string]string) (api.Operation, error) {
+	var op api.Operation
+	_, err := c.doJSON(ctx, http.MethodPost, api.PathPrefix+"/jobs/"+url.PathEscape(name)+"/run", nil, api.JobRunRequest{Inputs: inputs, Async: true}, &op)
+	return op, err
+}
+
+// JobRunPage returns the page of a job's run history q selects and the
+// cursor of the next page, "" on the last one.
+func (c *Client) JobRunPage(ctx context.Context, name string, q HistoryQuery) ([]api.JobRun, string, error) {
+	var runs []api.JobRun
+	header, err := c.doJSON(ctx, http.MethodGet, api.PathPrefix+"/jobs/"+url.PathEscape(name)+"/runs", q.values(), nil, &runs)
+	if err != nil {
+		return nil, "", err
+	}
+	return runs, header.Get(api.NextCursorHeader), nil
+}
+
+// DeployPage returns the page of the deploy ledger q selects and the
+// cursor of the next page, "" on the last one.
+func (c *Client) DeployPage(ctx context.Context, q HistoryQuery) ([]api.Deploy, string, error) {
+	var deploys []api.Deploy
+	header, err := c.doJSON(ctx, http.MethodGet, api.PathPrefix+"/deploys", q.values(), nil, &deploys)
+	if err != nil {
+		return nil, "", err
+	}
+	return deploys, header.Get(api.NextCursorHeader), nil
+}
+
+func (c *Client) OperationGet(ctx context.Context, id string) (api.Operation, error) {
+	var op api.Operation
+	_, err := c.doJSON(ctx, http.MethodGet, api.PathPrefix+"/operations/"+url.PathEscape(id), nil, nil, &op)
+	return op, err
+}
+
+// BackupList lists the backups of service, or of every service when
+// service is empty.
+func (c *Client) BackupList(ctx context.Context, service string) ([]api.Backup, error) {
+	var query url.Values
+	if service != "" {
+		query = url.Values{"service": {service}}
+	}
+	var backups []api.Backup
+	_, err := c.doJSON(ctx, http.MethodGet, api.PathPrefix+"/backups", query, nil, &backups)
+	return backups, err
+}
+
+func (c *Client) BackupRun(ctx context.Context, service string) (api.Backup, error) {
+	var backup api.Backup
+	_, err := c.doJSON(ctx, http.MethodPost, api.PathPrefix+"/backups/"+url.PathEscape(service)+"/run", nil, nil, &backup)
+	return backup, err
+}
+
+func (c *Client) BackupRestore(ctx context.Context, service, name string) error {
+	_, err := c.doJSON(ctx, http.MethodPost, api.PathPrefix+"/backups/"+url.PathEscape(service)+"/restore", nil, api.BackupRestoreRequest{Name: name}, nil)
+	return err
+}
+
+func (c *Client) ServiceList(ctx context.Context) ([]api.ServiceState, error) {
+	var services []api.ServiceState
+	_, err := c.doJSON(ctx, http.MethodGet, api.PathPrefix+"/services", nil, nil, &services)
+	return services, err
+}
+
+func (c *Client) ServiceInit(ctx context.Context, req api.ServiceInitRequest) error {
+	_, err := c.doJSON(ctx, http.MethodPost, api.PathPrefix+"/services", nil, req, nil)
+	return err
+}
+
+func (c *Client) ServiceUpdate(ctx context.Context, req api.ServiceInitRequest) error {
+	_, err := c.doJSON(ctx, http.MethodPatch, api.PathPrefix+"/services/"+url.PathEscape(req.Name), nil, req, nil)
+	return err
+}
+
+func (c *Client) ServiceStart(ctx context.Context, name string) error {
+	return c.post(ctx, api.PathPrefix+"/services/"+url.PathEscape(name)+"/start")
+}
+
+func (c *Client) ServiceStop(ctx context.Context, name string) error {
+	return c.post(ctx, api.PathPrefix+"/services/"+url.PathEscape(name)+"/stop")
+}
+
+func (c *Client) ServiceRestart(ctx context.Context, name string) error {
+	return c.post(ctx, api.PathPrefix+"/services/"+url.PathEscape(name)+"/restart")
+}
+
+func (c *Client) ServiceDestroy(ctx context.Context, name string) error {
+	return c.post(ctx, api.PathPrefix+"/services/"+url.PathEscape(name)+"/destroy")
+}
+
+func (c *Client) ServiceLogs(ctx context.Context, name string, limits LogLimits) (<-chan string, error) {
+	return c.lines(ctx, api.PathPrefix+"/services/"+url.PathEscape(name)+"/logs", limits.values())
+}
+
+func (c *Client) SourceList(ctx context.Context) ([]api.SourceState, error) {
+	var sources []api.SourceState
+	_, err := c.doJSON(ctx, http.MethodGet, api.PathPrefix+"/sources", nil, nil, &sources)
+	return sources, err
+}
+
+func (c *Client) SourceStatus(ctx context.Context, name string) (api.SourceState, error) {
+	var state api.SourceState
+	_, err := c.doJSON(ctx, http.MethodGet, api.PathPrefix+"/sources/"+url.PathEscape(name)+"/status", nil, nil, &state)
+	return state, err
+}
+
+func (c *Client) SourceFetch(ctx context.Context, name string) (api.SourceState, error) {
+	return c.sourceOperation(ctx, name, "fetch", nil)
+}
+
+func (c *Client) SourcePull(ctx context.Context, name string) (api.SourceState, error) {
+	return c.sourceOperation(ctx, name, "pull", nil)
+}
+
+// SourcePush pushes a source's branch, or ref when it is set.
+func (c *Client) SourcePush(ctx context.Context, name, ref string) (api.SourceState, error) {
+	return c.sourceOperation(ctx, name, "push", api.SourceOperationRequest{Ref: ref})
+}
+
+func (c *Client) sourceOperation(ctx context.Context, name, action string, in any) (api.SourceState, error) {
+	var state api.SourceState
+	_, err := c.doJSON(ctx, http.MethodPost, api.PathPrefix+"/sources/"+url.PathEscape(name)+"/"+action, nil, in, &state)
+	return state, err
+}
+
+func (c *Client) WorkspaceList(ctx context.Context) ([]api.WorkspaceRef, error) {
+	var refs []api.WorkspaceRef
+	_, err := c.doJSON(ctx, http.MethodGet, api.PathPrefix+"/workspaces", nil, nil, &refs)
+	return refs, err
+}
+
+func (c *Client) WorkspaceCreate(ctx context.Context, req api.WorkspaceCreateRequest) (api.WorkspaceRef, error) {
+	var ref api.WorkspaceRef
+	_, err := c.doJSON(ctx, http.MethodPost, api.PathPrefix+"/workspaces", nil, req, &ref)
+	return ref, err
+}
+
+func (c *Client) WorkspaceGet(ctx context.Context, name string) (api.WorkspaceRef, error) {
+	var ref api.WorkspaceRef
+	_, err := c.doJSON(ctx, http.MethodGet, api.PathPrefix+"/workspaces/"+url.PathEscape(name), nil, nil, &ref)
+	return ref, err
+}
+
+func (c *Client) WorkspaceUpdate(ctx context.Context, name string, req api.WorkspaceUpdateRequest) (api.WorkspaceRef, error) {
+	var ref api.WorkspaceRef
+	_, err := c.doJSON(ctx, http.MethodPatch, api.PathPrefix+"/workspaces/"+url.PathEscape(name), nil, req, &ref)
+	return ref, err
+}
+
+func (c *Client) WorkspaceStatus(ctx context.Context, name string) (api.WorkspaceStatusResponse, error) {
+	var status api.WorkspaceStatusResponse
+	_, err := c.doJSON(ctx, http.MethodGet, api.PathPrefix+"/workspaces/"+url.PathEscape(name)+"/status", nil, nil, &status)
+	return status, err
+}
+
+func (c *Client) WorkspaceLogs(ctx context.Context, name string, limits LogLimits) (<-chan string, error) {
+	return c.lines(ctx, api.PathPrefix+"/workspaces/"+url.PathEscape(name)+"/logs", limits.values())
+}
+
+func (c *Client) WorkspaceStart(ctx context.Context, name string) error {
+	return c.post(ctx, api.PathPrefix+"/workspaces/"+url.PathEscape(name)+"/start")
+}
+
+func (c *Client) WorkspaceStop(ctx context.Context, name string) error {
+	return c.post(ctx, api.PathPrefix+"/workspaces/"+url.PathEscape(name)+"/stop")
+}
+
+func (c *Client) WorkspaceRestart(ctx context.Context, name string) error {
+	return c.post(ctx, api.PathPrefix+"/workspaces/"+url.PathEscape(name)+"/restart")
+}
+
+func (c *Client) WorkspaceDestroy(ctx context.Context, name string, purge bool) error {
+	_, err := c.doJSON(ctx, http.MethodPost, api.PathPrefix+"/workspaces/"+url.PathEscape(name)+"/destroy", flag("purge", purge), nil, nil)
+	return err
+}
+
+func (c *Client) WorkspaceGitStatus(ctx context.Context, name string) ([]api.SourceState, error) {
+	var states []api.SourceState
+	_, err := c.doJSON(ctx, http.MethodGet, api.PathPrefix+"/workspaces/"+url.PathEscape(name)+"/git", nil, nil, &states)
+	return states, err
+}
+
+func (c *Client) WorkspacePush(ctx context.Context, name, ref string) ([]api.SourceState, error) {
+	var states []api.SourceState
+	_, err := c.doJSON(ctx, http.MethodPost, api.PathPrefix+"/workspaces/"+url.PathEscape(name)+"/push", nil, api.SourceOperationRequest{Ref: ref}, &states)
+	return states, err
+}
+
+func (c *Client) WorkspaceSyncBase(ctx context.Context, name, method string) ([]api.SourceState, error) {
+	var states []api.SourceState
+	_, err := c.doJSON(ctx, http.MethodPost, api.PathPrefix+"/workspaces/"+url.PathEscape(name)+"/sync-base", nil, api.WorkspaceSyncBaseRequest{Method: method}, &states)
+	return states, err
+}
+
+// Upgrade replaces the operator binary with that of release version; the
+// operator restarts into it once it has responded.
+func (c *Client) Upgrade(ctx context.Context, version string) (api.UpgradeResponse, error) {
+	var resp api.UpgradeResponse
+	_, err := c.doJSON(ctx, http.MethodPost, api.PathPrefix+"/upgrade", nil, api.UpgradeRequest{Version: version}, &resp)
+	return resp, err
+}
+
+func (c *Client) LogLevel(ctx context.Context) (string, error) {
+	var level api.LogLevel
+	_, err := c.doJSON(ctx, http.MethodGet, api.PathPrefix+"/loglevel", nil, nil, &level)
+	return level.Level, err
+}
+
+// SetLogLevel changes the operator's log level to debug, info, warn, or
+// error until it restarts.
+func (c *Client) SetLogLevel(ctx context.Context, level string) error {
+	_, err := c.doJSON(ctx, http.MethodPost, api.PathPrefix+"/loglevel", nil, api.LogLevel{Level: level}, nil)
+	return err
+}
+
+func (c *Client) MCP(ctx context.Context) (api.MCPDescriptor, error) {
+	var descriptor api.MCPDescriptor
+	_, err := c.doJSON(ctx, http.MethodGet, api.PathPrefix+"/mcp", nil, nil, &descriptor)
+	return descriptor, err
+}
+
+// GraphQL runs query with variables against POST /graphql and decodes its
+// data into out. Errors the query reports are returned as one error.
+func (c *Client) GraphQL(ctx context.Context, query string, variables map[string]any, out any) error {
+	req := struct {
+		Query     string         `json:"query"`
+		Variables map[string]any `json:"variables,omitempty"`
+	}{query, variables}
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if _, err := c.doJSON(ctx, http.MethodPost, api.PathPrefix+"/graphql", nil, req, &resp); err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		errs := make([]error, len(resp.Errors))
+		for i, e := range resp.Errors {
+			errs[i] = errors.New(e.Message)
+		}
+		return errors.Join(errs...)
+	}
+	if out == nil || len(resp.Data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(resp.Data, out)
+}
+
+func (c *Client) post(ctx context.Context, path string) error {
+	_, err := c.doJSON(ctx, http.MethodPost, path, nil, nil, nil)
+	return err
+}
+
+func flag(name string, set bool) url.Values {
+	if !set {
+		return nil
+	}
+	return url.Values{name: {"true"}}
+}
+
+func kindQuery(query url.Values, kind string) url.Values {
+	if kind == "" {
+		return query
+	}
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("kind", kind)
+	return query
+}
@@ -0,0 +1,104 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/fyltr/angee/api"
+)
+
+// lines streams the body of a GET line by line, each with its newline.
+// The channel closes when the stream ends or ctx is done.
+func (c *Client) lines(ctx context.Context, path string, query url.Values) (<-chan string, error) {
+	resp, err := c.send(ctx, http.MethodGet, path, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan string)
+	go func() {
+		defer close(out)
+		defer resp.Body.Close()
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			select {
+			case out <- scanner.Text() + "\n":
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// Event is a server-sent event from GET /events. Name is "message" when
+// the operator did not name it; Data is the JSON document it carries.
+type Event struct {
+	Name string
+	ID   string
+	Data json.RawMessage
+}
+
+// Events follows the operator's event stream. The channel closes when the
+// operator ends the stream or ctx is done.
+func (c *Client) Events(ctx context.Context) (<-chan Event, error) {
+	resp, err := c.send(ctx, http.MethodGet, api.PathPrefix+"/events", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan Event)
+	go func() {
+		defer close(out)
+		defer resp.Body.Close()
+		readEvents(ctx, bufio.NewScanner(resp.Body), out)
+	}()
+	return out, nil
+}
+
+// readEvents parses the text/event-stream format: fields up to a blank
+// line make an event, data lines are joined by newlines, and lines
+// starting with a colon are comments.
+func readEvents(ctx context.Context, scanner *bufio.Scanner, out chan<- Event) {
+	var event Event
+	var data bytes.Buffer
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if data.Len() == 0 && event.Name == "" {
+				continue
+			}
+			if event.Name == "" {
+				event.Name = "message"
+			}
+			event.Data = json.RawMessage(bytes.Clone(data.Bytes()))
+			select {
+			case out <- event:
+			case <-ctx.Done():
+				return
+			}
+			event = Event{}
+			data.Reset()
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event.Name = value
+		case "id":
+			event.ID = value
+		case "data":
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+		}
+	}
+}
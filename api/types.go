@@ -13,28 +13,60 @@ const (
 
 type Operation struct {
 	ID        string          `json:"id"`
+	Kind      string          `json:"kind,omitempty"`
+	Name      string          `json:"name,omitempty"`
 	Status    OperationStatus `json:"status"`
 	Message   string          `json:"message,omitempty"`
+	Output    string          `json:"output,omitempty"`
 	StartedAt time.Time       `json:"started_at"`
 	EndedAt   *time.Time      `json:"ended_at,omitempty"`
 }
 
+// ErrorResponse is the body of every failed request. Code is one of the
+// ErrorCode values, stable across releases, so clients branch on it rather
+// than on the Error text; Hint, when set, is the next step for a person.
 type ErrorResponse struct {
+	Code   string `json:"code,omitempty"`
 	Kind   string `json:"kind,omitempty"`
 	Name   string `json:"name,omitempty"`
 	Field  string `json:"field,omitempty"`
+	Rule   string `json:"rule,omitempty"`
 	Reason string `json:"reason,omitempty"`
 	Error  string `json:"error"`
+	Hint   string `json:"hint,omitempty"`
 }
 
+const (
+	ErrorCodeNotFound           = "not_found"
+	ErrorCodeConflict           = "conflict"
+	ErrorCodeInvalidInput       = "invalid_input"
+	ErrorCodePolicy             = "policy"
+	ErrorCodeUnauthorized       = "unauthorized"
+	ErrorCodeMissingSecret      = "missing_secret"
+	ErrorCodePortConflict       = "port_conflict"
+	ErrorCodeBackendUnreachable = "backend_unreachable"
+	ErrorCodeRuntimeFailed      = "runtime_failed"
+	ErrorCodeRolledBack         = "rolled_back"
+	ErrorCodeInternal           = "internal"
+)
+
 type StackInitRequest struct {
 	Template string            `json:"template"`
+	With     []string          `json:"with,omitempty"`
 	Path     string            `json:"path,omitempty"`
 	Inputs   map[string]string `json:"inputs,omitempty"`
+	Secrets  map[string]string `json:"secrets,omitempty"`
 	Force    bool              `json:"force,omitempty"`
 	Yes      bool              `json:"yes,omitempty"`
 }
 
+// StackInitResponse is the body of a successful POST /stack/init.
+type StackInitResponse struct {
+	Status   string `json:"status"`
+	Template string `json:"template"`
+	Root     string `json:"root"`
+}
+
 type StackPrepareRequest struct {
 	Root string `json:"root,omitempty"`
 }
@@ -42,29 +74,332 @@ type StackPrepareRequest struct {
 type StackRuntimeRequest struct {
 	Services []string `json:"services,omitempty"`
 	Build    bool     `json:"build,omitempty"`
+	// Wait is a duration such as "2m" to wait for the started services to
+	// be healthy before `up` reports success.
+	Wait string `json:"wait,omitempty"`
+	// AutoRollback redeploys the last successful angee.yaml when `up`
+	// fails.
+	AutoRollback bool `json:"auto_rollback,omitempty"`
+	// RollbackDepth bounds how many earlier manifests a rollback tries.
+	RollbackDepth int `json:"rollback_depth,omitempty"`
+	// LockImages records a digest in angee.lock for every image not yet
+	// locked before `up` compiles the stack.
+	LockImages bool `json:"lock_images,omitempty"`
+	// Message, for `up`, names the deploy in the ledger.
+	Message string `json:"message,omitempty"`
+	// Plan, for `up`, is a token from `angee plan approve`; the deploy is
+	// rejected unless the stack still compiles to what was planned.
+	Plan string `json:"plan,omitempty"`
+	// Push, for `build`, pushes the built images to their registries.
+	Push bool `json:"push,omitempty"`
+}
+
+// Plan is the compiled stack as POST /stack/plan found it, with an ID
+// naming exactly that. The ID is not an approval: `angee plan approve` on
+// the stack's host signs it into the Token that POST /stack/up deploys.
+type Plan struct {
+	ID    string `json:"id"`
+	Token string `json:"token,omitempty"`
+	// Manifest is the revision of angee.yaml the plan was made from.
+	Manifest string `json:"manifest"`
+	// Compiled is the digest of the compiled files below.
+	Compiled       string    `json:"compiled"`
+	Services       []string  `json:"services,omitempty"`
+	Processes      []string  `json:"processes,omitempty"`
+	Compose        string    `json:"compose,omitempty"`
+	ProcessCompose string    `json:"process_compose,omitempty"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+// ImageLockResponse lists the digests angee.lock pins the stack's images
+// to.
+type ImageLockResponse struct {
+	Images []ImageLock `json:"images"`
+}
+
+type ImageLock struct {
+	Image    string   `json:"image"`
+	Digest   string   `json:"digest"`
+	Services []string `json:"services"`
+}
+
+// OutdatedResponse lists what in the stack has a newer version upstream.
+type OutdatedResponse struct {
+	Updates []Update `json:"updates"`
+}
+
+// Update is one image, template, or source with a newer version upstream,
+// or, with Error set, one that could not be checked.
+type Update struct {
+	Kind     string   `json:"kind"`
+	Name     string   `json:"name"`
+	Current  string   `json:"current,omitempty"`
+	Latest   string   `json:"latest,omitempty"`
+	Behind   int      `json:"behind,omitempty"`
+	Services []string `json:"services,omitempty"`
+	Link     string   `json:"link,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+const (
+	UpdateImage    = "image"
+	UpdateTemplate = "template"
+	UpdateSource   = "source"
+)
+
+// PruneResponse lists the Docker objects labeled for the stack that its
+// current configuration no longer references. They were removed unless
+// DryRun is set.
+type PruneResponse struct {
+	Resources []PruneResource `json:"resources"`
+	DryRun    bool            `json:"dry_run,omitempty"`
+}
+
+// PruneRequest is the optional body of POST /stack/prune. Only lists the
+// resources a person confirmed from a dry run; prune then refuses with 409
+// unless it finds exactly those.
+type PruneRequest struct {
+	Only []PruneResource `json:"only,omitempty"`
+}
+
+type PruneResource struct {
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
 }
 
 type StackStatusResponse struct {
-	Root       string                  `json:"root"`
-	Name       string                  `json:"name"`
+	Root string `json:"root"`
+	Name string `json:"name"`
+	// Revision identifies the angee.yaml the status was read from. Send it
+	// as If-Match on a write to reject the write if the file has changed.
+	Revision   string                  `json:"revision,omitempty"`
 	Services   map[string]ServiceState `json:"services,omitempty"`
 	Jobs       map[string]JobState     `json:"jobs,omitempty"`
 	Workspaces map[string]WorkspaceRef `json:"workspaces,omitempty"`
 }
 
 type ServiceState struct {
-	Name    string `json:"name"`
-	Runtime string `json:"runtime"`
-	Status  string `json:"status"`
+	Name    string        `json:"name"`
+	Runtime string        `json:"runtime"`
+	Status  string        `json:"status"`
+	Stats   *ServiceStats `json:"stats,omitempty"`
+	// Alerts names the operator alert rules firing for the service, which
+	// is then reported as "degraded".
+	Alerts []string `json:"alerts,omitempty"`
+}
+
+type ServiceStats struct {
+	CPUPercent       float64 `json:"cpu_percent"`
+	MemoryBytes      uint64  `json:"memory_bytes"`
+	MemoryLimitBytes uint64  `json:"memory_limit_bytes,omitempty"`
+	Restarts         int     `json:"restarts"`
+	// StartedAt is when the container last started; UptimeSeconds is
+	// measured from it at sampling time.
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+	UptimeSeconds int64      `json:"uptime_seconds,omitempty"`
+	// Health is the readiness state of a service with a readiness probe:
+	// starting, healthy, or unhealthy.
+	Health string `json:"health,omitempty"`
 }
 
 type JobState struct {
-	Name    string `json:"name"`
-	Runtime string `json:"runtime"`
+	Name     string     `json:"name"`
+	Runtime  string     `json:"runtime"`
+	Schedule string     `json:"schedule,omitempty"`
+	NextRun  *time.Time `json:"next_run,omitempty"`
+}
+
+type JobRun struct {
+	Job        string    `json:"job"`
+	Trigger    string    `json:"trigger"`
+	Status     string    `json:"status"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Error      string    `json:"error,omitempty"`
+	RequestID  string    `json:"request_id,omitempty"`
+}
+
+// LogLevel is the body of GET and POST /loglevel.
+type LogLevel struct {
+	Level string `json:"level"`
+}
+
+// UpgradeRequest is the body of POST /upgrade.
+type UpgradeRequest struct {
+	Version string `json:"version"`
+}
+
+// UpgradeResponse reports a replaced operator binary. The operator
+// restarts into it once the response is sent.
+type UpgradeResponse struct {
+	Version string `json:"version"`
+	Path    string `json:"path"`
+	Status  string `json:"status"`
+}
+
+// Version is the version of the operator HTTP API. PathPrefix, built from
+// it, prefixes every API route; the unprefixed paths from before are
+// deprecated aliases.
+const (
+	Version    = "v1"
+	PathPrefix = "/" + Version
+)
+
+// HealthResponse is the body of GET /healthz. Status is "ok", or
+// "degraded" while angee.yaml does not load; ManifestError says why, to
+// authenticated callers only. Version is the API Version the operator
+// serves.
+type HealthResponse struct {
+	Status        string `json:"status"`
+	Version       string `json:"version"`
+	ManifestError string `json:"manifest_error,omitempty"`
+}
+
+// MCPDescriptor is the body of GET /mcp.
+type MCPDescriptor struct {
+	Name    string   `json:"name"`
+	Version string   `json:"version"`
+	Tools   []string `json:"tools"`
+}
+
+// CallerHeader names who is making an operator request, recorded as the
+// caller in the deploy ledger. Clients set it to e.g. "cli:alice".
+const CallerHeader = "X-Angee-Caller"
+
+// RequestIDHeader carries a request's ID. The CLI sends one with every
+// operator request; the operator generates one when a client does not, echoes
+// it in the response, and records it in its logs, the deploy ledger, and job
+// runs.
+const RequestIDHeader = "X-Request-ID"
+
+// NextCursorHeader carries the cursor of the next page of a paged history
+// response, such as GET /deploys. It is absent on the last page.
+const NextCursorHeader = "X-Next-Cursor"
+
+// Deploy is one entry of the deploy ledger: an `angee up` and the
+// configuration it applied.
+type Deploy struct {
+	Commit     string    `json:"commit,omitempty"`
+	Manifest   string    `json:"manifest"`
+	Services   []string  `json:"services,omitempty"`
+	Message    string    `json:"message,omitempty"`
+	Caller     string    `json:"caller"`
+	Status     string    `json:"status"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	DurationMS int64     `json:"duration_ms"`
+	Error      string    `json:"error,omitempty"`
+	RequestID  string    `json:"request_id,omitempty"`
+	// Scans lists the images operator.scan found vulnerabilities in at or
+	// above its severity, whether they blocked the deploy or not.
+	Scans []ImageScan `json:"scans,omitempty"`
+}
+
+type ImageScan struct {
+	Image           string          `json:"image"`
+	Vulnerabilities []Vulnerability `json:"vulnerabilities"`
+}
+
+type Vulnerability struct {
+	ID        string `json:"id"`
+	Package   string `json:"package"`
+	Installed string `json:"installed,omitempty"`
+	Fixed     string `json:"fixed,omitempty"`
+	Severity  string `json:"severity"`
+}
+
+// Backup is one database dump of a service with a `backup:` declaration.
+type Backup struct {
+	Service   string    `json:"service"`
+	Name      string    `json:"name"`
+	Kind      string    `json:"kind"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BackupRestoreRequest is the body of POST /backups/{service}/restore.
+type BackupRestoreRequest struct {
+	Name string `json:"name"`
+}
+
+// HookResponse answers a forge webhook delivery. An accepted push names the
+// sources it pulls and the operation that pulls them and redeploys; an
+// ignored delivery, such as a push to another branch, says why.
+type HookResponse struct {
+	Status    string     `json:"status"`
+	Reason    string     `json:"reason,omitempty"`
+	Sources   []string   `json:"sources,omitempty"`
+	Operation *Operation `json:"operation,omitempty"`
+}
+
+// Hook delivery statuses.
+const (
+	HookAccepted = "accepted"
+	HookIgnored  = "ignored"
+)
+
+// OIDCConfig is returned by GET /auth/oidc when the operator accepts
+// sign-in with an OpenID provider; `angee login` runs the device flow with
+// it.
+type OIDCConfig struct {
+	Issuer   string   `json:"issuer"`
+	ClientID string   `json:"client_id"`
+	Scopes   []string `json:"scopes,omitempty"`
+}
+
+// Notification is the body a generic-format notification webhook receives,
+// and the data of each GET /events event. Deploy or JobRun is set according
+// to the event; alerts name their Service.
+type Notification struct {
+	Event   string    `json:"event"`
+	Stack   string    `json:"stack"`
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+	Service string    `json:"service,omitempty"`
+	Deploy  *Deploy   `json:"deploy,omitempty"`
+	JobRun  *JobRun   `json:"job_run,omitempty"`
+}
+
+type TemplateInfo struct {
+	Name        string          `json:"name"`
+	Kind        string          `json:"kind"`
+	Ref         string          `json:"ref"`
+	Path        string          `json:"path"`
+	Description string          `json:"description,omitempty"`
+	Extends     string          `json:"extends,omitempty"`
+	Inputs      []TemplateInput `json:"inputs,omitempty"`
+}
+
+// Template status states reported by GET /templates/status.
+const (
+	TemplateCurrent   = "current"
+	TemplateOutdated  = "outdated"
+	TemplateUntracked = "untracked"
+	TemplateUnknown   = "unknown"
+)
+
+type TemplateStatus struct {
+	Source string   `json:"source"`
+	Commit string   `json:"commit,omitempty"`
+	With   []string `json:"with,omitempty"`
+	Latest string   `json:"latest,omitempty"`
+	Behind int      `json:"behind"`
+	State  string   `json:"state"`
+}
+
+type TemplateInput struct {
+	Name     string   `json:"name"`
+	Type     string   `json:"type,omitempty"`
+	Default  string   `json:"default,omitempty"`
+	Required bool     `json:"required,omitempty"`
+	Help     string   `json:"help,omitempty"`
+	Choices  []string `json:"choices,omitempty"`
 }
 
 type JobRunRequest struct {
 	Inputs map[string]string `json:"inputs,omitempty"`
+	Async  bool              `json:"async,omitempty"`
 }
 
 type WorkspaceRef struct {
@@ -3,6 +3,7 @@ module github.com/fyltr/angee
 go 1.25.0
 
 require (
+	github.com/flosch/pongo2/v6 v6.0.0
 	github.com/fyltr/copier-go v0.0.0-20260506181622-12e6fe84de57
 	github.com/gosimple/slug v1.15.0
 	github.com/spf13/cobra v1.10.2
@@ -42,7 +43,6 @@ require (
 	github.com/dustin/go-humanize v1.0.1 // indirect
 	github.com/emirpasic/gods v1.18.1 // indirect
 	github.com/erikgeiser/coninput v0.0.0-20211004153227-1c3628e74d0f // indirect
-	github.com/flosch/pongo2/v6 v6.0.0 // indirect
 	github.com/gabriel-vasile/mimetype v1.4.13 // indirect
 	github.com/go-git/gcfg v1.5.1-0.20230307220236-3a3c6141e376 // indirect
 	github.com/go-git/go-billy/v5 v5.9.0 // indirect
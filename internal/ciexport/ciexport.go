@@ -0,0 +1,109 @@
+// Package ciexport renders CI workflows that check a stack on every change
+// and deploy it through a remote operator.
+package ciexport
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"text/template"
+)
+
+// GitHubOptions describes the workflow written by GitHub.
+type GitHubOptions struct {
+	// Root is the stack root relative to the repository checkout.
+	Root string
+	// Branch is the branch whose pushes deploy.
+	Branch string
+	// Sources are the git sources the operator pulls before deploying.
+	Sources []string
+	// Build rebuilds images as part of the deploy.
+	Build bool
+}
+
+var (
+	namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+	pathPattern = regexp.MustCompile(`^[A-Za-z0-9._/-]+$`)
+)
+
+// GitHub returns a GitHub Actions workflow. Every push and pull request runs
+// `angee stack validate`; pushes to Branch then ask the operator at the
+// ANGEE_OPERATOR_URL repository variable to pull Sources and run
+// `POST /stack/up`, authenticated with the ANGEE_OPERATOR_TOKEN secret.
+func GitHub(opts GitHubOptions) ([]byte, error) {
+	if opts.Root == "" {
+		opts.Root = "."
+	}
+	if opts.Branch == "" {
+		opts.Branch = "main"
+	}
+	if !pathPattern.MatchString(opts.Root) {
+		return nil, fmt.Errorf("root %q cannot be used in a workflow command", opts.Root)
+	}
+	if !pathPattern.MatchString(opts.Branch) {
+		return nil, fmt.Errorf("branch %q cannot be used in a workflow trigger", opts.Branch)
+	}
+	for _, source := range opts.Sources {
+		if !namePattern.MatchString(source) {
+			return nil, fmt.Errorf("source %q cannot be used in a URL path", source)
+		}
+	}
+	var out bytes.Buffer
+	if err := githubTemplate.Execute(&out, opts); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+var githubTemplate = template.Must(template.New("github").Funcs(template.FuncMap{
+	"quote": strconv.Quote,
+}).Parse(`# Generated by ` + "`angee export ci github`" + `. Re-run it after adding or
+# removing sources. Set the ANGEE_OPERATOR_URL repository variable and the
+# ANGEE_OPERATOR_TOKEN repository secret to enable deploys.
+name: angee
+
+on:
+  push:
+    branches: [{{ quote .Branch }}]
+  pull_request:
+
+permissions:
+  contents: read
+
+jobs:
+  validate:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - name: Install angee
+        run: curl -fsSL https://angee.ai/install.sh | sudo sh
+      - name: Validate angee.yaml
+        run: angee --root {{ quote .Root }} stack validate
+
+  deploy:
+    needs: validate
+    if: github.event_name == 'push' && github.ref == 'refs/heads/{{ .Branch }}'
+    runs-on: ubuntu-latest
+    concurrency:
+      group: angee-deploy
+      cancel-in-progress: false
+    env:
+      ANGEE_OPERATOR_URL: ${{"{{"}} vars.ANGEE_OPERATOR_URL {{"}}"}}
+      ANGEE_OPERATOR_TOKEN: ${{"{{"}} secrets.ANGEE_OPERATOR_TOKEN {{"}}"}}
+    steps:
+{{- range .Sources }}
+      - name: Pull source {{ . }}
+        run: >-
+          curl --fail-with-body -sS -X POST
+          -H "Authorization: Bearer $ANGEE_OPERATOR_TOKEN"
+          "$ANGEE_OPERATOR_URL/sources/{{ . }}/pull"
+{{- end }}
+      - name: Deploy
+        run: >-
+          curl --fail-with-body -sS -X POST
+          -H "Authorization: Bearer $ANGEE_OPERATOR_TOKEN"
+          -H "Content-Type: application/json"
+          -d '{"build":{{ .Build }}}'
+          "$ANGEE_OPERATOR_URL/stack/up"
+`))
@@ -0,0 +1,65 @@
+package ciexport
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestGitHubWorkflowValidatesAndDeploys(t *testing.T) {
+	data, err := GitHub(GitHubOptions{Root: ".angee", Branch: "release", Sources: []string{"app"}, Build: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var workflow struct {
+		On struct {
+			Push struct {
+				Branches []string `yaml:"branches"`
+			} `yaml:"push"`
+		} `yaml:"on"`
+		Jobs map[string]struct {
+			Needs string `yaml:"needs"`
+			If    string `yaml:"if"`
+			Steps []struct {
+				Name string `yaml:"name"`
+				Run  string `yaml:"run"`
+			} `yaml:"steps"`
+		} `yaml:"jobs"`
+	}
+	if err := yaml.Unmarshal(data, &workflow); err != nil {
+		t.Fatalf("workflow is not YAML: %v\n%s", err, data)
+	}
+	if len(workflow.On.Push.Branches) != 1 || workflow.On.Push.Branches[0] != "release" {
+		t.Fatalf("push branches = %#v", workflow.On.Push.Branches)
+	}
+	validate := workflow.Jobs["validate"].Steps
+	if last := validate[len(validate)-1].Run; last != `angee --root ".angee" stack validate` {
+		t.Fatalf("validate step = %q", last)
+	}
+	deploy := workflow.Jobs["deploy"]
+	if deploy.Needs != "validate" || !strings.Contains(deploy.If, "refs/heads/release") {
+		t.Fatalf("deploy job = %#v", deploy)
+	}
+	if len(deploy.Steps) != 2 || !strings.Contains(deploy.Steps[0].Run, "/sources/app/pull") {
+		t.Fatalf("deploy steps = %#v", deploy.Steps)
+	}
+	if run := deploy.Steps[1].Run; !strings.Contains(run, `'{"build":true}'`) || !strings.HasSuffix(run, `"$ANGEE_OPERATOR_URL/stack/up"`) {
+		t.Fatalf("deploy step = %q", run)
+	}
+	if !strings.Contains(string(data), "${{ secrets.ANGEE_OPERATOR_TOKEN }}") {
+		t.Fatalf("workflow does not read the token secret:\n%s", data)
+	}
+}
+
+func TestGitHubRejectsUnsafeNames(t *testing.T) {
+	for _, opts := range []GitHubOptions{
+		{Root: "$(id)"},
+		{Branch: "main'"},
+		{Sources: []string{"../stack"}},
+	} {
+		if _, err := GitHub(opts); err == nil {
+			t.Fatalf("GitHub(%#v) succeeded", opts)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// initAnswers is an `angee init --answers` file: everything init would
+// otherwise prompt for or guess, so provisioning and CI get the same stack
+// every run.
+type initAnswers struct {
+	Template string                  `yaml:"template"`
+	Path     string                  `yaml:"path"`
+	Name     string                  `yaml:"name"`
+	With     []string                `yaml:"with"`
+	Inputs   map[string]string       `yaml:"inputs"`
+	Secrets  map[string]answerSecret `yaml:"secrets"`
+}
+
+// answerSecret is a secret value written inline, or a reference resolved
+// when init runs: `env: NAME` reads an environment variable and
+// `file: path` reads a file, so the answers file itself can be committed.
+type answerSecret struct {
+	Value string
+	Env   string `yaml:"env"`
+	File  string `yaml:"file"`
+}
+
+func (s *answerSecret) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&s.Value)
+	}
+	type plain answerSecret
+	var ref plain
+	if err := node.Decode(&ref); err != nil {
+		return err
+	}
+	if (ref.Env == "") == (ref.File == "") {
+		return fmt.Errorf("line %d: a secret reference sets exactly one of env or file", node.Line)
+	}
+	*s = answerSecret(ref)
+	return nil
+}
+
+func (s answerSecret) resolve(name string) (string, error) {
+	switch {
+	case s.Env != "":
+		value, ok := os.LookupEnv(s.Env)
+		if !ok {
+			return "", fmt.Errorf("secret %s: environment variable %s is not set", name, s.Env)
+		}
+		return value, nil
+	case s.File != "":
+		data, err := os.ReadFile(s.File)
+		if err != nil {
+			return "", fmt.Errorf("secret %s: %w", name, err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	default:
+		return s.Value, nil
+	}
+}
+
+// loadInitAnswers reads an answers file. An empty path returns empty
+// answers.
+func loadInitAnswers(path string) (initAnswers, error) {
+	var answers initAnswers
+	if path == "" {
+		return answers, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return answers, err
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&answers); err != nil && !errors.Is(err, io.EOF) {
+		return answers, fmt.Errorf("read %s: %w", path, err)
+	}
+	return answers, nil
+}
+
+// answersTemplate returns the template of the command's --answers file, or
+// "" without one.
+func answersTemplate(cmd *cobra.Command) string {
+	path, _ := cmd.Flags().GetString("answers")
+	answers, err := loadInitAnswers(path)
+	if err != nil {
+		return ""
+	}
+	return answers.Template
+}
+
+// merge layers flag inputs over the answers: `name` becomes the
+// `project_name` template input, and an --input flag wins over the file.
+func (a initAnswers) merge(flags map[string]string) map[string]string {
+	out := map[string]string{}
+	if a.Name != "" {
+		out["project_name"] = a.Name
+	}
+	for key, value := range a.Inputs {
+		out[key] = value
+	}
+	for key, value := range flags {
+		out[key] = value
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// secretValues resolves the answers' secrets to the values StackInit
+// stores.
+func (a initAnswers) secretValues() (map[string]string, error) {
+	if len(a.Secrets) == 0 {
+		return nil, nil
+	}
+	out := make(map[string]string, len(a.Secrets))
+	for name, secret := range a.Secrets {
+		value, err := secret.resolve(name)
+		if err != nil {
+			return nil, err
+		}
+		out[name] = value
+	}
+	return out, nil
+}
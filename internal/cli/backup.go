@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func backupCommand(stdout io.Writer, root, operatorURL *string, jsonOutput *bool) *cobra.Command {
+	cmd := &cobra.Command{Use: "backup", Short: "Manage database backups of services with a backup declaration"}
+	cmd.AddCommand(&cobra.Command{
+		Use:     "list [service]",
+		Aliases: []string{"ls"},
+		Short:   "List backups, newest first",
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			platform, err := localPlatform(root, operatorURL)
+			if err != nil {
+				return err
+			}
+			service := ""
+			if len(args) == 1 {
+				service = args[0]
+			}
+			backups, err := platform.BackupList(cmd.Context(), service)
+			if err != nil {
+				return err
+			}
+			if *jsonOutput {
+				return writeJSON(stdout, backups)
+			}
+			for _, backup := range backups {
+				if _, err := fmt.Fprintf(stdout, "%s\t%s\t%s\t%s\n", backup.Service, backup.Name, backup.CreatedAt.Format(time.RFC3339), formatBytes(uint64(backup.Size))); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "run <service>",
+		Short: "Dump a service's database now",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			platform, err := localPlatform(root, operatorURL)
+			if err != nil {
+				return err
+			}
+			backup, err := platform.BackupRun(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			if *jsonOutput {
+				return writeJSON(stdout, backup)
+			}
+			_, err = fmt.Fprintf(stdout, "backed up %s to %s (%s)\n", backup.Service, backup.Name, formatBytes(uint64(backup.Size)))
+			return err
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "restore <service> <backup>",
+		Short: "Load a backup into a running service's database",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			platform, err := localPlatform(root, operatorURL)
+			if err != nil {
+				return err
+			}
+			if err := platform.BackupRestore(cmd.Context(), args[0], args[1]); err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(stdout, "restored %s from %s\n", args[0], args[1])
+			return err
+		},
+	})
+	return cmd
+}
@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// completeTemplates completes template names of kind, with their
+// descriptions.
+func completeTemplates(root, operatorURL *string, kind string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		platform, err := localPlatformForRoot(root, operatorURL, false)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		templates, err := platform.TemplateList(cmd.Context(), kind)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		var out []string
+		for _, template := range templates {
+			if strings.HasPrefix(template.Name, toComplete) {
+				out = append(out, completion(template.Name, template.Description))
+			}
+		}
+		return out, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeStackInputs completes --input for a stack template: `key=` for
+// each question the template and its --with addons ask, described by the
+// question's help, and `key=value` for the choices of a typed key. template
+// names the template the command line selects so far.
+func completeStackInputs(root, operatorURL *string, template func(cmd *cobra.Command, args []string) string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		name := template(cmd, args)
+		if name == "" {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		platform, err := localPlatformForRoot(root, operatorURL, false)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		addons, _ := cmd.Flags().GetStringArray("with")
+		questions, defaults, err := platform.StackTemplateQuestions(cmd.Context(), name, addons)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		if key, prefix, ok := strings.Cut(toComplete, "="); ok {
+			var out []string
+			for _, choice := range questions[key].ChoiceValues() {
+				if strings.HasPrefix(choice, prefix) {
+					out = append(out, key+"="+choice)
+				}
+			}
+			return out, cobra.ShellCompDirectiveNoFileComp
+		}
+		given, _ := cmd.Flags().GetStringArray("input")
+		var out []string
+		for key, question := range questions {
+			if question.Generated || !strings.HasPrefix(key, toComplete) || inputGiven(given, key) {
+				continue
+			}
+			help := question.Help
+			if value, ok := defaults[key]; ok && value != "" {
+				help = strings.TrimSpace(help + " [" + value + "]")
+			}
+			out = append(out, completion(key+"=", help))
+		}
+		sort.Strings(out)
+		return out, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+	}
+}
+
+func inputGiven(inputs []string, key string) bool {
+	for _, input := range inputs {
+		if name, _, _ := strings.Cut(input, "="); name == key {
+			return true
+		}
+	}
+	return false
+}
+
+// completion is a candidate with its description, which shells show next to
+// it. The description is cut to its first line.
+func completion(value, description string) string {
+	description, _, _ = strings.Cut(strings.TrimSpace(description), "\n")
+	if description == "" {
+		return value
+	}
+	return value + "\t" + description
+}
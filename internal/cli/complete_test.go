@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStackInitCompletesTemplatesAndInputs(t *testing.T) {
+	root := t.TempDir()
+	templateRoot := writeStackTemplate(t, root)
+	f, err := os.OpenFile(filepath.Join(templateRoot, "copier.yml"), os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("OpenFile(copier.yml) error = %v", err)
+	}
+	if _, err := f.WriteString("database:\n  choices: [postgres, sqlite]\n  default: postgres\n"); err != nil {
+		t.Fatalf("WriteString(copier.yml) error = %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close(copier.yml) error = %v", err)
+	}
+	t.Chdir(root)
+
+	complete := func(args ...string) string {
+		t.Helper()
+		var stdout, stderr bytes.Buffer
+		cmd := NewRoot(&stdout, &stderr)
+		cmd.SetArgs(append([]string{"__complete"}, args...))
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("complete %v error = %v", args, err)
+		}
+		return stdout.String()
+	}
+	if got := complete("stack", "init", ""); !strings.Contains(got, "dev\tDevelopment stack\n") {
+		t.Fatalf("template completion = %q, want dev with its description", got)
+	}
+	got := complete("stack", "init", "dev", "--input", "")
+	if !strings.Contains(got, "ANGEE_ROOT=\tStack root directory [.angee]\n") || !strings.Contains(got, "database=\t[postgres]\n") {
+		t.Fatalf("input completion = %q, want keys with help and defaults", got)
+	}
+	got = complete("stack", "init", "dev", "--input", "database=ANGEE", "--input", "")
+	if strings.Contains(got, "database=") {
+		t.Fatalf("input completion = %q, want keys already given left out", got)
+	}
+	if got := complete("stack", "init", "dev", "--input", "database=s"); !strings.Contains(got, "database=sqlite\n") || strings.Contains(got, "postgres") {
+		t.Fatalf("choice completion = %q, want database=sqlite", got)
+	}
+}
@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/fyltr/angee/api"
+	"github.com/fyltr/angee/internal/service"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes are part of the CLI's contract, documented in
+// docs/guide/commands.md. They follow the error code, so a command exits
+// the same way whether it ran locally or against an operator.
+const (
+	ExitOK         = 0
+	ExitFailure    = 1
+	ExitInvalid    = 2
+	ExitRuntime    = 3
+	ExitAuth       = 4
+	ExitRolledBack = 5
+	ExitConflict   = 6
+)
+
+// ExitCode returns the process exit code for the error a command returned.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var usage *usageError
+	if errors.As(err, &usage) {
+		return ExitInvalid
+	}
+	var remote *RemoteError
+	if errors.As(err, &remote) && remote.Body.Code == "" && (remote.Status == http.StatusUnauthorized || remote.Status == http.StatusForbidden) {
+		return ExitAuth
+	}
+	switch errorResponse(err).Code {
+	case api.ErrorCodeInvalidInput, api.ErrorCodeNotFound, api.ErrorCodePolicy, api.ErrorCodeMissingSecret:
+		return ExitInvalid
+	case api.ErrorCodeRuntimeFailed, api.ErrorCodePortConflict, api.ErrorCodeBackendUnreachable:
+		return ExitRuntime
+	case api.ErrorCodeUnauthorized:
+		return ExitAuth
+	case api.ErrorCodeRolledBack:
+		return ExitRolledBack
+	case api.ErrorCodeConflict:
+		return ExitConflict
+	}
+	return ExitFailure
+}
+
+// usageError is a command invoked with flags or arguments it does not
+// accept.
+type usageError struct{ err error }
+
+func (e *usageError) Error() string { return e.err.Error() }
+
+func (e *usageError) Unwrap() error { return e.err }
+
+// markUsageErrors makes flag and argument errors from cmd and every
+// command under it usage errors.
+func markUsageErrors(cmd *cobra.Command) {
+	cmd.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return &usageError{err: err}
+	})
+	var walk func(*cobra.Command)
+	walk = func(c *cobra.Command) {
+		if validate := c.Args; validate != nil {
+			c.Args = func(c *cobra.Command, args []string) error {
+				if err := validate(c, args); err != nil {
+					return &usageError{err: err}
+				}
+				return nil
+			}
+		}
+		for _, child := range c.Commands() {
+			walk(child)
+		}
+	}
+	walk(cmd)
+}
+
+// errorResponse describes a failed command the way the operator describes
+// a failed request. A remote failure keeps the operator's code and hint.
+func errorResponse(err error) api.ErrorResponse {
+	var remote *RemoteError
+	if errors.As(err, &remote) {
+		body := remote.Body
+		if body.Code != "" {
+			body.Error = err.Error()
+			return body
+		}
+	}
+	code, hint := service.ErrorCode(err)
+	return api.ErrorResponse{Code: code, Error: err.Error(), Hint: hint}
+}
+
+// reportError writes a failed command to w: the error and its hint, or
+// with --json the api.ErrorResponse, so scripts and agents branch on its
+// code.
+func reportError(w io.Writer, err error, asJSON bool) {
+	body := errorResponse(err)
+	if asJSON {
+		_ = json.NewEncoder(w).Encode(body)
+		return
+	}
+	fmt.Fprintln(w, body.Error)
+	if body.Hint != "" {
+		fmt.Fprintln(w, "hint:", body.Hint)
+	}
+}
@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/fyltr/angee/api"
+	"github.com/fyltr/angee/internal/runtime"
+	"github.com/fyltr/angee/internal/service"
+)
+
+func TestExitCode(t *testing.T) {
+	failed := &runtime.CommandError{Runtime: "docker", Err: errors.New("exit status 1")}
+	for _, tc := range []struct {
+		err  error
+		want int
+	}{
+		{nil, ExitOK},
+		{errors.New("boom"), ExitFailure},
+		{&service.InvalidInputError{Field: "name", Reason: "is required"}, ExitInvalid},
+		{&service.NotFoundError{Kind: "service", Name: "web"}, ExitInvalid},
+		{fmt.Errorf("up: %w", failed), ExitRuntime},
+		{&service.RolledBackError{Err: failed, Revision: "sha256:ab"}, ExitRolledBack},
+		{&service.ConflictError{Kind: "manifest", Name: "angee.yaml"}, ExitConflict},
+		{&RemoteError{Status: http.StatusUnauthorized, Body: api.ErrorResponse{Error: "Unauthorized"}}, ExitAuth},
+		{&RemoteError{Status: http.StatusInternalServerError, Body: api.ErrorResponse{Code: api.ErrorCodeRuntimeFailed, Error: "docker compose up: exit status 1"}}, ExitRuntime},
+	} {
+		if got := ExitCode(tc.err); got != tc.want {
+			t.Errorf("ExitCode(%v) = %d, want %d", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestUsageErrorsExitInvalid(t *testing.T) {
+	for _, args := range [][]string{{"status", "--no-such-flag"}, {"root", "move"}} {
+		var stdout, stderr bytes.Buffer
+		cmd := NewRoot(&stdout, &stderr)
+		cmd.SetArgs(args)
+		if err := cmd.Execute(); ExitCode(err) != ExitInvalid {
+			t.Fatalf("Execute(%v) error = %v, exit %d; want %d", args, err, ExitCode(err), ExitInvalid)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fyltr/angee/api"
+	"github.com/fyltr/angee/api/client"
+	"github.com/spf13/cobra"
+)
+
+func eventsCommand(stdout io.Writer, operatorURL *string, jsonOutput *bool) *cobra.Command {
+	var filter eventFilter
+	cmd := &cobra.Command{
+		Use:   "events",
+		Short: "Follow the operator's deploy, job, and alert events",
+		Long: "Prints each event the operator raises, the same ones its notification\n" +
+			"webhooks and GET /events receive, as a line with its time, type, and\n" +
+			"message, or with --json as one JSON object per line. Runs until\n" +
+			"interrupted or the operator closes the stream.\n" +
+			"\n" +
+			"Examples:\n" +
+			"  angee events --operator http://127.0.0.1:9000\n" +
+			"  angee events --type deploy --type alert.firing --service web",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if *operatorURL == "" {
+				return errors.New("events follows a running operator; pass --operator or set ANGEE_OPERATOR_URL")
+			}
+			events, err := newRemotePlatform(*operatorURL).client.Events(cmd.Context())
+			if err != nil {
+				return err
+			}
+			enc := json.NewEncoder(stdout)
+			for event := range events {
+				notification, ok := decodeEvent(event)
+				if !ok || !filter.match(notification) {
+					continue
+				}
+				if *jsonOutput {
+					err = enc.Encode(notification)
+				} else {
+					_, err = io.WriteString(stdout, eventLine(notification))
+				}
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringArrayVar(&filter.types, "type", nil, "only events of this type, such as deploy.failed, or of this kind, such as deploy (repeatable)")
+	cmd.Flags().StringVar(&filter.service, "service", "", "only alerts for this service and deploys that included it")
+	return cmd
+}
+
+// decodeEvent reads a notification from an operator event; the ready event
+// and anything else without an event type are skipped.
+func decodeEvent(event client.Event) (api.Notification, bool) {
+	var notification api.Notification
+	if err := json.Unmarshal(event.Data, &notification); err != nil || notification.Event == "" {
+		return api.Notification{}, false
+	}
+	return notification, true
+}
+
+type eventFilter struct {
+	types   []string
+	service string
+}
+
+func (f eventFilter) match(event api.Notification) bool {
+	if len(f.types) > 0 && !slices.ContainsFunc(f.types, func(t string) bool {
+		return event.Event == t || strings.HasPrefix(event.Event, t+".")
+	}) {
+		return false
+	}
+	if f.service == "" || event.Service == f.service {
+		return true
+	}
+	// A deploy without services deployed the whole stack.
+	return event.Deploy != nil && (len(event.Deploy.Services) == 0 || slices.Contains(event.Deploy.Services, f.service))
+}
+
+func eventLine(event api.Notification) string {
+	return fmt.Sprintf("%s\t%s\t%s\n", event.Time.Format(time.RFC3339), event.Event, event.Message)
+}
@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fyltr/angee/api"
+	"github.com/fyltr/angee/internal/manifest"
+)
+
+func TestEventsFiltersOperatorStream(t *testing.T) {
+	at := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	notifications := []api.Notification{
+		{Event: manifest.EventDeploySucceeded, Stack: "notes", Message: "notes: deploy succeeded", Time: at, Deploy: &api.Deploy{Services: []string{"web"}}},
+		{Event: manifest.EventDeployFailed, Stack: "notes", Message: "notes: deploy failed", Time: at, Deploy: &api.Deploy{Services: []string{"worker"}}},
+		{Event: manifest.EventAlertFiring, Stack: "notes", Message: "notes: alert web-down firing", Time: at, Service: "web"},
+		{Event: manifest.EventJobFailed, Stack: "notes", Message: "notes: job check failed", Time: at, JobRun: &api.JobRun{Job: "check"}},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/events" {
+			t.Errorf("request = %s %s, want GET /v1/events", r.Method, r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "event: ready\ndata: {}\n\n")
+		for i, notification := range notifications {
+			data, _ := json.Marshal(notification)
+			fmt.Fprintf(w, "event: %s\nid: %d\ndata: %s\n\n", notification.Event, i+1, data)
+		}
+	}))
+	defer server.Close()
+
+	run := func(args ...string) string {
+		t.Helper()
+		var stdout, stderr bytes.Buffer
+		cmd := NewRoot(&stdout, &stderr)
+		cmd.SetArgs(append([]string{"--operator", server.URL, "events"}, args...))
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("events %v error = %v", args, err)
+		}
+		return stdout.String()
+	}
+	got := run("--type", "deploy", "--service", "web")
+	if want := "2026-05-01T12:00:00Z\tdeploy.succeeded\tnotes: deploy succeeded\n"; got != want {
+		t.Fatalf("events --type deploy --service web = %q, want %q", got, want)
+	}
+	got = run("--type", "alert.firing", "--type", "job")
+	if lines := strings.Split(strings.TrimSpace(got), "\n"); len(lines) != 2 || !strings.Contains(lines[0], "alert.firing") || !strings.Contains(lines[1], "job.failed") {
+		t.Fatalf("events --type alert.firing --type job = %q", got)
+	}
+	got = run("--json", "--type", "job")
+	var notification api.Notification
+	if err := json.Unmarshal([]byte(got), &notification); err != nil || notification.JobRun == nil || notification.JobRun.Job != "check" {
+		t.Fatalf("events --json = %s", got)
+	}
+}
+
+func TestEventsNeedsOperator(t *testing.T) {
+	t.Setenv("ANGEE_OPERATOR_URL", "")
+	var stdout, stderr bytes.Buffer
+	cmd := NewRoot(&stdout, &stderr)
+	cmd.SetArgs([]string{"events"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "--operator") {
+		t.Fatalf("events without an operator error = %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/fyltr/angee/internal/ciexport"
+	"github.com/fyltr/angee/internal/service"
+	"github.com/fyltr/angee/internal/tfexport"
+	"github.com/spf13/cobra"
+)
+
+func exportCommand(stdout io.Writer, root, operatorURL *string) *cobra.Command {
+	cmd := &cobra.Command{Use: "export", Short: "Export the compiled stack for other tools"}
+	var output string
+	terraformCmd := &cobra.Command{
+		Use:   "terraform",
+		Short: "Write docker provider resources equivalent to the compiled stack as Terraform JSON",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			platform, err := localPlatform(root, operatorURL)
+			if err != nil {
+				return err
+			}
+			local, ok := platform.(*service.Platform)
+			if !ok {
+				return errors.New("export terraform resolves paths in a local stack and is not available with --operator")
+			}
+			compiled, err := local.StackCompile(cmd.Context())
+			if err != nil {
+				return err
+			}
+			secretEnvVars := make([]string, 0, len(compiled.SecretEnvVars))
+			for _, env := range compiled.SecretEnvVars {
+				secretEnvVars = append(secretEnvVars, env)
+			}
+			sort.Strings(secretEnvVars)
+			data, warnings, err := tfexport.Export(compiled.Compose, tfexport.Options{Root: local.Root(), SecretEnvVars: secretEnvVars})
+			if err != nil {
+				return err
+			}
+			skipped := make([]string, 0, len(compiled.ProcessCompose.Processes))
+			for name := range compiled.ProcessCompose.Processes {
+				skipped = append(skipped, "service "+name+": local process services are not exported")
+			}
+			sort.Strings(skipped)
+			warnings = append(warnings, skipped...)
+			for _, warning := range warnings {
+				fmt.Fprintf(cmd.ErrOrStderr(), "warning: %s\n", warning)
+			}
+			if output == "" {
+				_, err = stdout.Write(data)
+				return err
+			}
+			return os.WriteFile(output, data, 0o644)
+		},
+	}
+	terraformCmd.Flags().StringVarP(&output, "output", "o", "", "write to this file (for example main.tf.json) instead of stdout")
+	cmd.AddCommand(terraformCmd)
+	cmd.AddCommand(exportCICommand(stdout, root, operatorURL))
+	return cmd
+}
+
+func exportCICommand(stdout io.Writer, root, operatorURL *string) *cobra.Command {
+	cmd := &cobra.Command{Use: "ci", Short: "Export CI workflows that validate and deploy the stack"}
+	var output, branch string
+	var sources []string
+	var build bool
+	githubCmd := &cobra.Command{
+		Use:   "github",
+		Short: "Write a GitHub Actions workflow that validates angee.yaml and deploys through the operator",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if filepath.IsAbs(*root) {
+				return errors.New("export ci github needs --root relative to the repository checkout")
+			}
+			platform, err := localPlatform(root, operatorURL)
+			if err != nil {
+				return err
+			}
+			local, ok := platform.(*service.Platform)
+			if !ok {
+				return errors.New("export ci github reads a local angee.yaml and is not available with --operator")
+			}
+			stack, err := local.LoadStack()
+			if err != nil {
+				return err
+			}
+			if !cmd.Flags().Changed("source") {
+				for name, source := range stack.Sources {
+					if source.Kind == "git" {
+						sources = append(sources, name)
+					}
+				}
+				sort.Strings(sources)
+			}
+			for _, name := range sources {
+				if _, ok := stack.Sources[name]; !ok {
+					return fmt.Errorf("source %q is not declared in angee.yaml", name)
+				}
+			}
+			if !cmd.Flags().Changed("build") {
+				for _, svc := range stack.Services {
+					build = build || svc.Build != nil
+				}
+			}
+			data, err := ciexport.GitHub(ciexport.GitHubOptions{
+				Root:    filepath.ToSlash(filepath.Clean(*root)),
+				Branch:  branch,
+				Sources: sources,
+				Build:   build,
+			})
+			if err != nil {
+				return err
+			}
+			if output == "" {
+				_, err = stdout.Write(data)
+				return err
+			}
+			if err := os.MkdirAll(filepath.Dir(output), 0o755); err != nil {
+				return err
+			}
+			return os.WriteFile(output, data, 0o644)
+		},
+	}
+	githubCmd.Flags().StringVarP(&output, "output", "o", "", "write to this file (for example .github/workflows/angee.yml) instead of stdout")
+	githubCmd.Flags().StringVar(&branch, "branch", "main", "branch whose pushes deploy")
+	githubCmd.Flags().StringSliceVar(&sources, "source", nil, "source the operator pulls before deploying (default: every git source)")
+	githubCmd.Flags().BoolVar(&build, "build", false, "rebuild images on deploy (default: when any service has a build)")
+	cmd.AddCommand(githubCmd)
+	return cmd
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fyltr/angee/internal/service"
+	"github.com/spf13/cobra"
+)
+
+// historyFlags are the paging flags of commands that show a history.
+type historyFlags struct {
+	limit        int
+	since, until string
+}
+
+func (f *historyFlags) register(cmd *cobra.Command, noun string) {
+	cmd.Flags().IntVarP(&f.limit, "limit", "n", 0, "show only the newest N "+noun)
+	cmd.Flags().StringVar(&f.since, "since", "", "show "+noun+" started at or after this RFC 3339 time or this long ago, e.g. 24h")
+	cmd.Flags().StringVar(&f.until, "until", "", "show "+noun+" started before this RFC 3339 time or this long ago")
+}
+
+func (f historyFlags) query(now time.Time) (service.HistoryQuery, error) {
+	query := service.HistoryQuery{Limit: f.limit}
+	for _, flag := range []struct {
+		name, raw string
+		value     *time.Time
+	}{{"since", f.since, &query.Since}, {"until", f.until, &query.Until}} {
+		if flag.raw == "" {
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339, flag.raw); err == nil {
+			*flag.value = t
+			continue
+		}
+		ago, err := time.ParseDuration(flag.raw)
+		if err != nil || ago < 0 {
+			return service.HistoryQuery{}, &usageError{err: fmt.Errorf("--%s %q is neither an RFC 3339 time nor a duration such as 24h", flag.name, flag.raw)}
+		}
+		*flag.value = now.Add(-ago)
+	}
+	return query, nil
+}
+
+// historyPages collects pages from fetch until the query's limit is met or
+// the last page, so a remote operator's page size does not cut a listing
+// short.
+func historyPages[T any](query service.HistoryQuery, fetch func(service.HistoryQuery) ([]T, string, error)) ([]T, error) {
+	limit := query.Limit
+	all := []T{}
+	for {
+		if limit > 0 {
+			query.Limit = limit - len(all)
+		}
+		page, next, err := fetch(query)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+		if next == "" || limit > 0 && len(all) >= limit {
+			break
+		}
+		query.Cursor = next
+	}
+	if limit > 0 && len(all) > limit {
+		all = all[:limit]
+	}
+	return all, nil
+}
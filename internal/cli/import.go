@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/fyltr/angee/internal/composeimport"
+	"github.com/fyltr/angee/internal/manifest"
+	"github.com/fyltr/angee/internal/service"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+func importCommand(stdout io.Writer, root, operatorURL *string) *cobra.Command {
+	cmd := &cobra.Command{Use: "import", Short: "Import services from other tools into angee.yaml"}
+	var dryRun bool
+	composeCmd := &cobra.Command{
+		Use:   "compose <file>",
+		Short: "Add the services and volumes of a docker-compose file to angee.yaml",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := os.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+			result, err := composeimport.Import(data)
+			if err != nil {
+				return err
+			}
+			for _, warning := range result.Warnings {
+				fmt.Fprintf(cmd.ErrOrStderr(), "warning: %s\n", warning)
+			}
+			if dryRun {
+				return writeImportedServices(stdout, result)
+			}
+			platform, err := localPlatform(root, operatorURL)
+			if err != nil {
+				return err
+			}
+			local, ok := platform.(*service.Platform)
+			if !ok {
+				return errors.New("import compose edits a local angee.yaml and is not available with --operator")
+			}
+			if err := local.ServiceImport(cmd.Context(), result.Services, result.Volumes); err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(stdout, "imported %d service(s) and %d volume(s) with %d warning(s)\n", len(result.Services), len(result.Volumes), len(result.Warnings))
+			return err
+		},
+	}
+	composeCmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the angee.yaml entries instead of writing them")
+	cmd.AddCommand(composeCmd)
+	return cmd
+}
+
+func writeImportedServices(w io.Writer, result *composeimport.Result) error {
+	data, err := yaml.Marshal(struct {
+		Volumes  map[string]manifest.Volume  `yaml:"volumes,omitempty"`
+		Services map[string]manifest.Service `yaml:"services"`
+	}{result.Volumes, result.Services})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
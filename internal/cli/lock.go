@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func lockCommand(stdout io.Writer, root, operatorURL *string, jsonOutput *bool) *cobra.Command {
+	cmd := &cobra.Command{Use: "lock", Short: "Pin what angee.yaml references to exact versions in angee.lock"}
+	var refresh bool
+	imagesCmd := &cobra.Command{
+		Use:   "images",
+		Short: "Resolve container images to digests and record them in angee.lock",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			platform, err := localPlatform(root, operatorURL)
+			if err != nil {
+				return err
+			}
+			resp, err := platform.StackLockImages(cmd.Context(), refresh)
+			if err != nil {
+				return err
+			}
+			if *jsonOutput {
+				return writeJSON(stdout, resp)
+			}
+			if len(resp.Images) == 0 {
+				_, err = fmt.Fprintln(stdout, "no images to lock")
+				return err
+			}
+			for _, image := range resp.Images {
+				if _, err := fmt.Fprintf(stdout, "%s\t%s\t%s\n", image.Image, image.Digest, strings.Join(image.Services, ",")); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	imagesCmd.Flags().BoolVar(&refresh, "refresh", false, "resolve locked images again, moving each to what its tag points at now")
+	cmd.AddCommand(imagesCmd)
+	return cmd
+}
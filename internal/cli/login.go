@@ -0,0 +1,188 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fyltr/angee/internal/oidc"
+	"github.com/spf13/cobra"
+)
+
+// credential is what `angee login` stores for one operator.
+type credential struct {
+	Issuer       string `json:"issuer"`
+	ClientID     string `json:"client_id"`
+	IDToken      string `json:"id_token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+func loginCommand(stdout io.Writer, operatorURL *string) *cobra.Command {
+	return &cobra.Command{
+		Use:   "login",
+		Short: "Sign in to an operator with its OpenID provider",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if *operatorURL == "" {
+				return errors.New("login needs --operator or ANGEE_OPERATOR_URL")
+			}
+			ctx := cmd.Context()
+			baseURL := strings.TrimRight(*operatorURL, "/")
+			config, err := newRemotePlatform(baseURL).client.OIDCConfig(ctx)
+			if err != nil {
+				return err
+			}
+			provider, err := oidc.Discover(ctx, nil, config.Issuer)
+			if err != nil {
+				return err
+			}
+			code, err := provider.DeviceAuth(ctx, config.ClientID, config.Scopes)
+			if err != nil {
+				return err
+			}
+			if code.VerificationURIComplete != "" {
+				fmt.Fprintf(stdout, "Open %s and confirm code %s\n", code.VerificationURIComplete, code.UserCode)
+			} else {
+				fmt.Fprintf(stdout, "Open %s and enter code %s\n", code.VerificationURI, code.UserCode)
+			}
+			token, err := provider.PollDevice(ctx, config.ClientID, code)
+			if err != nil {
+				return err
+			}
+			claims, err := provider.Verify(ctx, token.IDToken, config.ClientID)
+			if err != nil {
+				return err
+			}
+			credentials, err := loadCredentials()
+			if err != nil {
+				return err
+			}
+			credentials[operatorKey(baseURL)] = credential{Issuer: config.Issuer, ClientID: config.ClientID, IDToken: token.IDToken, RefreshToken: token.RefreshToken}
+			if err := saveCredentials(credentials); err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(stdout, "signed in to %s as %s\n", baseURL, claims.Identity())
+			return err
+		},
+	}
+}
+
+func logoutCommand(stdout io.Writer, operatorURL *string) *cobra.Command {
+	return &cobra.Command{
+		Use:   "logout",
+		Short: "Forget the sign-in to an operator",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if *operatorURL == "" {
+				return errors.New("logout needs --operator or ANGEE_OPERATOR_URL")
+			}
+			credentials, err := loadCredentials()
+			if err != nil {
+				return err
+			}
+			delete(credentials, operatorKey(*operatorURL))
+			if err := saveCredentials(credentials); err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(stdout, "signed out of %s\n", strings.TrimRight(*operatorURL, "/"))
+			return err
+		},
+	}
+}
+
+// operatorToken returns the bearer token for a request to the operator at
+// target: ANGEE_OPERATOR_TOKEN when set, else the ID token stored by
+// `angee login`, refreshed first when it is about to expire and a refresh
+// token was issued. Refresh failures leave the old token for the operator
+// to reject with a reason.
+func operatorToken(ctx context.Context, target *url.URL) string {
+	if token := os.Getenv("ANGEE_OPERATOR_TOKEN"); token != "" {
+		return token
+	}
+	credentials, err := loadCredentials()
+	if err != nil {
+		return ""
+	}
+	key := operatorKey(target.Scheme + "://" + target.Host)
+	cred, ok := credentials[key]
+	if !ok {
+		return ""
+	}
+	if expiry, err := oidc.UnverifiedExpiry(cred.IDToken); cred.RefreshToken == "" || err == nil && time.Until(expiry) > time.Minute {
+		return cred.IDToken
+	}
+	provider, err := oidc.Discover(ctx, nil, cred.Issuer)
+	if err != nil {
+		return cred.IDToken
+	}
+	token, err := provider.Refresh(ctx, cred.ClientID, cred.RefreshToken)
+	if err != nil {
+		return cred.IDToken
+	}
+	cred.IDToken = token.IDToken
+	if token.RefreshToken != "" {
+		cred.RefreshToken = token.RefreshToken
+	}
+	credentials[key] = cred
+	_ = saveCredentials(credentials)
+	return cred.IDToken
+}
+
+// operatorKey identifies an operator by scheme and host, so paths and
+// trailing slashes in --operator do not matter.
+func operatorKey(operatorURL string) string {
+	u, err := url.Parse(operatorURL)
+	if err != nil || u.Host == "" {
+		return strings.TrimRight(operatorURL, "/")
+	}
+	return strings.ToLower(u.Scheme + "://" + u.Host)
+}
+
+func credentialsPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "angee", "credentials.json"), nil
+}
+
+func loadCredentials() (map[string]credential, error) {
+	path, err := credentialsPath()
+	if err != nil {
+		return nil, err
+	}
+	credentials := map[string]credential{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return credentials, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &credentials); err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	return credentials, nil
+}
+
+func saveCredentials(credentials map[string]credential) error {
+	path, err := credentialsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(credentials, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
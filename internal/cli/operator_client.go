@@ -1,33 +1,37 @@
 package cli
 
 import (
-	"bufio"
-	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"io"
 	"net/http"
 	"net/url"
-	"strings"
 
 	"github.com/fyltr/angee/api"
+	"github.com/fyltr/angee/api/client"
 	"github.com/fyltr/angee/internal/copierx"
 	"github.com/fyltr/angee/internal/service"
 )
 
 type platformClient interface {
-	StackInit(context.Context, string, string, map[string]string, bool) (service.StackInitResult, error)
-	StackTemplateQuestions(context.Context, string) (map[string]copierx.Input, copierx.Inputs, error)
+	StackInit(context.Context, string, []string, string, map[string]string, map[string]string, bool) (service.StackInitResult, error)
+	StackTemplateQuestions(context.Context, string, []string) (map[string]copierx.Input, copierx.Inputs, error)
 	StackUpdate(context.Context) error
+	TemplateList(context.Context, string) ([]api.TemplateInfo, error)
+	TemplateInfo(context.Context, string, string) (api.TemplateInfo, error)
+	TemplateStatus(context.Context) (api.TemplateStatus, error)
 	StackDestroy(context.Context, bool) error
-	StackBuild(context.Context, []string) error
-	StackUp(context.Context, []string, bool) error
-	StackUpForeground(context.Context, []string, bool, io.Writer, io.Writer) error
+	StackPrune(context.Context, service.PruneOptions) (api.PruneResponse, error)
+	StackLockImages(context.Context, bool) (api.ImageLockResponse, error)
+	StackOutdated(context.Context) (api.OutdatedResponse, error)
+	StackPlan(context.Context) (api.Plan, error)
+	StackBuild(context.Context, []string, service.BuildOptions) error
+	StackUp(context.Context, []string, service.UpOptions) error
+	StackUpForeground(context.Context, []string, service.UpOptions, io.Writer, io.Writer) error
 	StackDevForeground(context.Context, bool, io.Writer, io.Writer) error
 	StackDown(context.Context) error
-	StackLogs(context.Context, []string, bool) (<-chan string, error)
+	StackLogsLimited(context.Context, []string, bool, service.LogLimits) (<-chan string, error)
 	StackStatus(context.Context) (api.StackStatusResponse, error)
 	StackCompile(context.Context) (*service.CompiledStack, error)
 	StackPrepare(context.Context) (*service.CompiledStack, error)
@@ -40,6 +44,11 @@ type platformClient interface {
 	ServiceRestart(context.Context, []string) error
 	JobList(context.Context) ([]api.JobState, error)
 	JobRun(context.Context, string, map[string]string) ([]byte, error)
+	JobRunPage(context.Context, string, service.HistoryQuery) ([]api.JobRun, string, error)
+	DeployPage(context.Context, service.HistoryQuery) ([]api.Deploy, string, error)
+	BackupList(context.Context, string) ([]api.Backup, error)
+	BackupRun(context.Context, string) (api.Backup, error)
+	BackupRestore(context.Context, string, string) error
 	SourceList(context.Context) ([]api.SourceState, error)
 	SourceFetch(context.Context, string) (api.SourceState, error)
 	SourceStatus(context.Context, string) (api.SourceState, error)
@@ -51,7 +60,7 @@ type platformClient interface {
 	WorkspaceStatus(context.Context, string) (api.WorkspaceStatusResponse, error)
 	WorkspaceUpdate(context.Context, string, map[string]string, string) (api.WorkspaceRef, error)
 	WorkspaceDestroy(context.Context, string, bool) error
-	WorkspaceLogs(context.Context, string, bool) (<-chan string, error)
+	WorkspaceLogsLimited(context.Context, string, bool, service.LogLimits) (<-chan string, error)
 	WorkspaceStart(context.Context, string) error
 	WorkspaceStop(context.Context, string) error
 	WorkspaceGitStatus(context.Context, string) ([]api.SourceState, error)
@@ -59,99 +68,105 @@ type platformClient interface {
 	WorkspaceSyncBase(context.Context, string, string) ([]api.SourceState, error)
 }
 
+// remotePlatform is platformClient over the operator's HTTP API, for
+// commands run with --operator.
 type remotePlatform struct {
-	baseURL string
-	client  *http.Client
+	client *client.Client
 }
 
-type RemoteError struct {
-	Status int
-	Body   api.ErrorResponse
+// RemoteError is an error response from the operator.
+type RemoteError = client.Error
+
+func newRemotePlatform(baseURL string) *remotePlatform {
+	token := func(ctx context.Context) string {
+		target, err := url.Parse(baseURL)
+		if err != nil {
+			return ""
+		}
+		return operatorToken(ctx, target)
+	}
+	return &remotePlatform{client: client.New(baseURL, client.WithCaller(service.LocalCaller()), client.WithTokenFunc(token))}
 }
 
-func (e *RemoteError) Error() string {
-	message := e.Body.Error
-	if message == "" {
-		message = http.StatusText(e.Status)
+func (p *remotePlatform) StackInit(ctx context.Context, template string, addons []string, targetPath string, inputs, secretValues map[string]string, force bool) (service.StackInitResult, error) {
+	req := api.StackInitRequest{Template: template, With: addons, Path: targetPath, Inputs: inputs, Secrets: secretValues, Force: force, Yes: true}
+	resp, err := p.client.StackInit(ctx, req)
+	if err != nil {
+		return service.StackInitResult{}, err
 	}
-	return fmt.Sprintf("operator returned HTTP %d: %s", e.Status, message)
+	return service.StackInitResult{Template: resp.Template, Root: resp.Root}, nil
 }
 
-type RemoteNotFound struct {
-	RemoteError
+func (p *remotePlatform) StackTemplateQuestions(context.Context, string, []string) (map[string]copierx.Input, copierx.Inputs, error) {
+	return nil, nil, nil
 }
 
-type RemoteConflict struct {
-	RemoteError
+func (p *remotePlatform) TemplateList(ctx context.Context, kind string) ([]api.TemplateInfo, error) {
+	return p.client.TemplateList(ctx, kind)
 }
 
-type RemoteInvalidInput struct {
-	RemoteError
+func (p *remotePlatform) TemplateInfo(ctx context.Context, ref string, kind string) (api.TemplateInfo, error) {
+	return p.client.TemplateInfo(ctx, ref, kind)
 }
 
-func newRemotePlatform(baseURL string) *remotePlatform {
-	return &remotePlatform{baseURL: strings.TrimRight(baseURL, "/"), client: http.DefaultClient}
+func (p *remotePlatform) TemplateStatus(ctx context.Context) (api.TemplateStatus, error) {
+	return p.client.TemplateStatus(ctx)
 }
 
-func (p *remotePlatform) StackInit(ctx context.Context, template string, targetPath string, inputs map[string]string, force bool) (service.StackInitResult, error) {
-	req := api.StackInitRequest{Template: template, Path: targetPath, Inputs: inputs, Force: force, Yes: true}
-	var resp service.StackInitResult
-	if err := p.doJSON(ctx, http.MethodPost, "/stack/init", nil, req, &resp); err != nil {
-		return service.StackInitResult{}, err
-	}
-	return resp, nil
+func (p *remotePlatform) StackUpdate(ctx context.Context) error {
+	return p.client.StackUpdate(ctx)
 }
 
-func (p *remotePlatform) StackTemplateQuestions(context.Context, string) (map[string]copierx.Input, copierx.Inputs, error) {
-	return nil, nil, nil
+func (p *remotePlatform) StackDestroy(ctx context.Context, purge bool) error {
+	return p.client.StackDestroy(ctx, purge)
 }
 
-func (p *remotePlatform) StackUpdate(ctx context.Context) error {
-	return p.doJSON(ctx, http.MethodPost, "/stack/update", nil, nil, nil)
+func (p *remotePlatform) StackPrune(ctx context.Context, opts service.PruneOptions) (api.PruneResponse, error) {
+	return p.client.StackPrune(ctx, opts.DryRun, opts.Only)
 }
 
-func (p *remotePlatform) StackDestroy(ctx context.Context, purge bool) error {
-	query := url.Values{}
-	if purge {
-		query.Set("purge", "true")
-	}
-	return p.doJSON(ctx, http.MethodPost, "/stack/destroy", query, nil, nil)
+func (p *remotePlatform) StackLockImages(ctx context.Context, refresh bool) (api.ImageLockResponse, error) {
+	return p.client.StackLockImages(ctx, refresh)
+}
+
+func (p *remotePlatform) StackOutdated(ctx context.Context) (api.OutdatedResponse, error) {
+	return p.client.StackOutdated(ctx)
 }
 
-func (p *remotePlatform) StackBuild(ctx context.Context, services []string) error {
-	return p.doJSON(ctx, http.MethodPost, "/stack/build", nil, api.StackRuntimeRequest{Services: services}, nil)
+func (p *remotePlatform) StackPlan(ctx context.Context) (api.Plan, error) {
+	return p.client.StackPlan(ctx)
 }
 
-func (p *remotePlatform) StackUp(ctx context.Context, services []string, build bool) error {
-	return p.doJSON(ctx, http.MethodPost, "/stack/up", nil, api.StackRuntimeRequest{Services: services, Build: build}, nil)
+func (p *remotePlatform) StackBuild(ctx context.Context, services []string, opts service.BuildOptions) error {
+	return p.client.StackBuild(ctx, api.StackRuntimeRequest{Services: services, Push: opts.Push})
 }
 
-func (p *remotePlatform) StackUpForeground(ctx context.Context, services []string, build bool, _ io.Writer, _ io.Writer) error {
-	return p.StackUp(ctx, services, build)
+func (p *remotePlatform) StackUp(ctx context.Context, services []string, opts service.UpOptions) error {
+	req := api.StackRuntimeRequest{Services: services, Build: opts.Build, AutoRollback: opts.AutoRollback, RollbackDepth: opts.RollbackDepth, LockImages: opts.LockImages, Message: opts.Message, Plan: opts.Plan}
+	if opts.Wait > 0 {
+		req.Wait = opts.Wait.String()
+	}
+	return p.client.StackUp(ctx, req)
+}
+
+func (p *remotePlatform) StackUpForeground(ctx context.Context, services []string, opts service.UpOptions, _ io.Writer, _ io.Writer) error {
+	return p.StackUp(ctx, services, opts)
 }
 
 func (p *remotePlatform) StackDevForeground(ctx context.Context, build bool, _ io.Writer, _ io.Writer) error {
-	return p.doJSON(ctx, http.MethodPost, "/stack/dev", nil, api.StackRuntimeRequest{Build: build}, nil)
+	return p.client.StackDev(ctx, api.StackRuntimeRequest{Build: build})
 }
 
 func (p *remotePlatform) StackDown(ctx context.Context) error {
-	return p.doJSON(ctx, http.MethodPost, "/stack/down", nil, nil, nil)
+	return p.client.StackDown(ctx)
 }
 
-func (p *remotePlatform) StackLogs(ctx context.Context, services []string, _ bool) (<-chan string, error) {
-	query := url.Values{}
-	for _, service := range services {
-		query.Add("service", service)
-	}
-	return p.stream(ctx, "/stack/logs", query)
+func (p *remotePlatform) StackLogsLimited(ctx context.Context, services []string, _ bool, limits service.LogLimits) (<-chan string, error) {
+	return p.client.StackLogs(ctx, services, client.LogLimits(limits))
 }
 
 func (p *remotePlatform) StackStatus(ctx context.Context) (api.StackStatusResponse, error) {
-	var status api.StackStatusResponse
-	if err := p.doJSON(ctx, http.MethodGet, "/stack/status", nil, nil, &status); err != nil {
-		return api.StackStatusResponse{}, err
-	}
-	return status, nil
+	return p.client.StackStatus(ctx)
 }
 
 func (p *remotePlatform) StackCompile(ctx context.Context) (*service.CompiledStack, error) {
@@ -159,48 +174,50 @@ func (p *remotePlatform) StackCompile(ctx context.Context) (*service.CompiledSta
 }
 
 func (p *remotePlatform) StackPrepare(ctx context.Context) (*service.CompiledStack, error) {
-	var compiled service.CompiledStack
-	if err := p.doJSON(ctx, http.MethodPost, "/stack/prepare", nil, nil, &compiled); err != nil {
+	data, err := p.client.StackPrepare(ctx)
+	if err != nil {
 		return nil, err
 	}
+	var compiled service.CompiledStack
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &compiled); err != nil {
+			return nil, err
+		}
+	}
 	return &compiled, nil
 }
 
 func (p *remotePlatform) ServiceInit(ctx context.Context, req api.ServiceInitRequest) error {
-	return p.doJSON(ctx, http.MethodPost, "/services", nil, req, nil)
+	return p.client.ServiceInit(ctx, req)
 }
 
 func (p *remotePlatform) ServiceUpdate(ctx context.Context, req api.ServiceInitRequest) error {
-	return p.doJSON(ctx, http.MethodPatch, "/services/"+url.PathEscape(req.Name), nil, req, nil)
+	return p.client.ServiceUpdate(ctx, req)
 }
 
 func (p *remotePlatform) ServiceDestroy(ctx context.Context, name string, _ bool) error {
-	return p.doJSON(ctx, http.MethodPost, "/services/"+url.PathEscape(name)+"/destroy", nil, nil, nil)
+	return p.client.ServiceDestroy(ctx, name)
 }
 
 func (p *remotePlatform) ServiceList(ctx context.Context) ([]api.ServiceState, error) {
-	var services []api.ServiceState
-	if err := p.doJSON(ctx, http.MethodGet, "/services", nil, nil, &services); err != nil {
-		return nil, err
-	}
-	return services, nil
+	return p.client.ServiceList(ctx)
 }
 
 func (p *remotePlatform) ServiceStart(ctx context.Context, names []string) error {
-	return p.serviceAction(ctx, names, "start")
+	return forEach(ctx, names, p.client.ServiceStart)
 }
 
 func (p *remotePlatform) ServiceStop(ctx context.Context, names []string) error {
-	return p.serviceAction(ctx, names, "stop")
+	return forEach(ctx, names, p.client.ServiceStop)
 }
 
 func (p *remotePlatform) ServiceRestart(ctx context.Context, names []string) error {
-	return p.serviceAction(ctx, names, "restart")
+	return forEach(ctx, names, p.client.ServiceRestart)
 }
 
-func (p *remotePlatform) serviceAction(ctx context.Context, names []string, action string) error {
+func forEach(ctx context.Context, names []string, action func(context.Context, string) error) error {
 	for _, name := range names {
-		if err := p.doJSON(ctx, http.MethodPost, "/services/"+url.PathEscape(name)+"/"+action, nil, nil, nil); err != nil {
+		if err := action(ctx, name); err != nil {
 			return err
 		}
 	}
@@ -208,274 +225,107 @@ func (p *remotePlatform) serviceAction(ctx context.Context, names []string, acti
 }
 
 func (p *remotePlatform) JobList(ctx context.Context) ([]api.JobState, error) {
-	var jobs []api.JobState
-	if err := p.doJSON(ctx, http.MethodGet, "/jobs", nil, nil, &jobs); err != nil {
-		return nil, err
-	}
-	return jobs, nil
+	return p.client.JobList(ctx)
 }
 
 func (p *remotePlatform) JobRun(ctx context.Context, name string, inputs map[string]string) ([]byte, error) {
-	return p.doBytes(ctx, http.MethodPost, "/jobs/"+url.PathEscape(name)+"/run", nil, api.JobRunRequest{Inputs: inputs})
+	return p.client.JobRun(ctx, name, inputs)
+}
+
+func (p *remotePlatform) JobRunPage(ctx context.Context, name string, q service.HistoryQuery) ([]api.JobRun, string, error) {
+	return p.client.JobRunPage(ctx, name, client.HistoryQuery(q))
+}
+
+func (p *remotePlatform) DeployPage(ctx context.Context, q service.HistoryQuery) ([]api.Deploy, string, error) {
+	return p.client.DeployPage(ctx, client.HistoryQuery(q))
+}
+
+func (p *remotePlatform) BackupList(ctx context.Context, service string) ([]api.Backup, error) {
+	return p.client.BackupList(ctx, service)
+}
+
+func (p *remotePlatform) BackupRun(ctx context.Context, service string) (api.Backup, error) {
+	return p.client.BackupRun(ctx, service)
+}
+
+func (p *remotePlatform) BackupRestore(ctx context.Context, service, name string) error {
+	return p.client.BackupRestore(ctx, service, name)
 }
 
 func (p *remotePlatform) SourceList(ctx context.Context) ([]api.SourceState, error) {
-	var sources []api.SourceState
-	if err := p.doJSON(ctx, http.MethodGet, "/sources", nil, nil, &sources); err != nil {
-		return nil, err
-	}
-	return sources, nil
+	return p.client.SourceList(ctx)
 }
 
 func (p *remotePlatform) SourceFetch(ctx context.Context, name string) (api.SourceState, error) {
-	return p.sourceOperation(ctx, name, "fetch")
+	return p.client.SourceFetch(ctx, name)
 }
 
 func (p *remotePlatform) SourceStatus(ctx context.Context, name string) (api.SourceState, error) {
-	var state api.SourceState
-	if err := p.doJSON(ctx, http.MethodGet, "/sources/"+url.PathEscape(name)+"/status", nil, nil, &state); err != nil {
-		return api.SourceState{}, err
-	}
-	return state, nil
+	return p.client.SourceStatus(ctx, name)
 }
 
 func (p *remotePlatform) SourcePull(ctx context.Context, name string) (api.SourceState, error) {
-	return p.sourceOperation(ctx, name, "pull")
+	return p.client.SourcePull(ctx, name)
 }
 
 func (p *remotePlatform) SourcePush(ctx context.Context, name string, ref string) (api.SourceState, error) {
-	var state api.SourceState
-	if err := p.doJSON(ctx, http.MethodPost, "/sources/"+url.PathEscape(name)+"/push", nil, api.SourceOperationRequest{Ref: ref}, &state); err != nil {
-		return api.SourceState{}, err
-	}
-	return state, nil
-}
-
-func (p *remotePlatform) sourceOperation(ctx context.Context, name string, action string) (api.SourceState, error) {
-	var state api.SourceState
-	if err := p.doJSON(ctx, http.MethodPost, "/sources/"+url.PathEscape(name)+"/"+action, nil, nil, &state); err != nil {
-		return api.SourceState{}, err
-	}
-	return state, nil
+	return p.client.SourcePush(ctx, name, ref)
 }
 
 func (p *remotePlatform) WorkspaceCreate(ctx context.Context, req api.WorkspaceCreateRequest) (api.WorkspaceRef, error) {
-	var ref api.WorkspaceRef
-	if err := p.doJSON(ctx, http.MethodPost, "/workspaces", nil, req, &ref); err != nil {
-		return api.WorkspaceRef{}, err
-	}
-	return ref, nil
+	return p.client.WorkspaceCreate(ctx, req)
 }
 
 func (p *remotePlatform) WorkspaceList(ctx context.Context) ([]api.WorkspaceRef, error) {
-	var refs []api.WorkspaceRef
-	if err := p.doJSON(ctx, http.MethodGet, "/workspaces", nil, nil, &refs); err != nil {
-		return nil, err
-	}
-	return refs, nil
+	return p.client.WorkspaceList(ctx)
 }
 
 func (p *remotePlatform) WorkspaceGet(ctx context.Context, name string) (api.WorkspaceRef, error) {
-	var ref api.WorkspaceRef
-	if err := p.doJSON(ctx, http.MethodGet, "/workspaces/"+url.PathEscape(name), nil, nil, &ref); err != nil {
-		return api.WorkspaceRef{}, err
-	}
-	return ref, nil
+	return p.client.WorkspaceGet(ctx, name)
 }
 
 func (p *remotePlatform) WorkspaceStatus(ctx context.Context, name string) (api.WorkspaceStatusResponse, error) {
-	var status api.WorkspaceStatusResponse
-	if err := p.doJSON(ctx, http.MethodGet, "/workspaces/"+url.PathEscape(name)+"/status", nil, nil, &status); err != nil {
-		return api.WorkspaceStatusResponse{}, err
-	}
-	return status, nil
+	return p.client.WorkspaceStatus(ctx, name)
 }
 
 func (p *remotePlatform) WorkspaceUpdate(ctx context.Context, name string, inputs map[string]string, ttl string) (api.WorkspaceRef, error) {
-	var ref api.WorkspaceRef
-	req := api.WorkspaceUpdateRequest{Inputs: inputs, TTL: ttl}
-	if err := p.doJSON(ctx, http.MethodPatch, "/workspaces/"+url.PathEscape(name), nil, req, &ref); err != nil {
-		return api.WorkspaceRef{}, err
-	}
-	return ref, nil
+	return p.client.WorkspaceUpdate(ctx, name, api.WorkspaceUpdateRequest{Inputs: inputs, TTL: ttl})
 }
 
 func (p *remotePlatform) WorkspaceDestroy(ctx context.Context, name string, purge bool) error {
-	query := url.Values{}
-	if purge {
-		query.Set("purge", "true")
-	}
-	return p.doJSON(ctx, http.MethodPost, "/workspaces/"+url.PathEscape(name)+"/destroy", query, nil, nil)
+	return p.client.WorkspaceDestroy(ctx, name, purge)
 }
 
-func (p *remotePlatform) WorkspaceLogs(ctx context.Context, name string, _ bool) (<-chan string, error) {
-	return p.stream(ctx, "/workspaces/"+url.PathEscape(name)+"/logs", nil)
+func (p *remotePlatform) WorkspaceLogsLimited(ctx context.Context, name string, _ bool, limits service.LogLimits) (<-chan string, error) {
+	return p.client.WorkspaceLogs(ctx, name, client.LogLimits(limits))
 }
 
 func (p *remotePlatform) WorkspaceStart(ctx context.Context, name string) error {
-	return p.doJSON(ctx, http.MethodPost, "/workspaces/"+url.PathEscape(name)+"/start", nil, nil, nil)
+	return p.client.WorkspaceStart(ctx, name)
 }
 
 func (p *remotePlatform) WorkspaceStop(ctx context.Context, name string) error {
-	return p.doJSON(ctx, http.MethodPost, "/workspaces/"+url.PathEscape(name)+"/stop", nil, nil, nil)
+	return p.client.WorkspaceStop(ctx, name)
 }
 
 func (p *remotePlatform) WorkspaceGitStatus(ctx context.Context, name string) ([]api.SourceState, error) {
-	var states []api.SourceState
-	if err := p.doJSON(ctx, http.MethodGet, "/workspaces/"+url.PathEscape(name)+"/git", nil, nil, &states); err != nil {
-		return nil, err
-	}
-	return states, nil
+	return p.client.WorkspaceGitStatus(ctx, name)
 }
 
 func (p *remotePlatform) WorkspacePush(ctx context.Context, name string, ref string) ([]api.SourceState, error) {
-	var states []api.SourceState
-	if err := p.doJSON(ctx, http.MethodPost, "/workspaces/"+url.PathEscape(name)+"/push", nil, api.SourceOperationRequest{Ref: ref}, &states); err != nil {
-		return nil, err
-	}
-	return states, nil
+	return p.client.WorkspacePush(ctx, name, ref)
 }
 
 func (p *remotePlatform) WorkspaceSyncBase(ctx context.Context, name string, method string) ([]api.SourceState, error) {
-	var states []api.SourceState
-	req := api.WorkspaceSyncBaseRequest{Method: method}
-	if err := p.doJSON(ctx, http.MethodPost, "/workspaces/"+url.PathEscape(name)+"/sync-base", nil, req, &states); err != nil {
-		return nil, err
-	}
-	return states, nil
-}
-
-func (p *remotePlatform) doJSON(ctx context.Context, method, path string, query url.Values, in any, out any) error {
-	body, err := jsonBody(in)
-	if err != nil {
-		return err
-	}
-	req, err := http.NewRequestWithContext(ctx, method, p.endpoint(path, query), body)
-	if err != nil {
-		return err
-	}
-	if in != nil {
-		req.Header.Set("Content-Type", "application/json")
-	}
-	resp, err := p.client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return err
-	}
-	if resp.StatusCode >= 300 {
-		return operatorHTTPError(resp.StatusCode, data)
-	}
-	if out == nil || len(bytes.TrimSpace(data)) == 0 {
-		return nil
-	}
-	return json.Unmarshal(data, out)
-}
-
-func (p *remotePlatform) doBytes(ctx context.Context, method, path string, query url.Values, in any) ([]byte, error) {
-	body, err := jsonBody(in)
-	if err != nil {
-		return nil, err
-	}
-	req, err := http.NewRequestWithContext(ctx, method, p.endpoint(path, query), body)
-	if err != nil {
-		return nil, err
-	}
-	if in != nil {
-		req.Header.Set("Content-Type", "application/json")
-	}
-	resp, err := p.client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode >= 300 {
-		return nil, operatorHTTPError(resp.StatusCode, data)
-	}
-	return data, nil
-}
-
-func (p *remotePlatform) stream(ctx context.Context, path string, query url.Values) (<-chan string, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.endpoint(path, query), nil)
-	if err != nil {
-		return nil, err
-	}
-	resp, err := p.client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode >= 300 {
-		defer resp.Body.Close()
-		data, readErr := io.ReadAll(resp.Body)
-		if readErr != nil {
-			return nil, readErr
-		}
-		return nil, operatorHTTPError(resp.StatusCode, data)
-	}
-	out := make(chan string)
-	go func() {
-		defer close(out)
-		defer resp.Body.Close()
-		scanner := bufio.NewScanner(resp.Body)
-		for scanner.Scan() {
-			out <- scanner.Text() + "\n"
-		}
-	}()
-	return out, nil
-}
-
-func (p *remotePlatform) endpoint(path string, query url.Values) string {
-	endpoint := p.baseURL + path
-	if len(query) > 0 {
-		endpoint += "?" + query.Encode()
-	}
-	return endpoint
-}
-
-func jsonBody(value any) (io.Reader, error) {
-	if value == nil {
-		return nil, nil
-	}
-	data, err := json.Marshal(value)
-	if err != nil {
-		return nil, err
-	}
-	return bytes.NewReader(data), nil
-}
-
-func operatorHTTPError(status int, data []byte) error {
-	var body api.ErrorResponse
-	if err := json.Unmarshal(data, &body); err == nil && body.Error != "" {
-		base := RemoteError{Status: status, Body: body}
-		switch status {
-		case http.StatusNotFound:
-			return &RemoteNotFound{RemoteError: base}
-		case http.StatusConflict:
-			return &RemoteConflict{RemoteError: base}
-		case http.StatusBadRequest:
-			return &RemoteInvalidInput{RemoteError: base}
-		default:
-			return &base
-		}
-	}
-	text := strings.TrimSpace(string(data))
-	if text == "" {
-		text = http.StatusText(status)
-	}
-	return &RemoteError{Status: status, Body: api.ErrorResponse{Error: text}}
+	return p.client.WorkspaceSyncBase(ctx, name, method)
 }
 
-func remoteConflict(err error, kind string) (*RemoteConflict, bool) {
-	var conflict *RemoteConflict
-	if !errors.As(err, &conflict) {
+// remoteConflict reports whether err is a 409 from the operator about a
+// resource of kind, or of any kind when kind is empty.
+func remoteConflict(err error, kind string) (*RemoteError, bool) {
+	var remote *RemoteError
+	if !errors.As(err, &remote) || remote.Status != http.StatusConflict {
 		return nil, false
 	}
-	return conflict, kind == "" || conflict.Body.Kind == kind
+	return remote, kind == "" || remote.Body.Kind == kind
 }
@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fyltr/angee/api"
+	"github.com/spf13/cobra"
+)
+
+func outdatedCommand(stdout io.Writer, root, operatorURL *string, jsonOutput *bool) *cobra.Command {
+	return &cobra.Command{
+		Use:   "outdated",
+		Short: "List images, the template, and sources with newer versions upstream",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			platform, err := localPlatform(root, operatorURL)
+			if err != nil {
+				return err
+			}
+			resp, err := platform.StackOutdated(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if *jsonOutput {
+				return writeJSON(stdout, resp)
+			}
+			if len(resp.Updates) == 0 {
+				_, err = fmt.Fprintln(stdout, "everything is up to date")
+				return err
+			}
+			for _, update := range resp.Updates {
+				if _, err := fmt.Fprintln(stdout, strings.Join(outdatedRow(update), "\t")); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// outdatedRow is the kind, name, change, and link of an update, with the
+// error in place of the change when it could not be checked.
+func outdatedRow(update api.Update) []string {
+	change := shortDigest(update.Current) + " -> " + shortDigest(update.Latest)
+	switch {
+	case update.Error != "":
+		change = "error: " + strings.TrimSpace(update.Error)
+	case update.Behind > 0:
+		change += fmt.Sprintf(" (%d commits behind)", update.Behind)
+	}
+	row := []string{update.Kind, update.Name, change}
+	if update.Link != "" {
+		row = append(row, update.Link)
+	}
+	return row
+}
+
+// shortDigest shortens a commit or an image digest such as sha256:9f86d0…
+// to twelve characters after the algorithm.
+func shortDigest(digest string) string {
+	algorithm, hex, ok := strings.Cut(digest, ":")
+	if !ok || strings.Contains(hex, "/") {
+		return shortCommit(digest)
+	}
+	return algorithm + ":" + shortCommit(hex)
+}
@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fyltr/angee/api"
+)
+
+func TestOutdatedRow(t *testing.T) {
+	for _, tc := range []struct {
+		update api.Update
+		want   string
+	}{
+		{
+			api.Update{Kind: api.UpdateImage, Name: "nginx:1", Current: "sha256:0123456789abcdef", Latest: "sha256:fedcba9876543210", Link: "https://hub.docker.com/_/nginx/tags"},
+			"image\tnginx:1\tsha256:0123456789ab -> sha256:fedcba987654\thttps://hub.docker.com/_/nginx/tags",
+		},
+		{
+			api.Update{Kind: api.UpdateSource, Name: "app", Current: "main", Latest: "origin/main", Behind: 3},
+			"source\tapp\tmain -> origin/main (3 commits behind)",
+		},
+		{
+			api.Update{Kind: api.UpdateImage, Name: "private/app:1", Error: "unauthorized\n"},
+			"image\tprivate/app:1\terror: unauthorized",
+		},
+	} {
+		if got := strings.Join(outdatedRow(tc.update), "\t"); got != tc.want {
+			t.Errorf("outdatedRow(%+v) = %q, want %q", tc.update, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// console writes what a command tells a person, as opposed to the data it
+// returns. Results go to stdout and progress to stderr; --quiet keeps only
+// errors, --verbose adds details and tool output, and --json keeps both
+// streams free of anything but the JSON document and errors.
+type console struct {
+	stdout, stderr  io.Writer
+	quiet, verbose  bool
+	json, terminal  bool
+	spinnerInterval time.Duration
+}
+
+func newConsole(cmd *cobra.Command, stdout io.Writer) *console {
+	quiet, _ := cmd.Flags().GetBool("quiet")
+	verbose, _ := cmd.Flags().GetBool("verbose")
+	asJSON, _ := cmd.Flags().GetBool("json")
+	stderr := cmd.ErrOrStderr()
+	return &console{
+		stdout:          stdout,
+		stderr:          stderr,
+		quiet:           quiet,
+		verbose:         verbose,
+		json:            asJSON,
+		terminal:        isTerminal(stderr),
+		spinnerInterval: 100 * time.Millisecond,
+	}
+}
+
+// Success reports what a command did, on stdout.
+func (c *console) Success(format string, args ...any) error {
+	if c.quiet || c.json {
+		return nil
+	}
+	_, err := fmt.Fprintf(c.stdout, format+"\n", args...)
+	return err
+}
+
+// Info reports something worth knowing along the way, on stderr.
+func (c *console) Info(format string, args ...any) {
+	if c.quiet || c.json {
+		return
+	}
+	fmt.Fprintf(c.stderr, format+"\n", args...)
+}
+
+// Detail is Info shown only with --verbose.
+func (c *console) Detail(format string, args ...any) {
+	if c.verbose {
+		c.Info(format, args...)
+	}
+}
+
+// Step shows msg while a long operation runs and returns the function that
+// ends it. On a terminal it is a spinner that is cleared at the end;
+// elsewhere, such as in CI logs, it is a single "msg..." line.
+func (c *console) Step(msg string) (done func()) {
+	if c.quiet || c.json {
+		return func() {}
+	}
+	if !c.terminal || c.verbose {
+		fmt.Fprintf(c.stderr, "%s...\n", msg)
+		return func() {}
+	}
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		frames := []rune("⠋⠙⠹⠸⠼⠴⠦⠧⠇⠏")
+		ticker := time.NewTicker(c.spinnerInterval)
+		defer ticker.Stop()
+		for i := 0; ; i++ {
+			fmt.Fprintf(c.stderr, "\r%c %s", frames[i%len(frames)], msg)
+			select {
+			case <-stop:
+				fmt.Fprint(c.stderr, "\r\033[K")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(stop)
+			wg.Wait()
+		})
+	}
+}
+
+// toolOutput returns the writers for a child tool such as docker compose.
+// Its output streams through with --verbose and when stderr is not a
+// terminal, so CI logs keep it. Otherwise it is held back, behind a Step
+// spinner, and replay writes it to stderr; callers replay it when the tool
+// fails.
+func (c *console) toolOutput() (stdout, stderr io.Writer, replay func()) {
+	if c.verbose || (!c.terminal && !c.quiet) {
+		stdout = c.stdout
+		if c.json {
+			stdout = c.stderr
+		}
+		return stdout, c.stderr, func() {}
+	}
+	held := &syncBuffer{}
+	return held, held, func() { _, _ = c.stderr.Write(held.Bytes()) }
+}
+
+// syncBuffer is a bytes.Buffer safe for a tool's stdout and stderr to
+// write to at once.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return bytes.Clone(b.buf.Bytes())
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	stat, err := f.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConsoleModes(t *testing.T) {
+	for _, tc := range []struct {
+		name                       string
+		console                    console
+		wantStdout, wantStderr     string
+		wantToolStdout, wantReplay bool
+	}{
+		{name: "default", wantStdout: "done\n", wantStderr: "note\nworking...\n", wantToolStdout: true},
+		{name: "quiet", console: console{quiet: true}, wantReplay: true},
+		{name: "verbose", console: console{verbose: true}, wantStdout: "done\n", wantStderr: "note\ndetail\nworking...\n", wantToolStdout: true},
+		{name: "json", console: console{json: true}, wantStderr: "tool\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			out := tc.console
+			out.stdout, out.stderr = &stdout, &stderr
+			out.Info("note")
+			out.Detail("detail")
+			done := out.Step("working")
+			toolStdout, _, replay := out.toolOutput()
+			_, _ = toolStdout.Write([]byte("tool\n"))
+			done()
+			if err := out.Success("done"); err != nil {
+				t.Fatalf("Success() error = %v", err)
+			}
+			wantStdout, wantStderr := tc.wantStdout, tc.wantStderr
+			if tc.wantToolStdout {
+				wantStdout = "tool\n" + wantStdout
+			}
+			if got := stdout.String(); got != wantStdout {
+				t.Fatalf("stdout = %q, want %q", got, wantStdout)
+			}
+			if got := stderr.String(); got != wantStderr {
+				t.Fatalf("stderr = %q, want %q", got, wantStderr)
+			}
+			replay()
+			if tc.wantReplay && stderr.String() != wantStderr+"tool\n" {
+				t.Fatalf("stderr after replay = %q, want held tool output", stderr.String())
+			}
+		})
+	}
+}
+
+func TestConsoleSpinnerHoldsToolOutputOnTerminal(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	out := &console{stdout: &stdout, stderr: &stderr, terminal: true, spinnerInterval: time.Millisecond}
+	done := out.Step("building")
+	toolStdout, toolStderr, replay := out.toolOutput()
+	_, _ = toolStdout.Write([]byte("step 1/3\n"))
+	_, _ = toolStderr.Write([]byte("warning\n"))
+	time.Sleep(5 * time.Millisecond)
+	done()
+	done()
+	if stdout.Len() != 0 {
+		t.Fatalf("stdout = %q, want tool output held", stdout.String())
+	}
+	if got := stderr.String(); !strings.Contains(got, "building") || !strings.HasSuffix(got, "\r\033[K") {
+		t.Fatalf("stderr = %q, want a spinner cleared at the end", got)
+	}
+	stderr.Reset()
+	replay()
+	if got := stderr.String(); got != "step 1/3\nwarning\n" {
+		t.Fatalf("replayed = %q", got)
+	}
+}
+
+func TestQuietSuppressesSuccessAndConflictsWithVerbose(t *testing.T) {
+	root := t.TempDir()
+	writeStackTemplate(t, root)
+	t.Chdir(root)
+
+	var stdout, stderr bytes.Buffer
+	cmd := NewRoot(&stdout, &stderr)
+	cmd.SetArgs([]string{"init", "--dev", "--yes", "-q"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if stdout.Len() != 0 {
+		t.Fatalf("quiet init stdout = %q, want nothing", stdout.String())
+	}
+
+	cmd = NewRoot(&stdout, &stderr)
+	cmd.SetArgs([]string{"status", "-q", "-v"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "quiet") {
+		t.Fatalf("Execute(-q -v) error = %v, want mutually exclusive flags", err)
+	}
+}
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/fyltr/angee/api"
+	"github.com/fyltr/angee/internal/service"
+	"github.com/spf13/cobra"
+)
+
+func planCommand(stdout io.Writer, root, operatorURL *string, jsonOutput *bool) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "plan",
+		Short: "Compile the stack for review and print a plan ID to approve",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			platform, err := localPlatform(root, operatorURL)
+			if err != nil {
+				return err
+			}
+			plan, err := platform.StackPlan(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if *jsonOutput {
+				return writeJSON(stdout, plan)
+			}
+			_, err = io.WriteString(stdout, planText(plan))
+			return err
+		},
+	}
+	cmd.AddCommand(planApproveCommand(stdout, root, operatorURL, jsonOutput))
+	return cmd
+}
+
+func planApproveCommand(stdout io.Writer, root, operatorURL *string, jsonOutput *bool) *cobra.Command {
+	return &cobra.Command{
+		Use:   "approve <id>",
+		Short: "Sign a plan so `angee up --plan` deploys it",
+		Long: "Checks that the stack still compiles to the plan, prints it, and signs\n" +
+			"it with the key in run/plan.key. Approving needs that key, so it runs on\n" +
+			"the stack's host only; the operator does not offer it, and a caller of\n" +
+			"its API cannot approve the plans it makes.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			platform, err := localPlatform(root, operatorURL)
+			if err != nil {
+				return err
+			}
+			local, ok := platform.(*service.Platform)
+			if !ok {
+				return errors.New("plan approve signs with the stack's run/plan.key and is not available with --operator")
+			}
+			plan, err := local.ApprovePlan(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			if *jsonOutput {
+				return writeJSON(stdout, plan)
+			}
+			_, err = io.WriteString(stdout, planText(plan))
+			return err
+		},
+	}
+}
+
+// planText is the compiled files of a plan, each under a comment naming
+// it, then how to approve it or, once approved, deploy it.
+func planText(plan api.Plan) string {
+	var out strings.Builder
+	if plan.Compose != "" {
+		fmt.Fprintf(&out, "# docker-compose.yaml (%s)\n%s\n", strings.Join(plan.Services, ", "), plan.Compose)
+	}
+	if plan.ProcessCompose != "" {
+		fmt.Fprintf(&out, "# process-compose.yaml (%s)\n%s\n", strings.Join(plan.Processes, ", "), plan.ProcessCompose)
+	}
+	fmt.Fprintf(&out, "manifest %s\ncompiled %s\nexpires  %s\n\n", plan.Manifest, plan.Compiled, plan.ExpiresAt.Format(time.RFC3339))
+	if plan.Token == "" {
+		fmt.Fprintf(&out, "approve this plan on the stack's host with:\n  angee plan approve %s\n", plan.ID)
+	} else {
+		fmt.Fprintf(&out, "deploy this plan with:\n  angee up --plan %s\n", plan.Token)
+	}
+	return out.String()
+}
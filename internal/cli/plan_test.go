@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fyltr/angee/api"
+)
+
+func TestPlanTextShowsFilesThenToken(t *testing.T) {
+	text := planText(api.Plan{
+		ID:        "abc",
+		Token:     "abc.def",
+		Manifest:  "sha256:aaa",
+		Compiled:  "sha256:bbb",
+		Services:  []string{"db", "web"},
+		Compose:   "services:\n  web:\n    image: nginx:1\n",
+		ExpiresAt: time.Date(2026, 5, 2, 10, 0, 0, 0, time.UTC),
+	})
+	compose, token := strings.Index(text, "# docker-compose.yaml (db, web)\nservices:"), strings.Index(text, "angee up --plan abc.def\n")
+	if compose < 0 || token < compose || strings.Contains(text, "process-compose") || !strings.Contains(text, "expires  2026-05-02T10:00:00Z") {
+		t.Fatalf("planText() =\n%s", text)
+	}
+}
+
+func TestPlanTextAsksForApprovalWithoutToken(t *testing.T) {
+	text := planText(api.Plan{ID: "abc", Manifest: "sha256:aaa", Compiled: "sha256:bbb"})
+	if !strings.Contains(text, "angee plan approve abc\n") || strings.Contains(text, "angee up --plan") {
+		t.Fatalf("planText() =\n%s", text)
+	}
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/fyltr/angee/internal/service"
+	"github.com/spf13/cobra"
+)
+
+func portForwardCommand(stdout io.Writer, root, operatorURL *string) *cobra.Command {
+	return &cobra.Command{
+		Use:   "port-forward <service> [local:]remote",
+		Short: "Forward a local port to a port inside a running container service",
+		Long: "Listens on 127.0.0.1 and relays each connection into the service's network\n" +
+			"namespace, so ports the service does not publish can be reached from host\n" +
+			"tools. Without a local port, a free one is chosen. Runs until interrupted.\n" +
+			"\n" +
+			"Examples:\n" +
+			"  angee port-forward postgres 5432\n" +
+			"  angee port-forward redis 16379:6379",
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			local, remote, err := parsePortForward(args[1])
+			if err != nil {
+				return err
+			}
+			platform, err := localPlatform(root, operatorURL)
+			if err != nil {
+				return err
+			}
+			p, ok := platform.(*service.Platform)
+			if !ok {
+				return errors.New("port-forward listens on this machine and is not available with --operator")
+			}
+			return p.PortForward(cmd.Context(), args[0], local, remote, stdout, cmd.ErrOrStderr())
+		},
+	}
+}
+
+// parsePortForward reads "remote", forwarded from the same local port, or
+// "local:remote"; a local port of 0 picks a free one.
+func parsePortForward(spec string) (local, remote int, err error) {
+	localSpec, remoteSpec, ok := strings.Cut(spec, ":")
+	if !ok {
+		localSpec, remoteSpec = spec, spec
+	}
+	if local, err = strconv.Atoi(localSpec); err != nil || local < 0 {
+		return 0, 0, fmt.Errorf("local port %q is not a port number", localSpec)
+	}
+	if remote, err = strconv.Atoi(remoteSpec); err != nil || remote < 1 {
+		return 0, 0, fmt.Errorf("remote port %q is not a port number", remoteSpec)
+	}
+	return local, remote, nil
+}
@@ -0,0 +1,24 @@
+package cli
+
+import "testing"
+
+func TestParsePortForward(t *testing.T) {
+	for _, tc := range []struct {
+		spec          string
+		local, remote int
+	}{
+		{"5432", 5432, 5432},
+		{"15432:5432", 15432, 5432},
+		{"0:6379", 0, 6379},
+	} {
+		local, remote, err := parsePortForward(tc.spec)
+		if err != nil || local != tc.local || remote != tc.remote {
+			t.Fatalf("parsePortForward(%q) = %d, %d, %v", tc.spec, local, remote, err)
+		}
+	}
+	for _, spec := range []string{"", "db", "5432:", ":5432", "-1:80", "80:0"} {
+		if _, _, err := parsePortForward(spec); err == nil {
+			t.Fatalf("parsePortForward(%q) error = nil", spec)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fyltr/angee/internal/service"
+	"github.com/spf13/cobra"
+)
+
+func pruneCommand(stdout io.Writer, root, operatorURL *string, jsonOutput *bool) *cobra.Command {
+	var yes, dryRun bool
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove containers, volumes, and networks the stack no longer declares",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			platform, err := localPlatform(root, operatorURL)
+			if err != nil {
+				return err
+			}
+			found, err := platform.StackPrune(cmd.Context(), service.PruneOptions{DryRun: true})
+			if err != nil {
+				return err
+			}
+			if *jsonOutput && (dryRun || len(found.Resources) == 0) {
+				return writeJSON(stdout, found)
+			}
+			if !*jsonOutput {
+				if len(found.Resources) == 0 {
+					_, err = fmt.Fprintln(stdout, "nothing to prune")
+					return err
+				}
+				for _, resource := range found.Resources {
+					if _, err := fmt.Fprintf(stdout, "%-9s %-30s %s\n", resource.Kind, resource.Name, resource.Reason); err != nil {
+						return err
+					}
+				}
+			}
+			if dryRun || len(found.Resources) == 0 {
+				return nil
+			}
+			if !yes {
+				if _, err := fmt.Fprintf(cmd.ErrOrStderr(), "Remove %d resources? Volume data is lost. [y/N]: ", len(found.Resources)); err != nil {
+					return err
+				}
+				line, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+				if answer := strings.ToLower(strings.TrimSpace(line)); answer != "y" && answer != "yes" {
+					_, err = fmt.Fprintln(stdout, "nothing removed")
+					return err
+				}
+			}
+			// Only what was listed and confirmed is removed; if the set
+			// changed meanwhile, prune refuses.
+			removed, err := platform.StackPrune(cmd.Context(), service.PruneOptions{Only: found.Resources})
+			if err != nil {
+				return err
+			}
+			if *jsonOutput {
+				return writeJSON(stdout, removed)
+			}
+			_, err = fmt.Fprintf(stdout, "removed %d resources\n", len(removed.Resources))
+			return err
+		},
+	}
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "remove without asking")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "list what would be removed and stop")
+	return cmd
+}
@@ -10,6 +10,7 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -25,10 +26,17 @@ import (
 
 var Version = "dev"
 
+// Execute runs the CLI and reports a failure on stderr before returning it.
 func Execute() error {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
-	return NewRootWithIO(os.Stdin, os.Stdout, os.Stderr).ExecuteContext(ctx)
+	cmd := NewRootWithIO(os.Stdin, os.Stdout, os.Stderr)
+	err := cmd.ExecuteContext(ctx)
+	if err != nil {
+		asJSON, _ := cmd.PersistentFlags().GetBool("json")
+		reportError(os.Stderr, err, asJSON)
+	}
+	return err
 }
 
 func NewRoot(stdout, stderr io.Writer) *cobra.Command {
@@ -53,18 +61,38 @@ func NewRootWithIO(stdin io.Reader, stdout, stderr io.Writer) *cobra.Command {
 	cmd.PersistentFlags().StringVar(&root, "root", ".", "ANGEE_ROOT containing angee.yaml")
 	cmd.PersistentFlags().StringVar(&operatorURL, "operator", os.Getenv("ANGEE_OPERATOR_URL"), "operator URL for HTTP mode")
 	cmd.PersistentFlags().BoolVar(&jsonOutput, "json", false, "write JSON output")
+	cmd.PersistentFlags().BoolP("quiet", "q", false, "print only errors")
+	cmd.PersistentFlags().BoolP("verbose", "v", false, "print progress details and tool output")
+	cmd.MarkFlagsMutuallyExclusive("quiet", "verbose")
 
 	cmd.AddCommand(initCommand(stdout, stderr, &root, &operatorURL))
 	cmd.AddCommand(stackCommand(stdout, &root, &operatorURL))
+	cmd.AddCommand(templateCommand(stdout, &root, &operatorURL, &jsonOutput))
 	cmd.AddCommand(statusCommand(stdout, &root, &operatorURL, &jsonOutput))
+	cmd.AddCommand(deploysCommand(stdout, &root, &operatorURL, &jsonOutput))
+	cmd.AddCommand(backupCommand(stdout, &root, &operatorURL, &jsonOutput))
 	cmd.AddCommand(runtimeCommands(stdout, &root, &operatorURL)...)
 	cmd.AddCommand(serviceCommand(stdout, &root, &operatorURL, &jsonOutput))
 	cmd.AddCommand(jobCommand(stdout, &root, &operatorURL, &jsonOutput))
 	cmd.AddCommand(sourceCommand(stdout, &root, &operatorURL, &jsonOutput))
 	cmd.AddCommand(workspaceCommand(stdout, &root, &operatorURL, &jsonOutput))
+	cmd.AddCommand(importCommand(stdout, &root, &operatorURL))
+	cmd.AddCommand(exportCommand(stdout, &root, &operatorURL))
+	cmd.AddCommand(pruneCommand(stdout, &root, &operatorURL, &jsonOutput))
+	cmd.AddCommand(lockCommand(stdout, &root, &operatorURL, &jsonOutput))
+	cmd.AddCommand(outdatedCommand(stdout, &root, &operatorURL, &jsonOutput))
+	cmd.AddCommand(planCommand(stdout, &root, &operatorURL, &jsonOutput))
+	cmd.AddCommand(portForwardCommand(stdout, &root, &operatorURL))
+	cmd.AddCommand(topCommand(stdout, &root, &operatorURL, &jsonOutput))
+	cmd.AddCommand(eventsCommand(stdout, &operatorURL, &jsonOutput))
+	cmd.AddCommand(uninstallCommand(stdout, &root, &operatorURL, &jsonOutput))
+	cmd.AddCommand(rootCommand(stdout, &root, &operatorURL, &jsonOutput))
 	cmd.AddCommand(doctorCommand(stdout, &root, &jsonOutput))
 	cmd.AddCommand(internalCommand(stdout, &root, &operatorURL, &jsonOutput))
 	cmd.AddCommand(operatorCommand(stdout, stderr))
+	cmd.AddCommand(loginCommand(stdout, &operatorURL))
+	cmd.AddCommand(logoutCommand(stdout, &operatorURL))
+	markUsageErrors(cmd)
 	return cmd
 }
 
@@ -73,16 +101,25 @@ func initCommand(stdout, stderr io.Writer, root, operatorURL *string) *cobra.Com
 	var force bool
 	var yes bool
 	var inputs []string
+	var addons []string
+	var refresh bool
+	var answersPath string
 	cmd := &cobra.Command{
 		Use:   "init [path]",
 		Short: "Initialize a stack",
 		Args:  cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			answers, err := loadInitAnswers(answersPath)
+			if err != nil {
+				return err
+			}
 			template := "dev"
-			if !dev {
-				return fmt.Errorf("init requires --dev or use stack init <template>")
+			if answers.Template != "" && !dev {
+				template = answers.Template
+			} else if !dev {
+				return fmt.Errorf("init requires --dev, an --answers file with a template, or use stack init <template>")
 			}
-			path := ""
+			path := answers.Path
 			if len(args) == 1 {
 				path = args[0]
 			}
@@ -90,32 +127,53 @@ func initCommand(stdout, stderr io.Writer, root, operatorURL *string) *cobra.Com
 			if err != nil {
 				return err
 			}
+			parsedInputs = answers.merge(parsedInputs)
+			secretValues, err := answers.secretValues()
+			if err != nil {
+				return err
+			}
+			withAddons := append(slices.Clone(answers.With), addons...)
 			platform, err := localPlatformForRoot(root, operatorURL, false)
 			if err != nil {
 				return err
 			}
-			parsedInputs, err = resolveStackTemplateInputs(cmd, platform, template, parsedInputs, yes)
+			setTemplateRefresh(platform, refresh)
+			parsedInputs, err = resolveStackTemplateInputs(cmd, platform, template, withAddons, parsedInputs, yes || answersPath != "")
 			if err != nil {
 				return err
 			}
-			result, err := platform.StackInit(cmd.Context(), template, path, parsedInputs, force)
+			out := newConsole(cmd, stdout)
+			done := out.Step("initializing stack from template " + template)
+			result, err := platform.StackInit(cmd.Context(), template, withAddons, path, parsedInputs, secretValues, force)
+			done()
 			if err != nil {
 				return stackInitError(template, err)
 			}
-			_, err = fmt.Fprintf(stdout, "stack template %s initialized as %s\n", result.Template, displayPath(result.Root))
-			return err
+			return writeStackInitResult(out, result)
 		},
 	}
 	cmd.Flags().BoolVar(&dev, "dev", false, "use the dev stack template")
 	cmd.Flags().BoolVar(&force, "force", false, "overwrite a non-empty stack root")
 	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "accept template defaults and run non-interactively")
 	cmd.Flags().StringArrayVar(&inputs, "input", nil, "template input K=V")
+	cmd.Flags().StringArrayVar(&addons, "with", nil, "addon template to layer on the stack, repeatable")
+	cmd.Flags().BoolVar(&refresh, "refresh", false, "fetch remote templates now instead of using the cache")
+	cmd.Flags().StringVar(&answersPath, "answers", "", "YAML file with the template, inputs, addons, and secrets; implies --yes")
+	_ = cmd.RegisterFlagCompletionFunc("with", completeTemplates(root, operatorURL, "addon"))
+	_ = cmd.RegisterFlagCompletionFunc("input", completeStackInputs(root, operatorURL, func(cmd *cobra.Command, _ []string) string {
+		if dev, _ := cmd.Flags().GetBool("dev"); dev {
+			return "dev"
+		}
+		return answersTemplate(cmd)
+	}))
 	cmd.AddCommand(initStackCommand(stdout, root, operatorURL))
 	return cmd
 }
 
 func initStackCommand(stdout io.Writer, root, operatorURL *string) *cobra.Command {
 	var template string
+	var addons []string
+	var refresh bool
 	var force bool
 	var yes bool
 	var inputValues []string
@@ -141,36 +199,55 @@ func initStackCommand(stdout io.Writer, root, operatorURL *string) *cobra.Comman
 			if err != nil {
 				return err
 			}
-			inputs, err = resolveStackTemplateInputs(cmd, platform, template, inputs, yes)
+			setTemplateRefresh(platform, refresh)
+			inputs, err = resolveStackTemplateInputs(cmd, platform, template, addons, inputs, yes)
 			if err != nil {
 				return err
 			}
-			result, err := platform.StackInit(cmd.Context(), template, args[0], inputs, force)
+			out := newConsole(cmd, stdout)
+			done := out.Step("initializing stack from template " + template)
+			result, err := platform.StackInit(cmd.Context(), template, addons, args[0], inputs, nil, force)
+			done()
 			if err != nil {
 				return stackInitError(template, err)
 			}
-			_, err = fmt.Fprintf(stdout, "stack template %s initialized as %s\n", result.Template, displayPath(result.Root))
-			return err
+			return writeStackInitResult(out, result)
 		},
 	}
 	cmd.Flags().StringVarP(&template, "template", "t", "", "template ref, URL, or path")
 	cmd.Flags().BoolVar(&force, "force", false, "overwrite a non-empty stack root")
 	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "accept template defaults and run non-interactively")
 	cmd.Flags().StringArrayVar(&inputValues, "input", nil, "template input K=V")
+	cmd.Flags().StringArrayVar(&addons, "with", nil, "addon template to layer on the stack, repeatable")
+	cmd.Flags().BoolVar(&refresh, "refresh", false, "fetch remote templates now instead of using the cache")
+	_ = cmd.RegisterFlagCompletionFunc("template", completeTemplates(root, operatorURL, "stack"))
+	_ = cmd.RegisterFlagCompletionFunc("with", completeTemplates(root, operatorURL, "addon"))
+	_ = cmd.RegisterFlagCompletionFunc("input", completeStackInputs(root, operatorURL, func(cmd *cobra.Command, _ []string) string {
+		template, _ := cmd.Flags().GetString("template")
+		return template
+	}))
 	return cmd
 }
 
 func stackCommand(stdout io.Writer, root, operatorURL *string) *cobra.Command {
 	cmd := &cobra.Command{Use: "stack", Short: "Manage stack configuration"}
 	var initInputs []string
+	var initAddons []string
+	var initRefresh bool
 	var initForce bool
 	var initYes bool
+	var initAnswersPath string
 	initCmd := &cobra.Command{
-		Use:   "init <template> [path]",
+		Use:   "init [template] [path]",
 		Short: "Initialize a stack from a template",
-		Args:  cobra.RangeArgs(1, 2),
+		Args:  cobra.RangeArgs(0, 2),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			path := ""
+			answers, err := loadInitAnswers(initAnswersPath)
+			if err != nil {
+				return err
+			}
+			yes := initYes || initAnswersPath != ""
+			path := answers.Path
 			if len(args) == 2 {
 				path = args[1]
 			}
@@ -178,25 +255,62 @@ func stackCommand(stdout io.Writer, root, operatorURL *string) *cobra.Command {
 			if err != nil {
 				return err
 			}
+			inputs = answers.merge(inputs)
+			secretValues, err := answers.secretValues()
+			if err != nil {
+				return err
+			}
+			withAddons := append(slices.Clone(answers.With), initAddons...)
 			platform, err := localPlatformForRoot(root, operatorURL, false)
 			if err != nil {
 				return err
 			}
-			inputs, err = resolveStackTemplateInputs(cmd, platform, args[0], inputs, initYes)
+			setTemplateRefresh(platform, initRefresh)
+			template := answers.Template
+			if len(args) > 0 {
+				template = args[0]
+			} else if template == "" {
+				if yes {
+					return fmt.Errorf("stack init --yes requires a template argument or an answers file with one")
+				}
+				if template, err = pickStackTemplate(cmd, platform); err != nil {
+					return err
+				}
+			}
+			inputs, err = resolveStackTemplateInputs(cmd, platform, template, withAddons, inputs, yes)
 			if err != nil {
 				return err
 			}
-			result, err := platform.StackInit(cmd.Context(), args[0], path, inputs, initForce)
+			out := newConsole(cmd, stdout)
+			done := out.Step("initializing stack from template " + template)
+			result, err := platform.StackInit(cmd.Context(), template, withAddons, path, inputs, secretValues, initForce)
+			done()
 			if err != nil {
-				return stackInitError(args[0], err)
+				return stackInitError(template, err)
 			}
-			_, err = fmt.Fprintf(stdout, "stack template %s initialized as %s\n", result.Template, displayPath(result.Root))
-			return err
+			return writeStackInitResult(out, result)
 		},
 	}
 	initCmd.Flags().BoolVar(&initForce, "force", false, "overwrite a non-empty stack root")
 	initCmd.Flags().BoolVarP(&initYes, "yes", "y", false, "accept template defaults and run non-interactively")
 	initCmd.Flags().StringArrayVar(&initInputs, "input", nil, "template input K=V")
+	initCmd.Flags().StringArrayVar(&initAddons, "with", nil, "addon template to layer on the stack, repeatable")
+	initCmd.Flags().BoolVar(&initRefresh, "refresh", false, "fetch remote templates now instead of using the cache")
+	initCmd.Flags().StringVar(&initAnswersPath, "answers", "", "YAML file with the template, inputs, addons, and secrets; implies --yes")
+	listStackTemplates := completeTemplates(root, operatorURL, "stack")
+	initCmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return listStackTemplates(cmd, args, toComplete)
+		}
+		return nil, cobra.ShellCompDirectiveFilterDirs
+	}
+	_ = initCmd.RegisterFlagCompletionFunc("with", completeTemplates(root, operatorURL, "addon"))
+	_ = initCmd.RegisterFlagCompletionFunc("input", completeStackInputs(root, operatorURL, func(cmd *cobra.Command, args []string) string {
+		if len(args) > 0 {
+			return args[0]
+		}
+		return answersTemplate(cmd)
+	}))
 	cmd.AddCommand(initCmd)
 	cmd.AddCommand(&cobra.Command{
 		Use:   "update",
@@ -210,8 +324,27 @@ func stackCommand(stdout io.Writer, root, operatorURL *string) *cobra.Command {
 			if err := platform.StackUpdate(cmd.Context()); err != nil {
 				return err
 			}
-			_, err = fmt.Fprintln(stdout, "stack updated")
-			return err
+			return newConsole(cmd, stdout).Success("stack updated")
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "validate",
+		Short: "Check angee.yaml and compile it without secrets or sources",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			platform, err := localPlatform(root, operatorURL)
+			if err != nil {
+				return err
+			}
+			local, ok := platform.(*service.Platform)
+			if !ok {
+				return errors.New("stack validate checks a local root and is not available with --operator")
+			}
+			compiled, err := local.StackValidate(cmd.Context())
+			if err != nil {
+				return err
+			}
+			return newConsole(cmd, stdout).Success("angee.yaml is valid (%d container, %d local)", len(compiled.Compose.Services), len(compiled.ProcessCompose.Processes))
 		},
 	})
 	var purge bool
@@ -227,8 +360,7 @@ func stackCommand(stdout io.Writer, root, operatorURL *string) *cobra.Command {
 			if err := platform.StackDestroy(cmd.Context(), purge); err != nil {
 				return err
 			}
-			_, err = fmt.Fprintln(stdout, "stack destroyed")
-			return err
+			return newConsole(cmd, stdout).Success("stack destroyed")
 		},
 	}
 	destroyCmd.Flags().BoolVar(&purge, "purge", false, "remove runtime state directories")
@@ -237,7 +369,12 @@ func stackCommand(stdout io.Writer, root, operatorURL *string) *cobra.Command {
 }
 
 func runtimeCommands(stdout io.Writer, root, operatorURL *string) []*cobra.Command {
-	var build bool
+	var (
+		build, watch, autoRollback, lockImages, push bool
+		message, plan                                string
+		wait                                         time.Duration
+		rollbackDepth                                int
+	)
 	upCmd := &cobra.Command{
 		Use:   "up [service...]",
 		Short: "Start container services",
@@ -247,14 +384,35 @@ func runtimeCommands(stdout io.Writer, root, operatorURL *string) []*cobra.Comma
 			if err != nil {
 				return err
 			}
-			if err := platform.StackUpForeground(cmd.Context(), args, build, stdout, cmd.ErrOrStderr()); err != nil {
+			local, ok := platform.(*service.Platform)
+			if watch && !ok {
+				return errors.New("--watch is not available with --operator")
+			}
+			opts := service.UpOptions{Build: build, Wait: wait, AutoRollback: autoRollback, RollbackDepth: rollbackDepth, LockImages: lockImages, Message: message, Plan: plan}
+			out := newConsole(cmd, stdout)
+			toolStdout, toolStderr, replay := out.toolOutput()
+			done := out.Step("starting container services")
+			err = platform.StackUpForeground(cmd.Context(), args, opts, toolStdout, toolStderr)
+			done()
+			if err != nil {
+				replay()
 				return err
 			}
-			_, err = fmt.Fprintln(stdout, "container services started")
-			return err
+			if err := out.Success("container services started"); err != nil || !watch {
+				return err
+			}
+			return local.StackWatch(cmd.Context(), args, stdout, cmd.ErrOrStderr())
 		},
 	}
 	upCmd.Flags().BoolVar(&build, "build", false, "build images before starting")
+	upCmd.Flags().BoolVar(&watch, "watch", false, "sync or rebuild services as their develop.watch paths change")
+	upCmd.Flags().DurationVar(&wait, "wait", 0, "wait up to this long for the started services to be healthy (2m when given without a value)")
+	upCmd.Flags().Lookup("wait").NoOptDefVal = service.DefaultUpWait.String()
+	upCmd.Flags().BoolVar(&autoRollback, "auto-rollback", false, "redeploy the angee.yaml of the last successful deploy if this one fails (implies --wait)")
+	upCmd.Flags().IntVar(&rollbackDepth, "rollback-depth", service.DefaultRollbackDepth, "earlier successful manifests --auto-rollback tries before giving up")
+	upCmd.Flags().StringVarP(&message, "message", "m", "", "what this deploy is for, recorded in the deploy ledger")
+	upCmd.Flags().StringVar(&plan, "plan", "", "deploy only if the stack still compiles to what this token from `angee plan approve` approved")
+	upCmd.Flags().BoolVar(&lockImages, "lock-images", false, "record a digest in angee.lock for each image not yet locked before deploying")
 
 	buildCmd := &cobra.Command{
 		Use:   "build [service...]",
@@ -265,13 +423,17 @@ func runtimeCommands(stdout io.Writer, root, operatorURL *string) []*cobra.Comma
 			if err != nil {
 				return err
 			}
-			if err := platform.StackBuild(cmd.Context(), args); err != nil {
+			out := newConsole(cmd, stdout)
+			done := out.Step("building container images")
+			err = platform.StackBuild(cmd.Context(), args, service.BuildOptions{Push: push})
+			done()
+			if err != nil {
 				return err
 			}
-			_, err = fmt.Fprintln(stdout, "container images built")
-			return err
+			return out.Success("container images built")
 		},
 	}
+	buildCmd.Flags().BoolVar(&push, "push", false, "push the built images to their registries, as a build for several platforms requires")
 
 	downCmd := &cobra.Command{
 		Use:   "down",
@@ -282,11 +444,14 @@ func runtimeCommands(stdout io.Writer, root, operatorURL *string) []*cobra.Comma
 			if err != nil {
 				return err
 			}
-			if err := platform.StackDown(cmd.Context()); err != nil {
+			out := newConsole(cmd, stdout)
+			done := out.Step("stopping services")
+			err = platform.StackDown(cmd.Context())
+			done()
+			if err != nil {
 				return err
 			}
-			_, err = fmt.Fprintln(stdout, "stack stopped")
-			return err
+			return out.Success("stack stopped")
 		},
 	}
 
@@ -294,7 +459,10 @@ func runtimeCommands(stdout io.Writer, root, operatorURL *string) []*cobra.Comma
 	stopCmd := serviceActionCommand(stdout, root, operatorURL, "stop")
 	restartCmd := serviceActionCommand(stdout, root, operatorURL, "restart")
 
-	var follow bool
+	var (
+		follow bool
+		tail   int
+	)
 	logsCmd := &cobra.Command{
 		Use:   "logs [service...]",
 		Short: "Show service logs",
@@ -304,7 +472,7 @@ func runtimeCommands(stdout io.Writer, root, operatorURL *string) []*cobra.Comma
 			if err != nil {
 				return err
 			}
-			lines, err := platform.StackLogs(cmd.Context(), args, follow)
+			lines, err := platform.StackLogsLimited(cmd.Context(), args, follow, service.LogLimits{Tail: tail})
 			if err != nil {
 				return err
 			}
@@ -317,6 +485,7 @@ func runtimeCommands(stdout io.Writer, root, operatorURL *string) []*cobra.Comma
 		},
 	}
 	logsCmd.Flags().BoolVarP(&follow, "follow", "f", false, "follow logs")
+	logsCmd.Flags().IntVarP(&tail, "tail", "n", 0, "show only the last N lines of each service")
 
 	var devBuild bool
 	devCmd := &cobra.Command{
@@ -357,8 +526,7 @@ func serviceActionCommand(stdout io.Writer, root, operatorURL *string, action st
 			if err != nil {
 				return err
 			}
-			_, err = fmt.Fprintf(stdout, "services %s\n", actionPast(action))
-			return err
+			return newConsole(cmd, stdout).Success("services %s", actionPast(action))
 		},
 	}
 }
@@ -390,7 +558,10 @@ func serviceCommand(stdout io.Writer, root, operatorURL *string, jsonOutput *boo
 }
 
 func serviceLogsCommand(stdout io.Writer, root, operatorURL *string) *cobra.Command {
-	var follow bool
+	var (
+		follow bool
+		tail   int
+	)
 	cmd := &cobra.Command{
 		Use:   "logs <name>",
 		Short: "Show service logs",
@@ -400,7 +571,7 @@ func serviceLogsCommand(stdout io.Writer, root, operatorURL *string) *cobra.Comm
 			if err != nil {
 				return err
 			}
-			logs, err := platform.StackLogs(cmd.Context(), args, follow)
+			logs, err := platform.StackLogsLimited(cmd.Context(), args, follow, service.LogLimits{Tail: tail})
 			if err != nil {
 				return err
 			}
@@ -413,6 +584,7 @@ func serviceLogsCommand(stdout io.Writer, root, operatorURL *string) *cobra.Comm
 		},
 	}
 	cmd.Flags().BoolVarP(&follow, "follow", "f", false, "follow logs")
+	cmd.Flags().IntVarP(&tail, "tail", "n", 0, "show only the last N lines")
 	return cmd
 }
 
@@ -420,6 +592,7 @@ func jobCommand(stdout io.Writer, root, operatorURL *string, jsonOutput *bool) *
 	cmd := &cobra.Command{Use: "job", Short: "Manage jobs"}
 	cmd.AddCommand(jobListCommand(stdout, root, operatorURL, jsonOutput))
 	cmd.AddCommand(jobRunCommand(stdout, root, operatorURL))
+	cmd.AddCommand(jobRunsCommand(stdout, root, operatorURL, jsonOutput))
 	cmd.AddCommand(&cobra.Command{
 		Use:   "logs <name>",
 		Short: "Show job logs",
@@ -450,7 +623,14 @@ func jobListCommand(stdout io.Writer, root, operatorURL *string, jsonOutput *boo
 				return writeJSON(stdout, jobs)
 			}
 			for _, job := range jobs {
-				if _, err := fmt.Fprintf(stdout, "%s\t%s\n", job.Name, job.Runtime); err != nil {
+				line := job.Name + "\t" + job.Runtime
+				if job.Schedule != "" {
+					line += "\t" + job.Schedule
+					if job.NextRun != nil {
+						line += "\tnext=" + job.NextRun.Format(time.RFC3339)
+					}
+				}
+				if _, err := fmt.Fprintln(stdout, line); err != nil {
 					return err
 				}
 			}
@@ -487,6 +667,96 @@ func jobRunCommand(stdout io.Writer, root, operatorURL *string) *cobra.Command {
 	return cmd
 }
 
+func jobRunsCommand(stdout io.Writer, root, operatorURL *string, jsonOutput *bool) *cobra.Command {
+	var history historyFlags
+	cmd := &cobra.Command{
+		Use:   "runs <name>",
+		Short: "Show job run history",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			query, err := history.query(time.Now())
+			if err != nil {
+				return err
+			}
+			platform, err := localPlatform(root, operatorURL)
+			if err != nil {
+				return err
+			}
+			runs, err := historyPages(query, func(q service.HistoryQuery) ([]api.JobRun, string, error) {
+				return platform.JobRunPage(cmd.Context(), args[0], q)
+			})
+			if err != nil {
+				return err
+			}
+			if *jsonOutput {
+				return writeJSON(stdout, runs)
+			}
+			for _, run := range runs {
+				line := fmt.Sprintf("%s\t%s\t%s\t%s", run.StartedAt.Format(time.RFC3339), run.Trigger, run.Status, run.FinishedAt.Sub(run.StartedAt).Round(time.Millisecond))
+				if run.Error != "" {
+					line += "\t" + strings.TrimSpace(run.Error)
+				}
+				if _, err := fmt.Fprintln(stdout, line); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	history.register(cmd, "runs")
+	return cmd
+}
+
+func deploysCommand(stdout io.Writer, root, operatorURL *string, jsonOutput *bool) *cobra.Command {
+	var history historyFlags
+	cmd := &cobra.Command{
+		Use:   "deploys",
+		Short: "Show the deploy ledger",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			query, err := history.query(time.Now())
+			if err != nil {
+				return err
+			}
+			platform, err := localPlatform(root, operatorURL)
+			if err != nil {
+				return err
+			}
+			deploys, err := historyPages(query, func(q service.HistoryQuery) ([]api.Deploy, string, error) {
+				return platform.DeployPage(cmd.Context(), q)
+			})
+			if err != nil {
+				return err
+			}
+			if *jsonOutput {
+				return writeJSON(stdout, deploys)
+			}
+			for _, deploy := range deploys {
+				commit := shortCommit(deploy.Commit)
+				if commit == "" {
+					commit = "-"
+				}
+				line := fmt.Sprintf("%s\t%s\t%s\t%s\t%s", deploy.StartedAt.Format(time.RFC3339), commit, deploy.Caller, deploy.Status, time.Duration(deploy.DurationMS)*time.Millisecond)
+				if len(deploy.Services) > 0 {
+					line += "\t" + strings.Join(deploy.Services, ",")
+				}
+				if deploy.Message != "" {
+					line += "\t" + strconv.Quote(deploy.Message)
+				}
+				if deploy.Error != "" {
+					line += "\t" + strings.TrimSpace(deploy.Error)
+				}
+				if _, err := fmt.Fprintln(stdout, line); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	history.register(cmd, "deploys")
+	return cmd
+}
+
 func serviceInitCommand(stdout io.Writer, root, operatorURL *string) *cobra.Command {
 	var req api.ServiceInitRequest
 	var env []string
@@ -589,7 +859,14 @@ func serviceListCommand(stdout io.Writer, root, operatorURL *string, jsonOutput
 				return writeJSON(stdout, services)
 			}
 			for _, service := range services {
-				if _, err := fmt.Fprintf(stdout, "%s\t%s\t%s\n", service.Name, service.Runtime, service.Status); err != nil {
+				line := service.Name + "\t" + service.Runtime + "\t" + service.Status
+				if service.Stats != nil {
+					line += fmt.Sprintf("\tcpu=%.1f%%\tmem=%s\trestarts=%d", service.Stats.CPUPercent, formatBytes(service.Stats.MemoryBytes), service.Stats.Restarts)
+					if service.Stats.StartedAt != nil {
+						line += "\tup=" + (time.Duration(service.Stats.UptimeSeconds) * time.Second).String()
+					}
+				}
+				if _, err := fmt.Fprintln(stdout, line); err != nil {
 					return err
 				}
 			}
@@ -598,6 +875,19 @@ func serviceListCommand(stdout io.Writer, root, operatorURL *string, jsonOutput
 	}
 }
 
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
 func bindServiceFlags(cmd *cobra.Command, req *api.ServiceInitRequest, env *[]string) {
 	cmd.Flags().StringVar(&req.Runtime, "runtime", "", "service runtime: container or local")
 	cmd.Flags().StringVar(&req.Image, "image", "", "container image")
@@ -623,6 +913,26 @@ func parseKeyValues(values []string) (map[string]string, error) {
 	return out, nil
 }
 
+func writeStackInitResult(out *console, result service.StackInitResult) error {
+	if err := out.Success("stack template %s initialized as %s", result.Template, displayPath(result.Root)); err != nil {
+		return err
+	}
+	for _, job := range result.InitJobs {
+		if err := out.Success("init job %s succeeded", job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setTemplateRefresh applies --refresh to a local platform. A remote
+// operator keeps its own template cache policy.
+func setTemplateRefresh(platform platformClient, refresh bool) {
+	if local, ok := platform.(*service.Platform); ok {
+		local.SetTemplateRefresh(refresh)
+	}
+}
+
 func stackInitError(template string, err error) error {
 	var conflict *service.ConflictError
 	if errors.As(err, &conflict) && conflict.Kind == "stack-root" {
@@ -634,14 +944,14 @@ func stackInitError(template string, err error) error {
 	return err
 }
 
-func resolveStackTemplateInputs(cmd *cobra.Command, platform platformClient, template string, provided map[string]string, yes bool) (map[string]string, error) {
+func resolveStackTemplateInputs(cmd *cobra.Command, platform platformClient, template string, addons []string, provided map[string]string, yes bool) (map[string]string, error) {
 	if provided == nil {
 		provided = map[string]string{}
 	}
 	if yes {
 		return provided, nil
 	}
-	questions, defaults, err := platform.StackTemplateQuestions(cmd.Context(), template)
+	questions, defaults, err := platform.StackTemplateQuestions(cmd.Context(), template, addons)
 	if err != nil {
 		return nil, err
 	}
@@ -667,9 +977,18 @@ func resolveStackTemplateInputs(cmd *cobra.Command, platform platformClient, tem
 			continue
 		}
 		defaultValue, hasDefault := defaults[key]
-		prompt := key + ": "
+		prompt := key
+		if choices := question.ChoiceValues(); len(choices) > 0 {
+			prompt += " (" + strings.Join(choices, "|") + ")"
+		} else if question.Type == "bool" || question.Type == "boolean" {
+			prompt += " (y/n)"
+		}
 		if hasDefault {
-			prompt = fmt.Sprintf("%s [%s]: ", key, defaultValue)
+			prompt += " [" + defaultValue + "]"
+		}
+		prompt += ": "
+		if question.Help != "" {
+			prompt = "# " + question.Help + "\n" + prompt
 		}
 		if _, err := fmt.Fprint(cmd.ErrOrStderr(), prompt); err != nil {
 			return nil, err
@@ -686,7 +1005,7 @@ func resolveStackTemplateInputs(cmd *cobra.Command, platform platformClient, tem
 			return nil, fmt.Errorf("template input %s is required; pass --input %s=value", key, key)
 		}
 		if value != "" {
-			if err := validateTemplateInputValue(key, question.Type, value); err != nil {
+			if err := question.Validate(key, value); err != nil {
 				return nil, err
 			}
 			out[key] = value
@@ -695,25 +1014,6 @@ func resolveStackTemplateInputs(cmd *cobra.Command, platform platformClient, tem
 	return out, nil
 }
 
-func validateTemplateInputValue(key string, typ string, value string) error {
-	switch typ {
-	case "", "str", "string", "path":
-		return nil
-	case "int", "integer":
-		if _, err := strconv.Atoi(value); err != nil {
-			return fmt.Errorf("template input %s must be an integer", key)
-		}
-		return nil
-	case "bool", "boolean":
-		if _, err := strconv.ParseBool(value); err != nil {
-			return fmt.Errorf("template input %s must be a boolean", key)
-		}
-		return nil
-	default:
-		return nil
-	}
-}
-
 func displayPath(path string) string {
 	cwd, err := os.Getwd()
 	if err != nil {
@@ -902,7 +1202,10 @@ func workspaceUpdateCommand(stdout io.Writer, root, operatorURL *string, jsonOut
 }
 
 func workspaceLogsCommand(stdout io.Writer, root, operatorURL *string) *cobra.Command {
-	var follow bool
+	var (
+		follow bool
+		tail   int
+	)
 	cmd := &cobra.Command{
 		Use:   "logs [name]",
 		Short: "Show workspace logs",
@@ -912,7 +1215,7 @@ func workspaceLogsCommand(stdout io.Writer, root, operatorURL *string) *cobra.Co
 			if err != nil {
 				return err
 			}
-			logs, err := platform.WorkspaceLogs(cmd.Context(), name, follow)
+			logs, err := platform.WorkspaceLogsLimited(cmd.Context(), name, follow, service.LogLimits{Tail: tail})
 			if err != nil {
 				return err
 			}
@@ -925,6 +1228,7 @@ func workspaceLogsCommand(stdout io.Writer, root, operatorURL *string) *cobra.Co
 		},
 	}
 	cmd.Flags().BoolVarP(&follow, "follow", "f", false, "follow logs")
+	cmd.Flags().IntVarP(&tail, "tail", "n", 0, "show only the last N lines")
 	return cmd
 }
 
@@ -1422,8 +1726,7 @@ func internalCommand(stdout io.Writer, root, operatorURL *string, jsonOutput *bo
 			if *jsonOutput {
 				return writeJSON(stdout, compiled)
 			}
-			_, err = fmt.Fprintln(stdout, "runtime files prepared")
-			return err
+			return newConsole(cmd, stdout).Success("runtime files prepared")
 		},
 	})
 	internalCmd.AddCommand(stackCmd)
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/fyltr/angee/internal/service"
+	"github.com/spf13/cobra"
+)
+
+func rootCommand(stdout io.Writer, root, operatorURL *string, jsonOutput *bool) *cobra.Command {
+	cmd := &cobra.Command{Use: "root", Short: "Manage the stack root directory"}
+	cmd.AddCommand(&cobra.Command{
+		Use:   "move <new-path>",
+		Short: "Move the stack root and rewrite the paths that point into it",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			platform, err := localPlatform(root, operatorURL)
+			if err != nil {
+				return err
+			}
+			local, ok := platform.(*service.Platform)
+			if !ok {
+				return errors.New("root move renames a local directory and is not available with --operator")
+			}
+			result, err := local.RootMove(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			if *jsonOutput {
+				return writeJSON(stdout, result)
+			}
+			if _, err := fmt.Fprintf(stdout, "moved %s to %s\n", result.From, result.To); err != nil {
+				return err
+			}
+			for _, path := range result.Rewritten {
+				if _, err := fmt.Fprintf(stdout, "rewrote %s\n", path); err != nil {
+					return err
+				}
+			}
+			if result.Restarted {
+				if _, err := fmt.Fprintln(stdout, "services restarted from the new root"); err != nil {
+					return err
+				}
+			}
+			_, err = fmt.Fprintf(stdout, "set ANGEE_ROOT=%s and restart any operator serving the old root\n", result.To)
+			return err
+		},
+	})
+	return cmd
+}
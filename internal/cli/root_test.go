@@ -2,6 +2,7 @@ package cli
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
@@ -107,6 +108,296 @@ func TestInitStackTemplateInitializesNamedRoot(t *testing.T) {
 	}
 }
 
+func TestStackInitPicksTemplateInteractively(t *testing.T) {
+	root := t.TempDir()
+	writeStackTemplate(t, root)
+	t.Chdir(root)
+
+	var stdout, stderr bytes.Buffer
+	cmd := NewRootWithIO(strings.NewReader("1\n\n"), &stdout, &stderr)
+	cmd.SetArgs([]string{"stack", "init"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if got := stderr.String(); !strings.Contains(got, "1) dev - Development stack") || !strings.Contains(got, "ANGEE_ROOT [.angee]:") {
+		t.Fatalf("prompts = %q, want template picker then input prompt", got)
+	}
+	want := "stack template dev initialized as .angee"
+	if got := strings.TrimSpace(stdout.String()); got != want {
+		t.Fatalf("init output = %q, want %q", got, want)
+	}
+}
+
+func TestStackInitAnswersFile(t *testing.T) {
+	root := t.TempDir()
+	templateRoot := writeStackTemplate(t, root)
+	manifestYAML := `version: 1
+kind: stack
+name: test
+secrets:
+  api-key:
+    required: true
+`
+	if err := os.WriteFile(filepath.Join(templateRoot, "template", "{{ ANGEE_ROOT }}", "angee.yaml.jinja"), []byte(manifestYAML), 0o644); err != nil {
+		t.Fatalf("WriteFile(angee.yaml.jinja) error = %v", err)
+	}
+	answers := `template: dev
+inputs:
+  ANGEE_ROOT: provisioned
+secrets:
+  api-key:
+    env: TEST_INIT_API_KEY
+`
+	if err := os.WriteFile(filepath.Join(root, "init.yaml"), []byte(answers), 0o644); err != nil {
+		t.Fatalf("WriteFile(init.yaml) error = %v", err)
+	}
+	t.Setenv("TEST_INIT_API_KEY", "k-123")
+	t.Chdir(root)
+
+	var stdout, stderr bytes.Buffer
+	cmd := NewRoot(&stdout, &stderr)
+	cmd.SetArgs([]string{"stack", "init", "--answers", "init.yaml"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if stderr.Len() != 0 {
+		t.Fatalf("stderr = %q, want no prompts", stderr.String())
+	}
+	env, err := os.ReadFile(filepath.Join(root, "provisioned", ".env"))
+	if err != nil {
+		t.Fatalf("ReadFile(.env) error = %v", err)
+	}
+	if !strings.Contains(string(env), "k-123") {
+		t.Fatalf(".env = %q, want the answered secret", env)
+	}
+}
+
+func TestStackInitRejectsInputOutsideChoices(t *testing.T) {
+	root := t.TempDir()
+	templateRoot := writeStackTemplate(t, root)
+	f, err := os.OpenFile(filepath.Join(templateRoot, "copier.yml"), os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("OpenFile(copier.yml) error = %v", err)
+	}
+	if _, err := f.WriteString("database:\n  choices: [postgres, sqlite]\n  default: postgres\n"); err != nil {
+		t.Fatalf("WriteString(copier.yml) error = %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close(copier.yml) error = %v", err)
+	}
+	t.Chdir(root)
+
+	var stdout, stderr bytes.Buffer
+	cmd := NewRoot(&stdout, &stderr)
+	cmd.SetArgs([]string{"stack", "init", "dev", "--yes", "--input", "database=mysql"})
+	err = cmd.Execute()
+	if err == nil {
+		t.Fatal("Execute() error is nil")
+	}
+	if got := err.Error(); !strings.Contains(got, "database: must be one of postgres, sqlite") {
+		t.Fatalf("init error = %q, want choices error", got)
+	}
+	if _, err := os.Stat(filepath.Join(root, ".angee")); !os.IsNotExist(err) {
+		t.Fatalf("stack root created despite invalid input, err = %v", err)
+	}
+}
+
+func TestStackInitLayersExtendedTemplate(t *testing.T) {
+	root := t.TempDir()
+	writeStackTemplate(t, root)
+	templateRoot := filepath.Join(root, ".templates", "stacks", "acme")
+	manifestDir := filepath.Join(templateRoot, "template", "{{ ANGEE_ROOT }}")
+	if err := os.MkdirAll(manifestDir, 0o755); err != nil {
+		t.Fatalf("MkdirAll(acme) error = %v", err)
+	}
+	copierYAML := `_subdirectory: template
+_templates_suffix: .jinja
+_angee:
+  kind: stack
+  name: acme
+  extends: ../dev
+team:
+  default: platform
+`
+	if err := os.WriteFile(filepath.Join(templateRoot, "copier.yml"), []byte(copierYAML), 0o644); err != nil {
+		t.Fatalf("WriteFile(copier.yml) error = %v", err)
+	}
+	manifestYAML := `services:
+  redis:
+    runtime: container
+    image: redis:7
+    env:
+      TEAM: "{{ team }}"
+`
+	if err := os.WriteFile(filepath.Join(manifestDir, "angee.yaml.jinja"), []byte(manifestYAML), 0o644); err != nil {
+		t.Fatalf("WriteFile(angee.yaml.jinja) error = %v", err)
+	}
+	t.Chdir(root)
+
+	var stdout, stderr bytes.Buffer
+	cmd := NewRoot(&stdout, &stderr)
+	cmd.SetArgs([]string{"stack", "init", "acme", "--yes"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	stack, err := manifest.LoadFile(filepath.Join(root, ".angee", "angee.yaml"))
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if stack.Name != "test" || stack.Services["redis"].Env["TEAM"] != "platform" {
+		t.Fatalf("stack = %+v, want base name with acme redis service", stack)
+	}
+
+	stdout.Reset()
+	cmd = NewRoot(&stdout, &stderr)
+	cmd.SetArgs([]string{"template", "info", "acme"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute(info) error = %v", err)
+	}
+	if got := stdout.String(); !strings.Contains(got, "extends: ../dev") || !strings.Contains(got, "ANGEE_ROOT [.angee]") || !strings.Contains(got, "team [platform]") {
+		t.Fatalf("template info = %q, want extends and inherited inputs", got)
+	}
+}
+
+func TestStackInitLayersAddons(t *testing.T) {
+	root := t.TempDir()
+	writeStackTemplate(t, root)
+	addonRoot := filepath.Join(root, ".templates", "addons", "observability")
+	manifestDir := filepath.Join(addonRoot, "template", "{{ ANGEE_ROOT }}")
+	if err := os.MkdirAll(manifestDir, 0o755); err != nil {
+		t.Fatalf("MkdirAll(addon) error = %v", err)
+	}
+	copierYAML := `_subdirectory: template
+_templates_suffix: .jinja
+_angee:
+  kind: addon
+  name: observability
+  description: Grafana dashboards
+`
+	if err := os.WriteFile(filepath.Join(addonRoot, "copier.yml"), []byte(copierYAML), 0o644); err != nil {
+		t.Fatalf("WriteFile(copier.yml) error = %v", err)
+	}
+	manifestYAML := `services:
+  grafana:
+    runtime: container
+    image: grafana/grafana:11
+`
+	if err := os.WriteFile(filepath.Join(manifestDir, "angee.yaml.jinja"), []byte(manifestYAML), 0o644); err != nil {
+		t.Fatalf("WriteFile(angee.yaml.jinja) error = %v", err)
+	}
+	t.Chdir(root)
+
+	var stdout, stderr bytes.Buffer
+	cmd := NewRoot(&stdout, &stderr)
+	cmd.SetArgs([]string{"stack", "init", "dev", "--with", "observability", "--yes"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	stack, err := manifest.LoadFile(filepath.Join(root, ".angee", "angee.yaml"))
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if stack.Name != "test" || stack.Services["grafana"].Image != "grafana/grafana:11" {
+		t.Fatalf("stack = %+v, want dev stack with grafana addon", stack)
+	}
+
+	cmd = NewRoot(&stdout, &stderr)
+	cmd.SetArgs([]string{"stack", "init", "dev", "other", "--with", "missing", "--yes"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), `addon template "missing"`) {
+		t.Fatalf("Execute(missing addon) error = %v, want addon not found", err)
+	}
+}
+
+func TestStackInitRunsInitJobsAndRollsBackOnFailure(t *testing.T) {
+	root := t.TempDir()
+	templateRoot := writeStackTemplate(t, root)
+	manifestYAML := `version: 1
+kind: stack
+name: test
+jobs:
+  seed:
+    runtime: local
+    command: [sh, -c, "test -f migrated && touch seeded"]
+    workdir: .
+    depends_on: [migrate]
+    run_on: [init]
+  migrate:
+    runtime: local
+    command: [sh, -c, "{{ migrate_command }}"]
+    workdir: .
+    run_on: [init]
+`
+	if err := os.WriteFile(filepath.Join(templateRoot, "template", "{{ ANGEE_ROOT }}", "angee.yaml.jinja"), []byte(manifestYAML), 0o644); err != nil {
+		t.Fatalf("WriteFile(angee.yaml.jinja) error = %v", err)
+	}
+	t.Chdir(root)
+
+	var stdout, stderr bytes.Buffer
+	cmd := NewRoot(&stdout, &stderr)
+	cmd.SetArgs([]string{"stack", "init", "dev", "--yes", "--input", "migrate_command=touch migrated"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	want := "stack template dev initialized as .angee\ninit job migrate succeeded\ninit job seed succeeded\n"
+	if got := stdout.String(); got != want {
+		t.Fatalf("init output = %q, want %q", got, want)
+	}
+	if _, err := os.Stat(filepath.Join(root, ".angee", "seeded")); err != nil {
+		t.Fatalf("Stat(seeded) error = %v, want seed to run after migrate", err)
+	}
+
+	if err := os.RemoveAll(filepath.Join(root, ".angee")); err != nil {
+		t.Fatalf("RemoveAll(.angee) error = %v", err)
+	}
+	cmd = NewRoot(&stdout, &stderr)
+	cmd.SetArgs([]string{"stack", "init", "dev", "--yes", "--input", "migrate_command=exit 3"})
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "init job migrate") {
+		t.Fatalf("Execute() error = %v, want init job failure", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, ".angee")); !os.IsNotExist(err) {
+		t.Fatalf("stack root left behind after failed init job, err = %v", err)
+	}
+}
+
+func TestTemplateListSearchAndInfo(t *testing.T) {
+	root := t.TempDir()
+	writeStackTemplate(t, root)
+	writeWorkspaceTemplate(t, root)
+	t.Chdir(root)
+
+	var stdout, stderr bytes.Buffer
+	cmd := NewRoot(&stdout, &stderr)
+	cmd.SetArgs([]string{"template", "list"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute(list) error = %v", err)
+	}
+	want := "stack\tdev\tDevelopment stack\nworkspace\tdev-pr\t\n"
+	if got := stdout.String(); got != want {
+		t.Fatalf("template list = %q, want %q", got, want)
+	}
+
+	stdout.Reset()
+	cmd = NewRoot(&stdout, &stderr)
+	cmd.SetArgs([]string{"template", "search", "development"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute(search) error = %v", err)
+	}
+	if got := stdout.String(); got != "stack\tdev\tDevelopment stack\n" {
+		t.Fatalf("template search = %q", got)
+	}
+
+	stdout.Reset()
+	cmd = NewRoot(&stdout, &stderr)
+	cmd.SetArgs([]string{"template", "info", "dev"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute(info) error = %v", err)
+	}
+	if got := stdout.String(); !strings.Contains(got, "ANGEE_ROOT [.angee] - Stack root directory") {
+		t.Fatalf("template info = %q, want input preview", got)
+	}
+}
+
 func TestOperatorCommandForwardsDaemonFlags(t *testing.T) {
 	var stdout, stderr bytes.Buffer
 	cmd := NewRoot(&stdout, &stderr)
@@ -121,25 +412,27 @@ func TestOperatorCommandForwardsDaemonFlags(t *testing.T) {
 }
 
 func TestOperatorHTTPErrorPreservesStatusAndFields(t *testing.T) {
-	body, err := json.Marshal(api.ErrorResponse{
-		Kind:  "workspace",
-		Name:  "missing",
-		Error: `workspace "missing" is not declared`,
-	})
-	if err != nil {
-		t.Fatalf("Marshal(ErrorResponse) error = %v", err)
-	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(api.RequestIDHeader, "req-42")
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(api.ErrorResponse{
+			Kind:  "workspace",
+			Name:  "missing",
+			Error: `workspace "missing" is not declared`,
+		})
+	}))
+	defer server.Close()
 
-	err = operatorHTTPError(http.StatusNotFound, body)
-	var notFound *RemoteNotFound
-	if !errors.As(err, &notFound) {
-		t.Fatalf("operatorHTTPError() = %T, want RemoteNotFound", err)
+	_, err := newRemotePlatform(server.URL).WorkspaceGet(context.Background(), "missing")
+	var remote *RemoteError
+	if !errors.As(err, &remote) {
+		t.Fatalf("WorkspaceGet() error = %T, want RemoteError", err)
 	}
-	if notFound.Status != http.StatusNotFound || notFound.Body.Kind != "workspace" || notFound.Body.Name != "missing" {
-		t.Fatalf("RemoteNotFound = %#v", notFound)
+	if remote.Status != http.StatusNotFound || remote.Body.Kind != "workspace" || remote.Body.Name != "missing" {
+		t.Fatalf("RemoteError = %#v", remote)
 	}
-	if got := err.Error(); !strings.Contains(got, "HTTP 404") || !strings.Contains(got, `workspace "missing" is not declared`) {
-		t.Fatalf("error string = %q, want status and message", got)
+	if got := err.Error(); !strings.Contains(got, "HTTP 404") || !strings.Contains(got, `workspace "missing" is not declared`) || !strings.Contains(got, "request req-42") {
+		t.Fatalf("error string = %q, want status, message, and request id", got)
 	}
 }
 
@@ -193,8 +486,8 @@ func TestStatusUsesOperatorURLFlag(t *testing.T) {
 	called := false
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		called = true
-		if r.Method != http.MethodGet || r.URL.Path != "/stack/status" {
-			t.Fatalf("request = %s %s, want GET /stack/status", r.Method, r.URL.Path)
+		if r.Method != http.MethodGet || r.URL.Path != "/v1/stack/status" {
+			t.Fatalf("request = %s %s, want GET /v1/stack/status", r.Method, r.URL.Path)
 		}
 		_ = json.NewEncoder(w).Encode(api.StackStatusResponse{Name: "remote", Root: "/remote"})
 	}))
@@ -216,8 +509,8 @@ func TestStatusUsesOperatorURLFlag(t *testing.T) {
 
 func TestStatusUsesOperatorURLEnv(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet || r.URL.Path != "/stack/status" {
-			t.Fatalf("request = %s %s, want GET /stack/status", r.Method, r.URL.Path)
+		if r.Method != http.MethodGet || r.URL.Path != "/v1/stack/status" {
+			t.Fatalf("request = %s %s, want GET /v1/stack/status", r.Method, r.URL.Path)
 		}
 		_ = json.NewEncoder(w).Encode(api.StackStatusResponse{Name: "env-remote", Root: "/env"})
 	}))
@@ -262,6 +555,55 @@ func TestStatusDiscoversParentAngeeRoot(t *testing.T) {
 	}
 }
 
+func TestImportComposeAddsServicesAndRejectsConflicts(t *testing.T) {
+	root := t.TempDir()
+	stack := &manifest.Stack{
+		Version:  manifest.VersionCurrent,
+		Kind:     manifest.KindStack,
+		Name:     "imported",
+		Services: map[string]manifest.Service{"web": {Runtime: manifest.RuntimeContainer, Image: "nginx:1"}},
+	}
+	if err := manifest.SaveFile(manifest.Path(root), stack); err != nil {
+		t.Fatalf("SaveFile(angee.yaml) error = %v", err)
+	}
+	composePath := filepath.Join(t.TempDir(), "docker-compose.yaml")
+	writeCompose := func(data string) {
+		t.Helper()
+		if err := os.WriteFile(composePath, []byte(data), 0o644); err != nil {
+			t.Fatalf("WriteFile(docker-compose.yaml) error = %v", err)
+		}
+	}
+	writeCompose("services:\n  cache:\n    image: redis:7\n    restart: always\n    volumes:\n      - cache:/data\nvolumes:\n  cache: {}\n")
+
+	var stdout, stderr bytes.Buffer
+	cmd := NewRoot(&stdout, &stderr)
+	cmd.SetArgs([]string{"--root", root, "import", "compose", composePath})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if !strings.Contains(stderr.String(), `warning: service cache: "restart" is not supported`) {
+		t.Fatalf("stderr = %q, want restart warning", stderr.String())
+	}
+	loaded, err := manifest.LoadFile(manifest.Path(root))
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	cache := loaded.Services["cache"]
+	if cache.Image != "redis:7" || len(cache.Mounts) != 1 || cache.Mounts[0] != "volume://cache:/data" {
+		t.Fatalf("imported cache = %#v", cache)
+	}
+	if _, ok := loaded.Volumes["cache"]; !ok || loaded.Services["web"].Image != "nginx:1" {
+		t.Fatalf("manifest after import = %#v", loaded)
+	}
+
+	writeCompose("services:\n  web:\n    image: httpd:2\n")
+	cmd = NewRoot(&stdout, &stderr)
+	cmd.SetArgs([]string{"--root", root, "import", "compose", composePath})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("Execute() error = %v, want conflict with existing service", err)
+	}
+}
+
 func TestWorkspaceCreateUsesDotAngeeForTemplatesDirectory(t *testing.T) {
 	root := t.TempDir()
 	writeWorkspaceTemplate(t, root)
@@ -366,8 +708,10 @@ _templates_suffix: .jinja
 _angee:
   kind: stack
   name: dev
+  description: Development stack
 ANGEE_ROOT:
   default: .angee
+  help: Stack root directory
 `
 	if err := os.WriteFile(filepath.Join(templateRoot, "copier.yml"), []byte(copierYAML), 0o644); err != nil {
 		t.Fatalf("WriteFile(copier.yml) error = %v", err)
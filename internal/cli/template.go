@@ -0,0 +1,252 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/fyltr/angee/api"
+	"github.com/spf13/cobra"
+)
+
+func templateCommand(stdout io.Writer, root, operatorURL *string, jsonOutput *bool) *cobra.Command {
+	cmd := &cobra.Command{Use: "template", Short: "Discover stack and workspace templates"}
+	var refresh bool
+	cmd.PersistentFlags().BoolVar(&refresh, "refresh", false, "fetch remote templates now instead of using the cache")
+	var listKind string
+	listCmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List known templates",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTemplateList(cmd, stdout, root, operatorURL, jsonOutput, listKind, "")
+		},
+	}
+	listCmd.Flags().StringVar(&listKind, "kind", "", "template kind: stack, workspace, or addon")
+	cmd.AddCommand(listCmd)
+
+	var searchKind string
+	searchCmd := &cobra.Command{
+		Use:   "search <term>",
+		Short: "Search templates by name or description",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTemplateList(cmd, stdout, root, operatorURL, jsonOutput, searchKind, args[0])
+		},
+	}
+	searchCmd.Flags().StringVar(&searchKind, "kind", "", "template kind: stack, workspace, or addon")
+	cmd.AddCommand(searchCmd)
+
+	infoKind := "stack"
+	infoCmd := &cobra.Command{
+		Use:   "info <template>",
+		Short: "Show a template's description and inputs",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			platform, err := localPlatformForRoot(root, operatorURL, false)
+			if err != nil {
+				return err
+			}
+			setTemplateRefresh(platform, refresh)
+			info, err := platform.TemplateInfo(cmd.Context(), args[0], infoKind)
+			if err != nil {
+				return err
+			}
+			if *jsonOutput {
+				return writeJSON(stdout, info)
+			}
+			return writeTemplateInfo(stdout, info)
+		},
+	}
+	infoCmd.Flags().StringVar(&infoKind, "kind", infoKind, "template kind: stack, workspace, or addon")
+	cmd.AddCommand(infoCmd)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "status",
+		Short: "Compare the stack's template with upstream",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			platform, err := localPlatform(root, operatorURL)
+			if err != nil {
+				return err
+			}
+			setTemplateRefresh(platform, refresh)
+			status, err := platform.TemplateStatus(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if *jsonOutput {
+				return writeJSON(stdout, status)
+			}
+			return writeTemplateStatus(stdout, status)
+		},
+	})
+	return cmd
+}
+
+func writeTemplateStatus(stdout io.Writer, status api.TemplateStatus) error {
+	line := fmt.Sprintf("%s\t%s", status.Source, status.State)
+	if status.Commit != "" {
+		line += "\tcommit=" + shortCommit(status.Commit)
+	}
+	if status.Latest != "" && status.Latest != status.Commit {
+		line += "\tlatest=" + shortCommit(status.Latest)
+	}
+	if status.Behind > 0 {
+		line += fmt.Sprintf("\tbehind=%d", status.Behind)
+	}
+	if len(status.With) > 0 {
+		line += "\twith=" + strings.Join(status.With, ",")
+	}
+	_, err := fmt.Fprintln(stdout, line)
+	return err
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
+
+func runTemplateList(cmd *cobra.Command, stdout io.Writer, root, operatorURL *string, jsonOutput *bool, kind, term string) error {
+	platform, err := localPlatformForRoot(root, operatorURL, false)
+	if err != nil {
+		return err
+	}
+	templates, err := platform.TemplateList(cmd.Context(), kind)
+	if err != nil {
+		return err
+	}
+	templates = filterTemplates(templates, term)
+	if *jsonOutput {
+		return writeJSON(stdout, templates)
+	}
+	for _, template := range templates {
+		if _, err := fmt.Fprintf(stdout, "%s\t%s\t%s\n", template.Kind, template.Name, template.Description); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func filterTemplates(templates []api.TemplateInfo, term string) []api.TemplateInfo {
+	term = strings.ToLower(strings.TrimSpace(term))
+	if term == "" {
+		return templates
+	}
+	filtered := []api.TemplateInfo{}
+	for _, template := range templates {
+		if strings.Contains(strings.ToLower(template.Name), term) || strings.Contains(strings.ToLower(template.Description), term) {
+			filtered = append(filtered, template)
+		}
+	}
+	return filtered
+}
+
+func writeTemplateInfo(stdout io.Writer, info api.TemplateInfo) error {
+	if _, err := fmt.Fprintf(stdout, "%s\nkind: %s\nref: %s\npath: %s\n", info.Name, info.Kind, info.Ref, info.Path); err != nil {
+		return err
+	}
+	if info.Description != "" {
+		if _, err := fmt.Fprintf(stdout, "description: %s\n", info.Description); err != nil {
+			return err
+		}
+	}
+	if info.Extends != "" {
+		if _, err := fmt.Fprintf(stdout, "extends: %s\n", info.Extends); err != nil {
+			return err
+		}
+	}
+	if len(info.Inputs) == 0 {
+		return nil
+	}
+	if _, err := fmt.Fprintln(stdout, "inputs:"); err != nil {
+		return err
+	}
+	for _, input := range info.Inputs {
+		line := "  " + input.Name
+		if input.Type != "" {
+			line += " (" + input.Type + ")"
+		}
+		if input.Required {
+			line += " required"
+		}
+		if len(input.Choices) > 0 {
+			line += " {" + strings.Join(input.Choices, "|") + "}"
+		}
+		if input.Default != "" {
+			line += " [" + input.Default + "]"
+		}
+		if input.Help != "" {
+			line += " - " + input.Help
+		}
+		if _, err := fmt.Fprintln(stdout, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pickStackTemplate presents the discovered stack templates as a numbered
+// list on stderr and reads the selection from stdin.
+func pickStackTemplate(cmd *cobra.Command, platform platformClient) (string, error) {
+	templates, err := platform.TemplateList(cmd.Context(), "stack")
+	if err != nil {
+		return "", err
+	}
+	if len(templates) == 0 {
+		return "", fmt.Errorf("no stack templates found; pass a template name, path, or URL")
+	}
+	stderr := cmd.ErrOrStderr()
+	for i, template := range templates {
+		line := fmt.Sprintf("%d) %s", i+1, template.Name)
+		if template.Description != "" {
+			line += " - " + template.Description
+		}
+		if _, err := fmt.Fprintln(stderr, line); err != nil {
+			return "", err
+		}
+	}
+	if _, err := fmt.Fprint(stderr, "template: "); err != nil {
+		return "", err
+	}
+	line, err := readLine(cmd.InOrStdin())
+	if err != nil && len(line) == 0 {
+		return "", fmt.Errorf("stack init requires a template; pass one as an argument")
+	}
+	choice := strings.TrimSpace(line)
+	if n, err := strconv.Atoi(choice); err == nil {
+		if n < 1 || n > len(templates) {
+			return "", fmt.Errorf("template choice %d is out of range", n)
+		}
+		return templates[n-1].Name, nil
+	}
+	for _, template := range templates {
+		if template.Name == choice {
+			return template.Name, nil
+		}
+	}
+	return "", fmt.Errorf("template %q is not in the list", choice)
+}
+
+// readLine reads up to and including the next newline without buffering
+// past it, so later prompts reading the same stdin see the remaining input.
+func readLine(r io.Reader) (string, error) {
+	var line []byte
+	buf := make([]byte, 1)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			line = append(line, buf[0])
+			if buf[0] == '\n' {
+				return string(line), nil
+			}
+		}
+		if err != nil {
+			return string(line), err
+		}
+	}
+}
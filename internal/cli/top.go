@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fyltr/angee/api"
+	"github.com/spf13/cobra"
+)
+
+// clearScreen moves the cursor home and clears the terminal before each
+// refresh of `angee top`.
+const clearScreen = "\x1b[H\x1b[2J"
+
+func topCommand(stdout io.Writer, root, operatorURL *string, jsonOutput *bool) *cobra.Command {
+	var interval time.Duration
+	var once bool
+	cmd := &cobra.Command{
+		Use:   "top",
+		Short: "Show live CPU, memory, restarts, and health of the stack's services",
+		Long: "Refreshes a table of the stack's services, container services first, with\n" +
+			"the resource usage `docker stats` reports for the running ones. When stdout\n" +
+			"is not a terminal, or with --once or --json, it prints a single sample.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if interval <= 0 {
+				return errors.New("--interval must be positive")
+			}
+			platform, err := localPlatform(root, operatorURL)
+			if err != nil {
+				return err
+			}
+			live := !once && !*jsonOutput && isTerminal(stdout)
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				services, err := platform.ServiceList(cmd.Context())
+				if err != nil {
+					return err
+				}
+				if *jsonOutput {
+					return writeJSON(stdout, services)
+				}
+				screen := topScreen(services, time.Now())
+				if live {
+					screen = clearScreen + screen
+				}
+				if _, err := io.WriteString(stdout, screen); err != nil || !live {
+					return err
+				}
+				select {
+				case <-cmd.Context().Done():
+					return nil
+				case <-ticker.C:
+				}
+			}
+		},
+	}
+	cmd.Flags().DurationVar(&interval, "interval", 2*time.Second, "time between refreshes")
+	cmd.Flags().BoolVar(&once, "once", false, "print one sample and exit")
+	return cmd
+}
+
+// topScreen renders a summary line and a table of services grouped by
+// runtime, container services first, each group in name order.
+func topScreen(services []api.ServiceState, now time.Time) string {
+	var running int
+	var cpu float64
+	var memory uint64
+	for _, service := range services {
+		if service.Stats != nil {
+			running++
+			cpu += service.Stats.CPUPercent
+			memory += service.Stats.MemoryBytes
+		}
+	}
+	var out strings.Builder
+	fmt.Fprintf(&out, "%s  %d services, %d sampled  cpu %.1f%%  mem %s\n\n", now.Format(time.TimeOnly), len(services), running, cpu, formatBytes(memory))
+	table := tabwriter.NewWriter(&out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "SERVICE\tRUNTIME\tSTATUS\tCPU\tMEMORY\tRESTARTS\tUP")
+	for _, runtime := range []string{"container", "local"} {
+		for _, service := range services {
+			if service.Runtime == runtime {
+				fmt.Fprintln(table, strings.Join(topRow(service), "\t"))
+			}
+		}
+	}
+	for _, service := range services {
+		if service.Runtime != "container" && service.Runtime != "local" {
+			fmt.Fprintln(table, strings.Join(topRow(service), "\t"))
+		}
+	}
+	table.Flush()
+	return out.String()
+}
+
+func topRow(service api.ServiceState) []string {
+	status := service.Status
+	if len(service.Alerts) > 0 {
+		status += " (" + strings.Join(service.Alerts, ", ") + ")"
+	}
+	row := []string{service.Name, service.Runtime, status, "-", "-", "-", "-"}
+	stats := service.Stats
+	if stats == nil {
+		return row
+	}
+	row[3] = fmt.Sprintf("%.1f%%", stats.CPUPercent)
+	row[4] = formatBytes(stats.MemoryBytes)
+	if stats.MemoryLimitBytes > 0 {
+		row[4] += fmt.Sprintf(" / %s (%.0f%%)", formatBytes(stats.MemoryLimitBytes), 100*float64(stats.MemoryBytes)/float64(stats.MemoryLimitBytes))
+	}
+	row[5] = fmt.Sprint(stats.Restarts)
+	if stats.StartedAt != nil {
+		row[6] = (time.Duration(stats.UptimeSeconds) * time.Second).String()
+	}
+	return row
+}
@@ -0,0 +1,31 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fyltr/angee/api"
+)
+
+func TestTopScreenGroupsContainerServicesFirst(t *testing.T) {
+	started := time.Date(2026, 5, 2, 9, 0, 0, 0, time.UTC)
+	screen := topScreen([]api.ServiceState{
+		{Name: "api", Runtime: "local", Status: "running"},
+		{Name: "db", Runtime: "container", Status: "healthy", Stats: &api.ServiceStats{CPUPercent: 12.5, MemoryBytes: 256 << 20, MemoryLimitBytes: 1 << 30, Restarts: 2, StartedAt: &started, UptimeSeconds: 3600}},
+		{Name: "web", Runtime: "container", Status: "degraded", Alerts: []string{"web-down"}},
+	}, time.Date(2026, 5, 2, 10, 0, 0, 0, time.UTC))
+	lines := strings.Split(strings.TrimRight(screen, "\n"), "\n")
+	if len(lines) != 6 || lines[0] != "10:00:00  3 services, 1 sampled  cpu 12.5%  mem 256.0MiB" {
+		t.Fatalf("topScreen() =\n%s", screen)
+	}
+	if !strings.HasPrefix(lines[3], "db ") || !strings.HasPrefix(lines[4], "web ") || !strings.HasPrefix(lines[5], "api ") {
+		t.Fatalf("topScreen() rows are not grouped by runtime:\n%s", screen)
+	}
+	if fields := strings.Join(strings.Fields(lines[3]), " "); fields != "db container healthy 12.5% 256.0MiB / 1.0GiB (25%) 2 1h0m0s" {
+		t.Fatalf("db row = %q", fields)
+	}
+	if !strings.Contains(lines[4], "degraded (web-down)") {
+		t.Fatalf("web row = %q", lines[4])
+	}
+}
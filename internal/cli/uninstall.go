@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fyltr/angee/internal/runtime"
+	"github.com/fyltr/angee/internal/service"
+	"github.com/spf13/cobra"
+)
+
+func uninstallCommand(stdout io.Writer, root, operatorURL *string, jsonOutput *bool) *cobra.Command {
+	var opts service.UninstallOptions
+	var yes bool
+	cmd := &cobra.Command{
+		Use:   "uninstall",
+		Short: "Stop the stack, remove its containers, networks, and volumes, and delete the root",
+		Long: "Lists what would be removed and asks before removing it: the containers,\n" +
+			"networks, and volumes labeled for the stack, then the stack root with its\n" +
+			"sources, workspaces, histories, and generated files. Volumes hold service\n" +
+			"data, so deleting them needs --volumes or typing the stack name. --archive\n" +
+			"first writes the root's git history to a bundle outside the root.\n" +
+			"\n" +
+			"Examples:\n" +
+			"  angee uninstall --dry-run\n" +
+			"  angee uninstall --archive ~/notes-history.bundle --volumes --yes",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			platform, err := localPlatform(root, operatorURL)
+			if err != nil {
+				return err
+			}
+			local, ok := platform.(*service.Platform)
+			if !ok {
+				return errors.New("uninstall deletes a local directory and is not available with --operator")
+			}
+			dryRun := opts.DryRun
+			opts.DryRun = true
+			plan, err := local.StackUninstall(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			if *jsonOutput && dryRun {
+				return writeJSON(stdout, plan)
+			}
+			if !*jsonOutput {
+				if _, err := io.WriteString(stdout, uninstallText(plan)); err != nil {
+					return err
+				}
+			}
+			if dryRun {
+				return nil
+			}
+			stdin := bufio.NewReader(cmd.InOrStdin())
+			if !yes {
+				if _, err := fmt.Fprintf(cmd.ErrOrStderr(), "Uninstall %s and delete %s? [y/N]: ", plan.Stack, plan.Root); err != nil {
+					return err
+				}
+				line, _ := stdin.ReadString('\n')
+				if answer := strings.ToLower(strings.TrimSpace(line)); answer != "y" && answer != "yes" {
+					_, err = fmt.Fprintln(stdout, "nothing removed")
+					return err
+				}
+			}
+			if plan.Persistent() && !opts.Volumes && !yes {
+				if _, err := fmt.Fprintf(cmd.ErrOrStderr(), "The volumes hold service data that cannot be recovered. Type %q to delete them: ", plan.Stack); err != nil {
+					return err
+				}
+				line, _ := stdin.ReadString('\n')
+				if strings.TrimSpace(line) != plan.Stack {
+					_, err = fmt.Fprintln(stdout, "nothing removed")
+					return err
+				}
+				opts.Volumes = true
+			}
+			opts.DryRun = false
+			result, err := local.StackUninstall(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			if *jsonOutput {
+				return writeJSON(stdout, result)
+			}
+			if result.Archive != "" {
+				if _, err := fmt.Fprintf(stdout, "archived git history to %s\n", result.Archive); err != nil {
+					return err
+				}
+			}
+			_, err = fmt.Fprintf(stdout, "uninstalled %s and removed %s\n", result.Stack, result.Root)
+			return err
+		},
+	}
+	cmd.Flags().BoolVar(&opts.Volumes, "volumes", false, "delete the stack's volumes and their data without asking")
+	cmd.Flags().StringVar(&opts.Archive, "archive", "", "write a git bundle of the root's history to this file first")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "list what would be removed and stop")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "remove without asking; volumes still need --volumes")
+	return cmd
+}
+
+// uninstallText lists what an uninstall removes, volumes marked, with the
+// root last, and notes an angee.yaml that no longer validates.
+func uninstallText(plan service.UninstallResult) string {
+	var out strings.Builder
+	for _, resource := range plan.Resources {
+		mark := ""
+		if resource.Kind == runtime.ResourceVolume || resource.Kind == "directory" {
+			mark = "  (data)"
+		}
+		fmt.Fprintf(&out, "%-9s %-30s %s%s\n", resource.Kind, resource.Name, resource.Reason, mark)
+	}
+	fmt.Fprintf(&out, "%-9s %-30s %s\n", "root", plan.Root, "sources, workspaces, histories, and generated files")
+	if plan.Archive != "" {
+		fmt.Fprintf(&out, "git history is archived to %s first\n", plan.Archive)
+	}
+	if plan.Invalid != "" {
+		fmt.Fprintf(&out, "angee.yaml does not validate, so the stack is removed by its name %q: %s\n", plan.Stack, plan.Invalid)
+	}
+	return out.String()
+}
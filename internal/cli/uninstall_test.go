@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fyltr/angee/api"
+	"github.com/fyltr/angee/internal/service"
+)
+
+func TestUninstallTextMarksDataAndListsRootLast(t *testing.T) {
+	text := uninstallText(service.UninstallResult{
+		Stack: "notes",
+		Root:  "/srv/notes",
+		Resources: []api.PruneResource{
+			{Kind: "container", Name: "notes-web-1", Reason: `container of service "web"`},
+			{Kind: "volume", Name: "notes_data", Reason: `volume "data" and its data`},
+		},
+		Archive: "/backups/notes.bundle",
+	})
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	if len(lines) != 4 || strings.Contains(lines[0], "(data)") || !strings.HasSuffix(lines[1], "(data)") {
+		t.Fatalf("uninstallText() =\n%s", text)
+	}
+	if !strings.HasPrefix(lines[2], "root ") || !strings.Contains(lines[2], "/srv/notes") || lines[3] != "git history is archived to /backups/notes.bundle first" {
+		t.Fatalf("uninstallText() =\n%s", text)
+	}
+}
+
+func TestUninstallIsLocalOnly(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	cmd := NewRoot(&stdout, &stderr)
+	cmd.SetArgs([]string{"--operator", "http://127.0.0.1:1", "uninstall", "--yes"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "not available with --operator") {
+		t.Fatalf("uninstall --operator error = %v", err)
+	}
+}
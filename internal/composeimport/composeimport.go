@@ -0,0 +1,453 @@
+// Package composeimport reverse-maps a docker-compose file into angee.yaml
+// services and volumes.
+//
+// The mapping covers what the compiler emits in the other direction: image,
+// build, command, environment, env_file, ports, volumes, working_dir,
+// depends_on, and healthcheck. Everything else is reported as a warning
+// rather than dropped silently, so the caller can show what still needs a
+// hand translation.
+package composeimport
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fyltr/angee/internal/manifest"
+	"gopkg.in/yaml.v3"
+)
+
+// Result is the imported stack fragment. Warnings name each construct that
+// was not carried over, prefixed with the service it came from.
+type Result struct {
+	Services map[string]manifest.Service
+	Volumes  map[string]manifest.Volume
+	Warnings []string
+}
+
+type file struct {
+	Services map[string]service `yaml:"services"`
+	Volumes  map[string]volume  `yaml:"volumes"`
+	Extra    map[string]any     `yaml:",inline"`
+}
+
+type service struct {
+	Image       string         `yaml:"image"`
+	Build       any            `yaml:"build"`
+	Command     stringOrList   `yaml:"command"`
+	Environment environment    `yaml:"environment"`
+	EnvFile     stringOrList   `yaml:"env_file"`
+	Ports       []port         `yaml:"ports"`
+	Volumes     []mount        `yaml:"volumes"`
+	WorkingDir  string         `yaml:"working_dir"`
+	DependsOn   dependsOn      `yaml:"depends_on"`
+	Healthcheck *healthcheck   `yaml:"healthcheck"`
+	Extra       map[string]any `yaml:",inline"`
+}
+
+type volume struct {
+	Driver string         `yaml:"driver"`
+	Extra  map[string]any `yaml:",inline"`
+}
+
+type healthcheck struct {
+	Test        stringOrList `yaml:"test"`
+	Interval    string       `yaml:"interval"`
+	Timeout     string       `yaml:"timeout"`
+	Retries     int          `yaml:"retries"`
+	StartPeriod string       `yaml:"start_period"`
+	Disable     bool         `yaml:"disable"`
+}
+
+// Import parses a compose file. Every service becomes a container service.
+func Import(data []byte) (*Result, error) {
+	var compose file
+	if err := yaml.Unmarshal(data, &compose); err != nil {
+		return nil, fmt.Errorf("parse compose file: %w", err)
+	}
+	if len(compose.Services) == 0 {
+		return nil, errors.New("compose file declares no services")
+	}
+	result := &Result{Services: map[string]manifest.Service{}}
+	for _, key := range sortedKeys(compose.Extra) {
+		if key != "version" && key != "name" && !strings.HasPrefix(key, "x-") {
+			result.warn("", "top-level %q is not imported", key)
+		}
+	}
+	for _, name := range sortedKeys(compose.Volumes) {
+		vol := compose.Volumes[name]
+		for _, key := range sortedKeys(vol.Extra) {
+			result.warn("", "volume %s: %q is not imported", name, key)
+		}
+		if result.Volumes == nil {
+			result.Volumes = map[string]manifest.Volume{}
+		}
+		result.Volumes[name] = manifest.Volume{Driver: vol.Driver}
+	}
+	for _, name := range sortedKeys(compose.Services) {
+		result.Services[name] = result.service(name, compose.Services[name], compose.Volumes)
+	}
+	return result, nil
+}
+
+func (r *Result) service(name string, in service, volumes map[string]volume) manifest.Service {
+	out := manifest.Service{
+		Runtime: manifest.RuntimeContainer,
+		Image:   in.Image,
+		Build:   in.Build,
+		Workdir: in.WorkingDir,
+	}
+	if len(in.Command.items) > 0 {
+		out.Command = in.Command.words()
+	}
+	if len(in.Environment) > 0 {
+		out.Env = map[string]string{}
+		for _, key := range sortedKeys(in.Environment) {
+			value := in.Environment[key]
+			if value == nil {
+				r.warn(name, "environment %s has no value and is taken from the host by compose; set it explicitly", key)
+				continue
+			}
+			out.Env[key] = *value
+		}
+		if len(out.Env) == 0 {
+			out.Env = nil
+		}
+	}
+	switch len(in.EnvFile.items) {
+	case 0:
+	case 1:
+		out.EnvFile = in.EnvFile.items[0]
+	default:
+		out.EnvFile = in.EnvFile.items[0]
+		r.warn(name, "env_file lists %d files; only %s is imported", len(in.EnvFile.items), out.EnvFile)
+	}
+	for _, p := range in.Ports {
+		out.Ports = append(out.Ports, p.value)
+	}
+	for _, m := range in.Volumes {
+		value, err := m.angee(volumes)
+		if err != nil {
+			r.warn(name, "%v", err)
+			continue
+		}
+		out.Mounts = append(out.Mounts, value)
+	}
+	out.DependsOn = in.DependsOn
+	if in.Healthcheck != nil && !in.Healthcheck.Disable {
+		if probe := in.Healthcheck.probe(); probe != nil {
+			out.Health = &manifest.Health{Readiness: probe}
+		}
+	}
+	for _, key := range sortedKeys(in.Extra) {
+		r.warn(name, "%q is not supported", key)
+	}
+	for _, ref := range interpolations(out) {
+		r.warn(name, "compose interpolation %s is not resolved by angee; use a literal or a ${secret.name} substitution", ref)
+	}
+	return out
+}
+
+func (r *Result) warn(service, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	if service != "" {
+		msg = "service " + service + ": " + msg
+	}
+	r.Warnings = append(r.Warnings, msg)
+}
+
+func (h *healthcheck) probe() *manifest.Probe {
+	var command []string
+	switch {
+	case len(h.Test.items) == 0:
+		return nil
+	case !h.Test.list:
+		command = []string{"sh", "-c", h.Test.items[0]}
+	case h.Test.items[0] == "NONE":
+		return nil
+	case h.Test.items[0] == "CMD":
+		command = h.Test.items[1:]
+	case h.Test.items[0] == "CMD-SHELL" && len(h.Test.items) == 2:
+		command = []string{"sh", "-c", h.Test.items[1]}
+	default:
+		command = h.Test.items
+	}
+	return &manifest.Probe{
+		Command:          command,
+		Interval:         h.Interval,
+		Timeout:          h.Timeout,
+		StartPeriod:      h.StartPeriod,
+		FailureThreshold: h.Retries,
+	}
+}
+
+// stringOrList accepts compose's string or list form. list records which one
+// was used, because the two mean different things for command and test.
+type stringOrList struct {
+	items []string
+	list  bool
+}
+
+func (s *stringOrList) UnmarshalYAML(value *yaml.Node) error {
+	switch value.Kind {
+	case yaml.ScalarNode:
+		s.items = []string{value.Value}
+		return nil
+	case yaml.SequenceNode:
+		s.list = true
+		return value.Decode(&s.items)
+	default:
+		return fmt.Errorf("line %d: expected a string or a list", value.Line)
+	}
+}
+
+// words splits a string command the way compose does, honoring quotes.
+func (s stringOrList) words() []string {
+	if s.list {
+		return s.items
+	}
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	for _, r := range s.items[0] {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			word.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words
+}
+
+// environment accepts the mapping and the KEY=VALUE list forms. A nil value
+// is a variable compose passes through from the host.
+type environment map[string]*string
+
+func (e *environment) UnmarshalYAML(value *yaml.Node) error {
+	out := environment{}
+	switch value.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(value.Content); i += 2 {
+			key, val := value.Content[i].Value, value.Content[i+1]
+			if val.Tag == "!!null" {
+				out[key] = nil
+				continue
+			}
+			v := val.Value
+			out[key] = &v
+		}
+	case yaml.SequenceNode:
+		for _, item := range value.Content {
+			key, val, ok := strings.Cut(item.Value, "=")
+			if !ok {
+				out[key] = nil
+				continue
+			}
+			out[key] = &val
+		}
+	default:
+		return fmt.Errorf("line %d: environment must be a mapping or a list", value.Line)
+	}
+	*e = out
+	return nil
+}
+
+// dependsOn accepts the list form and the mapping form with conditions.
+// angee derives the condition from the dependency's readiness probe, so
+// only the names are kept.
+type dependsOn []string
+
+func (d *dependsOn) UnmarshalYAML(value *yaml.Node) error {
+	switch value.Kind {
+	case yaml.SequenceNode:
+		var names []string
+		if err := value.Decode(&names); err != nil {
+			return err
+		}
+		*d = names
+	case yaml.MappingNode:
+		names := make([]string, 0, len(value.Content)/2)
+		for i := 0; i < len(value.Content); i += 2 {
+			names = append(names, value.Content[i].Value)
+		}
+		sort.Strings(names)
+		*d = names
+	default:
+		return fmt.Errorf("line %d: depends_on must be a list or a mapping", value.Line)
+	}
+	return nil
+}
+
+// port keeps the short syntax as written and renders the long syntax in the
+// same `[ip:]published:target[/protocol]` form.
+type port struct {
+	value string
+}
+
+func (p *port) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		p.value = value.Value
+		return nil
+	}
+	var long struct {
+		Target    int    `yaml:"target"`
+		Published string `yaml:"published"`
+		HostIP    string `yaml:"host_ip"`
+		Protocol  string `yaml:"protocol"`
+	}
+	if err := value.Decode(&long); err != nil {
+		return err
+	}
+	if long.Target == 0 {
+		return fmt.Errorf("line %d: port is missing target", value.Line)
+	}
+	out := strconv.Itoa(long.Target)
+	if long.Published != "" {
+		out = long.Published + ":" + out
+		if long.HostIP != "" {
+			out = long.HostIP + ":" + out
+		}
+	}
+	if long.Protocol != "" && long.Protocol != "tcp" {
+		out += "/" + long.Protocol
+	}
+	p.value = out
+	return nil
+}
+
+// mount is a compose volume entry in either syntax.
+type mount struct {
+	kind     string
+	source   string
+	target   string
+	readOnly bool
+	options  string
+}
+
+func (m *mount) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		parts := strings.Split(value.Value, ":")
+		switch len(parts) {
+		case 1:
+			m.target = parts[0]
+		case 2:
+			m.source, m.target = parts[0], parts[1]
+		case 3:
+			m.source, m.target = parts[0], parts[1]
+			for _, opt := range strings.Split(parts[2], ",") {
+				switch opt {
+				case "ro":
+					m.readOnly = true
+				case "rw":
+				default:
+					m.options = parts[2]
+				}
+			}
+		default:
+			return fmt.Errorf("line %d: volume %q is not source:target[:mode]", value.Line, value.Value)
+		}
+		return nil
+	}
+	var long struct {
+		Type     string `yaml:"type"`
+		Source   string `yaml:"source"`
+		Target   string `yaml:"target"`
+		ReadOnly bool   `yaml:"read_only"`
+	}
+	if err := value.Decode(&long); err != nil {
+		return err
+	}
+	m.kind, m.source, m.target, m.readOnly = long.Type, long.Source, long.Target, long.ReadOnly
+	return nil
+}
+
+// angee renders the mount as a `volume://` or `bind://` mount. Anonymous
+// volumes and tmpfs mounts have no angee equivalent.
+func (m mount) angee(volumes map[string]volume) (string, error) {
+	if m.options != "" {
+		return "", fmt.Errorf("volume %s:%s mode %q is not supported", m.source, m.target, m.options)
+	}
+	kind := m.kind
+	if kind == "" {
+		kind = "volume"
+		if isHostPath(m.source) {
+			kind = "bind"
+		}
+	}
+	if m.source == "" || (kind != "volume" && kind != "bind") {
+		return "", fmt.Errorf("%s mount at %s is not supported; declare a named volume", orDefault(m.kind, "anonymous"), m.target)
+	}
+	if kind == "volume" {
+		if _, ok := volumes[m.source]; !ok {
+			return "", fmt.Errorf("volume %s is not declared under top-level volumes", m.source)
+		}
+	}
+	out := kind + "://" + m.source + ":" + m.target
+	if m.readOnly {
+		out += ":ro"
+	}
+	return out, nil
+}
+
+func isHostPath(source string) bool {
+	return strings.HasPrefix(source, ".") || strings.HasPrefix(source, "/") || strings.HasPrefix(source, "~")
+}
+
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+var interpolationRE = regexp.MustCompile(`\$\{[^}]*\}|\$[A-Za-z_][A-Za-z0-9_]*`)
+
+// interpolations lists compose variable references, which angee's
+// substitution would reject or leave for the shell.
+func interpolations(service manifest.Service) []string {
+	values := append([]string{service.Image, service.Workdir, service.EnvFile}, service.Command...)
+	for _, key := range sortedKeys(service.Env) {
+		values = append(values, service.Env[key])
+	}
+	values = append(values, service.Ports...)
+	values = append(values, service.Mounts...)
+	seen := map[string]bool{}
+	var refs []string
+	for _, value := range values {
+		for _, ref := range interpolationRE.FindAllString(value, -1) {
+			if !seen[ref] {
+				seen[ref] = true
+				refs = append(refs, ref)
+			}
+		}
+	}
+	return refs
+}
+
+func sortedKeys[T any](values map[string]T) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
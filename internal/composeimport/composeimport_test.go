@@ -0,0 +1,139 @@
+package composeimport
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fyltr/angee/internal/manifest"
+)
+
+func TestImportMapsServices(t *testing.T) {
+	result, err := Import([]byte(`
+name: notes
+services:
+  web:
+    build:
+      context: ./web
+    command: gunicorn "app:create()" --bind 0.0.0.0:8000
+    environment:
+      - DEBUG=0
+      - DATABASE_URL=postgres://db/notes
+    env_file: .env
+    ports:
+      - "127.0.0.1:8000:8000"
+      - target: 9000
+        published: 9001
+        protocol: udp
+    volumes:
+      - ./web:/app:ro
+      - media:/app/media
+    working_dir: /app
+    depends_on:
+      db:
+        condition: service_healthy
+  db:
+    image: postgres:16
+    environment:
+      POSTGRES_DB: notes
+    volumes:
+      - type: volume
+        source: pgdata
+        target: /var/lib/postgresql/data
+    healthcheck:
+      test: ["CMD-SHELL", "pg_isready -U postgres"]
+      interval: 5s
+      timeout: 3s
+      retries: 10
+      start_period: 10s
+volumes:
+  pgdata: {}
+  media:
+    driver: local
+`))
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if len(result.Warnings) != 0 {
+		t.Fatalf("Warnings = %q, want none", result.Warnings)
+	}
+	want := map[string]manifest.Service{
+		"web": {
+			Runtime:   manifest.RuntimeContainer,
+			Build:     map[string]any{"context": "./web"},
+			Command:   []string{"gunicorn", "app:create()", "--bind", "0.0.0.0:8000"},
+			Env:       map[string]string{"DEBUG": "0", "DATABASE_URL": "postgres://db/notes"},
+			EnvFile:   ".env",
+			Ports:     manifest.StringList{"127.0.0.1:8000:8000", "9001:9000/udp"},
+			Mounts:    manifest.StringList{"bind://./web:/app:ro", "volume://media:/app/media"},
+			Workdir:   "/app",
+			DependsOn: []string{"db"},
+		},
+		"db": {
+			Runtime: manifest.RuntimeContainer,
+			Image:   "postgres:16",
+			Env:     map[string]string{"POSTGRES_DB": "notes"},
+			Mounts:  manifest.StringList{"volume://pgdata:/var/lib/postgresql/data"},
+			Health: &manifest.Health{Readiness: &manifest.Probe{
+				Command:          []string{"sh", "-c", "pg_isready -U postgres"},
+				Interval:         "5s",
+				Timeout:          "3s",
+				StartPeriod:      "10s",
+				FailureThreshold: 10,
+			}},
+		},
+	}
+	if !reflect.DeepEqual(result.Services, want) {
+		t.Fatalf("Services = %#v\nwant %#v", result.Services, want)
+	}
+	if !reflect.DeepEqual(result.Volumes, map[string]manifest.Volume{"pgdata": {}, "media": {Driver: "local"}}) {
+		t.Fatalf("Volumes = %#v", result.Volumes)
+	}
+}
+
+func TestImportFlagsUnsupportedConstructs(t *testing.T) {
+	result, err := Import([]byte(`
+services:
+  app:
+    image: app:${TAG}
+    restart: unless-stopped
+    networks: [back]
+    environment:
+      API_KEY:
+    volumes:
+      - /tmp/cache
+      - data:/data:z
+networks:
+  back: {}
+volumes:
+  data:
+    external: true
+`))
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	joined := strings.Join(result.Warnings, "\n")
+	for _, want := range []string{
+		`top-level "networks"`,
+		`volume data: "external"`,
+		"service app: environment API_KEY has no value",
+		"service app: anonymous mount at /tmp/cache",
+		`service app: volume data:/data mode "z"`,
+		`service app: "networks" is not supported`,
+		`service app: "restart" is not supported`,
+		"service app: compose interpolation ${TAG}",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("Warnings missing %q:\n%s", want, joined)
+		}
+	}
+	if got := result.Services["app"]; got.Image != "app:${TAG}" || len(got.Mounts) != 0 {
+		t.Fatalf("Services[app] = %#v", got)
+	}
+}
+
+func TestImportRejectsFileWithoutServices(t *testing.T) {
+	if _, err := Import([]byte("volumes:\n  data: {}\n")); err == nil {
+		t.Fatal("Import() error = nil, want no services")
+	}
+}
@@ -7,6 +7,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/fyltr/angee/internal/manifest"
@@ -130,6 +133,8 @@ type Renderer interface {
 type Metadata struct {
 	Kind           string                          `yaml:"kind"`
 	Name           string                          `yaml:"name"`
+	Description    string                          `yaml:"description"`
+	Extends        string                          `yaml:"extends"`
 	InstanceNaming InstanceNaming                  `yaml:"instance_naming"`
 	Inputs         map[string]Input                `yaml:"inputs"`
 	Sources        map[string]TemplateSource       `yaml:"sources"`
@@ -153,6 +158,95 @@ type Input struct {
 	Immutable bool   `yaml:"immutable"`
 	Generated bool   `yaml:"generated"`
 	Length    int    `yaml:"length"`
+	Help      string `yaml:"help"`
+	Choices   any    `yaml:"choices"`
+}
+
+// InputError reports an input value that does not satisfy its question.
+type InputError struct {
+	Name   string
+	Reason string
+}
+
+func (e *InputError) Error() string {
+	return fmt.Sprintf("template input %s %s", e.Name, e.Reason)
+}
+
+// ChoiceValues returns the allowed values of a `choices:` question. Copier
+// accepts either a list of values or a mapping of label to value.
+func (in Input) ChoiceValues() []string {
+	var values []string
+	switch choices := in.Choices.(type) {
+	case []any:
+		for _, choice := range choices {
+			values = append(values, fmt.Sprint(choice))
+		}
+	case map[string]any:
+		for _, choice := range choices {
+			values = append(values, fmt.Sprint(choice))
+		}
+		sort.Strings(values)
+	}
+	return values
+}
+
+// Validate checks one value against the question's type and choices.
+func (in Input) Validate(name, value string) error {
+	switch in.Type {
+	case "int", "integer":
+		if _, err := strconv.Atoi(value); err != nil {
+			return &InputError{Name: name, Reason: "must be an integer"}
+		}
+	case "float":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return &InputError{Name: name, Reason: "must be a number"}
+		}
+	case "bool", "boolean":
+		if _, err := ParseFlag(value); err != nil {
+			return &InputError{Name: name, Reason: "must be a boolean"}
+		}
+	}
+	if choices := in.ChoiceValues(); len(choices) > 0 && !slices.Contains(choices, value) {
+		return &InputError{Name: name, Reason: "must be one of " + strings.Join(choices, ", ")}
+	}
+	return nil
+}
+
+// ParseFlag parses a bool input the way Copier does, so feature flags accept
+// yes/no and on/off as well as true/false.
+func ParseFlag(value string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "true", "yes", "y", "on", "1":
+		return true, nil
+	case "false", "no", "n", "off", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("cannot parse %q as bool", value)
+}
+
+// ValidateInputs checks every supplied value against its question and that
+// every required question has a value, either supplied or defaulted.
+// Defaults are not type-checked; they may be Jinja expressions.
+func ValidateInputs(questions map[string]Input, defaults Inputs, inputs Inputs) error {
+	names := make([]string, 0, len(questions))
+	for name := range questions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		question := questions[name]
+		value, ok := inputs[name]
+		if !ok || value == "" {
+			if question.Required && !question.Generated && defaults[name] == "" {
+				return &InputError{Name: name, Reason: "is required"}
+			}
+			continue
+		}
+		if err := question.Validate(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 type TemplateSource struct {
@@ -221,7 +315,7 @@ func (LocalRenderer) Update(ctx context.Context, req UpdateRequest) error {
 func copierOptions(cfg config, inputs Inputs) []copier.Option {
 	return []copier.Option{
 		copier.WithAnswersFile(cfg.AnswersFile),
-		copier.WithData(inputsAsData(inputs)),
+		copier.WithData(inputsAsData(cfg.Questions, inputs)),
 		copier.WithDefaults(true),
 		copier.WithOverwrite(true),
 		copier.WithQuiet(true),
@@ -229,10 +323,18 @@ func copierOptions(cfg config, inputs Inputs) []copier.Option {
 	}
 }
 
-func inputsAsData(inputs Inputs) map[string]any {
+// inputsAsData passes bool questions to Copier as real bools: the string
+// "false" is truthy in Jinja, so `{% if with_celery %}` would misfire.
+func inputsAsData(questions map[string]Input, inputs Inputs) map[string]any {
 	data := make(map[string]any, len(inputs))
 	for key, value := range inputs {
 		data[key] = value
+		switch questions[key].Type {
+		case "bool", "boolean":
+			if flag, err := ParseFlag(value); err == nil {
+				data[key] = flag
+			}
+		}
 	}
 	return data
 }
@@ -297,11 +399,28 @@ func questionsFromRaw(raw map[string]any) map[string]Input {
 		if err := yaml.Unmarshal(encoded, &input); err != nil {
 			continue
 		}
+		if input.Type == "" {
+			input.Type = inferredType(input.Default)
+		}
 		questions[key] = input
 	}
 	return questions
 }
 
+// inferredType mirrors Copier: a question without `type:` takes its type from
+// the default, so `with_celery: {default: false}` is a bool.
+func inferredType(value any) string {
+	switch value.(type) {
+	case bool:
+		return "bool"
+	case int, int64, uint64:
+		return "int"
+	case float64:
+		return "float"
+	}
+	return ""
+}
+
 func mergeInputs(cfg config, inputs Inputs) Inputs {
 	mergedInputs := Inputs{}
 	for key, value := range cfg.Defaults {
@@ -1,6 +1,7 @@
 package copierx
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
@@ -133,3 +134,138 @@ func TestResolvePathInputsHandlesAngeeInputsBlock(t *testing.T) {
 		t.Fatalf("project_path = %q, want %q", got, "../examples/foo")
 	}
 }
+
+func TestValidateInputsChecksTypesChoicesAndRequired(t *testing.T) {
+	tmp := t.TempDir()
+	tpl := writeTemplate(t, filepath.Join(tmp, "tpl"), strings.Join([]string{
+		"_angee:",
+		"  kind: stack",
+		"  name: dev",
+		"port:",
+		"  type: int",
+		"  default: 8000",
+		"database:",
+		"  type: str",
+		"  choices: [postgres, sqlite]",
+		"  default: postgres",
+		"size:",
+		"  type: str",
+		"  choices:",
+		"    Small: s",
+		"    Large: l",
+		"  default: s",
+		"project:",
+		"  type: str",
+		"  required: true",
+	}, "\n"))
+	questions, defaults, err := TemplateQuestions(tpl)
+	if err != nil {
+		t.Fatalf("TemplateQuestions() = %v", err)
+	}
+	if got := strings.Join(questions["size"].ChoiceValues(), ","); got != "l,s" {
+		t.Fatalf("size choices = %q, want mapping values", got)
+	}
+	if err := ValidateInputs(questions, defaults, Inputs{"project": "notes", "database": "sqlite", "port": "9000"}); err != nil {
+		t.Fatalf("ValidateInputs(valid) = %v", err)
+	}
+	for _, tc := range []struct {
+		inputs Inputs
+		want   string
+	}{
+		{Inputs{"project": "notes", "port": "http"}, "template input port must be an integer"},
+		{Inputs{"project": "notes", "database": "mysql"}, "template input database must be one of postgres, sqlite"},
+		{Inputs{}, "template input project is required"},
+	} {
+		err := ValidateInputs(questions, defaults, tc.inputs)
+		if err == nil || err.Error() != tc.want {
+			t.Fatalf("ValidateInputs(%v) = %v, want %q", tc.inputs, err, tc.want)
+		}
+	}
+}
+
+func TestCopyRendersBoolInputsAsFeatureFlags(t *testing.T) {
+	tmp := t.TempDir()
+	tpl := writeTemplate(t, filepath.Join(tmp, "tpl"), strings.Join([]string{
+		"_subdirectory: template",
+		"_angee:",
+		"  kind: stack",
+		"  name: dev",
+		"with_celery:",
+		"  default: true",
+		"with_frontend:",
+		"  type: bool",
+		"  default: false",
+	}, "\n"))
+	files := map[string]string{
+		"services.txt.jinja": "web\n{% if with_celery %}celery\n{% endif %}{% if with_frontend %}frontend\n{% endif %}",
+		"{% if with_celery %}celery{% endif %}/config.yaml.jinja": "broker: redis\n",
+	}
+	for name, body := range files {
+		path := filepath.Join(tpl, "template", name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("MkdirAll(%s) = %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	questions, _, err := TemplateQuestions(tpl)
+	if err != nil {
+		t.Fatalf("TemplateQuestions() = %v", err)
+	}
+	if got := questions["with_celery"].Type; got != "bool" {
+		t.Fatalf("with_celery type = %q, want bool inferred from default", got)
+	}
+	dest := filepath.Join(tmp, "out")
+	err = (LocalRenderer{}).Copy(context.Background(), CopyRequest{
+		Template: tpl,
+		Dest:     dest,
+		Inputs:   Inputs{"with_celery": "no", "with_frontend": "yes"},
+	})
+	if err != nil {
+		t.Fatalf("Copy() = %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(dest, "services.txt"))
+	if err != nil {
+		t.Fatalf("read services.txt: %v", err)
+	}
+	if string(got) != "web\nfrontend\n" {
+		t.Fatalf("services.txt = %q, want celery off and frontend on", got)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "celery")); !os.IsNotExist(err) {
+		t.Fatalf("celery/ rendered with with_celery=no, err = %v", err)
+	}
+}
+
+func TestCopyRegistersSharedFilters(t *testing.T) {
+	tmp := t.TempDir()
+	tpl := writeTemplate(t, filepath.Join(tmp, "tpl"), strings.Join([]string{
+		"_subdirectory: template",
+		"_angee:",
+		"  kind: stack",
+		"  name: dev",
+		"project:",
+		"  default: My Project",
+		"cert:",
+		"  default: ''",
+	}, "\n"))
+	path := filepath.Join(tpl, "template", "out.txt.jinja")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("MkdirAll(template) = %v", err)
+	}
+	body := "{{ project | slug }} {{ project | b64encode }} {{ project | urlencode }}\ncert: |\n  {{ cert | indent:2 }}\n"
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatalf("write out.txt.jinja: %v", err)
+	}
+	dest := filepath.Join(tmp, "out")
+	if err := (LocalRenderer{}).Copy(context.Background(), CopyRequest{Template: tpl, Dest: dest, Inputs: Inputs{"project": "My Project", "cert": "a\nb"}}); err != nil {
+		t.Fatalf("Copy() = %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(dest, "out.txt"))
+	if err != nil {
+		t.Fatalf("read out.txt: %v", err)
+	}
+	if want := "my-project TXkgUHJvamVjdA== My%20Project\ncert: |\n  a\n  b\n"; string(got) != want {
+		t.Fatalf("out.txt = %q, want %q", got, want)
+	}
+}
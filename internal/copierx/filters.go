@@ -0,0 +1,35 @@
+package copierx
+
+import (
+	"github.com/flosch/pongo2/v6"
+
+	"github.com/fyltr/angee/internal/substitute"
+)
+
+// sharedFilters are the substitute filters pongo2 lacks. Registering them
+// lets a template author write `{{ project | slug }}` in a rendered file and
+// `${inputs.project | slug}` in angee.yaml with the same result.
+var sharedFilters = []string{"slug", "local_part", "trim", "b64encode", "b64decode", "indent", "required"}
+
+func init() {
+	for _, name := range sharedFilters {
+		_ = pongo2.RegisterFilter(name, pongoFilter(name))
+	}
+	// pongo2's urlencode turns a space into +, which a URL's userinfo
+	// reads as a literal plus; the substitute filter escapes it as %20.
+	_ = pongo2.ReplaceFilter("urlencode", pongoFilter("urlencode"))
+}
+
+func pongoFilter(name string) pongo2.FilterFunction {
+	return func(in *pongo2.Value, param *pongo2.Value) (*pongo2.Value, *pongo2.Error) {
+		var args []string
+		if !param.IsNil() {
+			args = append(args, param.String())
+		}
+		out, err := substitute.Filter(name, in.String(), args...)
+		if err != nil {
+			return nil, &pongo2.Error{Sender: "filter:" + name, OrigError: err}
+		}
+		return pongo2.AsValue(out), nil
+	}
+}
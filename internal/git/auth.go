@@ -0,0 +1,68 @@
+package git
+
+import (
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// tokenCredentialHelper answers git's credential requests with the token in
+// ANGEE_GIT_TOKEN, so the token never appears in a URL, argv, or git config.
+const tokenCredentialHelper = `!f() { test "$1" = get && echo username=x-access-token && echo "password=$ANGEE_GIT_TOKEN"; }; f`
+
+// WithSSHKey returns a client whose commands authenticate over SSH with the
+// private key at keyPath only, ignoring the user's agent and identities.
+func (c Client) WithSSHKey(keyPath string) Client {
+	c.Env = append(slices.Clone(c.Env),
+		"GIT_SSH_COMMAND=ssh -i "+shellQuote(keyPath)+" -o IdentitiesOnly=yes -o StrictHostKeyChecking=accept-new",
+	)
+	return c
+}
+
+// WithToken returns a client whose HTTPS commands authenticate with token
+// to the host of repo only. For that host it replaces the user's credential
+// helpers; other hosts, such as those of submodules or redirects, never see
+// the token. The terminal prompt is disabled, so a rejected token fails
+// instead of hanging. A repo that is not an http(s) URL gets no helper.
+// Config entries already passed through GIT_CONFIG_COUNT are kept.
+func (c Client) WithToken(repo, token string) Client {
+	env := append(slices.Clone(c.Env), "GIT_TERMINAL_PROMPT=0")
+	remote, err := url.Parse(repo)
+	if err != nil || (remote.Scheme != "https" && remote.Scheme != "http") || remote.Host == "" {
+		c.Env = env
+		return c
+	}
+	key := "credential." + remote.Scheme + "://" + remote.Host + ".helper"
+	n := c.configCount()
+	c.Env = append(env,
+		"ANGEE_GIT_TOKEN="+token,
+		"GIT_CONFIG_COUNT="+strconv.Itoa(n+2),
+		"GIT_CONFIG_KEY_"+strconv.Itoa(n)+"="+key,
+		"GIT_CONFIG_VALUE_"+strconv.Itoa(n)+"=",
+		"GIT_CONFIG_KEY_"+strconv.Itoa(n+1)+"="+key,
+		"GIT_CONFIG_VALUE_"+strconv.Itoa(n+1)+"="+tokenCredentialHelper,
+	)
+	return c
+}
+
+// configCount returns the GIT_CONFIG_COUNT the client's commands would
+// otherwise run with, from its Env or else the process environment.
+func (c Client) configCount() int {
+	value := os.Getenv("GIT_CONFIG_COUNT")
+	for _, entry := range c.Env {
+		if v, ok := strings.CutPrefix(entry, "GIT_CONFIG_COUNT="); ok {
+			value = v
+		}
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
@@ -11,6 +11,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 	"os/exec"
 	"strconv"
 	"strings"
@@ -21,6 +22,9 @@ import (
 
 type Client struct {
 	Bin string
+	// Env is added to the environment of git CLI commands; WithSSHKey and
+	// WithToken use it to carry per-source credentials.
+	Env []string
 }
 
 func New() Client {
@@ -39,6 +43,9 @@ func (c Client) Run(ctx context.Context, dir string, args ...string) ([]byte, er
 	if dir != "" {
 		cmd.Dir = dir
 	}
+	if len(c.Env) > 0 {
+		cmd.Env = append(os.Environ(), c.Env...)
+	}
 	out, err := cmd.CombinedOutput()
 	if err != nil {
 		return out, fmt.Errorf("git %v: %w: %s", args, err, out)
@@ -74,11 +81,52 @@ func (c Client) Clone(ctx context.Context, repo, dest string, args ...string) er
 }
 
 func (c Client) CloneRef(ctx context.Context, repo, dest, ref string) error {
+	return c.CloneWith(ctx, repo, dest, CloneOptions{Ref: ref})
+}
+
+// CloneOptions trims a clone. Depth alone still fetches every branch tip,
+// unlike git's own default, so worktrees can branch from any of them.
+type CloneOptions struct {
+	Ref          string
+	Depth        int
+	SingleBranch bool
+	Sparse       []string
+}
+
+// CloneWith clones repo into dest. With Sparse set the clone is partial:
+// only the listed directories (and top-level files) are checked out, and
+// blobs outside them are never fetched.
+func (c Client) CloneWith(ctx context.Context, repo, dest string, opts CloneOptions) error {
 	args := []string{}
-	if ref != "" {
-		args = append(args, "--branch", ref)
+	if opts.Ref != "" {
+		args = append(args, "--branch", opts.Ref)
+	}
+	if opts.Depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(opts.Depth))
+	}
+	switch {
+	case opts.SingleBranch:
+		args = append(args, "--single-branch")
+	case opts.Depth > 0:
+		args = append(args, "--no-single-branch")
 	}
-	return c.Clone(ctx, repo, dest, args...)
+	if len(opts.Sparse) > 0 {
+		args = append(args, "--filter=blob:none", "--sparse")
+	}
+	if err := c.Clone(ctx, repo, dest, args...); err != nil {
+		return err
+	}
+	if len(opts.Sparse) > 0 {
+		return c.SparseCheckout(ctx, dest, opts.Sparse)
+	}
+	return nil
+}
+
+// SparseCheckout limits the checkout in dir to paths, in cone mode.
+func (c Client) SparseCheckout(ctx context.Context, dir string, paths []string) error {
+	args := append([]string{"sparse-checkout", "set", "--cone"}, paths...)
+	_, err := c.Run(ctx, dir, args...)
+	return err
 }
 
 func (c Client) Fetch(ctx context.Context, dir string) error {
@@ -86,6 +134,21 @@ func (c Client) Fetch(ctx context.Context, dir string) error {
 	return err
 }
 
+// CheckoutLatest detaches a fetched clone at the newest commit for ref: the
+// remote-tracking branch when ref names a branch, the remote default branch
+// when ref is empty, and ref itself for a tag or commit.
+func (c Client) CheckoutLatest(ctx context.Context, dir, ref string) error {
+	target := "origin/HEAD"
+	if ref != "" {
+		target = ref
+		if c.RefExists(ctx, dir, "origin/"+ref) {
+			target = "origin/" + ref
+		}
+	}
+	_, err := c.Run(ctx, dir, "checkout", "--quiet", "--detach", target)
+	return err
+}
+
 func (c Client) Merge(ctx context.Context, dir, ref string) error {
 	_, err := c.Run(ctx, dir, "merge", "--no-edit", ref)
 	return err
@@ -369,6 +432,27 @@ func (c Client) Dirty(ctx context.Context, dir string) (bool, error) {
 	return !st.IsClean(), nil
 }
 
+// HeadCommit returns the full commit hash HEAD points at.
+func (c Client) HeadCommit(ctx context.Context, dir string) (string, error) {
+	return c.runText(ctx, dir, "rev-parse", "HEAD")
+}
+
+// ResolveCommit returns the full commit hash for a revision such as a branch
+// or `@{upstream}`.
+func (c Client) ResolveCommit(ctx context.Context, dir, rev string) (string, error) {
+	return c.runText(ctx, dir, "rev-parse", "--verify", rev+"^{commit}")
+}
+
+// CommitsTouching counts commits in from..to that change path, relative to
+// dir. It answers "has anything under this directory moved since from".
+func (c Client) CommitsTouching(ctx context.Context, dir, from, to, path string) (int, error) {
+	out, err := c.runText(ctx, dir, "rev-list", "--count", from+".."+to, "--", path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(out)
+}
+
 func (c Client) currentRefCLI(ctx context.Context, dir string) (string, error) {
 	branch, err := c.runText(ctx, dir, "symbolic-ref", "--quiet", "--short", "HEAD")
 	if err == nil && branch != "" {
@@ -248,3 +248,132 @@ func runGit(t *testing.T, dir string, args ...string) {
 		t.Fatalf("git %v error = %v: %s", args, err, out)
 	}
 }
+
+func TestCheckoutLatestMovesCacheToFetchedCommit(t *testing.T) {
+	isolateGitConfig(t)
+	ctx := context.Background()
+	base := t.TempDir()
+	origin := filepath.Join(base, "origin")
+	runGit(t, "", "init", "-b", "main", origin)
+	runGit(t, origin, "config", "user.email", "test@example.com")
+	runGit(t, origin, "config", "user.name", "Test User")
+	mustWriteFile(t, filepath.Join(origin, "README.md"), "v1\n")
+	runGit(t, origin, "add", "README.md")
+	runGit(t, origin, "commit", "-m", "v1")
+	cache := filepath.Join(base, "cache")
+	runGit(t, "", "clone", "--quiet", origin, cache)
+	mustWriteFile(t, filepath.Join(origin, "README.md"), "v2\n")
+	runGit(t, origin, "commit", "-am", "v2")
+
+	client := New()
+	if err := client.Fetch(ctx, cache); err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	for _, ref := range []string{"", "main"} {
+		if err := client.CheckoutLatest(ctx, cache, ref); err != nil {
+			t.Fatalf("CheckoutLatest(%q) error = %v", ref, err)
+		}
+		data, err := os.ReadFile(filepath.Join(cache, "README.md"))
+		if err != nil {
+			t.Fatalf("ReadFile(README.md) error = %v", err)
+		}
+		if string(data) != "v2\n" {
+			t.Fatalf("README.md after CheckoutLatest(%q) = %q, want v2", ref, data)
+		}
+	}
+}
+
+func TestWithTokenAnswersCredentialRequests(t *testing.T) {
+	isolateGitConfig(t)
+	client := New().WithToken("https://git.example.test/acme/notes.git", "s3cret")
+	fill := func(host string) string {
+		t.Helper()
+		cmd := exec.Command("git", "credential", "fill")
+		cmd.Env = append(os.Environ(), client.Env...)
+		cmd.Stdin = strings.NewReader("protocol=https\nhost=" + host + "\n\n")
+		out, _ := cmd.CombinedOutput()
+		return string(out)
+	}
+	if out := fill("git.example.test"); !strings.Contains(out, "username=x-access-token\n") || !strings.Contains(out, "password=s3cret\n") {
+		t.Fatalf("credential fill = %q, want token credentials", out)
+	}
+	if out := fill("evil.example.test"); strings.Contains(out, "s3cret") || strings.Contains(out, "password=") {
+		t.Fatalf("credential fill for another host = %q, want no password", out)
+	}
+	if strings.Contains(strings.Join(client.Env, "\n"), "s3cret@") {
+		t.Fatal("token leaked into a URL")
+	}
+}
+
+func TestWithTokenKeepsExistingConfigEnv(t *testing.T) {
+	isolateGitConfig(t)
+	t.Setenv("GIT_CONFIG_COUNT", "1")
+	t.Setenv("GIT_CONFIG_KEY_0", "angee.test")
+	t.Setenv("GIT_CONFIG_VALUE_0", "kept")
+	client := New().WithToken("https://git.example.test/acme/notes.git", "s3cret")
+	cmd := exec.Command("git", "config", "--get", "angee.test")
+	cmd.Env = append(os.Environ(), client.Env...)
+	out, err := cmd.CombinedOutput()
+	if err != nil || strings.TrimSpace(string(out)) != "kept" {
+		t.Fatalf("git config angee.test = %q, %v, want the inherited entry kept", out, err)
+	}
+	cmd = exec.Command("git", "credential", "fill")
+	cmd.Env = append(os.Environ(), client.Env...)
+	cmd.Stdin = strings.NewReader("protocol=https\nhost=git.example.test\n\n")
+	if out, err := cmd.CombinedOutput(); err != nil || !strings.Contains(string(out), "password=s3cret\n") {
+		t.Fatalf("credential fill = %q, %v, want token credentials", out, err)
+	}
+}
+
+func TestWithSSHKeyQuotesKeyPath(t *testing.T) {
+	client := New().WithSSHKey("/tmp/angee run/key-'1'")
+	want := `GIT_SSH_COMMAND=ssh -i '/tmp/angee run/key-'\''1'\''' -o IdentitiesOnly=yes -o StrictHostKeyChecking=accept-new`
+	if len(client.Env) != 1 || client.Env[0] != want {
+		t.Fatalf("Env = %q, want %q", client.Env, want)
+	}
+}
+
+func TestCloneWithSparseCheckoutLimitsWorkingTree(t *testing.T) {
+	isolateGitConfig(t)
+	ctx := context.Background()
+	base := t.TempDir()
+	origin := filepath.Join(base, "origin")
+	runGit(t, "", "init", "-b", "main", origin)
+	runGit(t, origin, "config", "user.email", "test@example.com")
+	runGit(t, origin, "config", "user.name", "Test User")
+	runGit(t, origin, "config", "uploadpack.allowFilter", "true")
+	for _, dir := range []string{"services/api", "services/web"} {
+		if err := os.MkdirAll(filepath.Join(origin, filepath.FromSlash(dir)), 0o755); err != nil {
+			t.Fatalf("MkdirAll(%s) error = %v", dir, err)
+		}
+	}
+	mustWriteFile(t, filepath.Join(origin, "README.md"), "root\n")
+	mustWriteFile(t, filepath.Join(origin, "services", "api", "main.go"), "package main\n")
+	mustWriteFile(t, filepath.Join(origin, "services", "web", "index.html"), "<html>\n")
+	runGit(t, origin, "add", ".")
+	runGit(t, origin, "commit", "-m", "v1")
+	mustWriteFile(t, filepath.Join(origin, "README.md"), "root v2\n")
+	runGit(t, origin, "commit", "-am", "v2")
+
+	dest := filepath.Join(base, "clone")
+	opts := CloneOptions{Ref: "main", Depth: 1, SingleBranch: true, Sparse: []string{"services/api"}}
+	client := New()
+	if err := client.CloneWith(ctx, "file://"+origin, dest, opts); err != nil {
+		t.Fatalf("CloneWith() error = %v", err)
+	}
+	for _, path := range []string{"README.md", "services/api/main.go"} {
+		if _, err := os.Stat(filepath.Join(dest, filepath.FromSlash(path))); err != nil {
+			t.Fatalf("Stat(%s) error = %v, want checked out", path, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dest, "services", "web")); !os.IsNotExist(err) {
+		t.Fatalf("Stat(services/web) error = %v, want outside sparse checkout", err)
+	}
+	count, err := client.Run(ctx, dest, "rev-list", "--count", "HEAD")
+	if err != nil {
+		t.Fatalf("rev-list error = %v", err)
+	}
+	if strings.TrimSpace(string(count)) != "1" {
+		t.Fatalf("rev-list --count HEAD = %s, want shallow history of 1", count)
+	}
+}
@@ -0,0 +1,336 @@
+package manifest
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// marshalPreserving encodes stack as an edit of the YAML already at path:
+// values that did not change keep their original nodes, so comments, key
+// order, quoting, anchors, and aliases survive; changed values are replaced
+// in place and new keys are appended. An alias whose anchor changed while
+// it should not is written out in full. Without a readable file at path it
+// is a plain yaml.Marshal.
+func marshalPreserving(path string, stack *Stack) ([]byte, error) {
+	var next yaml.Node
+	if err := next.Encode(stack); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return yaml.Marshal(stack)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil || doc.Kind != yaml.DocumentNode || len(doc.Content) != 1 {
+		return yaml.Marshal(stack)
+	}
+	var m merger
+	doc.Content[0] = m.mergeNode(doc.Content[0], &next)
+	m.checkAliases(&doc)
+	if !sameValue(doc.Content[0], &next) {
+		return yaml.Marshal(stack)
+	}
+	untagMergeKeys(&doc)
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(detectIndent(data))
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// merger edits a YAML tree toward a new value. An alias, or a `<<` merge
+// key, reads its anchor as written after the edit, so reusing one is only
+// safe once the anchors' new values are known; merger records each reused
+// node that holds one, with the value it must decode to, for checkAliases.
+type merger struct {
+	sites []aliasSite
+}
+
+type aliasSite struct {
+	node, old, want *yaml.Node
+}
+
+// checkAliases points the aliases in doc at the anchors as edited, then
+// expands every recorded site that no longer decodes to its wanted value
+// into explicit nodes, until they all do.
+func (m *merger) checkAliases(doc *yaml.Node) {
+	for changed := true; changed; {
+		changed = false
+		relinkAliases(doc, map[string]*yaml.Node{})
+		for _, site := range m.sites {
+			if sameValue(site.node, site.want) {
+				continue
+			}
+			*site.node = *m.mergeNode(expandAliases(site.old), site.want)
+			changed = true
+		}
+	}
+}
+
+// mergeNode returns the node to write for a value that was old and should
+// now be next. old is kept when it still means the same, recursion handles
+// mappings and sequences, and a replaced scalar keeps old's comments.
+func (m *merger) mergeNode(old, next *yaml.Node) *yaml.Node {
+	if sameValue(old, next) {
+		if hasAlias(old) {
+			m.sites = append(m.sites, aliasSite{node: old, old: old, want: next})
+		}
+		return old
+	}
+	if old.Kind == yaml.AliasNode {
+		return next
+	}
+	switch {
+	case old.Kind == yaml.MappingNode && next.Kind == yaml.MappingNode:
+		return m.mergeMapping(old, next)
+	case old.Kind == yaml.SequenceNode && next.Kind == yaml.SequenceNode:
+		return m.mergeSequence(old, next)
+	case old.Kind == yaml.ScalarNode && next.Kind == yaml.ScalarNode:
+		if old.Value == next.Value {
+			return old
+		}
+	}
+	next.HeadComment, next.LineComment, next.FootComment = old.HeadComment, old.LineComment, old.FootComment
+	return next
+}
+
+// mergeMapping keeps old's keys in their order, drops the ones next no
+// longer has, and appends next's new keys. A `<<` merge key is kept when
+// every key it supplies is still wanted; keys whose value equals the
+// inherited one are then left to it.
+func (m *merger) mergeMapping(old, next *yaml.Node) *yaml.Node {
+	want := map[string]*yaml.Node{}
+	var order []*yaml.Node
+	for i := 0; i+1 < len(next.Content); i += 2 {
+		want[next.Content[i].Value] = next.Content[i+1]
+		order = append(order, next.Content[i])
+	}
+	inherited, keepMerge := inheritedValues(old, want)
+
+	merged := *old
+	merged.Content = nil
+	seen := map[string]bool{}
+	for i := 0; i+1 < len(old.Content); i += 2 {
+		key, value := old.Content[i], old.Content[i+1]
+		if isMergeKey(key) {
+			if keepMerge {
+				merged.Content = append(merged.Content, key, value)
+			}
+			continue
+		}
+		nextValue, ok := want[key.Value]
+		if !ok {
+			continue
+		}
+		seen[key.Value] = true
+		merged.Content = append(merged.Content, key, m.mergeNode(value, nextValue))
+	}
+	for _, key := range order {
+		if seen[key.Value] {
+			continue
+		}
+		if base, ok := inherited[key.Value]; ok && keepMerge && sameValue(base, want[key.Value]) {
+			continue
+		}
+		merged.Content = append(merged.Content, key, want[key.Value])
+	}
+	if keepMerge {
+		m.sites = append(m.sites, aliasSite{node: &merged, old: old, want: next})
+	}
+	return &merged
+}
+
+// inheritedValues returns the values old's `<<` merge key supplies, and
+// whether the merge key can stay: not when it supplies a key next drops.
+func inheritedValues(old *yaml.Node, want map[string]*yaml.Node) (map[string]*yaml.Node, bool) {
+	inherited := map[string]*yaml.Node{}
+	explicit := map[string]bool{}
+	hasMerge := false
+	for i := 0; i+1 < len(old.Content); i += 2 {
+		key, value := old.Content[i], old.Content[i+1]
+		if !isMergeKey(key) {
+			explicit[key.Value] = true
+			continue
+		}
+		hasMerge = true
+		sources := []*yaml.Node{value}
+		if resolve(value).Kind == yaml.SequenceNode {
+			sources = resolve(value).Content
+		}
+		for _, source := range sources {
+			source = resolve(source)
+			if source.Kind != yaml.MappingNode {
+				return nil, false
+			}
+			for j := 0; j+1 < len(source.Content); j += 2 {
+				if _, ok := inherited[source.Content[j].Value]; !ok {
+					inherited[source.Content[j].Value] = source.Content[j+1]
+				}
+			}
+		}
+	}
+	if !hasMerge {
+		return nil, false
+	}
+	for key, value := range inherited {
+		if explicit[key] {
+			continue
+		}
+		nextValue, ok := want[key]
+		if !ok || !sameValue(value, nextValue) {
+			return nil, false
+		}
+	}
+	return inherited, true
+}
+
+// mergeSequence reuses old items: scalars by value, so a removed list
+// entry takes only its own comment with it, and other items by position.
+func (m *merger) mergeSequence(old, next *yaml.Node) *yaml.Node {
+	merged := *old
+	merged.Content = make([]*yaml.Node, 0, len(next.Content))
+	used := make([]bool, len(old.Content))
+	for i, item := range next.Content {
+		match := -1
+		if item.Kind == yaml.ScalarNode {
+			for j, candidate := range old.Content {
+				if !used[j] && resolve(candidate).Kind == yaml.ScalarNode && resolve(candidate).Value == item.Value {
+					match = j
+					break
+				}
+			}
+		} else if i < len(old.Content) && !used[i] && resolve(old.Content[i]).Kind != yaml.ScalarNode {
+			match = i
+		}
+		if match < 0 {
+			merged.Content = append(merged.Content, item)
+			continue
+		}
+		used[match] = true
+		merged.Content = append(merged.Content, m.mergeNode(old.Content[match], item))
+	}
+	return &merged
+}
+
+// sameValue reports whether old, with aliases and merge keys resolved,
+// decodes to the same value as next.
+func sameValue(old, next *yaml.Node) bool {
+	var a, b any
+	if err := old.Decode(&a); err != nil {
+		return false
+	}
+	if err := next.Decode(&b); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// relinkAliases points each alias in node at the node its anchor names at
+// that point in document order, as a parser reading the output would.
+func relinkAliases(node *yaml.Node, anchors map[string]*yaml.Node) {
+	if node.Kind == yaml.AliasNode {
+		if anchor, ok := anchors[node.Value]; ok {
+			node.Alias = anchor
+		}
+		return
+	}
+	if node.Anchor != "" {
+		anchors[node.Anchor] = node
+	}
+	for _, child := range node.Content {
+		relinkAliases(child, anchors)
+	}
+}
+
+// expandAliases returns a copy of node with each alias replaced by a copy
+// of what it refers to, without the anchor, and `<<` merge keys dropped;
+// mergeMapping adds the keys they supplied back explicitly.
+func expandAliases(node *yaml.Node) *yaml.Node {
+	out := *node
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		out = *expandAliases(resolve(node))
+		out.Anchor = ""
+		out.HeadComment, out.LineComment, out.FootComment = node.HeadComment, node.LineComment, node.FootComment
+		return &out
+	}
+	out.Content = nil
+	for i := 0; i < len(node.Content); i++ {
+		if node.Kind == yaml.MappingNode && i%2 == 0 && isMergeKey(node.Content[i]) {
+			i++
+			continue
+		}
+		out.Content = append(out.Content, expandAliases(node.Content[i]))
+	}
+	return &out
+}
+
+func hasAlias(node *yaml.Node) bool {
+	if node.Kind == yaml.AliasNode {
+		return true
+	}
+	for _, child := range node.Content {
+		if hasAlias(child) {
+			return true
+		}
+	}
+	return false
+}
+
+// untagMergeKeys clears the !!merge tag the parser gives `<<` keys, which
+// the encoder would otherwise write out as `!!merge <<`.
+func untagMergeKeys(node *yaml.Node) {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i < len(node.Content); i += 2 {
+			if isMergeKey(node.Content[i]) {
+				node.Content[i].Tag = ""
+			}
+		}
+	}
+	for _, child := range node.Content {
+		untagMergeKeys(child)
+	}
+}
+
+func isMergeKey(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Value == "<<" && node.Style == 0 && (node.Tag == "" || node.Tag == "!!merge")
+}
+
+func resolve(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+// detectIndent returns the indentation step of data: the smallest leading
+// run of spaces on a content line, or yaml.Marshal's 4 when there is none.
+func detectIndent(data []byte) int {
+	indent := 0
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		n := len(line) - len(trimmed)
+		if n > 0 && (indent == 0 || n < indent) {
+			indent = n
+		}
+	}
+	if indent < 2 {
+		return 4
+	}
+	return indent
+}
@@ -0,0 +1,124 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSaveFilePreservesCommentsAndAnchors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "angee.yaml")
+	original := `# Notes stack, maintained by hand.
+name: notes
+services:
+  base: &base
+    runtime: container
+    image: busybox:1
+    env:
+      LOG_LEVEL: info # keep quiet in prod
+  # The API talks to postgres.
+  api:
+    <<: *base
+    image: "ghcr.io/example/api:1"
+  postgres:
+    runtime: container
+    image: postgres:16 # pinned
+`
+	if err := os.WriteFile(path, []byte(original), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stack, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	postgres := stack.Services["postgres"]
+	postgres.Image = "postgres:17"
+	stack.Services["postgres"] = postgres
+	stack.Services["worker"] = Service{Runtime: RuntimeContainer, Image: "busybox:1", Command: []string{"sleep", "60"}}
+	if err := SaveFile(path, stack); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	for _, want := range []string{
+		"# Notes stack, maintained by hand.",
+		"# The API talks to postgres.",
+		"LOG_LEVEL: info # keep quiet in prod",
+		"base: &base",
+		"\n    <<: *base\n",
+		`image: "ghcr.io/example/api:1"`,
+		"image: postgres:17 # pinned",
+		"worker:",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("SaveFile() output lacks %q:\n%s", want, got)
+		}
+	}
+	if !strings.Contains(got, "\n  api:\n") || strings.Index(got, "  api:") > strings.Index(got, "  postgres:") {
+		t.Errorf("SaveFile() changed indentation or order:\n%s", got)
+	}
+	reloaded, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile(saved) error = %v", err)
+	}
+	if reloaded.Services["api"].Image != "ghcr.io/example/api:1" || reloaded.Services["api"].Env["LOG_LEVEL"] != "info" || reloaded.Services["postgres"].Image != "postgres:17" {
+		t.Fatalf("reloaded services = %+v", reloaded.Services)
+	}
+}
+
+func TestSaveFileKeepsAliasSitesWhenAnchorChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "angee.yaml")
+	original := `name: notes
+services:
+  base: &base
+    runtime: container
+    image: busybox:1
+    env: &env
+      LOG_LEVEL: info
+  api:
+    <<: *base
+  worker:
+    runtime: container
+    image: busybox:1
+    env: *env
+`
+	if err := os.WriteFile(path, []byte(original), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stack, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	base := stack.Services["base"]
+	base.Image = "busybox:2"
+	base.Env = map[string]string{"LOG_LEVEL": "debug"}
+	stack.Services["base"] = base
+	if err := SaveFile(path, stack); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile(saved) error = %v\n%s", err, data)
+	}
+	if got := reloaded.Services["base"]; got.Image != "busybox:2" || got.Env["LOG_LEVEL"] != "debug" {
+		t.Fatalf("base = %+v, want the edit\n%s", got, data)
+	}
+	if got := reloaded.Services["api"]; got.Image != "busybox:1" || got.Env["LOG_LEVEL"] != "info" {
+		t.Fatalf("api = %+v, want the values it had before the edit\n%s", got, data)
+	}
+	if got := reloaded.Services["worker"]; got.Env["LOG_LEVEL"] != "info" {
+		t.Fatalf("worker = %+v, want the env it had before the edit\n%s", got, data)
+	}
+	if !strings.Contains(string(data), "base: &base") {
+		t.Errorf("SaveFile() dropped the anchor:\n%s", data)
+	}
+}
@@ -0,0 +1,62 @@
+package manifest
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Lock is angee.lock, the resolved form of references in angee.yaml that
+// can move upstream. It is committed next to angee.yaml so every deploy of
+// a revision runs the same images.
+type Lock struct {
+	// Images maps each `image:` reference, as written in angee.yaml, to
+	// the digest it resolved to, such as sha256:9f86d0….
+	Images map[string]string `yaml:"images,omitempty"`
+}
+
+func LockPath(root string) string {
+	return filepath.Join(root, "angee.lock")
+}
+
+// LoadLock reads the lockfile at path. A missing file is an empty lock.
+func LoadLock(path string) (*Lock, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Lock{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var lock Lock
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&lock); err != nil && !errors.Is(err, io.EOF) {
+		return nil, &InvalidError{Path: path, Err: err}
+	}
+	return &lock, nil
+}
+
+func SaveLock(path string, lock *Lock) error {
+	data, err := yaml.Marshal(lock)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append([]byte("# Generated by `angee lock images`; commit it with angee.yaml.\n"), data...), 0o644)
+}
+
+// PinnedImage returns image pinned to digest, keeping the tag for readers:
+// postgres:16@sha256:…. An image that already names a digest is returned
+// as is.
+func PinnedImage(image, digest string) string {
+	if digest == "" || strings.Contains(image, "@") {
+		return image
+	}
+	return image + "@" + digest
+}
@@ -3,12 +3,19 @@ package manifest
 import (
 	"errors"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"sort"
 	"strings"
 	"time"
 
+	"github.com/fyltr/angee/internal/mount"
+	"github.com/fyltr/angee/internal/schedule"
 	"github.com/go-playground/validator/v10"
 	"gopkg.in/yaml.v3"
 )
@@ -25,12 +32,18 @@ const (
 	RuntimeLocal     Runtime = "local"
 )
 
+// RunOnInit in a job's run_on runs the job once, right after `angee stack
+// init` renders the root.
+const RunOnInit = "init"
+
 type Stack struct {
 	Version        int                    `yaml:"version" json:"version" validate:"oneof=1" jsonschema:"required,enum=1"`
 	Kind           string                 `yaml:"kind" json:"kind" validate:"required,oneof=stack" jsonschema:"required,enum=stack"`
 	Name           string                 `yaml:"name" json:"name"`
 	Template       *Template              `yaml:"template,omitempty" json:"template,omitempty"`
 	Operator       Operator               `yaml:"operator,omitempty" json:"operator,omitempty"`
+	Policy         *Policy                `yaml:"policy,omitempty" json:"policy,omitempty"`
+	Logging        *Logging               `yaml:"logging,omitempty" json:"logging,omitempty"`
 	SecretsBackend SecretsBackend         `yaml:"secrets_backend,omitempty" json:"secrets_backend,omitempty"`
 	Secrets        map[string]Secret      `yaml:"secrets,omitempty" json:"secrets,omitempty"`
 	Ports          map[string]Port        `yaml:"ports,omitempty" json:"ports,omitempty"`
@@ -42,17 +55,141 @@ type Stack struct {
 	PortLeases     map[string][]PortLease `yaml:"port_leases,omitempty" json:"port_leases,omitempty"`
 }
 
+// Template records the stack template a root was initialized from, so
+// `angee template status` can compare it with upstream.
 type Template struct {
-	Active      string `yaml:"active,omitempty" json:"active,omitempty"`
-	AnswersFile string `yaml:"answers_file,omitempty" json:"answers_file,omitempty"`
+	Active      string   `yaml:"active,omitempty" json:"active,omitempty"`
+	AnswersFile string   `yaml:"answers_file,omitempty" json:"answers_file,omitempty"`
+	Source      string   `yaml:"source,omitempty" json:"source,omitempty"`
+	Commit      string   `yaml:"commit,omitempty" json:"commit,omitempty"`
+	With        []string `yaml:"with,omitempty" json:"with,omitempty"`
+}
+
+// Policy constrains manifest changes made through the platform API (service
+// and workspace commands, REST, GraphQL). Hand edits to angee.yaml are not
+// checked.
+type Policy struct {
+	ProtectedServices []string `yaml:"protected_services,omitempty" json:"protected_services,omitempty"`
+	DenyPublicPorts   bool     `yaml:"deny_public_ports,omitempty" json:"deny_public_ports,omitempty"`
+	Command           []string `yaml:"command,omitempty" json:"command,omitempty"`
+	// RequirePlan refuses deploys that do not carry a plan token from
+	// `angee plan approve`, so each one is a change someone reviewed.
+	RequirePlan bool `yaml:"require_plan,omitempty" json:"require_plan,omitempty"`
+}
+
+// Logging sets the Docker logging driver for every container service, so an
+// addon such as observability can route all stack logs to one place.
+type Logging struct {
+	Driver  string            `yaml:"driver" json:"driver" validate:"required" jsonschema:"required"`
+	Options map[string]string `yaml:"options,omitempty" json:"options,omitempty"`
 }
 
 type Operator struct {
-	URL           string              `yaml:"url,omitempty" json:"url,omitempty"`
-	Domain        string              `yaml:"domain,omitempty" json:"domain,omitempty"`
-	TokenSecret   string              `yaml:"token_secret,omitempty" json:"token_secret,omitempty"`
-	PortPool      map[string]PortPool `yaml:"port_pool,omitempty" json:"port_pool,omitempty"`
-	TemplatePaths []string            `yaml:"template_paths,omitempty" json:"template_paths,omitempty"`
+	URL           string                  `yaml:"url,omitempty" json:"url,omitempty"`
+	Domain        string                  `yaml:"domain,omitempty" json:"domain,omitempty"`
+	TokenSecret   string                  `yaml:"token_secret,omitempty" json:"token_secret,omitempty"`
+	PortPool      map[string]PortPool     `yaml:"port_pool,omitempty" json:"port_pool,omitempty"`
+	TemplatePaths []string                `yaml:"template_paths,omitempty" json:"template_paths,omitempty"`
+	Log           OperatorLog             `yaml:"log,omitempty" json:"log,omitempty"`
+	Notifications map[string]Notification `yaml:"notifications,omitempty" json:"notifications,omitempty"`
+	Alerts        map[string]AlertRule    `yaml:"alerts,omitempty" json:"alerts,omitempty"`
+	Hooks         Hooks                   `yaml:"hooks,omitempty" json:"hooks,omitempty"`
+	OIDC          *OIDC                   `yaml:"oidc,omitempty" json:"oidc,omitempty"`
+	Scan          *ImageScan              `yaml:"scan,omitempty" json:"scan,omitempty"`
+	Resources     *ResourcePolicy         `yaml:"resources,omitempty" json:"resources,omitempty"`
+	// AllowedOrigins lists the browser origins, besides the operator's
+	// own, that may call the API with a bearer token, such as
+	// https://*.example.dev or http://localhost:{3000,5173}.
+	AllowedOrigins []string `yaml:"allowed_origins,omitempty" json:"allowed_origins,omitempty"`
+	// ForwardImage is the socat image `angee port-forward` runs inside a
+	// service's network namespace, alpine/socat by default. Like service
+	// images it is pinned through angee.lock.
+	ForwardImage string `yaml:"forward_image,omitempty" json:"forward_image,omitempty"`
+}
+
+// ImageScan scans the images of the services a deploy starts for known
+// vulnerabilities before starting them, with Trivy. Findings at or above
+// Severity (default high) block the deploy, or with WarnOnly are only
+// recorded in the deploy ledger. IgnoreUnfixed leaves out vulnerabilities
+// with no fixed version yet.
+type ImageScan struct {
+	Severity      string `yaml:"severity,omitempty" json:"severity,omitempty" validate:"omitempty,oneof=low medium high critical" jsonschema:"enum=low,enum=medium,enum=high,enum=critical"`
+	WarnOnly      bool   `yaml:"warn_only,omitempty" json:"warn_only,omitempty"`
+	IgnoreUnfixed bool   `yaml:"ignore_unfixed,omitempty" json:"ignore_unfixed,omitempty"`
+}
+
+// OIDC lets people sign in to the operator with an OpenID provider: the
+// dashboard through the browser, the CLI through `angee login`. ClientID
+// is a public client, so no client secret is configured. Only members of
+// one of AllowedGroups, as listed in the token's GroupsClaim (default
+// groups), are let in; a provider signs tokens for anyone with an account,
+// so the list is required. The operator's bearer token keeps working
+// alongside.
+type OIDC struct {
+	Issuer        string   `yaml:"issuer" json:"issuer" jsonschema:"required"`
+	ClientID      string   `yaml:"client_id" json:"client_id" jsonschema:"required"`
+	Scopes        []string `yaml:"scopes,omitempty" json:"scopes,omitempty"`
+	GroupsClaim   string   `yaml:"groups_claim,omitempty" json:"groups_claim,omitempty"`
+	AllowedGroups []string `yaml:"allowed_groups" json:"allowed_groups" jsonschema:"required"`
+}
+
+// Hooks configures the webhooks the operator receives from forges.
+type Hooks struct {
+	GitHub *GitHubHook `yaml:"github,omitempty" json:"github,omitempty"`
+}
+
+// GitHubHook enables POST /hooks/github. Secret names the declared secret
+// set as the webhook secret on GitHub. A push to Branch (default main) of a
+// repository that is a git source pulls the source and redeploys; with
+// Build, services built from the source are rebuilt first.
+type GitHubHook struct {
+	Secret string `yaml:"secret" json:"secret" jsonschema:"required"`
+	Branch string `yaml:"branch,omitempty" json:"branch,omitempty"`
+	Build  bool   `yaml:"build,omitempty" json:"build,omitempty"`
+}
+
+// AlertRule watches one container service from the operator. It fires when
+// the service restarts more than Restarts times within Within (default one
+// hour), or has not been running for DownFor.
+type AlertRule struct {
+	Service  string `yaml:"service" json:"service" jsonschema:"required"`
+	Restarts int    `yaml:"restarts,omitempty" json:"restarts,omitempty"`
+	Within   string `yaml:"within,omitempty" json:"within,omitempty"`
+	DownFor  string `yaml:"down_for,omitempty" json:"down_for,omitempty"`
+}
+
+// Notification events.
+const (
+	EventDeploySucceeded = "deploy.succeeded"
+	EventDeployFailed    = "deploy.failed"
+	EventJobFailed       = "job.failed"
+	EventAlertFiring     = "alert.firing"
+	EventAlertResolved   = "alert.resolved"
+)
+
+// Notification formats: generic posts the full event as JSON; slack and
+// discord post a message in the shape their incoming webhooks expect.
+const (
+	NotificationGeneric = "generic"
+	NotificationSlack   = "slack"
+	NotificationDiscord = "discord"
+)
+
+// Notification posts events to a webhook. The URL is given inline or, since
+// webhook URLs usually embed a credential, as a declared secret. No events
+// means all of them.
+type Notification struct {
+	URL       string   `yaml:"url,omitempty" json:"url,omitempty"`
+	URLSecret string   `yaml:"url_secret,omitempty" json:"url_secret,omitempty"`
+	Format    string   `yaml:"format,omitempty" json:"format,omitempty" jsonschema:"enum=generic,enum=slack,enum=discord"`
+	Events    []string `yaml:"events,omitempty" json:"events,omitempty"`
+}
+
+// OperatorLog sets the operator's log format and starting level; the
+// operator's --log-format and --log-level flags take precedence.
+type OperatorLog struct {
+	Format string `yaml:"format,omitempty" json:"format,omitempty" validate:"omitempty,oneof=text json" jsonschema:"enum=text,enum=json"`
+	Level  string `yaml:"level,omitempty" json:"level,omitempty" validate:"omitempty,oneof=debug info warn error" jsonschema:"enum=debug,enum=info,enum=warn,enum=error"`
 }
 
 type PortPool struct {
@@ -101,10 +238,25 @@ type Source struct {
 	Auth       SourceAuth `yaml:"auth,omitempty" json:"auth,omitempty"`
 	Git        SourceGit  `yaml:"git,omitempty" json:"git,omitempty"`
 	Checksum   string     `yaml:"checksum,omitempty" json:"checksum,omitempty"`
+
+	// Depth, SingleBranch, and Sparse trim the clone of a large git source.
+	// Sparse lists the directories to check out; blobs outside them are not
+	// downloaded.
+	Depth        int      `yaml:"depth,omitempty" json:"depth,omitempty" validate:"min=0"`
+	SingleBranch bool     `yaml:"single_branch,omitempty" json:"single_branch,omitempty"`
+	Sparse       []string `yaml:"sparse,omitempty" json:"sparse,omitempty"`
 }
 
+// Source auth modes. Host uses the user's own git credentials and is the
+// default; ssh and https-token authenticate with a declared secret.
+const (
+	SourceAuthHost       = "host"
+	SourceAuthSSH        = "ssh"
+	SourceAuthHTTPSToken = "https-token"
+)
+
 type SourceAuth struct {
-	Mode         string `yaml:"mode,omitempty" json:"mode,omitempty"`
+	Mode         string `yaml:"mode,omitempty" json:"mode,omitempty" validate:"omitempty,oneof=host ssh https-token" jsonschema:"enum=host,enum=ssh,enum=https-token"`
 	SSHKeySecret string `yaml:"ssh_key_secret,omitempty" json:"ssh_key_secret,omitempty"`
 	TokenSecret  string `yaml:"token_secret,omitempty" json:"token_secret,omitempty"`
 }
@@ -145,9 +297,12 @@ type PersistPath struct {
 }
 
 type Service struct {
-	Runtime   Runtime           `yaml:"runtime" json:"runtime" validate:"required,oneof=container local" jsonschema:"required,enum=container,enum=local"`
-	Image     string            `yaml:"image,omitempty" json:"image,omitempty"`
-	Build     any               `yaml:"build,omitempty" json:"build,omitempty"`
+	Runtime Runtime `yaml:"runtime" json:"runtime" validate:"required,oneof=container local" jsonschema:"required,enum=container,enum=local"`
+	Image   string  `yaml:"image,omitempty" json:"image,omitempty"`
+	Build   any     `yaml:"build,omitempty" json:"build,omitempty"`
+	// Platform is the os/arch the container runs as, such as linux/amd64;
+	// a built image is built for it.
+	Platform  string            `yaml:"platform,omitempty" json:"platform,omitempty"`
 	Command   []string          `yaml:"command,omitempty" json:"command,omitempty"`
 	Env       map[string]string `yaml:"env,omitempty" json:"env,omitempty"`
 	EnvFile   string            `yaml:"env_file,omitempty" json:"env_file,omitempty"`
@@ -156,6 +311,71 @@ type Service struct {
 	Workdir   string            `yaml:"workdir,omitempty" json:"workdir,omitempty"`
 	After     []string          `yaml:"after,omitempty" json:"after,omitempty"`
 	DependsOn []string          `yaml:"depends_on,omitempty" json:"depends_on,omitempty"`
+	Health    *Health           `yaml:"health,omitempty" json:"health,omitempty"`
+	Backup    *Backup           `yaml:"backup,omitempty" json:"backup,omitempty"`
+	Develop   *Develop          `yaml:"develop,omitempty" json:"develop,omitempty"`
+	Resources *Resources        `yaml:"resources,omitempty" json:"resources,omitempty"`
+}
+
+// Watch actions, as defined by compose watch.
+const (
+	WatchSync        = "sync"
+	WatchRebuild     = "rebuild"
+	WatchSyncRestart = "sync+restart"
+)
+
+// Develop configures `angee up --watch` for a container service.
+type Develop struct {
+	Watch []WatchRule `yaml:"watch,omitempty" json:"watch,omitempty"`
+}
+
+// WatchRule reacts to changes under Path, a host path relative to the
+// stack root. Sync actions copy changed files to Target in the container;
+// rebuild rebuilds the image and recreates the container.
+type WatchRule struct {
+	Path   string   `yaml:"path" json:"path" jsonschema:"required"`
+	Action string   `yaml:"action" json:"action" jsonschema:"required,enum=sync,enum=rebuild,enum=sync+restart"`
+	Target string   `yaml:"target,omitempty" json:"target,omitempty"`
+	Ignore []string `yaml:"ignore,omitempty" json:"ignore,omitempty"`
+}
+
+// Backup kinds name the database a service runs. Each dumps with the
+// client tools shipped in the official image.
+const (
+	BackupPostgres = "postgres"
+	BackupMySQL    = "mysql"
+)
+
+// Backup dumps a container service's database into
+// backups/<service>/ on Schedule, keeping the newest Retention dumps. A
+// zero Retention keeps every dump; without a Schedule dumps are only taken
+// by `angee backup run`.
+type Backup struct {
+	Kind      string `yaml:"kind" json:"kind" jsonschema:"required,enum=postgres,enum=mysql"`
+	Schedule  string `yaml:"schedule,omitempty" json:"schedule,omitempty"`
+	Retention int    `yaml:"retention,omitempty" json:"retention,omitempty" jsonschema:"minimum=0"`
+}
+
+// Health declares a service's probes. Readiness decides when dependents may
+// start; liveness restarts a local service whose probe keeps failing.
+// Container services support readiness only, as a Docker healthcheck.
+type Health struct {
+	Readiness *Probe `yaml:"readiness,omitempty" json:"readiness,omitempty"`
+	Liveness  *Probe `yaml:"liveness,omitempty" json:"liveness,omitempty"`
+}
+
+// Probe runs Command, or for local services requests HTTP, every Interval.
+// It fails after FailureThreshold consecutive failures and, for local
+// services, passes after SuccessThreshold consecutive successes. Failures
+// during StartPeriod do not count.
+type Probe struct {
+	Command          []string `yaml:"command,omitempty" json:"command,omitempty"`
+	HTTP             string   `yaml:"http,omitempty" json:"http,omitempty"`
+	Interval         string   `yaml:"interval,omitempty" json:"interval,omitempty"`
+	Timeout          string   `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	StartPeriod      string   `yaml:"start_period,omitempty" json:"start_period,omitempty"`
+	FailureThreshold int      `yaml:"failure_threshold,omitempty" json:"failure_threshold,omitempty"`
+	SuccessThreshold int      `yaml:"success_threshold,omitempty" json:"success_threshold,omitempty"`
 }
 
 type Job struct {
@@ -169,6 +389,7 @@ type Job struct {
 	Workdir   string            `yaml:"workdir,omitempty" json:"workdir,omitempty"`
 	DependsOn []string          `yaml:"depends_on,omitempty" json:"depends_on,omitempty"`
 	RunOn     []string          `yaml:"run_on,omitempty" json:"run_on,omitempty"`
+	Schedule  string            `yaml:"schedule,omitempty" json:"schedule,omitempty"`
 }
 
 type StringList []string
@@ -233,6 +454,17 @@ func stringifyMapping(node *yaml.Node) string {
 	return strings.Join(parts, ",")
 }
 
+// InvalidError reports a manifest that could not be parsed or failed
+// validation, as opposed to one that could not be read.
+type InvalidError struct {
+	Path string
+	Err  error
+}
+
+func (e *InvalidError) Error() string { return e.Err.Error() }
+
+func (e *InvalidError) Unwrap() error { return e.Err }
+
 func LoadFile(path string) (*Stack, error) {
 	f, err := os.Open(path)
 	if err != nil {
@@ -244,11 +476,11 @@ func LoadFile(path string) (*Stack, error) {
 	dec := yaml.NewDecoder(f)
 	dec.KnownFields(true)
 	if err := dec.Decode(&stack); err != nil {
-		return nil, err
+		return nil, &InvalidError{Path: path, Err: err}
 	}
 	stack.Defaults()
 	if err := stack.Validate(); err != nil {
-		return nil, err
+		return nil, &InvalidError{Path: path, Err: err}
 	}
 	return &stack, nil
 }
@@ -261,7 +493,7 @@ func SaveFile(path string, stack *Stack) error {
 	if err := stack.Validate(); err != nil {
 		return err
 	}
-	data, err := yaml.Marshal(stack)
+	data, err := marshalPreserving(path, stack)
 	if err != nil {
 		return err
 	}
@@ -272,12 +504,14 @@ func Path(root string) string {
 	return filepath.Join(root, "angee.yaml")
 }
 
+// ResolvePath resolves p against root. Absolute paths, Windows drive paths
+// included, are kept and spelled for the host angee runs on.
 func ResolvePath(root, p string) string {
 	if p == "" {
 		return ""
 	}
-	if filepath.IsAbs(p) {
-		return filepath.Clean(p)
+	if mount.CurrentHost.IsAbs(p) {
+		return mount.CurrentHost.NativePath(p)
 	}
 	return filepath.Clean(filepath.Join(root, p))
 }
@@ -326,11 +560,441 @@ func (s *Stack) ValidateExtended() error {
 		if err := validateRunnable("service", name, service.Runtime, service.Image, service.Build, service.Command); err != nil {
 			return err
 		}
+		if err := validateHealth(name, service); err != nil {
+			return err
+		}
+		if err := validateSparseMounts("service", name, service.Mounts, s.Sources); err != nil {
+			return err
+		}
+		if err := validateBackup(name, service); err != nil {
+			return err
+		}
+		if err := validateBuild(name, service.Build, s.Secrets); err != nil {
+			return err
+		}
+		if err := validatePlatform(name, service); err != nil {
+			return err
+		}
+		if err := validateDevelop(name, service); err != nil {
+			return err
+		}
+	}
+	for name, source := range s.Sources {
+		if err := validateSourceAuth(name, source.Auth, s.Secrets); err != nil {
+			return err
+		}
+		if err := validateSourceClone(name, source); err != nil {
+			return err
+		}
+	}
+	for name, notification := range s.Operator.Notifications {
+		if err := validateNotification(name, notification, s.Secrets); err != nil {
+			return err
+		}
+	}
+	for name, rule := range s.Operator.Alerts {
+		if err := validateAlertRule(name, rule, s.Services); err != nil {
+			return err
+		}
+	}
+	if err := validateOIDC(s.Operator.OIDC); err != nil {
+		return err
+	}
+	if err := validateOrigins(s.Operator.AllowedOrigins); err != nil {
+		return err
+	}
+	if err := validateResources(s); err != nil {
+		return err
+	}
+	if hook := s.Operator.Hooks.GitHub; hook != nil {
+		if hook.Secret == "" {
+			return errors.New("operator hooks.github requires secret")
+		}
+		if _, ok := s.Secrets[hook.Secret]; !ok {
+			return fmt.Errorf("operator hooks.github secret %q is not a declared secret", hook.Secret)
+		}
 	}
 	for name, job := range s.Jobs {
 		if err := validateRunnable("job", name, job.Runtime, job.Image, job.Build, job.Command); err != nil {
 			return err
 		}
+		if err := validateSparseMounts("job", name, job.Mounts, s.Sources); err != nil {
+			return err
+		}
+		if job.Schedule != "" {
+			if _, err := schedule.Parse(job.Schedule); err != nil {
+				return fmt.Errorf("job %q: %w", name, err)
+			}
+		}
+	}
+	return s.validateDependencies()
+}
+
+// validateDependencies checks the depends_on and after lists of services
+// and jobs: each names a declared service or job, and none leads back to
+// where it started. Compose and process-compose would otherwise fail on
+// them at `angee up`. Services and jobs share one namespace, since both
+// lists name either, so a job named like a service is rejected too. Every
+// problem is reported, not just the first.
+func (s *Stack) validateDependencies() error {
+	edges := map[string][]string{}
+	var errs []error
+	for _, name := range sortedKeys(s.Jobs) {
+		if _, ok := s.Services[name]; ok {
+			errs = append(errs, fmt.Errorf("job %q has the name of a service; depends_on, after, and the process-compose file cannot tell them apart", name))
+		}
+	}
+	check := func(kind, name, field string, targets []string) {
+		for _, target := range targets {
+			_, service := s.Services[target]
+			_, job := s.Jobs[target]
+			if !service && !job {
+				errs = append(errs, fmt.Errorf("%s %q %s %q, which is not a declared service or job", kind, name, field, target))
+				continue
+			}
+			edges[name] = append(edges[name], target)
+		}
+	}
+	for _, name := range sortedKeys(s.Services) {
+		service := s.Services[name]
+		check("service", name, "depends_on", service.DependsOn)
+		check("service", name, "after", service.After)
+	}
+	for _, name := range sortedKeys(s.Jobs) {
+		check("job", name, "depends_on", s.Jobs[name].DependsOn)
+	}
+
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := map[string]int{}
+	var path []string
+	var visit func(name string)
+	visit = func(name string) {
+		state[name] = visiting
+		path = append(path, name)
+		for _, next := range edges[name] {
+			switch state[next] {
+			case visiting:
+				cycle := append(slices.Clone(path[slices.Index(path, next):]), next)
+				errs = append(errs, fmt.Errorf("dependency cycle: %s", strings.Join(cycle, " -> ")))
+			case 0:
+				visit(next)
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = done
+	}
+	for _, name := range sortedKeys(edges) {
+		if state[name] == 0 {
+			visit(name)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func sortedKeys[T any](values map[string]T) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var platformPattern = regexp.MustCompile(`^[a-z0-9]+/[a-z0-9_]+(/[a-z0-9]+)?$`)
+
+// validatePlatform checks a service's platform and the platforms its build
+// lists. Compose builds an image for every listed platform and runs the
+// service's, so the two must agree.
+func validatePlatform(name string, service Service) error {
+	if service.Platform != "" && service.Runtime != RuntimeContainer {
+		return fmt.Errorf("service %q platform applies only to container services", name)
+	}
+	if service.Platform != "" && !platformPattern.MatchString(service.Platform) {
+		return fmt.Errorf("service %q platform %q must be os/arch or os/arch/variant, such as linux/amd64", name, service.Platform)
+	}
+	spec, ok := service.Build.(map[string]any)
+	if !ok {
+		return nil
+	}
+	raw, ok := spec["platforms"]
+	if !ok {
+		return nil
+	}
+	list, ok := raw.([]any)
+	if !ok {
+		return fmt.Errorf("service %q build platforms must be a list of os/arch platforms, such as [linux/amd64, linux/arm64]", name)
+	}
+	var platforms []string
+	for _, item := range list {
+		platform, ok := item.(string)
+		if !ok || !platformPattern.MatchString(platform) {
+			return fmt.Errorf("service %q build platforms must be a list of os/arch platforms, such as [linux/amd64, linux/arm64]", name)
+		}
+		platforms = append(platforms, platform)
+	}
+	if service.Platform != "" && !slices.Contains(platforms, service.Platform) {
+		return fmt.Errorf("service %q platform %s is not one of its build platforms %v", name, service.Platform, platforms)
+	}
+	if len(platforms) > 1 && service.Image == "" {
+		return fmt.Errorf("service %q builds for several platforms, so it needs an image to push them to", name)
+	}
+	return nil
+}
+
+// validateBuild checks the angee-specific part of a service build: secrets
+// go to BuildKit through `secrets`, never through `args`, whose values are
+// recorded in the image history.
+func validateBuild(name string, build any, secrets map[string]Secret) error {
+	spec, ok := build.(map[string]any)
+	if !ok {
+		return nil
+	}
+	if raw, ok := spec["secrets"]; ok {
+		list, ok := raw.([]any)
+		if !ok {
+			return fmt.Errorf("service %q build secrets must be a list of secret names", name)
+		}
+		for _, item := range list {
+			secret, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("service %q build secrets must be a list of secret names", name)
+			}
+			if _, ok := secrets[secret]; !ok {
+				return fmt.Errorf("service %q build secret %q is not a declared secret", name, secret)
+			}
+		}
+	}
+	if strings.Contains(fmt.Sprint(spec["args"]), "${secret.") {
+		return fmt.Errorf("service %q build args would store a secret in the image; list it under build secrets instead", name)
+	}
+	return nil
+}
+
+func validateDevelop(name string, service Service) error {
+	if service.Develop == nil {
+		return nil
+	}
+	if service.Runtime != RuntimeContainer {
+		return fmt.Errorf("service %q: develop requires runtime container", name)
+	}
+	for i, rule := range service.Develop.Watch {
+		if rule.Path == "" {
+			return fmt.Errorf("service %q develop watch[%d] requires path", name, i)
+		}
+		switch rule.Action {
+		case WatchSync, WatchSyncRestart:
+			if rule.Target == "" {
+				return fmt.Errorf("service %q develop watch[%d] action %s requires target", name, i, rule.Action)
+			}
+		case WatchRebuild:
+			if service.Build == nil {
+				return fmt.Errorf("service %q develop watch[%d] action rebuild requires build", name, i)
+			}
+		default:
+			return fmt.Errorf("service %q develop watch[%d] has unsupported action %q", name, i, rule.Action)
+		}
+	}
+	return nil
+}
+
+func validateBackup(name string, service Service) error {
+	if service.Backup == nil {
+		return nil
+	}
+	if service.Runtime != RuntimeContainer {
+		return fmt.Errorf("service %q: backup requires runtime container", name)
+	}
+	switch service.Backup.Kind {
+	case BackupPostgres, BackupMySQL:
+	default:
+		return fmt.Errorf("service %q has unsupported backup kind %q", name, service.Backup.Kind)
+	}
+	if service.Backup.Retention < 0 {
+		return fmt.Errorf("service %q backup retention must not be negative", name)
+	}
+	if service.Backup.Schedule != "" {
+		if _, err := schedule.Parse(service.Backup.Schedule); err != nil {
+			return fmt.Errorf("service %q backup: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func validateSourceAuth(name string, auth SourceAuth, secrets map[string]Secret) error {
+	var field, secret string
+	switch auth.Mode {
+	case SourceAuthSSH:
+		field, secret = "ssh_key_secret", auth.SSHKeySecret
+	case SourceAuthHTTPSToken:
+		field, secret = "token_secret", auth.TokenSecret
+	case "", SourceAuthHost:
+		return nil
+	default:
+		return fmt.Errorf("source %q has unsupported auth mode %q", name, auth.Mode)
+	}
+	if secret == "" {
+		return fmt.Errorf("source %q auth mode %s requires %s", name, auth.Mode, field)
+	}
+	if _, ok := secrets[secret]; !ok {
+		return fmt.Errorf("source %q auth %s %q is not a declared secret", name, field, secret)
+	}
+	return nil
+}
+
+func validateSourceClone(name string, source Source) error {
+	if source.Kind != "git" && (source.Depth > 0 || source.SingleBranch || len(source.Sparse) > 0) {
+		return fmt.Errorf("source %q: depth, single_branch, and sparse apply to git sources", name)
+	}
+	for _, dir := range source.Sparse {
+		clean := path.Clean(dir)
+		if dir == "" || path.IsAbs(dir) || clean == "." || clean == ".." || strings.HasPrefix(clean, "../") || strings.HasPrefix(dir, "-") {
+			return fmt.Errorf("source %q sparse path %q must be a relative directory inside the repository", name, dir)
+		}
+	}
+	return nil
+}
+
+// validateSparseMounts rejects source:// mounts of nested paths that a
+// sparse checkout leaves out, which would otherwise mount an empty
+// directory. Top-level entries pass: cone mode checks out root files.
+func validateSparseMounts(kind, name string, mounts StringList, sources map[string]Source) error {
+	for _, raw := range mounts {
+		m, err := mount.Parse(raw)
+		if err != nil || m.Scheme != "source" {
+			continue
+		}
+		source, ok := sources[m.Name]
+		if !ok || len(source.Sparse) == 0 {
+			continue
+		}
+		sub := path.Clean(m.Subpath)
+		if !strings.Contains(sub, "/") || sparseCovers(source.Sparse, sub) {
+			continue
+		}
+		return fmt.Errorf("%s %q mounts %q outside the sparse checkout of source %q", kind, name, m.Subpath, m.Name)
+	}
+	return nil
+}
+
+func sparseCovers(dirs []string, sub string) bool {
+	for _, dir := range dirs {
+		dir = path.Clean(dir)
+		if sub == dir || strings.HasPrefix(sub, dir+"/") || strings.HasPrefix(dir, sub+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func validateOIDC(config *OIDC) error {
+	if config == nil {
+		return nil
+	}
+	if config.ClientID == "" {
+		return errors.New("operator oidc requires client_id")
+	}
+	issuer, err := url.Parse(config.Issuer)
+	if err != nil || issuer.Host == "" {
+		return fmt.Errorf("operator oidc issuer %q is not a URL", config.Issuer)
+	}
+	if issuer.Scheme != "https" && (issuer.Scheme != "http" || !isLoopbackHost(issuer.Hostname())) {
+		return fmt.Errorf("operator oidc issuer %q must use https", config.Issuer)
+	}
+	if len(config.AllowedGroups) == 0 {
+		return errors.New("operator oidc requires allowed_groups")
+	}
+	return nil
+}
+
+func isLoopbackHost(host string) bool {
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.IsLoopback()
+	}
+	return host == "localhost"
+}
+
+func validateNotification(name string, notification Notification, secrets map[string]Secret) error {
+	switch {
+	case notification.URL == "" && notification.URLSecret == "":
+		return fmt.Errorf("notification %q requires url or url_secret", name)
+	case notification.URL != "" && notification.URLSecret != "":
+		return fmt.Errorf("notification %q sets both url and url_secret", name)
+	}
+	if notification.URLSecret != "" {
+		if _, ok := secrets[notification.URLSecret]; !ok {
+			return fmt.Errorf("notification %q url_secret %q is not a declared secret", name, notification.URLSecret)
+		}
+	}
+	switch notification.Format {
+	case "", NotificationGeneric, NotificationSlack, NotificationDiscord:
+	default:
+		return fmt.Errorf("notification %q has unsupported format %q", name, notification.Format)
+	}
+	for _, event := range notification.Events {
+		switch event {
+		case EventDeploySucceeded, EventDeployFailed, EventJobFailed, EventAlertFiring, EventAlertResolved:
+		default:
+			return fmt.Errorf("notification %q has unsupported event %q", name, event)
+		}
+	}
+	return nil
+}
+
+func validateHealth(name string, service Service) error {
+	if service.Health == nil {
+		return nil
+	}
+	if service.Runtime == RuntimeContainer && service.Health.Liveness != nil {
+		return fmt.Errorf("service %q: liveness probes are only supported for local services; Docker does not restart unhealthy containers", name)
+	}
+	for kind, probe := range map[string]*Probe{"readiness": service.Health.Readiness, "liveness": service.Health.Liveness} {
+		if probe == nil {
+			continue
+		}
+		switch {
+		case len(probe.Command) == 0 && probe.HTTP == "":
+			return fmt.Errorf("service %q %s probe requires command or http", name, kind)
+		case len(probe.Command) > 0 && probe.HTTP != "":
+			return fmt.Errorf("service %q %s probe sets both command and http", name, kind)
+		case probe.HTTP != "" && service.Runtime == RuntimeContainer:
+			return fmt.Errorf("service %q %s probe: container services probe with a command run inside the container", name, kind)
+		}
+		for field, value := range map[string]string{"interval": probe.Interval, "timeout": probe.Timeout, "start_period": probe.StartPeriod} {
+			if value == "" {
+				continue
+			}
+			if d, err := time.ParseDuration(value); err != nil || d < 0 {
+				return fmt.Errorf("service %q %s probe has invalid %s %q", name, kind, field, value)
+			}
+		}
+		if probe.FailureThreshold < 0 || probe.SuccessThreshold < 0 {
+			return fmt.Errorf("service %q %s probe thresholds must not be negative", name, kind)
+		}
+	}
+	return nil
+}
+
+func validateAlertRule(name string, rule AlertRule, services map[string]Service) error {
+	service, ok := services[rule.Service]
+	if !ok {
+		return fmt.Errorf("alert %q watches undeclared service %q", name, rule.Service)
+	}
+	if service.Runtime != RuntimeContainer {
+		return fmt.Errorf("alert %q watches service %q, which is not a container service", name, rule.Service)
+	}
+	if rule.Restarts <= 0 && rule.DownFor == "" {
+		return fmt.Errorf("alert %q requires restarts or down_for", name)
+	}
+	for field, value := range map[string]string{"within": rule.Within, "down_for": rule.DownFor} {
+		if value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+			return fmt.Errorf("alert %q has invalid %s %q", name, field, value)
+		}
 	}
 	return nil
 }
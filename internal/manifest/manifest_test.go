@@ -2,9 +2,12 @@ package manifest
 
 import (
 	"bytes"
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/fyltr/angee/internal/mount"
 	"gopkg.in/yaml.v3"
 )
 
@@ -96,3 +99,523 @@ func TestValidateDoesNotMutate(t *testing.T) {
 		t.Fatalf("Validate() mutated stack\nbefore:\n%s\nafter:\n%s", before, after)
 	}
 }
+
+func TestValidateSourceAuthRequiresDeclaredSecret(t *testing.T) {
+	stack := &Stack{
+		Version: VersionCurrent,
+		Kind:    KindStack,
+		Name:    "auth",
+		Sources: map[string]Source{
+			"app": {Kind: "git", Repo: "git@github.com:example/app.git", Auth: SourceAuth{Mode: SourceAuthSSH}},
+		},
+	}
+	if err := stack.Validate(); err == nil || !strings.Contains(err.Error(), "requires ssh_key_secret") {
+		t.Fatalf("Validate() error = %v, want missing ssh_key_secret", err)
+	}
+	stack.Sources["app"] = Source{Kind: "git", Repo: "git@github.com:example/app.git", Auth: SourceAuth{Mode: SourceAuthSSH, SSHKeySecret: "deploy-key"}}
+	if err := stack.Validate(); err == nil || !strings.Contains(err.Error(), "not a declared secret") {
+		t.Fatalf("Validate() error = %v, want undeclared secret", err)
+	}
+	stack.Secrets = map[string]Secret{"deploy-key": {Required: true}}
+	if err := stack.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	stack.Sources["app"] = Source{Kind: "git", Repo: "https://example.test/app.git", Auth: SourceAuth{Mode: "oauth"}}
+	if err := stack.Validate(); err == nil {
+		t.Fatal("Validate() error = nil, want unsupported auth mode")
+	}
+}
+
+func TestValidateSourceCloneOptions(t *testing.T) {
+	stack := &Stack{
+		Version: VersionCurrent,
+		Kind:    KindStack,
+		Name:    "sparse",
+		Sources: map[string]Source{
+			"mono": {Kind: "git", Repo: "https://example.test/mono.git", Depth: 1, Sparse: []string{"services/api"}},
+		},
+		Services: map[string]Service{
+			"api": {Runtime: RuntimeContainer, Image: "api:latest", Mounts: StringList{"source://mono/services/api:/app", "source://mono/README.md:/README.md:ro"}},
+		},
+	}
+	if err := stack.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	stack.Services["api"] = Service{Runtime: RuntimeContainer, Image: "api:latest", Mounts: StringList{"source://mono/services/web:/app"}}
+	if err := stack.Validate(); err == nil || !strings.Contains(err.Error(), "outside the sparse checkout") {
+		t.Fatalf("Validate() error = %v, want mount outside sparse checkout", err)
+	}
+	delete(stack.Services, "api")
+	stack.Sources["mono"] = Source{Kind: "git", Repo: "https://example.test/mono.git", Sparse: []string{"../etc"}}
+	if err := stack.Validate(); err == nil || !strings.Contains(err.Error(), "relative directory") {
+		t.Fatalf("Validate() error = %v, want relative sparse path", err)
+	}
+	stack.Sources["mono"] = Source{Kind: "local", Path: "..", Depth: 1}
+	if err := stack.Validate(); err == nil || !strings.Contains(err.Error(), "apply to git sources") {
+		t.Fatalf("Validate() error = %v, want git-only clone options", err)
+	}
+}
+
+func TestValidateNotifications(t *testing.T) {
+	stack := &Stack{
+		Version: VersionCurrent,
+		Kind:    KindStack,
+		Name:    "notify",
+		Operator: Operator{Notifications: map[string]Notification{
+			"ops": {Format: NotificationSlack},
+		}},
+	}
+	if err := stack.Validate(); err == nil || !strings.Contains(err.Error(), "requires url or url_secret") {
+		t.Fatalf("Validate() error = %v, want missing url", err)
+	}
+	stack.Operator.Notifications["ops"] = Notification{URLSecret: "slack-webhook"}
+	if err := stack.Validate(); err == nil || !strings.Contains(err.Error(), "not a declared secret") {
+		t.Fatalf("Validate() error = %v, want undeclared secret", err)
+	}
+	stack.Secrets = map[string]Secret{"slack-webhook": {Required: true}}
+	if err := stack.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	stack.Operator.Notifications["ops"] = Notification{URL: "https://hooks.example.test/x", Events: []string{"deploy.started"}}
+	if err := stack.Validate(); err == nil || !strings.Contains(err.Error(), "unsupported event") {
+		t.Fatalf("Validate() error = %v, want unsupported event", err)
+	}
+}
+
+func TestValidateHealthProbes(t *testing.T) {
+	stack := &Stack{
+		Version: VersionCurrent,
+		Kind:    KindStack,
+		Name:    "health",
+		Services: map[string]Service{
+			"web": {Runtime: RuntimeContainer, Image: "nginx:1", Health: &Health{Readiness: &Probe{Command: []string{"true"}, Interval: "5s"}}},
+			"api": {Runtime: RuntimeLocal, Command: []string{"serve"}, Health: &Health{Liveness: &Probe{HTTP: "http://127.0.0.1:8000/health"}}},
+		},
+	}
+	if err := stack.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	for _, health := range []*Health{
+		{Liveness: &Probe{Command: []string{"true"}}},
+		{Readiness: &Probe{HTTP: "http://127.0.0.1/"}},
+		{Readiness: &Probe{}},
+		{Readiness: &Probe{Command: []string{"true"}, Timeout: "soon"}},
+	} {
+		stack.Services["web"] = Service{Runtime: RuntimeContainer, Image: "nginx:1", Health: health}
+		if err := stack.Validate(); err == nil {
+			t.Fatalf("Validate(%+v) error = nil", health)
+		}
+	}
+}
+
+func TestValidateAlertRules(t *testing.T) {
+	stack := &Stack{
+		Version: VersionCurrent,
+		Kind:    KindStack,
+		Name:    "alerts",
+		Services: map[string]Service{
+			"web": {Runtime: RuntimeContainer, Image: "nginx:1"},
+			"api": {Runtime: RuntimeLocal, Command: []string{"serve"}},
+		},
+		Operator: Operator{Alerts: map[string]AlertRule{"crash": {Service: "web", Restarts: 3, Within: "1h"}}},
+	}
+	if err := stack.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	for _, rule := range []AlertRule{
+		{Service: "missing", Restarts: 1},
+		{Service: "api", Restarts: 1},
+		{Service: "web"},
+		{Service: "web", DownFor: "five minutes"},
+	} {
+		stack.Operator.Alerts["crash"] = rule
+		if err := stack.Validate(); err == nil {
+			t.Fatalf("Validate(%+v) error = nil", rule)
+		}
+	}
+}
+
+func TestValidateDependencies(t *testing.T) {
+	stack := &Stack{
+		Version: VersionCurrent,
+		Kind:    KindStack,
+		Name:    "deps",
+		Services: map[string]Service{
+			"db":  {Runtime: RuntimeContainer, Image: "postgres:16"},
+			"web": {Runtime: RuntimeContainer, Image: "nginx:1", DependsOn: []string{"db"}, After: []string{"migrate"}},
+		},
+		Jobs: map[string]Job{
+			"migrate": {Runtime: RuntimeLocal, Command: []string{"migrate"}, DependsOn: []string{"db"}},
+		},
+	}
+	if err := stack.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	stack.Services["db"] = Service{Runtime: RuntimeContainer, Image: "postgres:16", DependsOn: []string{"web"}}
+	stack.Services["api"] = Service{Runtime: RuntimeContainer, Image: "api:1", After: []string{"cache"}}
+	stack.Jobs["seed"] = Job{Runtime: RuntimeLocal, Command: []string{"seed"}, DependsOn: []string{"seed", "queue"}}
+	stack.Jobs["web"] = Job{Runtime: RuntimeLocal, Command: []string{"true"}}
+	err := stack.Validate()
+	if err == nil {
+		t.Fatal("Validate() error = nil, want dependency errors")
+	}
+	for _, want := range []string{
+		`service "api" after "cache", which is not a declared service or job`,
+		`job "seed" depends_on "queue", which is not a declared service or job`,
+		"dependency cycle: db -> web -> db",
+		"dependency cycle: seed -> seed",
+		`job "web" has the name of a service`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("Validate() error = %q, want %q", err, want)
+		}
+	}
+}
+
+func TestManifestRejectsInvalidJobSchedule(t *testing.T) {
+	stack := &Stack{
+		Version: VersionCurrent,
+		Kind:    KindStack,
+		Name:    "bad",
+		Jobs: map[string]Job{
+			"backup": {Runtime: RuntimeLocal, Command: []string{"true"}, Schedule: "every day"},
+		},
+	}
+	if err := stack.Validate(); err == nil {
+		t.Fatal("Validate() error = nil, want error")
+	}
+}
+
+func TestValidateBackups(t *testing.T) {
+	stack := &Stack{
+		Version: VersionCurrent,
+		Kind:    KindStack,
+		Name:    "backups",
+		Services: map[string]Service{
+			"db": {Runtime: RuntimeContainer, Image: "postgres:16", Backup: &Backup{Kind: BackupPostgres, Schedule: "0 2 * * *", Retention: 7}},
+		},
+	}
+	if err := stack.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	for _, service := range []Service{
+		{Runtime: RuntimeContainer, Image: "postgres:16", Backup: &Backup{Kind: "redis"}},
+		{Runtime: RuntimeContainer, Image: "postgres:16", Backup: &Backup{Kind: BackupPostgres, Schedule: "nightly"}},
+		{Runtime: RuntimeContainer, Image: "postgres:16", Backup: &Backup{Kind: BackupPostgres, Retention: -1}},
+		{Runtime: RuntimeLocal, Command: []string{"postgres"}, Backup: &Backup{Kind: BackupPostgres}},
+	} {
+		stack.Services["db"] = service
+		if err := stack.Validate(); err == nil {
+			t.Fatalf("Validate(%+v) error = nil", service.Backup)
+		}
+	}
+}
+
+func TestValidateBuildSecrets(t *testing.T) {
+	stack := &Stack{
+		Version: VersionCurrent,
+		Kind:    KindStack,
+		Name:    "builds",
+		Secrets: map[string]Secret{"npm-token": {Required: true}},
+		Services: map[string]Service{
+			"web": {Runtime: RuntimeContainer, Build: map[string]any{
+				"context": ".",
+				"args":    map[string]any{"NODE_ENV": "production"},
+				"secrets": []any{"npm-token"},
+			}},
+		},
+	}
+	if err := stack.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	for _, build := range []map[string]any{
+		{"context": ".", "secrets": []any{"github-token"}},
+		{"context": ".", "secrets": "npm-token"},
+		{"context": ".", "args": map[string]any{"NPM_TOKEN": "${secret.npm-token}"}},
+	} {
+		stack.Services["web"] = Service{Runtime: RuntimeContainer, Build: build}
+		if err := stack.Validate(); err == nil {
+			t.Fatalf("Validate(%v) error = nil", build)
+		}
+	}
+}
+
+func TestValidatePlatform(t *testing.T) {
+	stack := &Stack{
+		Version: VersionCurrent,
+		Kind:    KindStack,
+		Name:    "arch",
+		Services: map[string]Service{
+			"web": {Runtime: RuntimeContainer, Image: "ghcr.io/acme/web:1", Platform: "linux/amd64", Build: map[string]any{
+				"context":   ".",
+				"platforms": []any{"linux/amd64", "linux/arm64/v8"},
+			}},
+			"db": {Runtime: RuntimeContainer, Image: "postgres:16", Platform: "linux/arm64"},
+		},
+	}
+	if err := stack.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	for _, service := range []Service{
+		{Runtime: RuntimeContainer, Image: "postgres:16", Platform: "amd64"},
+		{Runtime: RuntimeLocal, Command: []string{"postgres"}, Platform: "linux/amd64"},
+		{Runtime: RuntimeContainer, Image: "acme/web", Build: map[string]any{"context": ".", "platforms": "linux/amd64"}},
+		{Runtime: RuntimeContainer, Build: map[string]any{"context": ".", "platforms": []any{"linux/amd64", "linux/arm64"}}},
+		{Runtime: RuntimeContainer, Image: "acme/web", Platform: "linux/arm64", Build: map[string]any{"context": ".", "platforms": []any{"linux/amd64"}}},
+	} {
+		stack.Services["web"] = service
+		if err := stack.Validate(); err == nil {
+			t.Fatalf("Validate(%+v) error = nil", service)
+		}
+	}
+}
+
+func TestValidateDevelopWatch(t *testing.T) {
+	stack := &Stack{
+		Version: VersionCurrent,
+		Kind:    KindStack,
+		Name:    "dev",
+		Services: map[string]Service{
+			"web": {Runtime: RuntimeContainer, Build: ".", Develop: &Develop{Watch: []WatchRule{
+				{Path: "src", Action: WatchSync, Target: "/app/src", Ignore: []string{"node_modules/"}},
+				{Path: "package.json", Action: WatchRebuild},
+			}}},
+		},
+	}
+	if err := stack.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	for _, service := range []Service{
+		{Runtime: RuntimeContainer, Build: ".", Develop: &Develop{Watch: []WatchRule{{Path: "src", Action: WatchSync}}}},
+		{Runtime: RuntimeContainer, Build: ".", Develop: &Develop{Watch: []WatchRule{{Path: "src", Action: "restart"}}}},
+		{Runtime: RuntimeContainer, Image: "nginx:1", Develop: &Develop{Watch: []WatchRule{{Path: "src", Action: WatchRebuild}}}},
+		{Runtime: RuntimeLocal, Command: []string{"serve"}, Develop: &Develop{Watch: []WatchRule{{Path: "src", Action: WatchSync, Target: "/app"}}}},
+	} {
+		stack.Services["web"] = service
+		if err := stack.Validate(); err == nil {
+			t.Fatalf("Validate(%+v) error = nil", service.Develop.Watch)
+		}
+	}
+}
+
+func TestValidateGitHubHookSecret(t *testing.T) {
+	stack := &Stack{
+		Version:  VersionCurrent,
+		Kind:     KindStack,
+		Name:     "hooks",
+		Secrets:  map[string]Secret{"github-hook": {Required: true}},
+		Operator: Operator{Hooks: Hooks{GitHub: &GitHubHook{Secret: "github-hook"}}},
+	}
+	if err := stack.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	for _, secret := range []string{"", "missing"} {
+		stack.Operator.Hooks.GitHub.Secret = secret
+		if err := stack.Validate(); err == nil {
+			t.Fatalf("Validate(secret %q) error = nil", secret)
+		}
+	}
+}
+
+func TestValidateOperatorOIDC(t *testing.T) {
+	stack := &Stack{Version: VersionCurrent, Kind: KindStack, Name: "sso"}
+	for _, config := range []OIDC{
+		{Issuer: "https://id.example.test/realms/angee", ClientID: "angee", AllowedGroups: []string{"platform-team"}},
+		{Issuer: "http://127.0.0.1:5556/dex", ClientID: "angee", AllowedGroups: []string{"platform-team"}},
+	} {
+		stack.Operator.OIDC = &config
+		if err := stack.Validate(); err != nil {
+			t.Fatalf("Validate(%+v) error = %v", config, err)
+		}
+	}
+	for _, config := range []OIDC{
+		{Issuer: "https://id.example.test", AllowedGroups: []string{"platform-team"}},
+		{Issuer: "http://id.example.test", ClientID: "angee", AllowedGroups: []string{"platform-team"}},
+		{Issuer: "id.example.test", ClientID: "angee", AllowedGroups: []string{"platform-team"}},
+	} {
+		stack.Operator.OIDC = &config
+		if err := stack.Validate(); err == nil {
+			t.Fatalf("Validate(%+v) error = nil", config)
+		}
+	}
+	stack.Operator.OIDC = &OIDC{Issuer: "https://id.example.test/realms/angee", ClientID: "angee"}
+	if err := stack.Validate(); err == nil || !strings.Contains(err.Error(), "allowed_groups") {
+		t.Fatalf("Validate(without allowed_groups) error = %v, want allowed_groups required", err)
+	}
+}
+
+func TestOverlayMergesMappingsAndReplacesLeaves(t *testing.T) {
+	base := []byte(`version: 1
+kind: stack
+name: base
+# shared database
+services:
+  postgres:
+    runtime: container
+    image: postgres:16
+    env:
+      POSTGRES_DB: app
+secrets:
+  postgres-password:
+    generated: true
+`)
+	overlay := []byte(`name: acme
+services:
+  postgres:
+    image: postgres:17
+    env:
+      POSTGRES_USER: acme
+  redis:
+    runtime: container
+    image: redis:7
+`)
+	merged, err := Overlay(base, overlay)
+	if err != nil {
+		t.Fatalf("Overlay() error = %v", err)
+	}
+	if !bytes.Contains(merged, []byte("# shared database")) {
+		t.Fatalf("Overlay() dropped base comment:\n%s", merged)
+	}
+	var stack Stack
+	if err := yaml.Unmarshal(merged, &stack); err != nil {
+		t.Fatalf("Unmarshal(merged) error = %v", err)
+	}
+	if stack.Name != "acme" {
+		t.Fatalf("Name = %q, want acme", stack.Name)
+	}
+	postgres := stack.Services["postgres"]
+	if postgres.Image != "postgres:17" || postgres.Env["POSTGRES_DB"] != "app" || postgres.Env["POSTGRES_USER"] != "acme" {
+		t.Fatalf("postgres = %+v, want merged image and env", postgres)
+	}
+	if stack.Services["redis"].Image != "redis:7" || !stack.Secrets["postgres-password"].Generated {
+		t.Fatalf("merged stack = %+v, want overlay service and base secret", stack)
+	}
+	if _, err := Overlay(base, []byte("servics: {}\n")); err == nil {
+		t.Fatal("Overlay() with unknown key error is nil")
+	}
+}
+
+func TestResolvePathWindowsDriveUnderWSL(t *testing.T) {
+	defer func(host mount.Host) { mount.CurrentHost = host }(mount.CurrentHost)
+	mount.CurrentHost = mount.HostWSL
+	if got := ResolvePath("/home/ada/angee", `C:\Users\ada\secrets.env`); got != "/mnt/c/Users/ada/secrets.env" {
+		t.Fatalf("ResolvePath() = %q", got)
+	}
+}
+
+func TestLockRoundTripsAndPinsImages(t *testing.T) {
+	path := LockPath(t.TempDir())
+	lock, err := LoadLock(path)
+	if err != nil || len(lock.Images) != 0 {
+		t.Fatalf("LoadLock(missing) = %+v, %v, want an empty lock", lock, err)
+	}
+	lock.Images = map[string]string{"nginx:1": "sha256:one"}
+	if err := SaveLock(path, lock); err != nil {
+		t.Fatalf("SaveLock() error = %v", err)
+	}
+	loaded, err := LoadLock(path)
+	if err != nil || loaded.Images["nginx:1"] != "sha256:one" {
+		t.Fatalf("LoadLock() = %+v, %v", loaded, err)
+	}
+	if got := PinnedImage("nginx:1", "sha256:one"); got != "nginx:1@sha256:one" {
+		t.Fatalf("PinnedImage() = %q", got)
+	}
+	if got := PinnedImage("nginx@sha256:old", "sha256:one"); got != "nginx@sha256:old" {
+		t.Fatalf("PinnedImage(digest) = %q, want it unchanged", got)
+	}
+}
+
+func TestResourcesParseAndValidate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "angee.yaml")
+	data := `version: 1
+kind: stack
+name: notes
+operator:
+  resources:
+    default: {cpus: 0.5, memory: 512m}
+    max: {cpus: 2, memory: 4g}
+services:
+  web:
+    runtime: container
+    image: nginx:1
+    resources: {cpus: 1.5, memory: 1gb}
+`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	stack, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if got := *stack.Services["web"].Resources; got != (Resources{CPUs: "1.5", Memory: "1gb"}) {
+		t.Fatalf("web resources = %+v", got)
+	}
+	if memory, err := ParseMemory("1gb"); err != nil || memory != 1<<30 {
+		t.Fatalf("ParseMemory(1gb) = %d, %v", memory, err)
+	}
+	if err := stack.Operator.Resources.Check(Resources{Memory: "8g"}); err == nil || !strings.Contains(err.Error(), "exceeds the operator maximum of 4g") {
+		t.Fatalf("Check(8g) error = %v", err)
+	}
+
+	stack.Services["web"] = Service{Runtime: RuntimeContainer, Image: "nginx:1", Resources: &Resources{Memory: "lots"}}
+	if err := stack.ValidateExtended(); err == nil || !strings.Contains(err.Error(), `service "web" resources: memory "lots"`) {
+		t.Fatalf("ValidateExtended() error = %v, want the bad memory named", err)
+	}
+	stack.Services["web"] = Service{Runtime: RuntimeContainer, Image: "nginx:1"}
+	stack.Operator.Resources.Default.CPUs = "3"
+	if err := stack.ValidateExtended(); err == nil || !strings.Contains(err.Error(), "operator default resources") {
+		t.Fatalf("ValidateExtended() error = %v, want a default above the maximum rejected", err)
+	}
+}
+
+func TestMatchOrigin(t *testing.T) {
+	for _, tc := range []struct {
+		pattern string
+		origin  string
+		want    bool
+	}{
+		{"https://*.example.dev", "https://app.example.dev", true},
+		{"https://*.example.dev", "https://a.b.example.dev", true},
+		{"https://*.example.dev", "https://APP.Example.dev", true},
+		{"https://*.example.dev", "https://example.dev", false},
+		{"https://*.example.dev", "http://app.example.dev", false},
+		{"https://*.example.dev", "https://evilexample.dev", false},
+		{"https://*.example.dev", "https://app.example.dev.evil.com", false},
+		{"https://*.example.dev", "https://app.example.dev:8443", false},
+		{"https://*.example.dev", "https://app.example.dev:443", true},
+		{"https://example.dev", "https://example.dev", true},
+		{"https://example.dev", "https://www.example.dev", false},
+		{"http://localhost:*", "http://localhost", true},
+		{"http://localhost:*", "http://localhost:5173", true},
+		{"http://localhost:{3000,5173}", "http://localhost:5173", true},
+		{"http://localhost:{3000,5173}", "http://localhost:8080", false},
+		{"http://localhost:3000", "http://localhost", false},
+		{"http://[::1]:*", "http://[::1]:3000", true},
+		{"http://[::1]:*", "http://127.0.0.1:3000", false},
+		{"*", "https://anything.test", true},
+		{"*", "null", false},
+		{"https://*.example.dev", "https://app.example.dev/path", false},
+		{"https://*.example.dev", "https://user@app.example.dev", false},
+	} {
+		if got := MatchOrigin([]string{tc.pattern}, tc.origin); got != tc.want {
+			t.Errorf("MatchOrigin(%q, %q) = %v, want %v", tc.pattern, tc.origin, got, tc.want)
+		}
+	}
+	for _, pattern := range []string{
+		"example.dev",
+		"https://app.*.dev",
+		"https://*",
+		"*://example.dev",
+		"https://example.dev/",
+		"https://example.dev:",
+		"https://example.dev:99999",
+		"https://example.dev:{3000,abc}",
+		"https://example.dev:{3000",
+		"http://[::1",
+	} {
+		if err := validateOrigins([]string{pattern}); err == nil {
+			t.Errorf("validateOrigins(%q) error = nil", pattern)
+		}
+	}
+}
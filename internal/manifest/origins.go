@@ -0,0 +1,150 @@
+package manifest
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// originPattern is a parsed entry of operator.allowed_origins. An empty
+// host matches any origin. A wildcard host matches subdomains of host at
+// any depth, never host itself. Ports nil means the scheme's default
+// port; a "*" in ports matches any port.
+type originPattern struct {
+	scheme   string
+	host     string
+	wildcard bool
+	ports    []string
+}
+
+// parseOriginPattern reads "*", or scheme://host[:port] where host may
+// start with "*." and port is a number, "*", or a list such as
+// {3000,5173}.
+func parseOriginPattern(pattern string) (originPattern, error) {
+	if pattern == "*" {
+		return originPattern{}, nil
+	}
+	scheme, rest, ok := strings.Cut(pattern, "://")
+	if !ok || scheme == "" || strings.ContainsAny(scheme, "*:/") {
+		return originPattern{}, fmt.Errorf("origin %q must be scheme://host[:port], such as https://*.example.dev", pattern)
+	}
+	if strings.ContainsAny(rest, "/?#@") {
+		return originPattern{}, fmt.Errorf("origin %q must not have a path, query, or user", pattern)
+	}
+	if strings.HasSuffix(rest, ":") {
+		return originPattern{}, fmt.Errorf("origin %q has an empty port", pattern)
+	}
+	host, port := rest, ""
+	if strings.HasPrefix(rest, "[") {
+		end := strings.Index(rest, "]")
+		if end < 0 {
+			return originPattern{}, fmt.Errorf("origin %q has an unclosed IPv6 address", pattern)
+		}
+		host, port = rest[:end+1], strings.TrimPrefix(rest[end+1:], ":")
+		if rest[end+1:] != "" && !strings.HasPrefix(rest[end+1:], ":") {
+			return originPattern{}, fmt.Errorf("origin %q has text after its IPv6 address", pattern)
+		}
+	} else if i := strings.LastIndex(rest, ":"); i >= 0 {
+		host, port = rest[:i], rest[i+1:]
+	}
+	p := originPattern{scheme: strings.ToLower(scheme), host: strings.ToLower(host)}
+	if suffix, ok := strings.CutPrefix(p.host, "*."); ok {
+		p.host, p.wildcard = suffix, true
+	}
+	if p.host == "" || strings.Contains(p.host, "*") {
+		return originPattern{}, fmt.Errorf("origin %q may use * only as its first host label, as in https://*.example.dev", pattern)
+	}
+	if port == "" {
+		return p, nil
+	}
+	if list, ok := strings.CutPrefix(port, "{"); ok {
+		list, ok = strings.CutSuffix(list, "}")
+		if !ok {
+			return originPattern{}, fmt.Errorf("origin %q has an unclosed port list", pattern)
+		}
+		p.ports = strings.Split(list, ",")
+	} else {
+		p.ports = []string{port}
+	}
+	for i, port := range p.ports {
+		port = strings.TrimSpace(port)
+		if port != "*" && !validPort(port) {
+			return originPattern{}, fmt.Errorf("origin %q port %q is not a port number or *", pattern, port)
+		}
+		p.ports[i] = port
+	}
+	return p, nil
+}
+
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n > 0 && n <= 65535 && strconv.Itoa(n) == port
+}
+
+func (p originPattern) match(origin *url.URL) bool {
+	if p.scheme == "" {
+		return true
+	}
+	if strings.ToLower(origin.Scheme) != p.scheme {
+		return false
+	}
+	host := strings.ToLower(origin.Hostname())
+	if strings.Contains(origin.Host, "[") {
+		host = "[" + host + "]"
+	}
+	if p.wildcard {
+		if !strings.HasSuffix(host, "."+p.host) {
+			return false
+		}
+	} else if host != p.host {
+		return false
+	}
+	port := origin.Port()
+	if port == "" {
+		port = defaultPort(p.scheme)
+	}
+	if p.ports == nil {
+		return port == defaultPort(p.scheme)
+	}
+	return slices.Contains(p.ports, "*") || slices.Contains(p.ports, port)
+}
+
+func defaultPort(scheme string) string {
+	switch scheme {
+	case "http", "ws":
+		return "80"
+	case "https", "wss":
+		return "443"
+	}
+	return ""
+}
+
+// MatchOrigin reports whether origin, the value of a request's Origin
+// header, matches one of patterns, as operator.allowed_origins lists
+// them. Schemes must match exactly, so https://*.example.dev does not admit
+// http://app.example.dev, and the pattern's port (the scheme's default
+// when it has none) must match.
+func MatchOrigin(patterns []string, origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil || u.Scheme == "" || u.Host == "" || u.User != nil || u.Path != "" || u.RawQuery != "" {
+		return false
+	}
+	for _, pattern := range patterns {
+		p, err := parseOriginPattern(pattern)
+		if err == nil && p.match(u) {
+			return true
+		}
+	}
+	return false
+}
+
+func validateOrigins(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := parseOriginPattern(pattern); err != nil {
+			return fmt.Errorf("operator allowed_origins: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package manifest
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Overlay deep-merges the overlay manifest document onto base and returns
+// the merged YAML. Mappings merge key by key, recursively; any other value
+// in overlay (scalar or sequence) replaces the base value. Keys keep their
+// base order, with overlay-only keys appended, and comments survive because
+// the merge works on yaml.Node trees. The result must decode as a Stack.
+func Overlay(base, overlay []byte) ([]byte, error) {
+	var baseDoc, overlayDoc yaml.Node
+	if err := yaml.Unmarshal(base, &baseDoc); err != nil {
+		return nil, fmt.Errorf("overlay: parse base: %w", err)
+	}
+	if err := yaml.Unmarshal(overlay, &overlayDoc); err != nil {
+		return nil, fmt.Errorf("overlay: parse overlay: %w", err)
+	}
+	if len(overlayDoc.Content) == 0 {
+		return base, nil
+	}
+	if len(baseDoc.Content) == 0 {
+		return overlay, nil
+	}
+	baseRoot, overlayRoot := baseDoc.Content[0], overlayDoc.Content[0]
+	if baseRoot.Kind != yaml.MappingNode || overlayRoot.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("overlay: manifest documents must be mappings")
+	}
+	mergeNodes(baseRoot, overlayRoot)
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&baseDoc); err != nil {
+		return nil, fmt.Errorf("overlay: encode: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("overlay: encode: %w", err)
+	}
+	var stack Stack
+	dec := yaml.NewDecoder(strings.NewReader(buf.String()))
+	dec.KnownFields(true)
+	if err := dec.Decode(&stack); err != nil {
+		return nil, fmt.Errorf("overlay: decode merged: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func mergeNodes(base, overlay *yaml.Node) {
+	for i := 0; i+1 < len(overlay.Content); i += 2 {
+		key, value := overlay.Content[i], overlay.Content[i+1]
+		existing := mappingValue(base, key.Value)
+		switch {
+		case existing == nil:
+			base.Content = append(base.Content, key, value)
+		case existing.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode:
+			mergeNodes(existing, value)
+		default:
+			*existing = *value
+		}
+	}
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
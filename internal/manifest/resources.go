@@ -0,0 +1,106 @@
+package manifest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Resources limits the CPU and memory of a container service. CPUs is a
+// number of cores, such as 0.5 or 2; Memory is a byte count with an
+// optional k, m, or g suffix, such as 512m.
+type Resources struct {
+	CPUs   string `yaml:"cpus,omitempty" json:"cpus,omitempty" jsonschema:"oneof_type=string;number"`
+	Memory string `yaml:"memory,omitempty" json:"memory,omitempty"`
+}
+
+// ResourcePolicy gives container services without their own resources
+// the Default ones, or Max where there is no default, and rejects services
+// asking for more than Max, so one service cannot claim the whole host.
+type ResourcePolicy struct {
+	Default Resources `yaml:"default,omitempty" json:"default,omitempty"`
+	Max     Resources `yaml:"max,omitempty" json:"max,omitempty"`
+}
+
+// ParseCPUs reads a number of cores.
+func ParseCPUs(cpus string) (float64, error) {
+	n, err := strconv.ParseFloat(strings.TrimSpace(cpus), 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("cpus %q is not a positive number of cores", cpus)
+	}
+	return n, nil
+}
+
+// ParseMemory reads a byte count such as 512m, 2g, or 1048576, with the
+// binary units Docker uses; a trailing b, as in 512mb, is allowed.
+func ParseMemory(memory string) (int64, error) {
+	value := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(memory)), "b")
+	multiplier := int64(1)
+	for suffix, m := range map[string]int64{"k": 1 << 10, "m": 1 << 20, "g": 1 << 30} {
+		if trimmed, ok := strings.CutSuffix(value, suffix); ok {
+			value, multiplier = trimmed, m
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("memory %q is not a size such as 512m or 2g", memory)
+	}
+	return int64(n * float64(multiplier)), nil
+}
+
+func (r Resources) validate(owner string) error {
+	if r.CPUs != "" {
+		if _, err := ParseCPUs(r.CPUs); err != nil {
+			return fmt.Errorf("%s resources: %w", owner, err)
+		}
+	}
+	if r.Memory != "" {
+		if _, err := ParseMemory(r.Memory); err != nil {
+			return fmt.Errorf("%s resources: %w", owner, err)
+		}
+	}
+	return nil
+}
+
+func validateResources(s *Stack) error {
+	for _, name := range sortedKeys(s.Services) {
+		if resources := s.Services[name].Resources; resources != nil {
+			if err := resources.validate(fmt.Sprintf("service %q", name)); err != nil {
+				return err
+			}
+		}
+	}
+	policy := s.Operator.Resources
+	if policy == nil {
+		return nil
+	}
+	if err := policy.Default.validate("operator default"); err != nil {
+		return err
+	}
+	if err := policy.Max.validate("operator max"); err != nil {
+		return err
+	}
+	if err := policy.Check(policy.Default); err != nil {
+		return fmt.Errorf("operator default resources: %w", err)
+	}
+	return nil
+}
+
+// Check reports resources that exceed the policy's maximum. Both must have
+// been validated.
+func (p *ResourcePolicy) Check(r Resources) error {
+	if p.Max.CPUs != "" && r.CPUs != "" {
+		limit, _ := ParseCPUs(p.Max.CPUs)
+		if asked, _ := ParseCPUs(r.CPUs); asked > limit {
+			return fmt.Errorf("cpus %s exceeds the operator maximum of %s", r.CPUs, p.Max.CPUs)
+		}
+	}
+	if p.Max.Memory != "" && r.Memory != "" {
+		limit, _ := ParseMemory(p.Max.Memory)
+		if asked, _ := ParseMemory(r.Memory); asked > limit {
+			return fmt.Errorf("memory %s exceeds the operator maximum of %s", r.Memory, p.Max.Memory)
+		}
+	}
+	return nil
+}
@@ -2,10 +2,88 @@ package mount
 
 import (
 	"fmt"
+	"os"
+	"path"
 	"path/filepath"
+	"runtime"
 	"strings"
 )
 
+// Host is how the machine running angee spells the host paths it hands to
+// Docker and to local processes.
+type Host int
+
+const (
+	// HostPOSIX is Linux or macOS with POSIX paths throughout.
+	HostPOSIX Host = iota
+	// HostWindows is a native Windows binary talking to Docker Desktop,
+	// which accepts drive paths with forward slashes (C:/Users/...).
+	HostWindows
+	// HostWSL is a Linux binary inside WSL, where a Windows drive path
+	// from a shared angee.yaml lives under /mnt/<drive>/.
+	HostWSL
+)
+
+// CurrentHost is detected once at startup.
+var CurrentHost = detectHost()
+
+func detectHost() Host {
+	switch runtime.GOOS {
+	case "windows":
+		return HostWindows
+	case "linux":
+		if os.Getenv("WSL_DISTRO_NAME") != "" {
+			return HostWSL
+		}
+		if _, err := os.Stat("/proc/sys/fs/binfmt_misc/WSLInterop"); err == nil {
+			return HostWSL
+		}
+	}
+	return HostPOSIX
+}
+
+// IsAbs reports whether p is absolute on h. Windows drive paths count as
+// absolute everywhere, so a manifest written on Windows resolves the same
+// under WSL instead of being joined onto the root.
+func (h Host) IsAbs(p string) bool {
+	return filepath.IsAbs(p) || isDrivePath(p)
+}
+
+// NativePath is p as a local process on h opens it: drive paths move under
+// /mnt/<drive>/ on WSL; everything else is only cleaned.
+func (h Host) NativePath(p string) string {
+	if h == HostWSL && isDrivePath(p) {
+		return wslPath(p)
+	}
+	return filepath.Clean(p)
+}
+
+// DockerPath is p as the Docker engine on h expects it in a compose file:
+// the native path with forward slashes, which both Docker Desktop and the
+// compose volume parser accept for drive paths.
+func (h Host) DockerPath(p string) string {
+	p = h.NativePath(p)
+	if h == HostWindows || isDrivePath(p) {
+		p = strings.ReplaceAll(p, `\`, "/")
+	}
+	return p
+}
+
+// isDrivePath reports whether p starts with a Windows drive, as in C:\ or
+// C:/.
+func isDrivePath(p string) bool {
+	return len(p) >= 3 && (p[0] >= 'a' && p[0] <= 'z' || p[0] >= 'A' && p[0] <= 'Z') && p[1] == ':' && (p[2] == '\\' || p[2] == '/')
+}
+
+func wslPath(p string) string {
+	rest := strings.Trim(strings.ReplaceAll(p[2:], `\`, "/"), "/")
+	out := "/mnt/" + strings.ToLower(p[:1])
+	if rest != "" {
+		out += "/" + rest
+	}
+	return path.Clean(out)
+}
+
 type Resolver struct {
 	Workspaces map[string]string
 	Sources    map[string]string
@@ -59,22 +137,22 @@ func ResolveContainer(raw string, resolver Resolver) (string, error) {
 		if err != nil {
 			return "", err
 		}
-		return host + ":" + m.Target + suffix, nil
+		return CurrentHost.DockerPath(host) + ":" + m.Target + suffix, nil
 	case "source":
 		host, err := resourcePath(resolver.Sources, m.Name, m.Subpath, "source")
 		if err != nil {
 			return "", err
 		}
-		return host + ":" + m.Target + suffix, nil
+		return CurrentHost.DockerPath(host) + ":" + m.Target + suffix, nil
 	case "volume":
 		if m.Subpath != "" {
 			return "", fmt.Errorf("volume mounts do not support subpaths: %q", raw)
 		}
 		return m.Name + ":" + m.Target + suffix, nil
 	case "bind":
-		host := filepath.Clean(m.HostPath)
-		if !filepath.IsAbs(host) && !strings.HasPrefix(host, ".") {
-			host = "." + string(filepath.Separator) + host
+		host := CurrentHost.DockerPath(m.HostPath)
+		if !CurrentHost.IsAbs(host) && !strings.HasPrefix(host, ".") {
+			host = "./" + host
 		}
 		return host + ":" + m.Target + suffix, nil
 	default:
@@ -98,7 +176,7 @@ func ResolveLocalEnv(raw string, resolver Resolver) (string, string, error) {
 		host, err := resourcePath(resolver.Volumes, m.Name, m.Subpath, "volume")
 		return envName("VOLUME", m.Name, m.Subpath), host, err
 	case "bind":
-		return envName("BIND", strings.Trim(m.HostPath, "/"), ""), filepath.Clean(m.HostPath), nil
+		return envName("BIND", strings.Trim(m.HostPath, "/"), ""), CurrentHost.NativePath(m.HostPath), nil
 	default:
 		return "", "", fmt.Errorf("unsupported mount scheme %q", m.Scheme)
 	}
@@ -110,7 +188,7 @@ func ResolveWorkdir(raw string, resolver Resolver) (string, error) {
 	}
 	scheme, rest, _ := strings.Cut(raw, "://")
 	if scheme == "bind" {
-		return filepath.Clean(rest), nil
+		return CurrentHost.NativePath(rest), nil
 	}
 	name, subpath, _ := strings.Cut(rest, "/")
 	switch scheme {
@@ -125,9 +203,19 @@ func ResolveWorkdir(raw string, resolver Resolver) (string, error) {
 	}
 }
 
+// splitTarget cuts source:/target at the first colon after any drive
+// letter, so bind://C:\data:/data keeps its host path whole.
 func splitTarget(rest string) (string, string, bool, error) {
-	left, right, ok := strings.Cut(rest, ":")
-	if !ok || left == "" || right == "" {
+	skip := 0
+	if isDrivePath(rest) {
+		skip = 2
+	}
+	i := strings.Index(rest[skip:], ":")
+	if i < 0 {
+		return "", "", false, fmt.Errorf("mount %q must have source:/target", rest)
+	}
+	left, right := rest[:skip+i], rest[skip+i+1:]
+	if left == "" || right == "" {
 		return "", "", false, fmt.Errorf("mount %q must have source:/target", rest)
 	}
 	readOnly := false
@@ -31,3 +31,48 @@ func TestResolveWorkdir(t *testing.T) {
 		t.Fatalf("ResolveWorkdir() = %q", got)
 	}
 }
+
+func TestResolveContainerWindowsRoots(t *testing.T) {
+	defer func(host Host) { CurrentHost = host }(CurrentHost)
+	resolver := Resolver{
+		Workspaces: map[string]string{"feat": `C:\Users\ada\angee\workspaces\feat`},
+		Sources:    map[string]string{"app": `D:\src\app`},
+	}
+	tests := []struct {
+		host Host
+		raw  string
+		want string
+	}{
+		{HostWindows, "workspace://feat/code:/workspace:ro", "C:/Users/ada/angee/workspaces/feat/code:/workspace:ro"},
+		{HostWindows, "source://app:/src", "D:/src/app:/src"},
+		{HostWindows, `bind://C:\data:/data`, "C:/data:/data"},
+		{HostWindows, "bind://C:/data:/data:ro", "C:/data:/data:ro"},
+		{HostWindows, `bind://cache\pip:/root/.cache/pip`, "./cache/pip:/root/.cache/pip"},
+		{HostWSL, "workspace://feat/code:/workspace", "/mnt/c/Users/ada/angee/workspaces/feat/code:/workspace"},
+		{HostWSL, `bind://D:\:/d`, "/mnt/d:/d"},
+		{HostPOSIX, "bind://cache:/cache", "./cache:/cache"},
+	}
+	for _, tt := range tests {
+		CurrentHost = tt.host
+		got, err := ResolveContainer(tt.raw, resolver)
+		if err != nil {
+			t.Fatalf("ResolveContainer(%q) error = %v", tt.raw, err)
+		}
+		if got != tt.want {
+			t.Fatalf("ResolveContainer(%q) on host %d = %q, want %q", tt.raw, tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestParseBindDrivePath(t *testing.T) {
+	m, err := Parse(`bind://C:\data:/data:ro`)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if m.HostPath != `C:\data` || m.Target != "/data" || !m.ReadOnly {
+		t.Fatalf("Parse() = %+v", m)
+	}
+	if _, err := Parse("bind://C:/data"); err == nil {
+		t.Fatal("Parse(missing target) error = nil")
+	}
+}
@@ -0,0 +1,492 @@
+// Package oidc is the OpenID Connect client shared by the operator and the
+// CLI: provider discovery, ID token verification against the provider's
+// published keys, and the authorization code and device flows.
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultScopes are requested when the configuration names none.
+var DefaultScopes = []string{"openid", "email", "profile"}
+
+// clockSkew is the leeway allowed on token expiry and not-before times.
+const clockSkew = time.Minute
+
+// Provider is a discovered OpenID provider.
+type Provider struct {
+	Issuer                      string `json:"issuer"`
+	AuthorizationEndpoint       string `json:"authorization_endpoint"`
+	TokenEndpoint               string `json:"token_endpoint"`
+	DeviceAuthorizationEndpoint string `json:"device_authorization_endpoint,omitempty"`
+	JWKSURI                     string `json:"jwks_uri"`
+
+	client   *http.Client
+	pollUnit time.Duration
+
+	mu      sync.Mutex
+	keys    map[string]crypto.PublicKey
+	fetched time.Time
+}
+
+// Discover reads the provider's configuration from
+// <issuer>/.well-known/openid-configuration.
+func Discover(ctx context.Context, client *http.Client, issuer string) (*Provider, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	issuer = strings.TrimRight(issuer, "/")
+	provider := &Provider{client: client, pollUnit: time.Second}
+	if err := provider.getJSON(ctx, issuer+"/.well-known/openid-configuration", provider); err != nil {
+		return nil, fmt.Errorf("discover %s: %w", issuer, err)
+	}
+	if strings.TrimRight(provider.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("discover %s: provider reports issuer %q", issuer, provider.Issuer)
+	}
+	if provider.AuthorizationEndpoint == "" || provider.TokenEndpoint == "" || provider.JWKSURI == "" {
+		return nil, fmt.Errorf("discover %s: configuration lacks an authorization, token, or jwks endpoint", issuer)
+	}
+	return provider, nil
+}
+
+// Claims are the verified claims of an ID token. Raw holds every claim, for
+// the configurable groups claim.
+type Claims struct {
+	Issuer    string   `json:"iss"`
+	Subject   string   `json:"sub"`
+	Audience  audience `json:"aud"`
+	Expiry    int64    `json:"exp"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	Email     string   `json:"email,omitempty"`
+	Name      string   `json:"name,omitempty"`
+
+	Raw map[string]any `json:"-"`
+}
+
+// Identity names the user for logs and the deploy ledger: the email when
+// the token carries one, otherwise the subject.
+func (c Claims) Identity() string {
+	if c.Email != "" {
+		return c.Email
+	}
+	return c.Subject
+}
+
+// Groups returns the string values of claim, which providers send as a
+// list or, with a single group, a string.
+func (c Claims) Groups(claim string) []string {
+	switch value := c.Raw[claim].(type) {
+	case string:
+		return []string{value}
+	case []any:
+		groups := make([]string, 0, len(value))
+		for _, item := range value {
+			if group, ok := item.(string); ok {
+				groups = append(groups, group)
+			}
+		}
+		return groups
+	}
+	return nil
+}
+
+// ExpiresAt is the token's expiry time.
+func (c Claims) ExpiresAt() time.Time {
+	return time.Unix(c.Expiry, 0)
+}
+
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*a = audience{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+// Verify checks an ID token's signature, issuer, audience, and validity
+// period and returns its claims.
+func (p *Provider) Verify(ctx context.Context, raw, clientID string) (Claims, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return Claims{}, errors.New("id token is not a JWT")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Claims{}, fmt.Errorf("id token header: %w", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Claims{}, fmt.Errorf("id token signature: %w", err)
+	}
+	key, err := p.key(ctx, header.Kid)
+	if err != nil {
+		return Claims{}, err
+	}
+	if err := verifySignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return Claims{}, err
+	}
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return Claims{}, fmt.Errorf("id token claims: %w", err)
+	}
+	if err := decodeSegment(parts[1], &claims.Raw); err != nil {
+		return Claims{}, fmt.Errorf("id token claims: %w", err)
+	}
+	now := time.Now()
+	switch {
+	case strings.TrimRight(claims.Issuer, "/") != strings.TrimRight(p.Issuer, "/"):
+		return Claims{}, fmt.Errorf("id token issuer %q is not %q", claims.Issuer, p.Issuer)
+	case !slices.Contains(claims.Audience, clientID):
+		return Claims{}, fmt.Errorf("id token is not issued to client %q", clientID)
+	case claims.Expiry == 0 || now.After(claims.ExpiresAt().Add(clockSkew)):
+		return Claims{}, errors.New("id token has expired")
+	case claims.NotBefore != 0 && now.Add(clockSkew).Before(time.Unix(claims.NotBefore, 0)):
+		return Claims{}, errors.New("id token is not valid yet")
+	}
+	return claims, nil
+}
+
+func verifySignature(alg string, key crypto.PublicKey, signed string, signature []byte) error {
+	digest := sha256.Sum256([]byte(signed))
+	switch alg {
+	case "RS256":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errors.New("id token key is not an RSA key")
+		}
+		if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature); err != nil {
+			return errors.New("id token signature does not verify")
+		}
+	case "ES256":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || len(signature) != 64 {
+			return errors.New("id token key is not a P-256 key")
+		}
+		r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(pub, digest[:], r, s) {
+			return errors.New("id token signature does not verify")
+		}
+	default:
+		return fmt.Errorf("id token algorithm %q is not supported", alg)
+	}
+	return nil
+}
+
+// key returns the signing key kid, refetching the key set when kid is not
+// in it, which is how providers roll keys, at most once a minute.
+func (p *Provider) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	if time.Since(p.fetched) < time.Minute && p.keys != nil {
+		return nil, fmt.Errorf("id token key %q is not published by the provider", kid)
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := p.getJSON(ctx, p.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("fetch provider keys: %w", err)
+	}
+	p.keys = map[string]crypto.PublicKey{}
+	p.fetched = time.Now()
+	for _, k := range set.Keys {
+		if key, err := k.publicKey(); err == nil {
+			p.keys[k.Kid] = key
+		}
+	}
+	key, ok := p.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("id token key %q is not published by the provider", kid)
+	}
+	return key, nil
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("curve %q is not supported", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	}
+	return nil, fmt.Errorf("key type %q is not supported", k.Kty)
+}
+
+// Token is a token endpoint response.
+type Token struct {
+	AccessToken  string `json:"access_token"`
+	IDToken      string `json:"id_token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	TokenType    string `json:"token_type,omitempty"`
+	ExpiresIn    int    `json:"expires_in,omitempty"`
+}
+
+// TokenError is an OAuth error returned by the token endpoint.
+type TokenError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+func (e *TokenError) Error() string {
+	if e.Description != "" {
+		return e.Code + ": " + e.Description
+	}
+	return e.Code
+}
+
+// AuthCodeURL is where a browser starts the authorization code flow.
+// challenge is the PKCE S256 challenge of the verifier later passed to
+// Exchange.
+func (p *Provider) AuthCodeURL(clientID, redirectURI, state, challenge string, scopes []string) string {
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {clientID},
+		"redirect_uri":          {redirectURI},
+		"scope":                 {strings.Join(scopesOrDefault(scopes), " ")},
+		"state":                 {state},
+		"code_challenge":        {challenge},
+		"code_challenge_method": {"S256"},
+	}
+	separator := "?"
+	if strings.Contains(p.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return p.AuthorizationEndpoint + separator + query.Encode()
+}
+
+// Exchange trades an authorization code for tokens.
+func (p *Provider) Exchange(ctx context.Context, clientID, code, redirectURI, verifier string) (Token, error) {
+	return p.token(ctx, url.Values{
+		"grant_type":    {"authorization_code"},
+		"client_id":     {clientID},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {verifier},
+	})
+}
+
+// Refresh trades a refresh token for new tokens.
+func (p *Provider) Refresh(ctx context.Context, clientID, refreshToken string) (Token, error) {
+	return p.token(ctx, url.Values{
+		"grant_type":    {"refresh_token"},
+		"client_id":     {clientID},
+		"refresh_token": {refreshToken},
+	})
+}
+
+// DeviceCode is a device authorization response: the user opens
+// VerificationURI and enters UserCode while the client polls.
+type DeviceCode struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval,omitempty"`
+}
+
+// DeviceAuth starts the device authorization flow.
+func (p *Provider) DeviceAuth(ctx context.Context, clientID string, scopes []string) (DeviceCode, error) {
+	if p.DeviceAuthorizationEndpoint == "" {
+		return DeviceCode{}, errors.New("the provider does not support the device flow")
+	}
+	var code DeviceCode
+	err := p.postForm(ctx, p.DeviceAuthorizationEndpoint, url.Values{
+		"client_id": {clientID},
+		"scope":     {strings.Join(scopesOrDefault(scopes), " ")},
+	}, &code)
+	if err != nil {
+		return DeviceCode{}, fmt.Errorf("device authorization: %w", err)
+	}
+	return code, nil
+}
+
+// PollDevice polls the token endpoint until the user approves or denies
+// code, the code expires, or ctx is done.
+func (p *Provider) PollDevice(ctx context.Context, clientID string, code DeviceCode) (Token, error) {
+	interval := code.Interval
+	if interval <= 0 {
+		interval = 5
+	}
+	expires := time.Now().Add(time.Duration(code.ExpiresIn) * time.Second)
+	for {
+		select {
+		case <-ctx.Done():
+			return Token{}, ctx.Err()
+		case <-time.After(time.Duration(interval) * p.pollUnit):
+		}
+		token, err := p.token(ctx, url.Values{
+			"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+			"client_id":   {clientID},
+			"device_code": {code.DeviceCode},
+		})
+		var tokenErr *TokenError
+		switch {
+		case err == nil:
+			return token, nil
+		case !errors.As(err, &tokenErr):
+			return Token{}, err
+		case tokenErr.Code == "authorization_pending":
+		case tokenErr.Code == "slow_down":
+			interval += 5
+		default:
+			return Token{}, err
+		}
+		if code.ExpiresIn > 0 && time.Now().After(expires) {
+			return Token{}, errors.New("device code expired before it was approved")
+		}
+	}
+}
+
+func (p *Provider) token(ctx context.Context, form url.Values) (Token, error) {
+	var token Token
+	if err := p.postForm(ctx, p.TokenEndpoint, form, &token); err != nil {
+		return Token{}, err
+	}
+	if token.IDToken == "" {
+		return Token{}, errors.New("token response has no id_token; request the openid scope")
+	}
+	return token, nil
+}
+
+func (p *Provider) postForm(ctx context.Context, endpoint string, form url.Values, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	return p.do(req, out)
+}
+
+func (p *Provider) getJSON(ctx context.Context, endpoint string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	return p.do(req, out)
+}
+
+func (p *Provider) do(req *http.Request, out any) error {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		var tokenErr TokenError
+		if json.Unmarshal(data, &tokenErr) == nil && tokenErr.Code != "" {
+			return &tokenErr
+		}
+		return fmt.Errorf("%s %s: HTTP %d", req.Method, req.URL.Redacted(), resp.StatusCode)
+	}
+	return json.Unmarshal(data, out)
+}
+
+// UnverifiedExpiry reads the expiry of a token this process obtained
+// itself, to decide when to refresh it. It does not verify the token.
+func UnverifiedExpiry(raw string) (time.Time, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return time.Time{}, errors.New("id token is not a JWT")
+	}
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return time.Time{}, err
+	}
+	return claims.ExpiresAt(), nil
+}
+
+// NewVerifier returns a PKCE code verifier and its S256 challenge.
+func NewVerifier() (verifier, challenge string) {
+	verifier = RandomString()
+	sum := sha256.Sum256([]byte(verifier))
+	return verifier, base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// RandomString returns 32 random bytes, base64url encoded, for states,
+// nonces, and verifiers.
+func RandomString() string {
+	buf := make([]byte, 32)
+	_, _ = rand.Read(buf)
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+func scopesOrDefault(scopes []string) []string {
+	if len(scopes) == 0 {
+		return DefaultScopes
+	}
+	return scopes
+}
+
+func decodeSegment(segment string, out any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
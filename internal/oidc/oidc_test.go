@@ -0,0 +1,176 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testProvider struct {
+	*httptest.Server
+	key      *rsa.PrivateKey
+	pending  atomic.Int32
+	verifier string
+}
+
+func newTestProvider(t *testing.T) *testProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &testProvider{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                        p.URL,
+			"authorization_endpoint":        p.URL + "/authorize",
+			"token_endpoint":                p.URL + "/token",
+			"device_authorization_endpoint": p.URL + "/device",
+			"jwks_uri":                      p.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "k1",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("POST /device", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(DeviceCode{DeviceCode: "dc", UserCode: "ABCD-EFGH", VerificationURI: p.URL + "/activate", ExpiresIn: 60, Interval: 1})
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		switch r.Form.Get("grant_type") {
+		case "urn:ietf:params:oauth:grant-type:device_code":
+			if p.pending.Add(-1) >= 0 {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(TokenError{Code: "authorization_pending"})
+				return
+			}
+		case "authorization_code":
+			sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+			if r.Form.Get("code") != "code-1" || base64.RawURLEncoding.EncodeToString(sum[:]) != p.verifier {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(TokenError{Code: "invalid_grant"})
+				return
+			}
+		}
+		_ = json.NewEncoder(w).Encode(Token{IDToken: p.sign(t, map[string]any{"sub": "u1", "email": "ada@example.test", "groups": []string{"ops"}}), TokenType: "Bearer"})
+	})
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+	return p
+}
+
+func (p *testProvider) sign(t *testing.T, claims map[string]any) string {
+	t.Helper()
+	base := map[string]any{"iss": p.URL, "aud": "angee", "exp": time.Now().Add(time.Hour).Unix()}
+	for key, value := range claims {
+		base[key] = value
+	}
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "k1", "typ": "JWT"})
+	payload, _ := json.Marshal(base)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestVerifyChecksSignatureAudienceAndExpiry(t *testing.T) {
+	idp := newTestProvider(t)
+	ctx := context.Background()
+	provider, err := Discover(ctx, nil, idp.URL+"/")
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+	claims, err := provider.Verify(ctx, idp.sign(t, map[string]any{"sub": "u1", "groups": []string{"ops", "dev"}}), "angee")
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if claims.Identity() != "u1" || strings.Join(claims.Groups("groups"), ",") != "ops,dev" {
+		t.Fatalf("claims = %+v, groups %v", claims, claims.Groups("groups"))
+	}
+	for name, token := range map[string]string{
+		"audience": idp.sign(t, map[string]any{"aud": "other"}),
+		"expired":  idp.sign(t, map[string]any{"exp": time.Now().Add(-time.Hour).Unix()}),
+		"issuer":   idp.sign(t, map[string]any{"iss": "https://evil.example.test"}),
+		"tampered": tamper(idp.sign(t, map[string]any{"sub": "u1"})),
+	} {
+		if _, err := provider.Verify(ctx, token, "angee"); err == nil {
+			t.Fatalf("Verify(%s) error = nil", name)
+		}
+	}
+}
+
+func TestDeviceFlowPollsUntilApproved(t *testing.T) {
+	idp := newTestProvider(t)
+	idp.pending.Store(2)
+	ctx := context.Background()
+	provider, err := Discover(ctx, nil, idp.URL)
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+	provider.pollUnit = time.Millisecond
+	code, err := provider.DeviceAuth(ctx, "angee", nil)
+	if err != nil {
+		t.Fatalf("DeviceAuth() error = %v", err)
+	}
+	token, err := provider.PollDevice(ctx, "angee", code)
+	if err != nil {
+		t.Fatalf("PollDevice() error = %v", err)
+	}
+	claims, err := provider.Verify(ctx, token.IDToken, "angee")
+	if err != nil || claims.Identity() != "ada@example.test" {
+		t.Fatalf("Verify() = %+v, %v", claims, err)
+	}
+}
+
+func TestAuthCodeFlowSendsPKCE(t *testing.T) {
+	idp := newTestProvider(t)
+	ctx := context.Background()
+	provider, err := Discover(ctx, nil, idp.URL)
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+	verifier, challenge := NewVerifier()
+	idp.verifier = challenge
+	authURL, err := url.Parse(provider.AuthCodeURL("angee", "http://127.0.0.1:9000/auth/callback", "state-1", challenge, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q := authURL.Query(); q.Get("code_challenge") != challenge || q.Get("code_challenge_method") != "S256" || q.Get("scope") != "openid email profile" {
+		t.Fatalf("auth URL query = %v", q)
+	}
+	if _, err := provider.Exchange(ctx, "angee", "code-1", "http://127.0.0.1:9000/auth/callback", "wrong"); err == nil {
+		t.Fatal("Exchange(wrong verifier) error = nil")
+	}
+	if _, err := provider.Exchange(ctx, "angee", "code-1", "http://127.0.0.1:9000/auth/callback", verifier); err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+}
+
+func tamper(token string) string {
+	parts := strings.Split(token, ".")
+	payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	payload = []byte(strings.Replace(string(payload), `"u1"`, `"u2"`, 1))
+	parts[1] = base64.RawURLEncoding.EncodeToString(payload)
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,68 @@
+package operator
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/fyltr/angee/api"
+	"github.com/fyltr/angee/internal/manifest"
+)
+
+var (
+	corsAllowHeaders  = strings.Join([]string{"Authorization", "Content-Type", "If-Match", api.CallerHeader, api.RequestIDHeader}, ", ")
+	corsExposeHeaders = strings.Join([]string{"ETag", api.RequestIDHeader, api.NextCursorHeader}, ", ")
+)
+
+// allowedOrigin reports whether r comes from a browser origin listed in
+// operator.allowed_origins. The list is read on each call so edits to
+// angee.yaml apply without a restart.
+func (s *Server) allowedOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return false
+	}
+	stack, err := s.platform.LoadStack()
+	if err != nil || len(stack.Operator.AllowedOrigins) == 0 {
+		return false
+	}
+	return manifest.MatchOrigin(stack.Operator.AllowedOrigins, origin)
+}
+
+// cors lets the origins in operator.allowed_origins call the API from a
+// browser and answers their preflight requests. Responses never allow
+// credentials, so those calls authenticate with a bearer token; the
+// session cookie stays limited to the operator's own origin.
+func (s *Server) cors(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.allowedOrigin(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		header := w.Header()
+		header.Set("Access-Control-Allow-Origin", r.Header.Get("Origin"))
+		header.Add("Vary", "Origin")
+		header.Set("Access-Control-Expose-Headers", corsExposeHeaders)
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			header.Set("Access-Control-Allow-Methods", "GET, POST, PATCH")
+			header.Set("Access-Control-Allow-Headers", corsAllowHeaders)
+			header.Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// crossOriginHandler protects h against cross-site requests, except those
+// from an allowed origin that carry a bearer token, which a cross-site form
+// or link cannot send.
+func (s *Server) crossOriginHandler(h http.Handler) http.Handler {
+	protected := s.crossOrigin.Handler(h)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" && s.allowedOrigin(r) {
+			h.ServeHTTP(w, r)
+			return
+		}
+		protected.ServeHTTP(w, r)
+	})
+}
@@ -14,33 +14,44 @@ func writeServiceError(w http.ResponseWriter, err error) {
 }
 
 func serviceErrorResponse(err error) (int, api.ErrorResponse) {
+	code, hint := service.ErrorCode(err)
+	body := api.ErrorResponse{Code: code, Error: err.Error(), Hint: hint}
+
 	var notFound *service.NotFoundError
 	if errors.As(err, &notFound) {
-		return http.StatusNotFound, api.ErrorResponse{
-			Kind:  notFound.Kind,
-			Name:  notFound.Name,
-			Error: notFound.Error(),
-		}
+		body.Kind, body.Name, body.Error = notFound.Kind, notFound.Name, notFound.Error()
+		return http.StatusNotFound, body
 	}
 
 	var conflict *service.ConflictError
 	if errors.As(err, &conflict) {
-		return http.StatusConflict, api.ErrorResponse{
-			Kind:   conflict.Kind,
-			Name:   conflict.Name,
-			Reason: conflict.Reason,
-			Error:  conflict.Error(),
-		}
+		body.Kind, body.Name, body.Reason, body.Error = conflict.Kind, conflict.Name, conflict.Reason, conflict.Error()
+		return http.StatusConflict, body
 	}
 
 	var invalid *service.InvalidInputError
 	if errors.As(err, &invalid) {
-		return http.StatusBadRequest, api.ErrorResponse{
-			Field:  invalid.Field,
-			Reason: invalid.Reason,
-			Error:  invalid.Error(),
-		}
+		body.Field, body.Reason, body.Error = invalid.Field, invalid.Reason, invalid.Error()
+		return http.StatusBadRequest, body
+	}
+
+	var policy *service.PolicyError
+	if errors.As(err, &policy) {
+		body.Kind, body.Rule, body.Reason, body.Error = "policy", policy.Rule, policy.Reason, policy.Error()
+		return http.StatusUnprocessableEntity, body
 	}
 
-	return http.StatusInternalServerError, api.ErrorResponse{Error: err.Error()}
+	switch code {
+	case api.ErrorCodeInvalidInput:
+		return http.StatusBadRequest, body
+	case api.ErrorCodeUnauthorized:
+		return http.StatusUnauthorized, body
+	case api.ErrorCodeMissingSecret, api.ErrorCodePolicy:
+		return http.StatusUnprocessableEntity, body
+	case api.ErrorCodePortConflict:
+		return http.StatusConflict, body
+	case api.ErrorCodeBackendUnreachable:
+		return http.StatusServiceUnavailable, body
+	}
+	return http.StatusInternalServerError, body
 }
@@ -1821,6 +1821,7 @@ input KeyValueInput {
 
 input StackInitInput {
   template: String!
+  with: [String!]
   path: String
   inputs: [KeyValueInput!]
   force: Boolean
@@ -1829,6 +1830,7 @@ input StackInitInput {
 input StackRuntimeInput {
   services: [String!]
   build: Boolean
+  push: Boolean
 }
 
 input ServiceInput {
@@ -9084,7 +9086,7 @@ func (ec *executionContext) unmarshalInputStackInitInput(ctx context.Context, ob
 		asMap[k] = v
 	}
 
-	fieldsInOrder := [...]string{"template", "path", "inputs", "force"}
+	fieldsInOrder := [...]string{"template", "with", "path", "inputs", "force"}
 	for _, k := range fieldsInOrder {
 		v, ok := asMap[k]
 		if !ok {
@@ -9098,6 +9100,13 @@ func (ec *executionContext) unmarshalInputStackInitInput(ctx context.Context, ob
 				return it, err
 			}
 			it.Template = data
+		case "with":
+			ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("with"))
+			data, err := ec.unmarshalOString2ᚕstringᚄ(ctx, v)
+			if err != nil {
+				return it, err
+			}
+			it.With = data
 		case "path":
 			ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("path"))
 			data, err := ec.unmarshalOString2ᚖstring(ctx, v)
@@ -9135,7 +9144,7 @@ func (ec *executionContext) unmarshalInputStackRuntimeInput(ctx context.Context,
 		asMap[k] = v
 	}
 
-	fieldsInOrder := [...]string{"services", "build"}
+	fieldsInOrder := [...]string{"services", "build", "push"}
 	for _, k := range fieldsInOrder {
 		v, ok := asMap[k]
 		if !ok {
@@ -9156,6 +9165,13 @@ func (ec *executionContext) unmarshalInputStackRuntimeInput(ctx context.Context,
 				return it, err
 			}
 			it.Build = data
+		case "push":
+			ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("push"))
+			data, err := ec.unmarshalOBoolean2ᚖbool(ctx, v)
+			if err != nil {
+				return it, err
+			}
+			it.Push = data
 		}
 	}
 	return it, nil
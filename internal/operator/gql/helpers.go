@@ -47,7 +47,7 @@ func stackRuntimeRequest(input *model.StackRuntimeInput) api.StackRuntimeRequest
 	if input == nil {
 		return api.StackRuntimeRequest{}
 	}
-	return api.StackRuntimeRequest{Services: input.Services, Build: boolPtrValue(input.Build)}
+	return api.StackRuntimeRequest{Services: input.Services, Build: boolPtrValue(input.Build), Push: boolPtrValue(input.Push)}
 }
 
 func keyValuesFrom(values []*model.KeyValueInput) map[string]string {
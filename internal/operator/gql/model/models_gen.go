@@ -38,6 +38,7 @@ type ServiceInput struct {
 
 type StackInitInput struct {
 	Template string           `json:"template"`
+	With     []string         `json:"with,omitempty"`
 	Path     *string          `json:"path,omitempty"`
 	Inputs   []*KeyValueInput `json:"inputs,omitempty"`
 	Force    *bool            `json:"force,omitempty"`
@@ -52,6 +53,7 @@ type StackInitResult struct {
 type StackRuntimeInput struct {
 	Services []string `json:"services,omitempty"`
 	Build    *bool    `json:"build,omitempty"`
+	Push     *bool    `json:"push,omitempty"`
 }
 
 type WorkspaceCreateInput struct {
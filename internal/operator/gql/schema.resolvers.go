@@ -39,7 +39,7 @@ func (r *compiledStackResolver) SecretEnvVars(ctx context.Context, obj *service.
 
 // StackInit is the resolver for the stackInit field.
 func (r *mutationResolver) StackInit(ctx context.Context, input model.StackInitInput) (*model.StackInitResult, error) {
-	result, err := r.Platform.StackInit(ctx, input.Template, stringPtrValue(input.Path), keyValuesFrom(input.Inputs), boolPtrValue(input.Force))
+	result, err := r.Platform.StackInit(ctx, input.Template, input.With, stringPtrValue(input.Path), keyValuesFrom(input.Inputs), nil, boolPtrValue(input.Force))
 	if err != nil {
 		return nil, err
 	}
@@ -62,7 +62,7 @@ func (r *mutationResolver) StackPrepare(ctx context.Context) (*service.CompiledS
 // StackBuild is the resolver for the stackBuild field.
 func (r *mutationResolver) StackBuild(ctx context.Context, input *model.StackRuntimeInput) (*model.MutationResult, error) {
 	req := stackRuntimeRequest(input)
-	if err := r.Platform.StackBuild(ctx, req.Services); err != nil {
+	if err := r.Platform.StackBuild(ctx, req.Services, service.BuildOptions{Push: req.Push}); err != nil {
 		return nil, err
 	}
 	return actionResult("built"), nil
@@ -71,7 +71,7 @@ func (r *mutationResolver) StackBuild(ctx context.Context, input *model.StackRun
 // StackUp is the resolver for the stackUp field.
 func (r *mutationResolver) StackUp(ctx context.Context, input *model.StackRuntimeInput) (*model.MutationResult, error) {
 	req := stackRuntimeRequest(input)
-	if err := r.Platform.StackUp(ctx, req.Services, req.Build); err != nil {
+	if err := r.Platform.StackUp(ctx, req.Services, service.UpOptions{Build: req.Build}); err != nil {
 		return nil, err
 	}
 	return actionResult("started"), nil
@@ -325,7 +325,7 @@ func (r *queryResolver) GitOpsTopology(ctx context.Context) (*api.GitOpsTopology
 // StackLogs is the resolver for the stackLogs field.
 func (r *queryResolver) StackLogs(ctx context.Context, services []string, limit *int) (string, error) {
 	maxBytes := logLimitValue(limit)
-	logs, err := r.Platform.StackLogsLimited(ctx, services, false, maxBytes)
+	logs, err := r.Platform.StackLogsLimited(ctx, services, false, service.LogLimits{MaxBytes: maxBytes})
 	if err != nil {
 		return "", err
 	}
@@ -335,7 +335,7 @@ func (r *queryResolver) StackLogs(ctx context.Context, services []string, limit
 // ServiceLogs is the resolver for the serviceLogs field.
 func (r *queryResolver) ServiceLogs(ctx context.Context, name string, limit *int) (string, error) {
 	maxBytes := logLimitValue(limit)
-	logs, err := r.Platform.StackLogsLimited(ctx, []string{name}, false, maxBytes)
+	logs, err := r.Platform.StackLogsLimited(ctx, []string{name}, false, service.LogLimits{MaxBytes: maxBytes})
 	if err != nil {
 		return "", err
 	}
@@ -345,7 +345,7 @@ func (r *queryResolver) ServiceLogs(ctx context.Context, name string, limit *int
 // WorkspaceLogs is the resolver for the workspaceLogs field.
 func (r *queryResolver) WorkspaceLogs(ctx context.Context, name string, limit *int) (string, error) {
 	maxBytes := logLimitValue(limit)
-	logs, err := r.Platform.WorkspaceLogsLimited(ctx, name, false, maxBytes)
+	logs, err := r.Platform.WorkspaceLogsLimited(ctx, name, false, service.LogLimits{MaxBytes: maxBytes})
 	if err != nil {
 		return "", err
 	}
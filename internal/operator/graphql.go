@@ -82,6 +82,11 @@ func formatGraphQLError(ctx context.Context, err error) *gqlerror.Error {
 	if gqlErr.Extensions == nil {
 		gqlErr.Extensions = map[string]any{}
 	}
+	code, hint := service.ErrorCode(err)
+	gqlErr.Extensions["code"] = code
+	if hint != "" {
+		gqlErr.Extensions["hint"] = hint
+	}
 
 	var notFound *service.NotFoundError
 	if errors.As(err, &notFound) {
@@ -105,8 +110,11 @@ func formatGraphQLError(ctx context.Context, err error) *gqlerror.Error {
 		return gqlErr
 	}
 
-	if len(gqlErr.Extensions) == 0 {
-		gqlErr.Extensions = nil
+	var policy *service.PolicyError
+	if errors.As(err, &policy) {
+		gqlErr.Extensions["kind"] = "policy"
+		gqlErr.Extensions["rule"] = policy.Rule
+		gqlErr.Extensions["reason"] = policy.Reason
 	}
 	return gqlErr
 }
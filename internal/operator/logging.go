@@ -0,0 +1,140 @@
+package operator
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fyltr/angee/api"
+	"github.com/fyltr/angee/internal/service"
+)
+
+// configureLogging applies the format and level from flags, falling back to
+// operator.log in angee.yaml. A root without a readable manifest uses the
+// defaults.
+func (s *Server) configureLogging() error {
+	format, levelName := s.config.LogFormat, s.config.LogLevel
+	if stack, err := s.platform.LoadStack(); err == nil {
+		if format == "" {
+			format = stack.Operator.Log.Format
+		}
+		if levelName == "" {
+			levelName = stack.Operator.Log.Level
+		}
+	}
+	level, err := parseLogLevel(levelName)
+	if err != nil {
+		return err
+	}
+	s.logLevel.Set(level)
+	out := s.config.LogOutput
+	if out == nil {
+		out = os.Stderr
+	}
+	logger, err := newLogger(out, format, s.logLevel)
+	if err != nil {
+		return err
+	}
+	s.logger = logger
+	return nil
+}
+
+func newLogger(w io.Writer, format string, level *slog.LevelVar) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{Level: level}
+	switch format {
+	case "", "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("unsupported log format %q (want text or json)", format)
+	}
+}
+
+func parseLogLevel(value string) (slog.Level, error) {
+	var level slog.Level
+	if value == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := level.UnmarshalText([]byte(value)); err != nil {
+		return level, fmt.Errorf("unsupported log level %q (want debug, info, warn, or error)", value)
+	}
+	return level, nil
+}
+
+// logRequests logs one line per request with its ID and caller. Health
+// checks log at debug so a polling load balancer does not flood info.
+func (s *Server) logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started := time.Now()
+		id := r.Header.Get(api.RequestIDHeader)
+		if id == "" {
+			id = service.NewRequestID()
+			r.Header.Set(api.RequestIDHeader, id)
+		}
+		w.Header().Set(api.RequestIDHeader, id)
+		r = r.WithContext(service.WithRequestID(r.Context(), id))
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+		level := slog.LevelInfo
+		switch {
+		case recorder.status >= 500:
+			level = slog.LevelError
+		case r.URL.Path == "/healthz":
+			level = slog.LevelDebug
+		}
+		caller := r.Header.Get(api.CallerHeader)
+		if caller == "" {
+			caller = "operator"
+		}
+		s.logger.LogAttrs(r.Context(), level, "request",
+			slog.String("request_id", id),
+			slog.String("caller", caller),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", recorder.status),
+			slog.Int64("duration_ms", time.Since(started).Milliseconds()),
+		)
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, for
+// flushing streamed responses.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func (s *Server) logLevelGet(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, api.LogLevel{Level: strings.ToLower(s.logLevel.Level().String())})
+}
+
+func (s *Server) logLevelSet(w http.ResponseWriter, r *http.Request) {
+	req, err := decode[api.LogLevel](r)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	level, err := parseLogLevel(req.Level)
+	if err != nil || req.Level == "" {
+		writeJSON(w, http.StatusBadRequest, api.ErrorResponse{Code: api.ErrorCodeInvalidInput, Field: "level", Reason: "want debug, info, warn, or error", Error: "level: want debug, info, warn, or error"})
+		return
+	}
+	previous := s.logLevel.Level()
+	s.logLevel.Set(level)
+	s.logger.Info("log level changed", slog.String("from", strings.ToLower(previous.String())), slog.String("to", strings.ToLower(level.String())))
+	s.logLevelGet(w, r)
+}
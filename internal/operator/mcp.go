@@ -1,10 +1,12 @@
 package operator
 
-func mcpDescriptor() map[string]any {
-	return map[string]any{
-		"name":    "angee-operator",
-		"version": "0.1",
-		"tools": []string{
+import "github.com/fyltr/angee/api"
+
+func mcpDescriptor() api.MCPDescriptor {
+	return api.MCPDescriptor{
+		Name:    "angee-operator",
+		Version: "0.1",
+		Tools: []string{
 			"stack.status",
 			"stack.up",
 			"stack.down",
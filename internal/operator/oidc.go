@@ -0,0 +1,212 @@
+package operator
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fyltr/angee/api"
+	"github.com/fyltr/angee/internal/manifest"
+	"github.com/fyltr/angee/internal/oidc"
+	"github.com/fyltr/angee/internal/service"
+)
+
+// The session cookie holds the ID token the dashboard signed in with. The
+// login cookie carries the state and PKCE verifier from /auth/login to the
+// callback, which the provider reaches by a cross-site redirect, hence Lax.
+const (
+	sessionCookie = "angee_session"
+	loginCookie   = "angee_login"
+)
+
+var errNoOIDC = &service.NotFoundError{Kind: "oidc", Name: "operator"}
+
+// oidcState caches the discovered provider for the configured issuer.
+type oidcState struct {
+	mu       sync.Mutex
+	issuer   string
+	provider *oidc.Provider
+}
+
+// oidcProvider returns the stack's OIDC configuration and its provider, or
+// nil when sign-in is not configured. The configuration is read on each
+// call so edits to angee.yaml apply without a restart.
+func (s *Server) oidcProvider(ctx context.Context) (*manifest.OIDC, *oidc.Provider, error) {
+	stack, err := s.platform.LoadStack()
+	if err != nil || stack.Operator.OIDC == nil {
+		return nil, nil, err
+	}
+	config := stack.Operator.OIDC
+	s.oidc.mu.Lock()
+	defer s.oidc.mu.Unlock()
+	if s.oidc.provider == nil || s.oidc.issuer != config.Issuer {
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		provider, err := oidc.Discover(ctx, nil, config.Issuer)
+		if err != nil {
+			return nil, nil, err
+		}
+		s.oidc.issuer, s.oidc.provider = config.Issuer, provider
+	}
+	return config, s.oidc.provider, nil
+}
+
+// oidcIdentity authenticates r by an ID token, sent as the bearer token by
+// the CLI or in the session cookie by the dashboard. It returns "" without
+// an error when r carries neither. Cookies ride along on cross-site
+// requests, so a cookie is only accepted from a same-origin request.
+func (s *Server) oidcIdentity(r *http.Request) (string, error) {
+	raw, fromCookie := "", false
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && strings.Count(token, ".") == 2 {
+		raw = token
+	} else if cookie, err := r.Cookie(sessionCookie); err == nil {
+		raw, fromCookie = cookie.Value, true
+	}
+	if raw == "" {
+		return "", nil
+	}
+	config, provider, err := s.oidcProvider(r.Context())
+	if err != nil || config == nil {
+		return "", err
+	}
+	if fromCookie {
+		if err := s.crossOrigin.Check(r); err != nil {
+			return "", err
+		}
+	}
+	claims, err := provider.Verify(r.Context(), raw, config.ClientID)
+	if err != nil {
+		return "", err
+	}
+	if err := allowedGroup(config, claims); err != nil {
+		return "", err
+	}
+	return claims.Identity(), nil
+}
+
+// allowedGroup lets in members of config.AllowedGroups. Validation requires
+// the list, and an empty one still lets no one in.
+func allowedGroup(config *manifest.OIDC, claims oidc.Claims) error {
+	claim := config.GroupsClaim
+	if claim == "" {
+		claim = "groups"
+	}
+	for _, group := range claims.Groups(claim) {
+		if slices.Contains(config.AllowedGroups, group) {
+			return nil
+		}
+	}
+	return errors.New(claims.Identity() + " is not in an allowed group")
+}
+
+func (s *Server) authOIDC(w http.ResponseWriter, r *http.Request) {
+	config, _, err := s.oidcProvider(r.Context())
+	if err == nil && config == nil {
+		err = errNoOIDC
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, api.OIDCConfig{Issuer: config.Issuer, ClientID: config.ClientID, Scopes: config.Scopes})
+}
+
+func (s *Server) authLogin(w http.ResponseWriter, r *http.Request) {
+	config, provider, err := s.oidcProvider(r.Context())
+	if err == nil && config == nil {
+		err = errNoOIDC
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	state := oidc.RandomString()
+	verifier, challenge := oidc.NewVerifier()
+	http.SetCookie(w, &http.Cookie{
+		Name:     loginCookie,
+		Value:    state + "." + verifier,
+		Path:     "/auth/",
+		MaxAge:   600,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, provider.AuthCodeURL(config.ClientID, s.callbackURL(r), state, challenge, config.Scopes), http.StatusFound)
+}
+
+func (s *Server) authCallback(w http.ResponseWriter, r *http.Request) {
+	config, provider, err := s.oidcProvider(r.Context())
+	if err == nil && config == nil {
+		err = errNoOIDC
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if reason := r.URL.Query().Get("error"); reason != "" {
+		writeJSON(w, http.StatusUnauthorized, api.ErrorResponse{Code: api.ErrorCodeUnauthorized, Error: "sign-in failed: " + reason})
+		return
+	}
+	cookie, err := r.Cookie(loginCookie)
+	state, verifier, ok := strings.Cut(cookieValue(cookie, err), ".")
+	if !ok || subtle.ConstantTimeCompare([]byte(state), []byte(r.URL.Query().Get("state"))) != 1 {
+		writeJSON(w, http.StatusBadRequest, api.ErrorResponse{Code: api.ErrorCodeInvalidInput, Error: "sign-in state does not match; start again from /auth/login"})
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: loginCookie, Path: "/auth/", MaxAge: -1})
+	token, err := provider.Exchange(r.Context(), config.ClientID, r.URL.Query().Get("code"), s.callbackURL(r), verifier)
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, api.ErrorResponse{Code: api.ErrorCodeUnauthorized, Error: "sign-in failed: " + err.Error()})
+		return
+	}
+	claims, err := provider.Verify(r.Context(), token.IDToken, config.ClientID)
+	if err == nil {
+		err = allowedGroup(config, claims)
+	}
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, api.ErrorResponse{Code: api.ErrorCodeUnauthorized, Error: "sign-in failed: " + err.Error()})
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    token.IDToken,
+		Path:     "/",
+		Expires:  claims.ExpiresAt(),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+	http.Redirect(w, r, "/ui/", http.StatusFound)
+}
+
+func (s *Server) authLogout(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{Name: sessionCookie, Path: "/", MaxAge: -1})
+	writeJSON(w, http.StatusOK, map[string]string{"status": "signed out"})
+}
+
+// callbackURL is the redirect URI registered with the provider: under
+// operator.url when the stack sets it, since a proxy in front of the
+// operator changes the scheme and host, else the address the request came
+// in on.
+func (s *Server) callbackURL(r *http.Request) string {
+	if stack, err := s.platform.LoadStack(); err == nil && stack.Operator.URL != "" {
+		return strings.TrimRight(stack.Operator.URL, "/") + "/auth/callback"
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + "/auth/callback"
+}
+
+func cookieValue(cookie *http.Cookie, err error) string {
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net"
 	"net/http"
 	"os"
@@ -19,14 +20,19 @@ import (
 	"github.com/fyltr/angee/api"
 	"github.com/fyltr/angee/internal/service"
 	"github.com/fyltr/angee/internal/stackroot"
+	"github.com/fyltr/angee/internal/upgrade"
 	"github.com/spf13/cobra"
 )
 
 type Config struct {
-	Root  string
-	Bind  string
-	Port  int
-	Token string
+	Root      string
+	Bind      string
+	Port      int
+	Token     string
+	LogFormat string
+	LogLevel  string
+	// LogOutput receives operator logs; nil means stderr.
+	LogOutput io.Writer
 }
 
 type Server struct {
@@ -34,6 +40,12 @@ type Server struct {
 	platform       *service.Platform
 	graphqlHandler http.Handler
 	server         *http.Server
+	logger         *slog.Logger
+	logLevel       *slog.LevelVar
+	crossOrigin    *http.CrossOriginProtection
+	oidc           oidcState
+	upgrader       upgrade.Fetcher
+	restart        chan struct{}
 }
 
 func Execute(ctx context.Context, args []string, stdout, stderr io.Writer) error {
@@ -48,9 +60,19 @@ func Execute(ctx context.Context, args []string, stdout, stderr io.Writer) error
 			if err != nil {
 				return err
 			}
+			// Resolve the binary now: once an upgrade renames over it, the
+			// running process can no longer name its own path.
+			executable, execErr := os.Executable()
 			addr := net.JoinHostPort(config.Bind, strconv.Itoa(config.Port))
 			fmt.Fprintf(stdout, "operator listening on http://%s\n", addr)
-			return server.ListenAndServe(ctx)
+			err = server.ListenAndServe(ctx)
+			if errors.Is(err, errRestart) {
+				if execErr != nil {
+					return execErr
+				}
+				return reexec(executable)
+			}
+			return err
 		},
 	}
 	cmd.SetOut(stdout)
@@ -60,6 +82,9 @@ func Execute(ctx context.Context, args []string, stdout, stderr io.Writer) error
 	cmd.Flags().StringVar(&config.Bind, "bind", config.Bind, "listen address")
 	cmd.Flags().IntVar(&config.Port, "port", config.Port, "listen port")
 	cmd.Flags().StringVar(&config.Token, "token", config.Token, "bearer token for protected endpoints")
+	cmd.Flags().StringVar(&config.LogFormat, "log-format", "", "log format: text or json (default from operator.log in angee.yaml, else text)")
+	cmd.Flags().StringVar(&config.LogLevel, "log-level", "", "log level: debug, info, warn, or error (default from operator.log in angee.yaml, else info)")
+	config.LogOutput = stderr
 	return cmd.ExecuteContext(ctx)
 }
 
@@ -82,60 +107,102 @@ func NewServer(config Config) (*Server, error) {
 	if err != nil {
 		return nil, err
 	}
-	s := &Server{config: config, platform: platform}
+	s := &Server{config: config, platform: platform, logLevel: new(slog.LevelVar), restart: make(chan struct{}, 1)}
+	if err := s.configureLogging(); err != nil {
+		return nil, err
+	}
+	if _, err := platform.LoadStack(); err != nil {
+		s.logger.Warn("starting degraded: angee.yaml could not be loaded", slog.Any("error", err))
+	}
 	graphqlHandler, err := newGraphQLHandler(s)
 	if err != nil {
 		return nil, err
 	}
 	s.graphqlHandler = graphqlHandler
 	cop := http.NewCrossOriginProtection()
+	s.crossOrigin = cop
 	mux := http.NewServeMux()
+	// API routes live under /v1. Their unversioned paths from before the
+	// prefix remain as deprecated aliases; browser, probe, and webhook URLs
+	// that are configured elsewhere stay unversioned.
+	handleAPI := func(pattern string, h http.Handler) {
+		method, path, _ := strings.Cut(pattern, " ")
+		mux.Handle(method+" "+api.PathPrefix+path, h)
+		mux.Handle(pattern, deprecated(h))
+	}
 	mux.HandleFunc("GET /healthz", s.health)
-	mux.Handle("POST /graphql", s.auth(cop.Handler(s.graphqlHandler)))
-	mux.Handle("GET /stack/status", s.auth(http.HandlerFunc(s.stackStatus)))
-	mux.Handle("POST /stack/init", s.auth(http.HandlerFunc(s.stackInit)))
-	mux.Handle("POST /stack/update", s.auth(http.HandlerFunc(s.stackUpdate)))
-	mux.Handle("POST /stack/prepare", s.auth(http.HandlerFunc(s.stackPrepare)))
-	mux.Handle("POST /stack/build", s.auth(http.HandlerFunc(s.stackBuild)))
-	mux.Handle("POST /stack/up", s.auth(http.HandlerFunc(s.stackUp)))
-	mux.Handle("POST /stack/dev", s.auth(http.HandlerFunc(s.stackDev)))
-	mux.Handle("POST /stack/down", s.auth(http.HandlerFunc(s.stackDown)))
-	mux.Handle("POST /stack/destroy", s.auth(http.HandlerFunc(s.stackDestroy)))
-	mux.Handle("GET /stack/logs", s.auth(http.HandlerFunc(s.stackLogs)))
-	mux.Handle("GET /jobs", s.auth(http.HandlerFunc(s.jobList)))
-	mux.Handle("POST /jobs/{name}/run", s.auth(http.HandlerFunc(s.jobRun)))
-	mux.Handle("GET /jobs/{name}/logs", s.auth(http.HandlerFunc(s.jobLogs)))
-	mux.Handle("GET /services", s.auth(http.HandlerFunc(s.serviceList)))
-	mux.Handle("POST /services", s.auth(http.HandlerFunc(s.serviceInit)))
-	mux.Handle("PATCH /services/{name}", s.auth(http.HandlerFunc(s.serviceUpdate)))
-	mux.Handle("POST /services/{name}/start", s.auth(http.HandlerFunc(s.serviceStart)))
-	mux.Handle("POST /services/{name}/stop", s.auth(http.HandlerFunc(s.serviceStop)))
-	mux.Handle("POST /services/{name}/restart", s.auth(http.HandlerFunc(s.serviceRestart)))
-	mux.Handle("POST /services/{name}/destroy", s.auth(http.HandlerFunc(s.serviceDestroy)))
-	mux.Handle("GET /services/{name}/logs", s.auth(http.HandlerFunc(s.serviceLogs)))
-	mux.Handle("GET /sources", s.auth(http.HandlerFunc(s.sourceList)))
-	mux.Handle("GET /sources/{name}/status", s.auth(http.HandlerFunc(s.sourceStatus)))
-	mux.Handle("POST /sources/{name}/fetch", s.auth(http.HandlerFunc(s.sourceFetch)))
-	mux.Handle("POST /sources/{name}/pull", s.auth(http.HandlerFunc(s.sourcePull)))
-	mux.Handle("POST /sources/{name}/push", s.auth(http.HandlerFunc(s.sourcePush)))
-	mux.Handle("GET /workspaces", s.auth(http.HandlerFunc(s.workspaceList)))
-	mux.Handle("POST /workspaces", s.auth(http.HandlerFunc(s.workspaceCreate)))
-	mux.Handle("GET /workspaces/{name}", s.auth(http.HandlerFunc(s.workspaceGet)))
-	mux.Handle("PATCH /workspaces/{name}", s.auth(http.HandlerFunc(s.workspaceUpdate)))
-	mux.Handle("GET /workspaces/{name}/status", s.auth(http.HandlerFunc(s.workspaceStatus)))
-	mux.Handle("GET /workspaces/{name}/logs", s.auth(http.HandlerFunc(s.workspaceLogs)))
-	mux.Handle("POST /workspaces/{name}/start", s.auth(http.HandlerFunc(s.workspaceStart)))
-	mux.Handle("POST /workspaces/{name}/stop", s.auth(http.HandlerFunc(s.workspaceStop)))
-	mux.Handle("POST /workspaces/{name}/restart", s.auth(http.HandlerFunc(s.workspaceRestart)))
-	mux.Handle("POST /workspaces/{name}/destroy", s.auth(http.HandlerFunc(s.workspaceDestroy)))
-	mux.Handle("GET /workspaces/{name}/git", s.auth(http.HandlerFunc(s.workspaceGit)))
-	mux.Handle("POST /workspaces/{name}/push", s.auth(http.HandlerFunc(s.workspacePush)))
-	mux.Handle("POST /workspaces/{name}/sync-base", s.auth(http.HandlerFunc(s.workspaceSyncBase)))
-	mux.Handle("GET /events", s.auth(http.HandlerFunc(s.events)))
-	mux.Handle("GET /mcp", s.auth(http.HandlerFunc(s.mcp)))
+	mux.Handle("GET /ui/", uiHandler())
+	mux.Handle("GET /ui", http.RedirectHandler("/ui/", http.StatusMovedPermanently))
+	handleAPI("POST /graphql", s.auth(s.crossOriginHandler(s.graphqlHandler)))
+	handleAPI("GET /stack/status", s.auth(http.HandlerFunc(s.stackStatus)))
+	handleAPI("POST /stack/init", s.auth(http.HandlerFunc(s.stackInit)))
+	handleAPI("POST /stack/update", s.auth(http.HandlerFunc(s.stackUpdate)))
+	handleAPI("POST /stack/prepare", s.auth(http.HandlerFunc(s.stackPrepare)))
+	handleAPI("POST /stack/build", s.auth(http.HandlerFunc(s.stackBuild)))
+	handleAPI("POST /stack/up", s.auth(http.HandlerFunc(s.stackUp)))
+	handleAPI("POST /stack/dev", s.auth(http.HandlerFunc(s.stackDev)))
+	handleAPI("POST /stack/down", s.auth(http.HandlerFunc(s.stackDown)))
+	handleAPI("POST /stack/destroy", s.auth(http.HandlerFunc(s.stackDestroy)))
+	handleAPI("POST /stack/prune", s.auth(http.HandlerFunc(s.stackPrune)))
+	handleAPI("POST /stack/lock-images", s.auth(http.HandlerFunc(s.stackLockImages)))
+	handleAPI("GET /stack/outdated", s.auth(http.HandlerFunc(s.stackOutdated)))
+	handleAPI("POST /stack/plan", s.auth(http.HandlerFunc(s.stackPlan)))
+	handleAPI("GET /stack/logs", s.auth(http.HandlerFunc(s.stackLogs)))
+	handleAPI("GET /templates", s.auth(http.HandlerFunc(s.templateList)))
+	handleAPI("GET /templates/info", s.auth(http.HandlerFunc(s.templateInfo)))
+	handleAPI("GET /templates/status", s.auth(http.HandlerFunc(s.templateStatus)))
+	handleAPI("GET /jobs", s.auth(http.HandlerFunc(s.jobList)))
+	handleAPI("POST /jobs/{name}/run", s.auth(http.HandlerFunc(s.jobRun)))
+	handleAPI("GET /jobs/{name}/runs", s.auth(http.HandlerFunc(s.jobRuns)))
+	handleAPI("GET /jobs/{name}/logs", s.auth(http.HandlerFunc(s.jobLogs)))
+	handleAPI("GET /deploys", s.auth(http.HandlerFunc(s.deploys)))
+	handleAPI("GET /backups", s.auth(http.HandlerFunc(s.backupList)))
+	handleAPI("POST /backups/{service}/run", s.auth(http.HandlerFunc(s.backupRun)))
+	handleAPI("POST /backups/{service}/restore", s.auth(http.HandlerFunc(s.backupRestore)))
+	// Forges cannot send the bearer token; the delivery signature
+	// authenticates the hook instead.
+	mux.HandleFunc("POST /hooks/github", s.hookGitHub)
+	// Sign-in with the stack's OpenID provider happens before there is a
+	// credential to check.
+	handleAPI("GET /auth/oidc", http.HandlerFunc(s.authOIDC))
+	mux.HandleFunc("GET /auth/login", s.authLogin)
+	mux.HandleFunc("GET /auth/callback", s.authCallback)
+	handleAPI("POST /auth/logout", http.HandlerFunc(s.authLogout))
+	handleAPI("GET /operations/{id}", s.auth(http.HandlerFunc(s.operationGet)))
+	handleAPI("GET /services", s.auth(http.HandlerFunc(s.serviceList)))
+	handleAPI("POST /services", s.auth(http.HandlerFunc(s.serviceInit)))
+	handleAPI("PATCH /services/{name}", s.auth(http.HandlerFunc(s.serviceUpdate)))
+	handleAPI("POST /services/{name}/start", s.auth(http.HandlerFunc(s.serviceStart)))
+	handleAPI("POST /services/{name}/stop", s.auth(http.HandlerFunc(s.serviceStop)))
+	handleAPI("POST /services/{name}/restart", s.auth(http.HandlerFunc(s.serviceRestart)))
+	handleAPI("POST /services/{name}/destroy", s.auth(http.HandlerFunc(s.serviceDestroy)))
+	handleAPI("GET /services/{name}/logs", s.auth(http.HandlerFunc(s.serviceLogs)))
+	handleAPI("GET /sources", s.auth(http.HandlerFunc(s.sourceList)))
+	handleAPI("GET /sources/{name}/status", s.auth(http.HandlerFunc(s.sourceStatus)))
+	handleAPI("POST /sources/{name}/fetch", s.auth(http.HandlerFunc(s.sourceFetch)))
+	handleAPI("POST /sources/{name}/pull", s.auth(http.HandlerFunc(s.sourcePull)))
+	handleAPI("POST /sources/{name}/push", s.auth(http.HandlerFunc(s.sourcePush)))
+	handleAPI("GET /workspaces", s.auth(http.HandlerFunc(s.workspaceList)))
+	handleAPI("POST /workspaces", s.auth(http.HandlerFunc(s.workspaceCreate)))
+	handleAPI("GET /workspaces/{name}", s.auth(http.HandlerFunc(s.workspaceGet)))
+	handleAPI("PATCH /workspaces/{name}", s.auth(http.HandlerFunc(s.workspaceUpdate)))
+	handleAPI("GET /workspaces/{name}/status", s.auth(http.HandlerFunc(s.workspaceStatus)))
+	handleAPI("GET /workspaces/{name}/logs", s.auth(http.HandlerFunc(s.workspaceLogs)))
+	handleAPI("POST /workspaces/{name}/start", s.auth(http.HandlerFunc(s.workspaceStart)))
+	handleAPI("POST /workspaces/{name}/stop", s.auth(http.HandlerFunc(s.workspaceStop)))
+	handleAPI("POST /workspaces/{name}/restart", s.auth(http.HandlerFunc(s.workspaceRestart)))
+	handleAPI("POST /workspaces/{name}/destroy", s.auth(http.HandlerFunc(s.workspaceDestroy)))
+	handleAPI("GET /workspaces/{name}/git", s.auth(http.HandlerFunc(s.workspaceGit)))
+	handleAPI("POST /workspaces/{name}/push", s.auth(http.HandlerFunc(s.workspacePush)))
+	handleAPI("POST /workspaces/{name}/sync-base", s.auth(http.HandlerFunc(s.workspaceSyncBase)))
+	handleAPI("POST /upgrade", s.auth(http.HandlerFunc(s.upgrade)))
+	handleAPI("GET /loglevel", s.auth(http.HandlerFunc(s.logLevelGet)))
+	handleAPI("POST /loglevel", s.auth(http.HandlerFunc(s.logLevelSet)))
+	handleAPI("GET /events", s.auth(http.HandlerFunc(s.events)))
+	handleAPI("GET /mcp", s.auth(http.HandlerFunc(s.mcp)))
 	s.server = &http.Server{
 		Addr:              net.JoinHostPort(config.Bind, strconv.Itoa(config.Port)),
-		Handler:           mux,
+		Handler:           s.logRequests(s.cors(mux)),
 		ReadHeaderTimeout: 5 * time.Second,
 	}
 	return s, nil
@@ -149,6 +216,23 @@ func (s *Server) ListenAndServe(ctx context.Context) error {
 	signal.Notify(sigint, os.Interrupt)
 	defer signal.Stop(sigint)
 
+	schedulerCtx, stopScheduler := context.WithCancel(ctx)
+	schedulerDone := make(chan struct{})
+	go func() {
+		defer close(schedulerDone)
+		service.NewJobScheduler(s.platform).Run(schedulerCtx)
+	}()
+	alertsDone := make(chan struct{})
+	go func() {
+		defer close(alertsDone)
+		service.NewAlertMonitor(s.platform).Run(schedulerCtx)
+	}()
+	defer func() {
+		stopScheduler()
+		<-schedulerDone
+		<-alertsDone
+	}()
+
 	errCh := make(chan error, 1)
 	go func() {
 		err := s.server.ListenAndServe()
@@ -159,11 +243,13 @@ func (s *Server) ListenAndServe(ctx context.Context) error {
 		errCh <- nil
 	}()
 
-	var tearDown bool
+	var tearDown, restart bool
 	select {
 	case <-ctx.Done():
 	case <-sigint:
 		tearDown = true
+	case <-s.restart:
+		restart = true
 	case err := <-errCh:
 		return err
 	}
@@ -189,7 +275,10 @@ func (s *Server) ListenAndServe(ctx context.Context) error {
 	if tearDown {
 		s.tearDownStack()
 	}
-	return <-errCh
+	if err := <-errCh; err != nil || !restart {
+		return err
+	}
+	return errRestart
 }
 
 // tearDownStack brings the local stack down when the operator receives
@@ -202,16 +291,28 @@ func (s *Server) tearDownStack() {
 	if s.platform == nil {
 		return
 	}
-	fmt.Fprintln(os.Stderr, "operator: tearing down stack on SIGINT")
+	s.logger.Info("tearing down stack on SIGINT")
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 	if err := s.platform.StackDown(ctx); err != nil {
-		fmt.Fprintln(os.Stderr, "operator:", err)
+		s.logger.Error("stack teardown failed", slog.Any("error", err))
 	}
 }
 
+// health answers even when angee.yaml is missing or invalid: the operator
+// re-reads the manifest on every request, so fixing the file recovers the
+// stack without a restart. The load error is only shown to authorized
+// callers because /healthz itself needs no token.
 func (s *Server) health(w http.ResponseWriter, r *http.Request) {
-	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	if _, err := s.platform.LoadStack(); err != nil {
+		body := api.HealthResponse{Status: "degraded", Version: api.Version}
+		if s.authorized(r) {
+			body.ManifestError = err.Error()
+		}
+		writeJSON(w, http.StatusOK, body)
+		return
+	}
+	writeJSON(w, http.StatusOK, api.HealthResponse{Status: "ok", Version: api.Version})
 }
 
 func (s *Server) stackStatus(w http.ResponseWriter, r *http.Request) {
@@ -220,6 +321,9 @@ func (s *Server) stackStatus(w http.ResponseWriter, r *http.Request) {
 		writeError(w, err)
 		return
 	}
+	if status.Revision != "" {
+		w.Header().Set("ETag", `"`+status.Revision+`"`)
+	}
 	writeJSON(w, http.StatusOK, status)
 }
 
@@ -238,12 +342,12 @@ func (s *Server) stackInit(w http.ResponseWriter, r *http.Request) {
 		writeBadRequest(w, err)
 		return
 	}
-	result, err := s.platform.StackInit(r.Context(), req.Template, req.Path, req.Inputs, req.Force)
+	result, err := s.platform.StackInit(r.Context(), req.Template, req.With, req.Path, req.Inputs, req.Secrets, req.Force)
 	if err != nil {
 		writeError(w, err)
 		return
 	}
-	writeJSON(w, http.StatusCreated, map[string]any{"status": "initialized", "template": result.Template, "root": result.Root})
+	writeJSON(w, http.StatusCreated, api.StackInitResponse{Status: "initialized", Template: result.Template, Root: result.Root})
 }
 
 func (s *Server) stackUpdate(w http.ResponseWriter, r *http.Request) {
@@ -260,7 +364,7 @@ func (s *Server) stackBuild(w http.ResponseWriter, r *http.Request) {
 		writeBadRequest(w, err)
 		return
 	}
-	if err := s.platform.StackBuild(r.Context(), req.Services); err != nil {
+	if err := s.platform.StackBuild(r.Context(), req.Services, service.BuildOptions{Push: req.Push}); err != nil {
 		writeError(w, err)
 		return
 	}
@@ -273,7 +377,14 @@ func (s *Server) stackUp(w http.ResponseWriter, r *http.Request) {
 		writeBadRequest(w, err)
 		return
 	}
-	if err := s.platform.StackUp(r.Context(), req.Services, req.Build); err != nil {
+	opts := service.UpOptions{Build: req.Build, AutoRollback: req.AutoRollback, RollbackDepth: req.RollbackDepth, LockImages: req.LockImages, Message: req.Message, Plan: req.Plan}
+	if req.Wait != "" {
+		if opts.Wait, err = time.ParseDuration(req.Wait); err != nil {
+			writeBadRequest(w, fmt.Errorf("wait: %w", err))
+			return
+		}
+	}
+	if err := s.platform.StackUp(r.Context(), req.Services, opts); err != nil {
 		writeError(w, err)
 		return
 	}
@@ -310,13 +421,86 @@ func (s *Server) stackDestroy(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]string{"status": "destroyed"})
 }
 
+func (s *Server) stackPrune(w http.ResponseWriter, r *http.Request) {
+	req, err := decode[api.PruneRequest](r)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	resp, err := s.platform.StackPrune(r.Context(), service.PruneOptions{DryRun: r.URL.Query().Get("dry_run") == "true", Only: req.Only})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) stackLockImages(w http.ResponseWriter, r *http.Request) {
+	resp, err := s.platform.StackLockImages(r.Context(), r.URL.Query().Get("refresh") == "true")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) stackOutdated(w http.ResponseWriter, r *http.Request) {
+	resp, err := s.platform.StackOutdated(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) stackPlan(w http.ResponseWriter, r *http.Request) {
+	plan, err := s.platform.StackPlan(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, plan)
+}
+
 func (s *Server) stackLogs(w http.ResponseWriter, r *http.Request) {
-	logs, err := s.platform.StackLogs(r.Context(), r.URL.Query()["service"], false)
+	limits, err := logLimits(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	logs, err := s.platform.StackLogsLimited(r.Context(), r.URL.Query()["service"], false, limits)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeLogStream(w, logs, limits.MaxBytes)
+}
+
+func (s *Server) templateList(w http.ResponseWriter, r *http.Request) {
+	templates, err := s.platform.TemplateList(r.Context(), r.URL.Query().Get("kind"))
 	if err != nil {
 		writeError(w, err)
 		return
 	}
-	writeLogStream(w, logs)
+	writeJSON(w, http.StatusOK, templates)
+}
+
+func (s *Server) templateInfo(w http.ResponseWriter, r *http.Request) {
+	info, err := s.platform.TemplateInfo(r.Context(), r.URL.Query().Get("ref"), r.URL.Query().Get("kind"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, info)
+}
+
+func (s *Server) templateStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := s.platform.TemplateStatus(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
 }
 
 func (s *Server) serviceList(w http.ResponseWriter, r *http.Request) {
@@ -343,6 +527,15 @@ func (s *Server) jobRun(w http.ResponseWriter, r *http.Request) {
 		writeBadRequest(w, err)
 		return
 	}
+	if req.Async {
+		op, err := s.platform.JobStart(r.Context(), r.PathValue("name"), req.Inputs)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusAccepted, op)
+		return
+	}
 	out, err := s.platform.JobRun(r.Context(), r.PathValue("name"), req.Inputs)
 	if err != nil {
 		writeError(w, err)
@@ -353,6 +546,95 @@ func (s *Server) jobRun(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write(out)
 }
 
+func (s *Server) jobRuns(w http.ResponseWriter, r *http.Request) {
+	query, err := historyQuery(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	runs, next, err := s.platform.JobRunPage(r.Context(), r.PathValue("name"), query)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeHistoryPage(w, runs, next)
+}
+
+func (s *Server) deploys(w http.ResponseWriter, r *http.Request) {
+	query, err := historyQuery(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	deploys, next, err := s.platform.DeployPage(r.Context(), query)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeHistoryPage(w, deploys, next)
+}
+
+func (s *Server) backupList(w http.ResponseWriter, r *http.Request) {
+	backups, err := s.platform.BackupList(r.Context(), r.URL.Query().Get("service"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, backups)
+}
+
+func (s *Server) backupRun(w http.ResponseWriter, r *http.Request) {
+	backup, err := s.platform.BackupRun(r.Context(), r.PathValue("service"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, backup)
+}
+
+func (s *Server) backupRestore(w http.ResponseWriter, r *http.Request) {
+	req, err := decode[api.BackupRestoreRequest](r)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	if err := s.platform.BackupRestore(r.Context(), r.PathValue("service"), req.Name); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "restored"})
+}
+
+// maxHookBodyBytes matches the largest payload GitHub delivers.
+const maxHookBodyBytes = 25 << 20
+
+func (s *Server) hookGitHub(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxHookBodyBytes))
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	resp, err := s.platform.HookGitHub(r.Context(), r.Header.Get("X-GitHub-Event"), r.Header.Get("X-Hub-Signature-256"), body)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	status := http.StatusOK
+	if resp.Status == api.HookAccepted {
+		status = http.StatusAccepted
+	}
+	writeJSON(w, status, resp)
+}
+
+func (s *Server) operationGet(w http.ResponseWriter, r *http.Request) {
+	op, err := s.platform.OperationGet(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, op)
+}
+
 func (s *Server) jobLogs(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusNotImplemented, api.ErrorResponse{Error: "job logs are returned by job run"})
 }
@@ -423,12 +705,17 @@ func (s *Server) serviceDestroy(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) serviceLogs(w http.ResponseWriter, r *http.Request) {
-	logs, err := s.platform.StackLogs(r.Context(), []string{r.PathValue("name")}, false)
+	limits, err := logLimits(r)
 	if err != nil {
 		writeError(w, err)
 		return
 	}
-	writeLogStream(w, logs)
+	logs, err := s.platform.StackLogsLimited(r.Context(), []string{r.PathValue("name")}, false, limits)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeLogStream(w, logs, limits.MaxBytes)
 }
 
 func (s *Server) sourceList(w http.ResponseWriter, r *http.Request) {
@@ -537,12 +824,17 @@ func (s *Server) workspaceUpdate(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) workspaceLogs(w http.ResponseWriter, r *http.Request) {
-	logs, err := s.platform.WorkspaceLogs(r.Context(), r.PathValue("name"), false)
+	limits, err := logLimits(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	logs, err := s.platform.WorkspaceLogsLimited(r.Context(), r.PathValue("name"), false, limits)
 	if err != nil {
 		writeError(w, err)
 		return
 	}
-	writeLogStream(w, logs)
+	writeLogStream(w, logs, limits.MaxBytes)
 }
 
 func (s *Server) workspaceStart(w http.ResponseWriter, r *http.Request) {
@@ -620,10 +912,43 @@ func (s *Server) workspaceSyncBase(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, states)
 }
 
+// eventKeepalive is how often an idle /events stream sends a comment, so
+// proxies and clients can tell a quiet stack from a dropped connection.
+const eventKeepalive = 30 * time.Second
+
+// events streams the platform's notifications as server-sent events named
+// after their event type, such as deploy.succeeded, after an initial ready
+// event. IDs count the events sent on this connection.
 func (s *Server) events(w http.ResponseWriter, r *http.Request) {
+	events := s.platform.Events(r.Context())
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
+	flusher := http.NewResponseController(w)
 	_, _ = fmt.Fprint(w, "event: ready\ndata: {}\n\n")
+	_ = flusher.Flush()
+	keepalive := time.NewTicker(eventKeepalive)
+	defer keepalive.Stop()
+	for id := 1; ; {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\nid: %d\ndata: %s\n\n", event.Event, id, data); err != nil {
+				return
+			}
+			id++
+		case <-keepalive.C:
+			if _, err := io.WriteString(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+		}
+		_ = flusher.Flush()
+	}
 }
 
 func (s *Server) mcp(w http.ResponseWriter, r *http.Request) {
@@ -632,27 +957,54 @@ func (s *Server) mcp(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) auth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if s.config.Token == "" {
-			next.ServeHTTP(w, r)
-			return
+		caller := r.Header.Get(api.CallerHeader)
+		if caller == "" {
+			caller = "operator"
 		}
-		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
-		got := sha256.Sum256([]byte(token))
-		want := sha256.Sum256([]byte(s.config.Token))
-		if !ok || subtle.ConstantTimeCompare(got[:], want[:]) != 1 {
-			writeJSON(w, http.StatusUnauthorized, api.ErrorResponse{Error: "unauthorized"})
+		// Without a match for the operator token, a signed-in user's ID
+		// token authenticates the request and names the caller.
+		var identity string
+		var oidcErr error
+		if s.config.Token == "" || !s.authorized(r) {
+			identity, oidcErr = s.oidcIdentity(r)
+		}
+		switch {
+		case identity != "":
+			caller = "oidc:" + identity
+		case !s.authorized(r):
+			message := "unauthorized"
+			if oidcErr != nil {
+				message += ": " + oidcErr.Error()
+			}
+			writeJSON(w, http.StatusUnauthorized, api.ErrorResponse{Code: api.ErrorCodeUnauthorized, Error: message, Hint: "set ANGEE_OPERATOR_TOKEN to the operator token, or run `angee login`"})
 			return
 		}
-		next.ServeHTTP(w, r)
+		ctx := service.WithCaller(r.Context(), caller)
+		// If-Match carries the manifest revision the client last read;
+		// writes made by this request fail with 409 if angee.yaml moved on.
+		if revision := strings.Trim(r.Header.Get("If-Match"), `"`); revision != "" {
+			ctx = service.WithBaseRevision(ctx, revision)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+func (s *Server) authorized(r *http.Request) bool {
+	if s.config.Token == "" {
+		return true
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	got := sha256.Sum256([]byte(token))
+	want := sha256.Sum256([]byte(s.config.Token))
+	return ok && subtle.ConstantTimeCompare(got[:], want[:]) == 1
+}
+
 func writeError(w http.ResponseWriter, err error) {
 	writeServiceError(w, err)
 }
 
 func writeBadRequest(w http.ResponseWriter, err error) {
-	writeJSON(w, http.StatusBadRequest, api.ErrorResponse{Error: err.Error()})
+	writeJSON(w, http.StatusBadRequest, api.ErrorResponse{Code: api.ErrorCodeInvalidInput, Error: err.Error()})
 }
 
 func decode[T any](r *http.Request) (T, error) {
@@ -667,11 +1019,112 @@ func decode[T any](r *http.Request) (T, error) {
 	return value, nil
 }
 
-func writeLogStream(w http.ResponseWriter, logs <-chan string) {
+// maxLogBytes caps a REST log response; `max_bytes` can only lower it.
+const maxLogBytes = 1 << 20
+
+// logLimits reads the `tail` and `max_bytes` query parameters of a log
+// request.
+func logLimits(r *http.Request) (service.LogLimits, error) {
+	limits := service.LogLimits{MaxBytes: maxLogBytes}
+	for _, param := range []struct {
+		name  string
+		value *int
+	}{{"tail", &limits.Tail}, {"max_bytes", &limits.MaxBytes}} {
+		raw := r.URL.Query().Get(param.name)
+		if raw == "" {
+			continue
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			return service.LogLimits{}, &service.InvalidInputError{Field: param.name, Reason: "must be a positive integer"}
+		}
+		*param.value = n
+	}
+	limits.MaxBytes = min(limits.MaxBytes, maxLogBytes)
+	return limits, nil
+}
+
+// History responses hold defaultHistoryLimit entries unless `limit` asks
+// for another number, up to maxHistoryLimit.
+const (
+	defaultHistoryLimit = 100
+	maxHistoryLimit     = 1000
+)
+
+// historyQuery reads the `limit`, `cursor`, `since`, and `until` query
+// parameters of a history request. Times are RFC 3339.
+func historyQuery(r *http.Request) (service.HistoryQuery, error) {
+	params := r.URL.Query()
+	query := service.HistoryQuery{Limit: defaultHistoryLimit, Cursor: params.Get("cursor")}
+	if raw := params.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			return service.HistoryQuery{}, &service.InvalidInputError{Field: "limit", Reason: "must be a positive integer"}
+		}
+		query.Limit = min(n, maxHistoryLimit)
+	}
+	for _, param := range []struct {
+		name  string
+		value *time.Time
+	}{{"since", &query.Since}, {"until", &query.Until}} {
+		raw := params.Get(param.name)
+		if raw == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return service.HistoryQuery{}, &service.InvalidInputError{Field: param.name, Reason: "must be an RFC 3339 time"}
+		}
+		*param.value = t
+	}
+	return query, nil
+}
+
+func writeHistoryPage(w http.ResponseWriter, page any, next string) {
+	if next != "" {
+		w.Header().Set(api.NextCursorHeader, next)
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+// writeLogStream copies logs to w until maxBytes have been written, then
+// drains the rest and ends the response with a "[truncated]" marker.
+// Unversioned API paths were deprecated when the /v1 prefix arrived and
+// stop being served after legacySunset.
+var (
+	legacyDeprecated = time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	legacySunset     = time.Date(2027, 4, 16, 0, 0, 0, 0, time.UTC)
+)
+
+// deprecated serves h at an unversioned path, marking the response with
+// the Deprecation (RFC 9745) and Sunset (RFC 8594) headers and a Link to
+// the versioned path.
+func deprecated(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "@"+strconv.FormatInt(legacyDeprecated.Unix(), 10))
+		w.Header().Set("Sunset", legacySunset.Format(http.TimeFormat))
+		w.Header().Set("Link", "<"+api.PathPrefix+r.URL.EscapedPath()+`>; rel="successor-version"`)
+		h.ServeHTTP(w, r)
+	})
+}
+
+func writeLogStream(w http.ResponseWriter, logs <-chan string, maxBytes int) {
 	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
+	remaining := maxBytes
+	truncated := false
 	for line := range logs {
+		if truncated {
+			continue
+		}
+		if len(line) > remaining {
+			_, _ = io.WriteString(w, line[:remaining])
+			_, _ = io.WriteString(w, "\n[truncated]\n")
+			truncated = true
+			continue
+		}
 		_, _ = io.WriteString(w, line)
+		remaining -= len(line)
 	}
 }
 
@@ -2,7 +2,11 @@ package operator
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -10,9 +14,13 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/fyltr/angee/api"
+	"github.com/fyltr/angee/api/client"
 	"github.com/fyltr/angee/internal/manifest"
+	"github.com/fyltr/angee/internal/oidc"
+	"github.com/fyltr/angee/internal/service"
 )
 
 func TestNewServerRequiresTokenForNonLoopbackBind(t *testing.T) {
@@ -99,6 +107,32 @@ services:
 	}
 }
 
+func TestGraphQLStackBuildAcceptsPush(t *testing.T) {
+	root := t.TempDir()
+	writeTestStack(t, root, `version: 1
+kind: stack
+name: test
+services:
+  web:
+    runtime: local
+    command: ["true"]
+`)
+	server, err := NewServer(Config{Root: root, Bind: "127.0.0.1", Port: 9000})
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+
+	resp := doGraphQL(t, server, map[string]any{
+		"query": `mutation { stackBuild(input: {push: true}) { status } }`,
+	})
+	if len(resp.Errors) > 0 {
+		t.Fatalf("GraphQL errors = %#v", resp.Errors)
+	}
+	if result := resp.Data["stackBuild"].(map[string]any); result["status"] != "built" {
+		t.Fatalf("stackBuild = %#v, want built", result)
+	}
+}
+
 func TestGraphQLWorkspaceStatus(t *testing.T) {
 	root := t.TempDir()
 	writeTestStack(t, root, `version: 1
@@ -403,6 +437,157 @@ services:
 	}
 }
 
+func TestRESTLogLimits(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/services/web/logs?tail=20&max_bytes=4096", nil)
+	limits, err := logLimits(req)
+	if err != nil || limits.Tail != 20 || limits.MaxBytes != 4096 {
+		t.Fatalf("logLimits() = %+v, %v", limits, err)
+	}
+	req = httptest.NewRequest(http.MethodGet, "/services/web/logs?max_bytes=999999999", nil)
+	if limits, err := logLimits(req); err != nil || limits.MaxBytes != maxLogBytes {
+		t.Fatalf("logLimits() over cap = %+v, %v", limits, err)
+	}
+	req = httptest.NewRequest(http.MethodGet, "/services/web/logs?tail=-1", nil)
+	var invalid *service.InvalidInputError
+	if _, err := logLimits(req); !errors.As(err, &invalid) || invalid.Field != "tail" {
+		t.Fatalf("logLimits() negative tail error = %v", err)
+	}
+
+	logs := make(chan string, 3)
+	logs <- "one\n"
+	logs <- "two\n"
+	logs <- "three\n"
+	close(logs)
+	rr := httptest.NewRecorder()
+	writeLogStream(rr, logs, 6)
+	if got := rr.Body.String(); got != "one\ntw\n[truncated]\n" {
+		t.Fatalf("writeLogStream() = %q", got)
+	}
+}
+
+func TestRequestLoggingAndLogLevel(t *testing.T) {
+	root := t.TempDir()
+	writeTestStack(t, root, `version: 1
+kind: stack
+name: test
+operator:
+  log:
+    format: json
+    level: warn
+`)
+	var logs bytes.Buffer
+	server, err := NewServer(Config{Root: root, Bind: "127.0.0.1", Port: 9000, LogOutput: &logs})
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/loglevel", nil)
+	req.Header.Set(api.RequestIDHeader, "req-1")
+	rr := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(rr, req)
+	if got := rr.Header().Get(api.RequestIDHeader); got != "req-1" {
+		t.Fatalf("request id header = %q, want req-1", got)
+	}
+	if !strings.Contains(rr.Body.String(), `"level":"warn"`) {
+		t.Fatalf("GET /loglevel body = %s", rr.Body.String())
+	}
+	if logs.Len() != 0 {
+		t.Fatalf("info request logged at warn level: %s", logs.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/loglevel", strings.NewReader(`{"level":"verbose"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr = httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("invalid level status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/loglevel", strings.NewReader(`{"level":"info"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(api.CallerHeader, "cli:test")
+	rr = httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("POST /loglevel status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	generated := rr.Header().Get(api.RequestIDHeader)
+	if generated == "" {
+		t.Fatal("request id header was not generated")
+	}
+	var line struct {
+		Msg       string `json:"msg"`
+		RequestID string `json:"request_id"`
+		Caller    string `json:"caller"`
+		Path      string `json:"path"`
+	}
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &line); err != nil {
+		t.Fatalf("log line is not JSON: %v\n%s", err, logs.String())
+	}
+	if line.Msg != "request" || line.RequestID != generated || line.Caller != "cli:test" || line.Path != "/loglevel" {
+		t.Fatalf("request log = %#v", line)
+	}
+}
+
+func TestOperatorStartsDegradedWithBrokenManifest(t *testing.T) {
+	root := t.TempDir()
+	writeTestStack(t, root, "version: 1\nkind: stack\nname: test\nservices: [\n")
+	var logs bytes.Buffer
+	server, err := NewServer(Config{Root: root, Bind: "127.0.0.1", Port: 9000, Token: "secret", LogOutput: &logs})
+	if err != nil {
+		t.Fatalf("NewServer() error = %v, want degraded start", err)
+	}
+	if !strings.Contains(logs.String(), "starting degraded") {
+		t.Fatalf("startup log = %q, want degraded warning", logs.String())
+	}
+
+	rr := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"status":"degraded"`) || strings.Contains(rr.Body.String(), "manifest_error") {
+		t.Fatalf("anonymous GET /healthz = %d %s, want degraded without details", rr.Code, rr.Body.String())
+	}
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rr = httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(rr, req)
+	if !strings.Contains(rr.Body.String(), `"manifest_error"`) {
+		t.Fatalf("authorized GET /healthz = %s, want manifest_error", rr.Body.String())
+	}
+
+	writeTestStack(t, root, "version: 1\nkind: stack\nname: test\n")
+	rr = httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if !strings.Contains(rr.Body.String(), `"status":"ok"`) {
+		t.Fatalf("GET /healthz after fixing angee.yaml = %s, want ok", rr.Body.String())
+	}
+}
+
+func TestDashboardIsServedWithoutToken(t *testing.T) {
+	root := t.TempDir()
+	writeTestStack(t, root, "version: 1\nkind: stack\nname: test\n")
+	server, err := NewServer(Config{Root: root, Bind: "127.0.0.1", Port: 9000, Token: "secret", LogOutput: io.Discard})
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ui", nil))
+	if rr.Code != http.StatusMovedPermanently || rr.Header().Get("Location") != "/ui/" {
+		t.Fatalf("GET /ui status = %d, location = %q", rr.Code, rr.Header().Get("Location"))
+	}
+	rr = httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ui/", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "/stack/status") {
+		t.Fatalf("GET /ui/ status = %d, body = %.200s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/stack/status", nil))
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("GET /stack/status without token = %d, want 401", rr.Code)
+	}
+}
+
 func TestRESTStackInitConflictUsesTypedStatusCode(t *testing.T) {
 	root := t.TempDir()
 	writeOperatorStackTemplate(t, root)
@@ -457,11 +642,32 @@ services:
 	if !ok {
 		t.Fatalf("GraphQL error extensions = %#v, want object", errObj["extensions"])
 	}
-	if extensions["kind"] != "workspace" || extensions["name"] != "missing" {
+	if extensions["kind"] != "workspace" || extensions["name"] != "missing" || extensions["code"] != api.ErrorCodeNotFound {
 		t.Fatalf("GraphQL error extensions = %#v, want workspace missing", extensions)
 	}
 }
 
+func TestGraphQLStackInitPassesAddons(t *testing.T) {
+	root := t.TempDir()
+	writeOperatorStackTemplate(t, root)
+	server, err := NewServer(Config{Root: root, Bind: "127.0.0.1", Port: 9000})
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+
+	resp := doGraphQL(t, server, map[string]any{
+		"query": `mutation { stackInit(input: {template: "dev", with: ["missing"]}) { status } }`,
+	})
+	if len(resp.Errors) != 1 {
+		t.Fatalf("GraphQL errors = %#v, want the missing addon", resp.Errors)
+	}
+	errObj, _ := resp.Errors[0].(map[string]any)
+	extensions, _ := errObj["extensions"].(map[string]any)
+	if extensions["kind"] != "addon template" || extensions["name"] != "missing" {
+		t.Fatalf("GraphQL error = %#v, want addon template missing", resp.Errors[0])
+	}
+}
+
 func TestGraphQLServiceInit(t *testing.T) {
 	root := t.TempDir()
 	writeTestStack(t, root, `version: 1
@@ -602,6 +808,55 @@ name: test
 	}
 }
 
+func TestAllowedOriginsGetCORSAndBypassCrossOriginProtection(t *testing.T) {
+	root := t.TempDir()
+	writeTestStack(t, root, `version: 1
+kind: stack
+name: test
+operator:
+  allowed_origins: ["https://*.example.dev", "http://localhost:{3000,5173}"]
+`)
+	server, err := NewServer(Config{Root: root, Bind: "127.0.0.1", Port: 9000, Token: "secret"})
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+
+	preflight := httptest.NewRequest(http.MethodOptions, "/v1/stack/status", nil)
+	preflight.Header.Set("Origin", "https://app.example.dev")
+	preflight.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	rr := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(rr, preflight)
+	if rr.Code != http.StatusNoContent || rr.Header().Get("Access-Control-Allow-Origin") != "https://app.example.dev" || !strings.Contains(rr.Header().Get("Access-Control-Allow-Headers"), "Authorization") {
+		t.Fatalf("preflight = %d %v", rr.Code, rr.Header())
+	}
+	if rr.Header().Get("Access-Control-Allow-Credentials") != "" {
+		t.Fatal("preflight allows credentials")
+	}
+
+	for _, tc := range []struct {
+		origin string
+		want   int
+	}{
+		{"http://localhost:5173", http.StatusOK},
+		{"https://example.dev", http.StatusForbidden},
+		{"http://app.example.dev", http.StatusForbidden},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/v1/graphql", bytes.NewBufferString(`{"query":"{ health { status } }"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer secret")
+		req.Header.Set("Origin", tc.origin)
+		req.Header.Set("Sec-Fetch-Site", "cross-site")
+		rr := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(rr, req)
+		if rr.Code != tc.want {
+			t.Fatalf("GraphQL from %s status = %d, want %d", tc.origin, rr.Code, tc.want)
+		}
+		if allowed := rr.Header().Get("Access-Control-Allow-Origin") == tc.origin; allowed != (tc.want == http.StatusOK) {
+			t.Fatalf("GraphQL from %s Access-Control-Allow-Origin = %q", tc.origin, rr.Header().Get("Access-Control-Allow-Origin"))
+		}
+	}
+}
+
 func TestGraphQLBodySizeLimit(t *testing.T) {
 	root := t.TempDir()
 	writeTestStack(t, root, `version: 1
@@ -787,3 +1042,254 @@ func doGraphQL(t *testing.T, server *Server, body map[string]any) graphQLTestRes
 	}
 	return resp
 }
+
+func TestRESTAsyncJobRunReturnsPollableOperation(t *testing.T) {
+	root := t.TempDir()
+	writeTestStack(t, root, `version: 1
+kind: stack
+name: test
+jobs:
+  triage:
+    runtime: local
+    command: ["sh", "-c", "echo summary"]
+`)
+	server, err := NewServer(Config{Root: root, Bind: "127.0.0.1", Port: 9000})
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/jobs/triage/run", strings.NewReader(`{"async":true}`))
+	rr := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("async job run status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	var op api.Operation
+	if err := json.Unmarshal(rr.Body.Bytes(), &op); err != nil {
+		t.Fatalf("Unmarshal operation error = %v", err)
+	}
+	if op.ID == "" || op.Kind != "job" || op.Name != "triage" {
+		t.Fatalf("operation = %#v", op)
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for op.Status == api.OperationRunning && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		req = httptest.NewRequest(http.MethodGet, "/operations/"+op.ID, nil)
+		rr = httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("operation get status = %d, body = %s", rr.Code, rr.Body.String())
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &op); err != nil {
+			t.Fatalf("Unmarshal operation error = %v", err)
+		}
+	}
+	if op.Status != api.OperationSucceeded || op.Output != "summary\n" || op.EndedAt == nil {
+		t.Fatalf("finished operation = %#v", op)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/operations/missing", nil)
+	rr = httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("missing operation status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestEventsStreamsNotifications(t *testing.T) {
+	root := t.TempDir()
+	writeTestStack(t, root, `version: 1
+kind: stack
+name: test
+jobs:
+  check:
+    runtime: local
+    command: ["false"]
+`)
+	server, err := NewServer(Config{Root: root, Bind: "127.0.0.1", Port: 9000})
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	ts := httptest.NewServer(server.server.Handler)
+	defer ts.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	c := client.New(ts.URL)
+	events, err := c.Events(ctx)
+	if err != nil {
+		t.Fatalf("Events() error = %v", err)
+	}
+	if ready := <-events; ready.Name != "ready" {
+		t.Fatalf("first event = %#v, want ready", ready)
+	}
+	if _, err := c.JobRun(ctx, "check", nil); err == nil {
+		t.Fatal("JobRun() error is nil, want job failure")
+	}
+	event := <-events
+	var notification api.Notification
+	if err := json.Unmarshal(event.Data, &notification); err != nil {
+		t.Fatalf("event data = %s", event.Data)
+	}
+	if event.Name != manifest.EventJobFailed || event.ID != "1" || notification.Stack != "test" || notification.JobRun == nil || notification.JobRun.Job != "check" {
+		t.Fatalf("event = %#v, data %+v", event, notification)
+	}
+}
+
+func TestUpgradeRejectsInvalidVersion(t *testing.T) {
+	root := t.TempDir()
+	writeTestStack(t, root, "version: 1\nkind: stack\nname: test\n")
+	server, err := NewServer(Config{Root: root, Bind: "127.0.0.1", Port: 9000})
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	for _, body := range []string{`{}`, `{"version":"latest"}`} {
+		req := httptest.NewRequest(http.MethodPost, "/upgrade", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(rr, req)
+		if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "version") {
+			t.Fatalf("POST /upgrade %s = %d %s, want 400 naming version", body, rr.Code, rr.Body.String())
+		}
+	}
+	select {
+	case <-server.restart:
+		t.Fatal("rejected upgrade requested a restart")
+	default:
+	}
+}
+
+func TestDeploysArePaged(t *testing.T) {
+	root := t.TempDir()
+	writeTestStack(t, root, "version: 1\nkind: stack\nname: test\n")
+	var ledger strings.Builder
+	for hour := range 3 {
+		fmt.Fprintf(&ledger, `{"manifest":"sha256:%d","caller":"cli:test","status":"succeeded","started_at":"2026-05-01T0%d:00:00Z"}`+"\n", hour, hour)
+	}
+	writeTestFile(t, filepath.Join(root, "run", "deploys.jsonl"), ledger.String())
+	server, err := NewServer(Config{Root: root, Bind: "127.0.0.1", Port: 9000})
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	get := func(target string) (*httptest.ResponseRecorder, []api.Deploy) {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, target, nil))
+		var deploys []api.Deploy
+		if rr.Code == http.StatusOK {
+			if err := json.Unmarshal(rr.Body.Bytes(), &deploys); err != nil {
+				t.Fatalf("GET %s body = %s", target, rr.Body.String())
+			}
+		}
+		return rr, deploys
+	}
+
+	rr, deploys := get("/deploys?limit=2")
+	next := rr.Header().Get(api.NextCursorHeader)
+	if len(deploys) != 2 || deploys[0].Manifest != "sha256:2" || next == "" {
+		t.Fatalf("first page = %+v, next %q", deploys, next)
+	}
+	rr, deploys = get("/deploys?limit=2&cursor=" + next)
+	if len(deploys) != 1 || deploys[0].Manifest != "sha256:0" || rr.Header().Get(api.NextCursorHeader) != "" {
+		t.Fatalf("last page = %+v, next %q", deploys, rr.Header().Get(api.NextCursorHeader))
+	}
+	_, deploys = get("/deploys?since=2026-05-01T01:00:00Z&until=2026-05-01T02:00:00Z")
+	if len(deploys) != 1 || deploys[0].Manifest != "sha256:1" {
+		t.Fatalf("since/until page = %+v", deploys)
+	}
+	if rr, _ := get("/deploys?since=yesterday"); rr.Code != http.StatusBadRequest {
+		t.Fatalf("bad since = %d %s, want 400", rr.Code, rr.Body.String())
+	}
+}
+
+func TestUnversionedRoutesAreDeprecatedAliases(t *testing.T) {
+	root := t.TempDir()
+	writeTestStack(t, root, "version: 1\nkind: stack\nname: test\n")
+	server, err := NewServer(Config{Root: root, Bind: "127.0.0.1", Port: 9000})
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	get := func(target string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, target, nil))
+		return rr
+	}
+
+	rr := get("/v1/stack/status")
+	if rr.Code != http.StatusOK || rr.Header().Get("Deprecation") != "" {
+		t.Fatalf("GET /v1/stack/status = %d, Deprecation %q", rr.Code, rr.Header().Get("Deprecation"))
+	}
+	rr = get("/stack/status")
+	if rr.Code != http.StatusOK || !strings.HasPrefix(rr.Header().Get("Deprecation"), "@") || rr.Header().Get("Sunset") == "" {
+		t.Fatalf("GET /stack/status = %d, headers %v, want a deprecated alias", rr.Code, rr.Header())
+	}
+	if got := rr.Header().Get("Link"); got != `</v1/stack/status>; rel="successor-version"` {
+		t.Fatalf("Link = %q", got)
+	}
+
+	rr = get("/healthz")
+	var health api.HealthResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &health); err != nil || health.Version != api.Version || rr.Header().Get("Deprecation") != "" {
+		t.Fatalf("GET /healthz = %s, want version %s and no deprecation", rr.Body.String(), api.Version)
+	}
+}
+
+func TestAllowedGroupFailsClosed(t *testing.T) {
+	claims := oidc.Claims{Subject: "ada", Raw: map[string]any{"groups": []any{"platform-team"}}}
+	if err := allowedGroup(&manifest.OIDC{AllowedGroups: []string{"platform-team"}}, claims); err != nil {
+		t.Fatalf("allowedGroup(member) error = %v", err)
+	}
+	if err := allowedGroup(&manifest.OIDC{AllowedGroups: []string{"admins"}}, claims); err == nil {
+		t.Fatal("allowedGroup(non-member) error = nil")
+	}
+	if err := allowedGroup(&manifest.OIDC{}, claims); err == nil {
+		t.Fatal("allowedGroup(no allowed groups) error = nil, want sign-in refused")
+	}
+}
+
+func TestStackUpRejectsUnapprovedPlanFromAPI(t *testing.T) {
+	root := t.TempDir()
+	writeTestStack(t, root, `version: 1
+kind: stack
+name: test
+policy:
+  require_plan: true
+services:
+  api:
+    runtime: container
+    image: nginx:1
+`)
+	server, err := NewServer(Config{Root: root, Bind: "127.0.0.1", Port: 9000})
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	rr := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, api.PathPrefix+"/stack/plan", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("POST /stack/plan = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	var plan api.Plan
+	if err := json.Unmarshal(rr.Body.Bytes(), &plan); err != nil {
+		t.Fatalf("Unmarshal plan error = %v", err)
+	}
+	if plan.ID == "" || plan.Token != "" {
+		t.Fatalf("plan = %+v, want an ID and no approval", plan)
+	}
+
+	for _, token := range []string{plan.ID, plan.ID + ".forged"} {
+		body, _ := json.Marshal(api.StackRuntimeRequest{Plan: token})
+		rr = httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, api.PathPrefix+"/stack/up", bytes.NewReader(body)))
+		if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "plan") {
+			t.Fatalf("POST /stack/up with %q = %d, body = %s, want the plan rejected", token, rr.Code, rr.Body.String())
+		}
+	}
+}
+
+func TestScanErrorIsUnprocessable(t *testing.T) {
+	err := fmt.Errorf("deploy: %w", &service.ScanError{Severity: "high", Scans: []api.ImageScan{{Image: "nginx:1"}}})
+	status, body := serviceErrorResponse(err)
+	if status != http.StatusUnprocessableEntity || body.Code != api.ErrorCodePolicy {
+		t.Fatalf("serviceErrorResponse(scan) = %d, %+v, want 422 with code policy", status, body)
+	}
+}
@@ -0,0 +1,14 @@
+//go:build darwin || linux
+
+package operator
+
+import (
+	"os"
+	"syscall"
+)
+
+// reexec replaces this process with executable, keeping its arguments and
+// environment, so the upgraded operator comes back on the same address.
+func reexec(executable string) error {
+	return syscall.Exec(executable, os.Args, os.Environ())
+}
@@ -0,0 +1,9 @@
+//go:build windows
+
+package operator
+
+import "errors"
+
+func reexec(string) error {
+	return errors.New("the operator binary was upgraded; start it again to run the new version")
+}
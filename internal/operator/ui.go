@@ -0,0 +1,23 @@
+package operator
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// The dashboard is one static page that reads the REST API from the
+// browser, so it needs no handlers of its own and carries no data; the API
+// calls it makes go through the usual bearer-token check, or carry the
+// session cookie of a user signed in through /auth/login.
+//
+//go:embed ui
+var uiFiles embed.FS
+
+func uiHandler() http.Handler {
+	files, err := fs.Sub(uiFiles, "ui")
+	if err != nil {
+		panic(err)
+	}
+	return http.StripPrefix("/ui/", http.FileServerFS(files))
+}
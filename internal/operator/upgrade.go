@@ -0,0 +1,67 @@
+package operator
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"strings"
+
+	"github.com/fyltr/angee/api"
+	"github.com/fyltr/angee/internal/service"
+	"github.com/fyltr/angee/internal/upgrade"
+)
+
+// errRestart ends ListenAndServe after an upgrade replaced the binary, so
+// Execute runs the new one in place of this process.
+var errRestart = errors.New("operator restarting after upgrade")
+
+// upgrade replaces the operator's binary with the one from a verified
+// release bundle and restarts into it once the response is sent. Stack
+// state lives in the root and survives; in-memory operations do not. An
+// operator in a container is upgraded by recreating it from a new image
+// instead, since a swapped binary would not outlive the container.
+func (s *Server) upgrade(w http.ResponseWriter, r *http.Request) {
+	req, err := decode[api.UpgradeRequest](r)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	if !upgrade.ValidVersion(req.Version) {
+		writeError(w, &service.InvalidInputError{Field: "version", Reason: "must be a release version such as 1.4.0"})
+		return
+	}
+	if _, err := os.Stat("/.dockerenv"); err == nil {
+		writeError(w, &service.ConflictError{Kind: "operator", Name: "upgrade", Reason: "the operator runs in a container; pull the new image and recreate the container"})
+		return
+	}
+	executable, err := os.Executable()
+	if err == nil {
+		executable, err = filepath.EvalSymlinks(executable)
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	name := "angee"
+	if strings.TrimSuffix(filepath.Base(executable), ".exe") == "angee-operator" {
+		name = "angee-operator"
+	}
+	data, err := s.upgrader.Binary(r.Context(), req.Version, goruntime.GOOS, goruntime.GOARCH, name)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := upgrade.Replace(executable, data); err != nil {
+		writeError(w, err)
+		return
+	}
+	s.logger.Info("operator binary upgraded; restarting", slog.String("version", req.Version), slog.String("path", executable))
+	writeJSON(w, http.StatusAccepted, api.UpgradeResponse{Version: req.Version, Path: executable, Status: "restarting"})
+	select {
+	case s.restart <- struct{}{}:
+	default:
+	}
+}
@@ -2,15 +2,27 @@ package runtime
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"time"
 )
 
 type Target struct {
-	Root        string
-	Services    []string
-	Build       bool
+	Root     string
+	Services []string
+	Build    bool
+	// Push, for Build, pushes the built images to the registries their
+	// image names point at. A build for several platforms needs it, since
+	// the local image store holds only one platform of an image.
+	Push        bool
 	EnvFile     string
 	ControlPort int
+	// Wait, when positive, makes Up return only once the started services
+	// are running and healthy, failing if they are not within Wait.
+	Wait time.Duration
+	// NoDeps makes Up leave the dependencies of Services alone, neither
+	// starting nor recreating them.
+	NoDeps bool
 }
 
 type LogsRequest struct {
@@ -18,6 +30,7 @@ type LogsRequest struct {
 	Services    []string
 	Follow      bool
 	EnvFile     string
+	Tail        int
 	MaxBytes    int
 	ControlPort int
 }
@@ -28,6 +41,132 @@ type ServiceStatus struct {
 	State   string `json:"state"`
 }
 
+// ServiceStats is a point-in-time resource sample for one running service.
+type ServiceStats struct {
+	Name             string    `json:"name"`
+	CPUPercent       float64   `json:"cpu_percent"`
+	MemoryBytes      uint64    `json:"memory_bytes"`
+	MemoryLimitBytes uint64    `json:"memory_limit_bytes,omitempty"`
+	Restarts         int       `json:"restarts"`
+	StartedAt        time.Time `json:"started_at,omitzero"`
+	// Health is the Docker healthcheck state (starting, healthy, or
+	// unhealthy), empty for containers without one.
+	Health string `json:"health,omitempty"`
+}
+
+// StatsReporter is implemented by backends that can sample resource usage.
+type StatsReporter interface {
+	Stats(ctx context.Context, root string) ([]ServiceStats, error)
+}
+
+// ExecRequest runs Command in a running service, with Stdin and Stdout
+// connected to the command's own streams.
+type ExecRequest struct {
+	Root    string
+	Service string
+	EnvFile string
+	Command []string
+	Stdin   io.Reader
+	Stdout  io.Writer
+}
+
+// Executor is implemented by backends that can run a command inside a
+// running service.
+type Executor interface {
+	Exec(ctx context.Context, req ExecRequest) error
+}
+
+// ForwardRequest relays Conn to Port inside the network namespace of a
+// running service, so ports the service does not publish are reachable.
+type ForwardRequest struct {
+	Root    string
+	Service string
+	EnvFile string
+	Port    int
+	Conn    io.ReadWriter
+	// Image is the relay image to run, pinned by the caller; empty means
+	// the backend's default.
+	Image string
+}
+
+// Forwarder is implemented by backends that can relay a connection into a
+// running service. Forward returns when either side closes.
+type Forwarder interface {
+	Forward(ctx context.Context, req ForwardRequest) error
+}
+
+// Watcher is implemented by backends that can sync or rebuild running
+// services as their sources change. Watch runs until ctx is cancelled.
+type Watcher interface {
+	Watch(ctx context.Context, target Target, stdout io.Writer, stderr io.Writer) error
+}
+
+// Verifier is implemented by backends that can check a generated runtime
+// file before anything is started from it.
+type Verifier interface {
+	Verify(ctx context.Context, root, envFile string) error
+}
+
+// ConfigError reports a generated runtime file the runtime rejected.
+// Service names the service the problem is in, empty when the runtime's
+// message did not name one.
+type ConfigError struct {
+	Service string
+	Reason  string
+}
+
+func (e *ConfigError) Error() string {
+	if e.Service == "" {
+		return e.Reason
+	}
+	return fmt.Sprintf("service %s: %s", e.Service, e.Reason)
+}
+
+// ImageResolver is implemented by backends that can look up the digest an
+// image reference currently points to in its registry, and the digest of
+// the copy pulled locally, "" when there is none.
+type ImageResolver interface {
+	ResolveImage(ctx context.Context, image string) (string, error)
+	LocalImageDigest(ctx context.Context, image string) (string, error)
+}
+
+// Vulnerability is a known vulnerability found in a package of an image.
+type Vulnerability struct {
+	ID        string
+	Package   string
+	Installed string
+	Fixed     string
+	Severity  string
+}
+
+// ImageScanner is implemented by backends that can scan an image for known
+// vulnerabilities. Only those with one of severities (low, medium, high,
+// critical) are returned, and with ignoreUnfixed only those with a fix.
+type ImageScanner interface {
+	ScanImage(ctx context.Context, image string, severities []string, ignoreUnfixed bool) ([]Vulnerability, error)
+}
+
+// Resource is a container, volume, or network a backend created, with its
+// labels.
+type Resource struct {
+	Kind   string
+	Name   string
+	Labels map[string]string
+}
+
+const (
+	ResourceContainer = "container"
+	ResourceVolume    = "volume"
+	ResourceNetwork   = "network"
+)
+
+// Pruner is implemented by backends that can list the objects carrying a
+// label, given as key=value, and remove them.
+type Pruner interface {
+	Resources(ctx context.Context, label string) ([]Resource, error)
+	Remove(ctx context.Context, resources []Resource) error
+}
+
 type Backend interface {
 	Build(ctx context.Context, target Target) error
 	Up(ctx context.Context, target Target) error
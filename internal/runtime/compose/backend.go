@@ -2,13 +2,16 @@ package compose
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/fyltr/angee/internal/runtime"
@@ -41,6 +44,9 @@ func NewBackend() Backend {
 func (b Backend) Build(ctx context.Context, target runtime.Target) error {
 	args := b.baseArgs(target.Root, target.EnvFile)
 	args = append(args, "build")
+	if target.Push {
+		args = append(args, "--push")
+	}
 	args = append(args, target.Services...)
 	_, err := b.run(ctx, target.Root, args...)
 	return err
@@ -48,21 +54,36 @@ func (b Backend) Build(ctx context.Context, target runtime.Target) error {
 
 func (b Backend) Up(ctx context.Context, target runtime.Target) error {
 	args := b.baseArgs(target.Root, target.EnvFile)
-	args = append(args, "up", "-d")
-	if target.Build {
-		args = append(args, "--build")
-	}
-	args = append(args, target.Services...)
+	args = append(args, upArgs(target)...)
 	_, err := b.run(ctx, target.Root, args...)
 	return err
 }
 
 func (b Backend) UpForeground(ctx context.Context, target runtime.Target, stdout io.Writer, stderr io.Writer) error {
 	args := b.baseArgs(target.Root, target.EnvFile)
-	args = append(args, "up", "-d")
+	args = append(args, upArgs(target)...)
+	return b.runForeground(ctx, target.Root, stdout, stderr, args...)
+}
+
+func upArgs(target runtime.Target) []string {
+	args := []string{"up", "-d"}
 	if target.Build {
 		args = append(args, "--build")
 	}
+	if target.Wait > 0 {
+		args = append(args, "--wait", "--wait-timeout", strconv.Itoa(int(math.Ceil(target.Wait.Seconds()))))
+	}
+	if target.NoDeps {
+		args = append(args, "--no-deps")
+	}
+	return append(args, target.Services...)
+}
+
+// Watch runs `docker compose watch` on services that are already up, so
+// it only applies the develop.watch rules and does not start anything.
+func (b Backend) Watch(ctx context.Context, target runtime.Target, stdout io.Writer, stderr io.Writer) error {
+	args := b.baseArgs(target.Root, target.EnvFile)
+	args = append(args, "watch", "--no-up")
 	args = append(args, target.Services...)
 	return b.runForeground(ctx, target.Root, stdout, stderr, args...)
 }
@@ -104,6 +125,9 @@ func (b Backend) Logs(ctx context.Context, req runtime.LogsRequest) (<-chan stri
 	if req.Follow {
 		args = append(args, "--follow")
 	}
+	if req.Tail > 0 {
+		args = append(args, "--tail", strconv.Itoa(req.Tail))
+	}
 	args = append(args, req.Services...)
 	var (
 		out []byte
@@ -123,6 +147,24 @@ func (b Backend) Logs(ctx context.Context, req runtime.LogsRequest) (<-chan stri
 	return ch, nil
 }
 
+// Exec runs req.Command in the service's container without a TTY, so
+// binary output such as a database dump passes through unchanged.
+func (b Backend) Exec(ctx context.Context, req runtime.ExecRequest) error {
+	args := b.baseArgs(req.Root, req.EnvFile)
+	args = append(args, "exec", "-T", req.Service)
+	args = append(args, req.Command...)
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	cmd.Dir = req.Root
+	cmd.Stdin = req.Stdin
+	cmd.Stdout = req.Stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return runtime.ClassifyError("docker", stderr.Bytes(), fmt.Errorf("docker %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String())))
+	}
+	return nil
+}
+
 func (b Backend) Status(ctx context.Context, root string) ([]runtime.ServiceStatus, error) {
 	args := b.baseArgs(root, "")
 	args = append(args, "ps", "--format", "json")
@@ -137,7 +179,8 @@ func (b Backend) run(ctx context.Context, root string, args ...string) ([]byte,
 	if b.Runner == nil {
 		b.Runner = ExecRunner{}
 	}
-	return b.Runner.Run(ctx, root, "docker", args...)
+	out, err := b.Runner.Run(ctx, root, "docker", args...)
+	return out, runtime.ClassifyError("docker", out, err)
 }
 
 func (b Backend) runLimited(ctx context.Context, root string, maxBytes int, args ...string) ([]byte, error) {
@@ -152,7 +195,7 @@ func (b Backend) runLimited(ctx context.Context, root string, maxBytes int, args
 	cmd.Stdout = buf
 	cmd.Stderr = buf
 	if err := cmd.Run(); err != nil {
-		return buf.Bytes(), fmt.Errorf("docker %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(buf.Bytes())))
+		return buf.Bytes(), runtime.ClassifyError("docker", buf.Bytes(), fmt.Errorf("docker %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(buf.Bytes()))))
 	}
 	return buf.Bytes(), nil
 }
@@ -163,7 +206,7 @@ func (b Backend) runForeground(ctx context.Context, root string, stdout io.Write
 	cmd.Stdout = stdout
 	cmd.Stderr = stderr
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("docker %s: %w", strings.Join(args, " "), err)
+		return runtime.ClassifyError("docker", nil, fmt.Errorf("docker %s: %w", strings.Join(args, " "), err))
 	}
 	return nil
 }
@@ -2,8 +2,12 @@ package compose
 
 import (
 	"context"
+	"errors"
+	"os/exec"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/fyltr/angee/internal/runtime"
 )
@@ -23,16 +27,58 @@ func (r *recordingRunner) Run(_ context.Context, _ string, name string, args ...
 func TestBackendUpCommand(t *testing.T) {
 	runner := &recordingRunner{}
 	backend := Backend{Runner: runner}
-	err := backend.Up(context.Background(), runtime.Target{Root: "/stack", EnvFile: "/stack/.env", Services: []string{"web"}, Build: true})
+	err := backend.Up(context.Background(), runtime.Target{Root: "/stack", EnvFile: "/stack/.env", Services: []string{"web"}, Build: true, NoDeps: true})
 	if err != nil {
 		t.Fatalf("Up() error = %v", err)
 	}
-	want := []string{"compose", "-f", "/stack/docker-compose.yaml", "--env-file", "/stack/.env", "up", "-d", "--build", "web"}
+	want := []string{"compose", "-f", "/stack/docker-compose.yaml", "--env-file", "/stack/.env", "up", "-d", "--build", "--no-deps", "web"}
 	if runner.name != "docker" || !reflect.DeepEqual(runner.args, want) {
 		t.Fatalf("command = %s %v, want docker %v", runner.name, runner.args, want)
 	}
 }
 
+func TestBackendUpWaitsForHealth(t *testing.T) {
+	runner := &recordingRunner{}
+	backend := Backend{Runner: runner}
+	err := backend.Up(context.Background(), runtime.Target{Root: "/stack", Services: []string{"web"}, Wait: 90 * time.Second})
+	if err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	want := []string{"compose", "-f", "/stack/docker-compose.yaml", "up", "-d", "--wait", "--wait-timeout", "90", "web"}
+	if !reflect.DeepEqual(runner.args, want) {
+		t.Fatalf("command = %v, want %v", runner.args, want)
+	}
+}
+
+func TestBackendBuildPushes(t *testing.T) {
+	runner := &recordingRunner{}
+	backend := Backend{Runner: runner}
+	err := backend.Build(context.Background(), runtime.Target{Root: "/stack", Services: []string{"web"}, Push: true})
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	want := []string{"compose", "-f", "/stack/docker-compose.yaml", "build", "--push", "web"}
+	if !reflect.DeepEqual(runner.args, want) {
+		t.Fatalf("command = %v, want %v", runner.args, want)
+	}
+}
+
+func TestBackendLogsCommandTails(t *testing.T) {
+	runner := &recordingRunner{out: []byte("web-1  | ready\n")}
+	backend := Backend{Runner: runner}
+	logs, err := backend.Logs(context.Background(), runtime.LogsRequest{Root: "/stack", Services: []string{"web"}, Tail: 50})
+	if err != nil {
+		t.Fatalf("Logs() error = %v", err)
+	}
+	if line := <-logs; line != "web-1  | ready\n" {
+		t.Fatalf("Logs() = %q", line)
+	}
+	want := []string{"compose", "-f", "/stack/docker-compose.yaml", "logs", "--tail", "50", "web"}
+	if !reflect.DeepEqual(runner.args, want) {
+		t.Fatalf("command = %v, want %v", runner.args, want)
+	}
+}
+
 func TestParsePS(t *testing.T) {
 	got := parsePS([]byte(`{"Service":"web","State":"running"}
 {"Service":"db","State":"exited"}
@@ -41,3 +87,135 @@ func TestParsePS(t *testing.T) {
 		t.Fatalf("parsePS() = %#v", got)
 	}
 }
+
+func TestParseStats(t *testing.T) {
+	containers := parsePSContainers([]byte(`{"Service":"web","Name":"demo-web-1","State":"running"}
+{"Service":"db","Name":"demo-db-1","State":"exited"}
+`))
+	if len(containers) != 1 || containers["demo-web-1"] != "web" {
+		t.Fatalf("parsePSContainers() = %#v", containers)
+	}
+	states := parseInspect([]byte("/demo-web-1 3 2026-05-10T12:00:00.123456789Z starting\n"))
+	got := parseStats([]byte(`{"Name":"demo-web-1","CPUPerc":"12.50%","MemUsage":"256MiB / 2GiB"}
+`), containers, states)
+	started := time.Date(2026, 5, 10, 12, 0, 0, 123456789, time.UTC)
+	want := []runtime.ServiceStats{{Name: "web", CPUPercent: 12.5, MemoryBytes: 256 << 20, MemoryLimitBytes: 2 << 30, Restarts: 3, StartedAt: started, Health: "starting"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("parseStats() = %#v, want %#v", got, want)
+	}
+}
+
+func TestParseResources(t *testing.T) {
+	out := []byte("notes-old-1\tai.angee.stack=notes,ai.angee.service=old,com.docker.compose.project=notes\n\nnotes-web-1\tai.angee.stack=notes,ai.angee.service=web\n")
+	got := parseResources(runtime.ResourceContainer, out)
+	if len(got) != 2 || got[0].Name != "notes-old-1" || got[0].Labels["ai.angee.service"] != "old" || got[1].Kind != runtime.ResourceContainer {
+		t.Fatalf("parseResources() = %+v", got)
+	}
+}
+
+func TestBackendRemoveOrdersContainersFirst(t *testing.T) {
+	runner := &recordingRunner{}
+	backend := Backend{Runner: runner}
+	err := backend.Remove(context.Background(), []runtime.Resource{
+		{Kind: runtime.ResourceVolume, Name: "notes_cache"},
+		{Kind: runtime.ResourceContainer, Name: "notes-old-1"},
+	})
+	if err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if got := strings.Join(runner.args, " "); got != "volume rm notes_cache" {
+		t.Fatalf("last docker args = %q, want the volume removed last", got)
+	}
+}
+
+type failingRunner struct {
+	args []string
+	out  []byte
+}
+
+func (r *failingRunner) Run(_ context.Context, _ string, _ string, args ...string) ([]byte, error) {
+	r.args = append([]string(nil), args...)
+	return r.out, &exec.ExitError{}
+}
+
+func TestBackendVerifyNamesRejectedService(t *testing.T) {
+	for _, tc := range []struct {
+		out, service string
+	}{
+		{"validating /stack/docker-compose.yaml: services.web.ports.0 Does not match format 'ports'\n", "web"},
+		{"service \"api\" refers to undefined volume data: invalid compose project\n", "api"},
+		{"networks must be a mapping\n", ""},
+	} {
+		runner := &failingRunner{out: []byte(tc.out)}
+		err := Backend{Runner: runner}.Verify(context.Background(), "/stack", "/stack/.env")
+		want := []string{"compose", "-f", "/stack/docker-compose.yaml", "--env-file", "/stack/.env", "config", "--quiet"}
+		if !reflect.DeepEqual(runner.args, want) {
+			t.Fatalf("command = %v, want %v", runner.args, want)
+		}
+		var configErr *runtime.ConfigError
+		if !errors.As(err, &configErr) || configErr.Service != tc.service || configErr.Reason != strings.TrimSpace(tc.out) {
+			t.Fatalf("Verify() error = %#v, want service %q", err, tc.service)
+		}
+	}
+	if err := (Backend{Runner: &recordingRunner{}}).Verify(context.Background(), "/stack", ""); err != nil {
+		t.Fatalf("Verify() error = %v, want nil for a valid file", err)
+	}
+}
+
+func TestBackendResolveImageReadsManifestDigest(t *testing.T) {
+	runner := &recordingRunner{out: []byte(`{"mediaType":"application/vnd.oci.image.index.v1+json","digest":"sha256:abc","size":1609}` + "\n")}
+	digest, err := Backend{Runner: runner}.ResolveImage(context.Background(), "postgres:16")
+	if err != nil {
+		t.Fatalf("ResolveImage() error = %v", err)
+	}
+	want := []string{"buildx", "imagetools", "inspect", "--format", "{{json .Manifest}}", "postgres:16"}
+	if digest != "sha256:abc" || !reflect.DeepEqual(runner.args, want) {
+		t.Fatalf("ResolveImage() = %q via %v, want sha256:abc via %v", digest, runner.args, want)
+	}
+	if _, err := (Backend{Runner: &recordingRunner{out: []byte("{}")}}).ResolveImage(context.Background(), "postgres:16"); err == nil {
+		t.Fatal("ResolveImage() error is nil for a manifest without a digest")
+	}
+}
+
+func TestBackendLocalImageDigest(t *testing.T) {
+	runner := &recordingRunner{out: []byte(`["nginx@sha256:abc"]` + "\n")}
+	digest, err := Backend{Runner: runner}.LocalImageDigest(context.Background(), "nginx:1")
+	if err != nil || digest != "sha256:abc" {
+		t.Fatalf("LocalImageDigest() = %q, %v, want sha256:abc", digest, err)
+	}
+	missing := &failingRunner{out: []byte("Error response from daemon: No such image: nginx:1\n")}
+	if digest, err := (Backend{Runner: missing}).LocalImageDigest(context.Background(), "nginx:1"); err != nil || digest != "" {
+		t.Fatalf("LocalImageDigest(missing) = %q, %v, want no digest", digest, err)
+	}
+}
+
+func TestBackendScanImageRunsTrivy(t *testing.T) {
+	runner := &recordingRunner{out: []byte(`{"Results":[{"Target":"nginx:1 (debian 12)","Vulnerabilities":[{"VulnerabilityID":"CVE-2026-1","PkgName":"openssl","InstalledVersion":"3.0.1","FixedVersion":"3.0.2","Severity":"CRITICAL"}]},{"Target":"usr/bin/app"}]}`)}
+	found, err := Backend{Runner: runner}.ScanImage(context.Background(), "nginx:1", []string{"high", "critical"}, true)
+	if err != nil {
+		t.Fatalf("ScanImage() error = %v", err)
+	}
+	want := []string{"image", "--quiet", "--format", "json", "--severity", "HIGH,CRITICAL", "--ignore-unfixed", "nginx:1"}
+	if runner.name != "trivy" || !reflect.DeepEqual(runner.args, want) {
+		t.Fatalf("command = %s %v, want trivy %v", runner.name, runner.args, want)
+	}
+	if len(found) != 1 || found[0] != (runtime.Vulnerability{ID: "CVE-2026-1", Package: "openssl", Installed: "3.0.1", Fixed: "3.0.2", Severity: "critical"}) {
+		t.Fatalf("ScanImage() = %+v", found)
+	}
+}
+
+func TestBackendForwardNeedsRunningService(t *testing.T) {
+	runner := &recordingRunner{}
+	err := Backend{Runner: runner}.Forward(context.Background(), runtime.ForwardRequest{Root: "/stack", Service: "db", Port: 5432})
+	if err == nil || !strings.Contains(err.Error(), "db is not running") {
+		t.Fatalf("Forward() error = %v, want not running", err)
+	}
+	want := []string{"compose", "-f", "/stack/docker-compose.yaml", "ps", "-q", "db"}
+	if !reflect.DeepEqual(runner.args, want) {
+		t.Fatalf("command = %v, want %v", runner.args, want)
+	}
+	args := forwardArgs("alpine/socat@sha256:abc", "abc123", 5432)
+	if strings.Join(args, " ") != "run --rm -i --network container:abc123 alpine/socat@sha256:abc STDIO TCP:127.0.0.1:5432" {
+		t.Fatalf("forwardArgs() = %v", args)
+	}
+}
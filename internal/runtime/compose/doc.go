@@ -5,18 +5,51 @@ import "gopkg.in/yaml.v3"
 type File struct {
 	Name     string             `yaml:"name,omitempty"`
 	Services map[string]Service `yaml:"services,omitempty"`
+	Networks map[string]Network `yaml:"networks,omitempty"`
 	Volumes  map[string]Volume  `yaml:"volumes,omitempty"`
+	Secrets  map[string]Secret  `yaml:"secrets,omitempty"`
 }
 
 type Service struct {
 	Image       string                       `yaml:"image,omitempty"`
 	Build       any                          `yaml:"build,omitempty"`
+	Platform    string                       `yaml:"platform,omitempty"`
 	Command     []string                     `yaml:"command,omitempty"`
 	Environment map[string]string            `yaml:"environment,omitempty"`
 	Ports       []string                     `yaml:"ports,omitempty"`
 	Volumes     []string                     `yaml:"volumes,omitempty"`
 	WorkingDir  string                       `yaml:"working_dir,omitempty"`
 	DependsOn   map[string]ServiceDependency `yaml:"depends_on,omitempty"`
+	Labels      map[string]string            `yaml:"labels,omitempty"`
+	Logging     *Logging                     `yaml:"logging,omitempty"`
+	Healthcheck *Healthcheck                 `yaml:"healthcheck,omitempty"`
+	Develop     *Develop                     `yaml:"develop,omitempty"`
+	CPUs        string                       `yaml:"cpus,omitempty"`
+	MemLimit    string                       `yaml:"mem_limit,omitempty"`
+}
+
+type Develop struct {
+	Watch []Watch `yaml:"watch,omitempty"`
+}
+
+type Watch struct {
+	Action string   `yaml:"action"`
+	Path   string   `yaml:"path"`
+	Target string   `yaml:"target,omitempty"`
+	Ignore []string `yaml:"ignore,omitempty"`
+}
+
+type Healthcheck struct {
+	Test        []string `yaml:"test"`
+	Interval    string   `yaml:"interval,omitempty"`
+	Timeout     string   `yaml:"timeout,omitempty"`
+	Retries     int      `yaml:"retries,omitempty"`
+	StartPeriod string   `yaml:"start_period,omitempty"`
+}
+
+type Logging struct {
+	Driver  string            `yaml:"driver,omitempty"`
+	Options map[string]string `yaml:"options,omitempty"`
 }
 
 type ServiceDependency struct {
@@ -24,8 +57,19 @@ type ServiceDependency struct {
 }
 
 type Volume struct {
-	Driver string `yaml:"driver,omitempty"`
-	Name   string `yaml:"name,omitempty"`
+	Driver string            `yaml:"driver,omitempty"`
+	Name   string            `yaml:"name,omitempty"`
+	Labels map[string]string `yaml:"labels,omitempty"`
+}
+
+type Network struct {
+	Labels map[string]string `yaml:"labels,omitempty"`
+}
+
+// Secret is a top-level compose secret read from an environment variable,
+// which compose takes from its --env-file. Builds mount it with BuildKit.
+type Secret struct {
+	Environment string `yaml:"environment,omitempty"`
 }
 
 func Marshal(file File) ([]byte, error) {
@@ -0,0 +1,66 @@
+package compose
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/fyltr/angee/internal/runtime"
+)
+
+// ForwardImage is the default image that relays a forwarded connection
+// from inside the service's network namespace, where the service's
+// unpublished ports are on localhost.
+const ForwardImage = "alpine/socat"
+
+// Forward runs a throwaway socat container that shares the network
+// namespace of the service's container and pipes req.Conn to req.Port
+// there. Each connection gets its own container. The caller closes
+// req.Conn once Forward returns.
+func (b Backend) Forward(ctx context.Context, req runtime.ForwardRequest) error {
+	args := b.baseArgs(req.Root, req.EnvFile)
+	args = append(args, "ps", "-q", req.Service)
+	out, err := b.run(ctx, req.Root, args...)
+	if err != nil {
+		return err
+	}
+	id, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	if id == "" {
+		return fmt.Errorf("service %s is not running", req.Service)
+	}
+	image := req.Image
+	if image == "" {
+		image = ForwardImage
+	}
+	args = forwardArgs(image, id, req.Port)
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	cmd.Dir = req.Root
+	cmd.Stdout = req.Conn
+	cmd.Stderr = &stderr
+	// Copying stdin by hand rather than through cmd.Stdin lets Forward
+	// return once socat exits, without waiting for the client to close.
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go func() {
+		_, _ = io.Copy(stdin, req.Conn)
+		stdin.Close()
+	}()
+	if err := cmd.Wait(); err != nil {
+		return runtime.ClassifyError("docker", stderr.Bytes(), fmt.Errorf("docker %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String())))
+	}
+	return nil
+}
+
+func forwardArgs(image, container string, port int) []string {
+	return []string{"run", "--rm", "-i", "--network", "container:" + container, image, "STDIO", "TCP:127.0.0.1:" + strconv.Itoa(port)}
+}
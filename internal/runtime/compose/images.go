@@ -0,0 +1,50 @@
+package compose
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ResolveImage asks the registry for the digest image points to. For a
+// multi-platform image it is the digest of the index, so a pinned
+// reference still runs on every platform the image was built for.
+func (b Backend) ResolveImage(ctx context.Context, image string) (string, error) {
+	out, err := b.run(ctx, "", "buildx", "imagetools", "inspect", "--format", "{{json .Manifest}}", image)
+	if err != nil {
+		return "", err
+	}
+	var manifest struct {
+		Digest string `json:"digest"`
+	}
+	if err := json.Unmarshal(out, &manifest); err != nil {
+		return "", fmt.Errorf("image %s: reading the registry manifest: %w", image, err)
+	}
+	if !strings.HasPrefix(manifest.Digest, "sha256:") {
+		return "", fmt.Errorf("image %s: the registry returned no digest", image)
+	}
+	return manifest.Digest, nil
+}
+
+// LocalImageDigest returns the registry digest the local copy of image was
+// pulled as, "" when it is not pulled or was built here.
+func (b Backend) LocalImageDigest(ctx context.Context, image string) (string, error) {
+	out, err := b.run(ctx, "", "image", "inspect", "--format", "{{json .RepoDigests}}", image)
+	if err != nil {
+		if strings.Contains(string(out), "No such image") {
+			return "", nil
+		}
+		return "", err
+	}
+	var digests []string
+	if err := json.Unmarshal(out, &digests); err != nil {
+		return "", fmt.Errorf("image %s: reading local digests: %w", image, err)
+	}
+	for _, ref := range digests {
+		if _, digest, ok := strings.Cut(ref, "@"); ok {
+			return digest, nil
+		}
+	}
+	return "", nil
+}
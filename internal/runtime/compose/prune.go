@@ -0,0 +1,77 @@
+package compose
+
+import (
+	"bufio"
+	"context"
+	"strings"
+
+	"github.com/fyltr/angee/internal/runtime"
+)
+
+// Resources lists the containers, volumes, and networks carrying label,
+// stopped containers included.
+func (b Backend) Resources(ctx context.Context, label string) ([]runtime.Resource, error) {
+	var resources []runtime.Resource
+	for _, list := range []struct {
+		kind string
+		args []string
+	}{
+		{runtime.ResourceContainer, []string{"ps", "--all", "--format", "{{.Names}}\t{{.Labels}}"}},
+		{runtime.ResourceVolume, []string{"volume", "ls", "--format", "{{.Name}}\t{{.Labels}}"}},
+		{runtime.ResourceNetwork, []string{"network", "ls", "--format", "{{.Name}}\t{{.Labels}}"}},
+	} {
+		out, err := b.run(ctx, "", append(list.args, "--filter", "label="+label)...)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, parseResources(list.kind, out)...)
+	}
+	return resources, nil
+}
+
+// Remove deletes resources: containers first, since they hold the volumes
+// and networks.
+func (b Backend) Remove(ctx context.Context, resources []runtime.Resource) error {
+	for _, remove := range []struct {
+		kind string
+		args []string
+	}{
+		{runtime.ResourceContainer, []string{"rm", "--force", "--volumes"}},
+		{runtime.ResourceNetwork, []string{"network", "rm"}},
+		{runtime.ResourceVolume, []string{"volume", "rm"}},
+	} {
+		args := remove.args
+		for _, resource := range resources {
+			if resource.Kind == remove.kind {
+				args = append(args, resource.Name)
+			}
+		}
+		if len(args) == len(remove.args) {
+			continue
+		}
+		if _, err := b.run(ctx, "", args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseResources reads the "<name>\t<k=v,k=v>" lines of a listing.
+func parseResources(kind string, data []byte) []runtime.Resource {
+	var resources []runtime.Resource
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		name, labels, _ := strings.Cut(strings.TrimSpace(scanner.Text()), "\t")
+		if name == "" {
+			continue
+		}
+		resource := runtime.Resource{Kind: kind, Name: name, Labels: map[string]string{}}
+		for _, pair := range strings.Split(labels, ",") {
+			if key, value, ok := strings.Cut(pair, "="); ok {
+				resource.Labels[key] = value
+			}
+		}
+		resources = append(resources, resource)
+	}
+	return resources
+}
@@ -0,0 +1,54 @@
+package compose
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/fyltr/angee/internal/runtime"
+)
+
+// ScanImage scans image with Trivy, which must be on PATH. Trivy reads the
+// local copy when Docker has one and pulls from the registry otherwise.
+func (b Backend) ScanImage(ctx context.Context, image string, severities []string, ignoreUnfixed bool) ([]runtime.Vulnerability, error) {
+	if b.Runner == nil {
+		b.Runner = ExecRunner{}
+	}
+	args := []string{"image", "--quiet", "--format", "json", "--severity", strings.ToUpper(strings.Join(severities, ","))}
+	if ignoreUnfixed {
+		args = append(args, "--ignore-unfixed")
+	}
+	args = append(args, image)
+	out, err := b.Runner.Run(ctx, "", "trivy", args...)
+	if err != nil {
+		return nil, runtime.ClassifyError("trivy", out, err)
+	}
+	var report struct {
+		Results []struct {
+			Vulnerabilities []struct {
+				VulnerabilityID  string
+				PkgName          string
+				InstalledVersion string
+				FixedVersion     string
+				Severity         string
+			}
+		}
+	}
+	if err := json.Unmarshal(out, &report); err != nil {
+		return nil, fmt.Errorf("image %s: reading the trivy report: %w", image, err)
+	}
+	var found []runtime.Vulnerability
+	for _, result := range report.Results {
+		for _, v := range result.Vulnerabilities {
+			found = append(found, runtime.Vulnerability{
+				ID:        v.VulnerabilityID,
+				Package:   v.PkgName,
+				Installed: v.InstalledVersion,
+				Fixed:     v.FixedVersion,
+				Severity:  strings.ToLower(v.Severity),
+			})
+		}
+	}
+	return found, nil
+}
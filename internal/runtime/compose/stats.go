@@ -0,0 +1,167 @@
+package compose
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fyltr/angee/internal/runtime"
+)
+
+// Stats samples CPU, memory, restart counts, start times, and health for
+// the stack's running containers with one `docker stats --no-stream` and one
+// `docker inspect`.
+func (b Backend) Stats(ctx context.Context, root string) ([]runtime.ServiceStats, error) {
+	args := b.baseArgs(root, "")
+	args = append(args, "ps", "--format", "json")
+	out, err := b.run(ctx, root, args...)
+	if err != nil {
+		return nil, err
+	}
+	containers := parsePSContainers(out)
+	if len(containers) == 0 {
+		return nil, nil
+	}
+	names := make([]string, 0, len(containers))
+	for name := range containers {
+		names = append(names, name)
+	}
+	statsOut, err := b.run(ctx, root, append([]string{"stats", "--no-stream", "--format", "{{json .}}"}, names...)...)
+	if err != nil {
+		return nil, err
+	}
+	inspectOut, err := b.run(ctx, root, append([]string{"inspect", "--format", "{{.Name}} {{.RestartCount}} {{.State.StartedAt}} {{if .State.Health}}{{.State.Health.Status}}{{end}}"}, names...)...)
+	if err != nil {
+		return nil, err
+	}
+	return parseStats(statsOut, containers, parseInspect(inspectOut)), nil
+}
+
+// parsePSContainers maps running container names to compose service names.
+func parsePSContainers(data []byte) map[string]string {
+	containers := map[string]string{}
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var one struct {
+			Service string `json:"Service"`
+			Name    string `json:"Name"`
+			State   string `json:"State"`
+		}
+		if err := json.Unmarshal([]byte(line), &one); err != nil {
+			continue
+		}
+		if one.Name == "" || one.Service == "" || one.State != "running" {
+			continue
+		}
+		containers[one.Name] = one.Service
+	}
+	return containers
+}
+
+type containerState struct {
+	restarts  int
+	startedAt time.Time
+	health    string
+}
+
+// parseInspect reads "<name> <restart count> <started at> [<health>]" lines;
+// health is only present for containers with a healthcheck.
+func parseInspect(data []byte) map[string]containerState {
+	states := map[string]containerState{}
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		n, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		state := containerState{restarts: n}
+		if len(fields) > 2 {
+			state.startedAt, _ = time.Parse(time.RFC3339Nano, fields[2])
+		}
+		if len(fields) > 3 {
+			state.health = fields[3]
+		}
+		states[strings.TrimPrefix(fields[0], "/")] = state
+	}
+	return states
+}
+
+func parseStats(data []byte, containers map[string]string, states map[string]containerState) []runtime.ServiceStats {
+	var stats []runtime.ServiceStats
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var one struct {
+			Name     string `json:"Name"`
+			CPUPerc  string `json:"CPUPerc"`
+			MemUsage string `json:"MemUsage"`
+		}
+		if err := json.Unmarshal([]byte(line), &one); err != nil {
+			continue
+		}
+		service, ok := containers[one.Name]
+		if !ok {
+			continue
+		}
+		cpu, _ := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(one.CPUPerc), "%"), 64)
+		usage, limit, _ := strings.Cut(one.MemUsage, "/")
+		memory, _ := parseByteSize(usage)
+		memoryLimit, _ := parseByteSize(limit)
+		stats = append(stats, runtime.ServiceStats{
+			Name:             service,
+			CPUPercent:       cpu,
+			MemoryBytes:      memory,
+			MemoryLimitBytes: memoryLimit,
+			Restarts:         states[one.Name].restarts,
+			StartedAt:        states[one.Name].startedAt,
+			Health:           states[one.Name].health,
+		})
+	}
+	return stats
+}
+
+var byteUnits = map[string]float64{
+	"B":   1,
+	"kB":  1e3,
+	"KB":  1e3,
+	"MB":  1e6,
+	"GB":  1e9,
+	"TB":  1e12,
+	"KiB": 1 << 10,
+	"MiB": 1 << 20,
+	"GiB": 1 << 30,
+	"TiB": 1 << 40,
+}
+
+// parseByteSize parses docker's human-readable sizes such as "12.5MiB".
+func parseByteSize(text string) (uint64, error) {
+	text = strings.TrimSpace(text)
+	i := strings.IndexFunc(text, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i <= 0 {
+		return 0, fmt.Errorf("invalid size %q", text)
+	}
+	value, err := strconv.ParseFloat(text[:i], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: %w", text, err)
+	}
+	unit, ok := byteUnits[strings.TrimSpace(text[i:])]
+	if !ok {
+		return 0, fmt.Errorf("invalid size unit in %q", text)
+	}
+	return uint64(value * unit), nil
+}
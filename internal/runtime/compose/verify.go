@@ -0,0 +1,42 @@
+package compose
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/fyltr/angee/internal/runtime"
+)
+
+// configService finds the service a `docker compose config` error is about,
+// whether it names the path (services.web.ports.0) or the service
+// (service "web" refers to undefined volume data).
+var configService = regexp.MustCompile(`services\.([A-Za-z0-9_-]+)|service "([^"]+)"`)
+
+// Verify runs `docker compose config` on the generated file, so a field
+// combination Compose does not accept fails before anything is started.
+func (b Backend) Verify(ctx context.Context, root, envFile string) error {
+	args := b.baseArgs(root, envFile)
+	args = append(args, "config", "--quiet")
+	out, err := b.run(ctx, root, args...)
+	var command *runtime.CommandError
+	if !errors.As(err, &command) {
+		return err
+	}
+	return parseConfigError(out, err)
+}
+
+// parseConfigError turns the output of a failed `docker compose config`
+// into a ConfigError, falling back to err when there is no message.
+func parseConfigError(out []byte, err error) error {
+	reason := strings.TrimSpace(string(out))
+	if reason == "" {
+		return err
+	}
+	configErr := &runtime.ConfigError{Reason: reason}
+	if match := configService.FindStringSubmatch(reason); match != nil {
+		configErr.Service = match[1] + match[2]
+	}
+	return configErr
+}
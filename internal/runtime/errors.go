@@ -0,0 +1,88 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// PortConflictError reports a host port the runtime could not publish
+// because something else holds it. Port is 0 when the output did not name
+// it.
+type PortConflictError struct {
+	Port int
+	Err  error
+}
+
+func (e *PortConflictError) Error() string {
+	if e.Port == 0 {
+		return fmt.Sprintf("host port is already in use: %v", e.Err)
+	}
+	return fmt.Sprintf("host port %d is already in use: %v", e.Port, e.Err)
+}
+
+func (e *PortConflictError) Unwrap() error { return e.Err }
+
+// UnreachableError reports a runtime that could not be driven at all: its
+// binary is not installed or, for Docker, the daemon is not answering.
+type UnreachableError struct {
+	Runtime string
+	Err     error
+}
+
+func (e *UnreachableError) Error() string {
+	return fmt.Sprintf("%s is unreachable: %v", e.Runtime, e.Err)
+}
+
+func (e *UnreachableError) Unwrap() error { return e.Err }
+
+// CommandError reports a runtime command that ran and failed, such as a
+// service that would not start or an image that would not build.
+type CommandError struct {
+	Runtime string
+	Err     error
+}
+
+func (e *CommandError) Error() string { return e.Err.Error() }
+
+func (e *CommandError) Unwrap() error { return e.Err }
+
+var (
+	portInUse = regexp.MustCompile(`(?:Bind for \S*:(\d+) failed: port is already allocated|listen tcp \S*:(\d+): bind: address already in use|port is already allocated|address already in use)`)
+
+	unreachableOutput = []string{
+		"Cannot connect to the Docker daemon",
+		"error during connect",
+		"docker daemon is not running",
+	}
+)
+
+// ClassifyError wraps err, from a command of the named runtime that
+// printed output, in PortConflictError or UnreachableError when the output
+// shows one of those failures. Other errors are returned unchanged.
+func ClassifyError(runtimeName string, output []byte, err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, exec.ErrNotFound) {
+		return &UnreachableError{Runtime: runtimeName, Err: err}
+	}
+	text := string(output)
+	if match := portInUse.FindStringSubmatch(text); match != nil {
+		port, _ := strconv.Atoi(match[1] + match[2])
+		return &PortConflictError{Port: port, Err: err}
+	}
+	for _, marker := range unreachableOutput {
+		if strings.Contains(text, marker) {
+			return &UnreachableError{Runtime: runtimeName, Err: err}
+		}
+	}
+	var exit *exec.ExitError
+	if errors.As(err, &exit) {
+		return &CommandError{Runtime: runtimeName, Err: err}
+	}
+	return err
+}
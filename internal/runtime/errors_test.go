@@ -0,0 +1,38 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"testing"
+)
+
+func TestClassifyError(t *testing.T) {
+	failed := errors.New("exit status 1")
+	var conflict *PortConflictError
+	err := ClassifyError("docker", []byte("Error response from daemon: driver failed programming external connectivity: Bind for 0.0.0.0:8080 failed: port is already allocated"), failed)
+	if !errors.As(err, &conflict) || conflict.Port != 8080 || !errors.Is(err, failed) {
+		t.Fatalf("ClassifyError(port) = %#v", err)
+	}
+	err = ClassifyError("docker", []byte("listen tcp 127.0.0.1:5432: bind: address already in use"), failed)
+	if !errors.As(err, &conflict) || conflict.Port != 5432 {
+		t.Fatalf("ClassifyError(listen) = %#v", err)
+	}
+	var unreachable *UnreachableError
+	err = ClassifyError("docker", []byte("Cannot connect to the Docker daemon at unix:///var/run/docker.sock. Is the docker daemon running?"), failed)
+	if !errors.As(err, &unreachable) || unreachable.Runtime != "docker" {
+		t.Fatalf("ClassifyError(daemon) = %#v", err)
+	}
+	if err := ClassifyError("process-compose", nil, fmt.Errorf("start: %w", exec.ErrNotFound)); !errors.As(err, &unreachable) {
+		t.Fatalf("ClassifyError(not found) = %#v", err)
+	}
+	if err := ClassifyError("docker", []byte("no such service: web"), failed); err != failed {
+		t.Fatalf("ClassifyError(other) = %#v, want the error unchanged", err)
+	}
+	exitErr := exec.Command("sh", "-c", "exit 3").Run()
+	var command *CommandError
+	err = ClassifyError("docker", []byte("no such service: web"), fmt.Errorf("docker compose up: %w", exitErr))
+	if !errors.As(err, &command) || command.Runtime != "docker" || err.Error() != "docker compose up: exit status 3" {
+		t.Fatalf("ClassifyError(exit) = %#v, want a CommandError with the same text", err)
+	}
+}
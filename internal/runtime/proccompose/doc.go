@@ -8,10 +8,41 @@ type File struct {
 }
 
 type Process struct {
-	Command     string                       `yaml:"command,omitempty"`
-	Environment []string                     `yaml:"environment,omitempty"`
-	WorkingDir  string                       `yaml:"working_dir,omitempty"`
-	DependsOn   map[string]ProcessDependency `yaml:"depends_on,omitempty"`
+	Command      string                       `yaml:"command,omitempty"`
+	Environment  []string                     `yaml:"environment,omitempty"`
+	WorkingDir   string                       `yaml:"working_dir,omitempty"`
+	DependsOn    map[string]ProcessDependency `yaml:"depends_on,omitempty"`
+	Readiness    *Probe                       `yaml:"readiness_probe,omitempty"`
+	Liveness     *Probe                       `yaml:"liveness_probe,omitempty"`
+	Availability *Availability                `yaml:"availability,omitempty"`
+}
+
+type Probe struct {
+	Exec                *ExecProbe `yaml:"exec,omitempty"`
+	HTTPGet             *HTTPProbe `yaml:"http_get,omitempty"`
+	InitialDelaySeconds int        `yaml:"initial_delay_seconds,omitempty"`
+	PeriodSeconds       int        `yaml:"period_seconds,omitempty"`
+	TimeoutSeconds      int        `yaml:"timeout_seconds,omitempty"`
+	SuccessThreshold    int        `yaml:"success_threshold,omitempty"`
+	FailureThreshold    int        `yaml:"failure_threshold,omitempty"`
+}
+
+type ExecProbe struct {
+	Command    string `yaml:"command"`
+	WorkingDir string `yaml:"working_dir,omitempty"`
+}
+
+type HTTPProbe struct {
+	Host   string `yaml:"host"`
+	Scheme string `yaml:"scheme,omitempty"`
+	Path   string `yaml:"path,omitempty"`
+	Port   int    `yaml:"port,omitempty"`
+}
+
+// Availability is set for processes with a liveness probe, which
+// process-compose only restarts under a restart policy.
+type Availability struct {
+	Restart string `yaml:"restart"`
 }
 
 type ProcessDependency struct {
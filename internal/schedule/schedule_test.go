@@ -0,0 +1,53 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRejectsInvalidExpressions(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "*/0 * * * *", "5-1 * * * *", "x * * * *"} {
+		if _, err := Parse(expr); err == nil {
+			t.Fatalf("Parse(%q) error = nil, want error", expr)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	base := time.Date(2026, 5, 10, 12, 34, 56, 0, time.UTC) // Sunday
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, 5, 10, 12, 35, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 5, 10, 12, 45, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2026, 5, 11, 3, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, 5, 10, 13, 0, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2026, 5, 11, 9, 0, 0, 0, time.UTC)},
+		{"30 12 * * 7", time.Date(2026, 5, 17, 12, 30, 0, 0, time.UTC)},
+		{"0 0 1 1 *", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 13 * 5", time.Date(2026, 5, 13, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.expr, err)
+		}
+		if got := s.Next(base); !got.Equal(tt.want) {
+			t.Fatalf("Parse(%q).Next() = %s, want %s", tt.expr, got, tt.want)
+		}
+		if !s.Matches(tt.want) {
+			t.Fatalf("Parse(%q).Matches(%s) = false, want true", tt.expr, tt.want)
+		}
+	}
+}
+
+func TestNextImpossibleSchedule(t *testing.T) {
+	s, err := Parse("0 0 30 2 *")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got := s.Next(time.Now()); !got.IsZero() {
+		t.Fatalf("Next() = %s, want zero time", got)
+	}
+}
@@ -7,6 +7,30 @@ import (
 	"github.com/fyltr/angee/internal/manifest"
 )
 
+// MissingError reports a required secret with no stored value, nothing to
+// import it from, and no generator.
+type MissingError struct {
+	Name string
+}
+
+func (e *MissingError) Error() string {
+	return fmt.Sprintf("required secret %q is missing", e.Name)
+}
+
+// UnreachableError reports a secrets backend that could not be contacted,
+// as opposed to one that answered with an error.
+type UnreachableError struct {
+	Backend string
+	Address string
+	Err     error
+}
+
+func (e *UnreachableError) Error() string {
+	return fmt.Sprintf("%s at %s is unreachable: %v", e.Backend, e.Address, e.Err)
+}
+
+func (e *UnreachableError) Unwrap() error { return e.Err }
+
 type Backend interface {
 	Get(ctx context.Context, key string) (string, bool, error)
 	Set(ctx context.Context, key, value string) error
@@ -14,6 +38,30 @@ type Backend interface {
 	List(ctx context.Context) ([]string, error)
 }
 
+// BatchGetter is implemented by backends that can read many secrets faster
+// than one Get at a time. GetMany returns the keys that exist.
+type BatchGetter interface {
+	GetMany(ctx context.Context, keys []string) (map[string]string, error)
+}
+
+// getMany reads keys through the backend's GetMany when it has one.
+func getMany(ctx context.Context, backend Backend, keys []string) (map[string]string, error) {
+	if batch, ok := backend.(BatchGetter); ok {
+		return batch.GetMany(ctx, keys)
+	}
+	values := make(map[string]string, len(keys))
+	for _, key := range keys {
+		value, ok, err := backend.Get(ctx, key)
+		if err != nil {
+			return nil, fmt.Errorf("get secret %q: %w", key, err)
+		}
+		if ok {
+			values[key] = value
+		}
+	}
+	return values, nil
+}
+
 func FromManifest(root string, config manifest.SecretsBackend, keyMapper func(string) string) (Backend, error) {
 	switch config.Type {
 	case "", "env-file":
@@ -51,6 +51,26 @@ func (b *EnvFileBackend) Get(ctx context.Context, key string) (string, bool, err
 	return value, ok, nil
 }
 
+// GetMany reads the file once for all keys.
+func (b *EnvFileBackend) GetMany(ctx context.Context, keys []string) (map[string]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	values, err := b.load()
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]string, len(keys))
+	for _, key := range keys {
+		if value, ok := values[b.keyFor(key)]; ok {
+			found[key] = value
+		}
+	}
+	return found, nil
+}
+
 func (b *EnvFileBackend) Set(ctx context.Context, key, value string) error {
 	if err := ctx.Err(); err != nil {
 		return err
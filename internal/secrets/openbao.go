@@ -4,10 +4,13 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -36,9 +39,15 @@ func NewOpenBaoBackend(config OpenBaoConfig) *OpenBaoBackend {
 	if config.Path == "" {
 		config.Path = "angee"
 	}
-	return &OpenBaoBackend{config: config, client: &http.Client{Timeout: 10 * time.Second}}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = openBaoParallelism
+	return &OpenBaoBackend{config: config, client: &http.Client{Timeout: 10 * time.Second, Transport: transport}}
 }
 
+// openBaoParallelism bounds concurrent reads in GetMany. The client keeps as
+// many idle connections, so a batch reuses them instead of reconnecting.
+const openBaoParallelism = 8
+
 func (b *OpenBaoBackend) Get(ctx context.Context, key string) (string, bool, error) {
 	var resp struct {
 		Data struct {
@@ -56,6 +65,41 @@ func (b *OpenBaoBackend) Get(ctx context.Context, key string) (string, bool, err
 	return value, ok, nil
 }
 
+// GetMany reads keys concurrently. KV has no multi-key read, so each key is
+// still one request. Every key is attempted; the error names each failure.
+func (b *OpenBaoBackend) GetMany(ctx context.Context, keys []string) (map[string]string, error) {
+	values := make([]string, len(keys))
+	found := make([]bool, len(keys))
+	errs := make([]error, len(keys))
+	slots := make(chan struct{}, openBaoParallelism)
+	var wg sync.WaitGroup
+	for i, key := range keys {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			value, ok, err := b.Get(ctx, key)
+			if err != nil {
+				errs[i] = fmt.Errorf("get secret %q: %w", key, err)
+				return
+			}
+			values[i], found[i] = value, ok
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	result := make(map[string]string, len(keys))
+	for i, key := range keys {
+		if found[i] {
+			result[key] = values[i]
+		}
+	}
+	return result, nil
+}
+
 func (b *OpenBaoBackend) Set(ctx context.Context, key, value string) error {
 	body := map[string]any{"data": map[string]string{"value": value}}
 	_, err := b.request(ctx, http.MethodPost, b.dataPath(key), body, nil)
@@ -102,9 +146,16 @@ func (b *OpenBaoBackend) request(ctx context.Context, method, path string, body
 	}
 	resp, err := b.client.Do(req)
 	if err != nil {
+		if ctx.Err() == nil {
+			err = &UnreachableError{Backend: "openbao", Address: b.config.Address, Err: err}
+		}
 		return 0, err
 	}
-	defer resp.Body.Close()
+	defer func() {
+		// Drain the body so the connection goes back to the pool.
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}()
 	if resp.StatusCode == http.StatusNotFound {
 		return resp.StatusCode, nil
 	}
@@ -0,0 +1,64 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOpenBaoGetManyReadsConcurrently(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, peak := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		peak = max(peak, inFlight)
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+
+		if r.Header.Get("X-Vault-Token") != "token" || !strings.HasPrefix(r.URL.Path, "/v1/secret/data/angee/") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		key := path.Base(r.URL.Path)
+		switch key {
+		case "missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "denied":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"data": map[string]string{"value": key + "-value"}}})
+		}
+	}))
+	defer server.Close()
+
+	backend := NewOpenBaoBackend(OpenBaoConfig{Address: server.URL, Token: "token"})
+	keys := []string{"a", "b", "c", "d", "e", "f", "missing"}
+	values, err := backend.GetMany(context.Background(), keys)
+	if err != nil {
+		t.Fatalf("GetMany() error = %v", err)
+	}
+	if len(values) != 6 || values["a"] != "a-value" || values["f"] != "f-value" {
+		t.Fatalf("GetMany() = %v", values)
+	}
+	if _, ok := values["missing"]; ok {
+		t.Fatalf("GetMany() returned missing key: %v", values)
+	}
+	if peak < 2 || peak > openBaoParallelism {
+		t.Fatalf("peak concurrent reads = %d, want 2..%d", peak, openBaoParallelism)
+	}
+
+	_, err = backend.GetMany(context.Background(), []string{"a", "denied"})
+	if err == nil || !strings.Contains(err.Error(), `"denied"`) || strings.Contains(err.Error(), `"a"`) {
+		t.Fatalf("GetMany() error = %v, want only denied to fail", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/fyltr/angee/internal/manifest"
@@ -16,12 +17,19 @@ func ResolveDeclarations(ctx context.Context, backend Backend, declarations map[
 	if lookup == nil {
 		lookup = func(string) (string, bool) { return "", false }
 	}
+	names := make([]string, 0, len(declarations))
+	for name := range declarations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	stored, err := getMany(ctx, backend, names)
+	if err != nil {
+		return nil, err
+	}
 	resolved := make(map[string]string, len(declarations))
-	for name, spec := range declarations {
-		value, ok, err := backend.Get(ctx, name)
-		if err != nil {
-			return nil, fmt.Errorf("get secret %q: %w", name, err)
-		}
+	for _, name := range names {
+		spec := declarations[name]
+		value, ok := stored[name]
 		if !ok && spec.Import != "" {
 			importValue, err := importSecret(spec.Import, lookup)
 			if err != nil {
@@ -49,7 +57,7 @@ func ResolveDeclarations(ctx context.Context, backend Backend, declarations map[
 			}
 		}
 		if !ok && spec.Required {
-			return nil, fmt.Errorf("required secret %q is missing", name)
+			return nil, &MissingError{Name: name}
 		}
 		if ok {
 			resolved[name] = value
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/fyltr/angee/api"
+	"github.com/fyltr/angee/internal/manifest"
+)
+
+// alertInterval is how often the monitor samples container services.
+const alertInterval = 30 * time.Second
+
+const defaultAlertWindow = time.Hour
+
+// AlertMonitor evaluates `operator.alerts` rules against periodic samples of
+// the stack's container services. A rule that starts firing or resolves is
+// sent to the notification webhooks, and while it fires the service is
+// reported as degraded. Rules are re-read on every sample.
+type AlertMonitor struct {
+	platform *Platform
+
+	restarts  map[string][]restartSample
+	downSince map[string]time.Time
+	firing    map[string]bool
+}
+
+type restartSample struct {
+	at    time.Time
+	count int
+}
+
+func NewAlertMonitor(platform *Platform) *AlertMonitor {
+	return &AlertMonitor{
+		platform:  platform,
+		restarts:  map[string][]restartSample{},
+		downSince: map[string]time.Time{},
+		firing:    map[string]bool{},
+	}
+}
+
+// Run blocks until ctx is cancelled.
+func (m *AlertMonitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(alertInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			m.check(ctx, now)
+		}
+	}
+}
+
+func (m *AlertMonitor) check(ctx context.Context, now time.Time) {
+	stack, err := m.platform.LoadStack()
+	if err != nil || len(stack.Operator.Alerts) == 0 {
+		return
+	}
+	stats := m.platform.serviceStatsAt(ctx, now)
+	if stats == nil {
+		// Sampling failed; keep the previous state rather than guess.
+		return
+	}
+	m.observe(stack, stats, now)
+	active := map[string][]string{}
+	for _, name := range sortedKeys(stack.Operator.Alerts) {
+		rule := stack.Operator.Alerts[name]
+		reason := m.evaluate(rule, now)
+		if reason != "" {
+			active[rule.Service] = append(active[rule.Service], name)
+		}
+		switch {
+		case reason != "" && !m.firing[name]:
+			m.firing[name] = true
+			m.platform.notify(ctx, api.Notification{Event: manifest.EventAlertFiring, Service: rule.Service, Message: fmt.Sprintf("alert %s firing: %s", name, reason)})
+		case reason == "" && m.firing[name]:
+			delete(m.firing, name)
+			m.platform.notify(ctx, api.Notification{Event: manifest.EventAlertResolved, Service: rule.Service, Message: fmt.Sprintf("alert %s resolved: service %s", name, rule.Service)})
+		}
+	}
+	for name := range m.firing {
+		if _, ok := stack.Operator.Alerts[name]; !ok {
+			delete(m.firing, name)
+		}
+	}
+	m.platform.setActiveAlerts(active)
+}
+
+// observe records the sample for every container service. A service with no
+// running container counts as down from the first sample that misses it.
+func (m *AlertMonitor) observe(stack *manifest.Stack, stats map[string]api.ServiceStats, now time.Time) {
+	for name, service := range stack.Services {
+		if service.Runtime != manifest.RuntimeContainer {
+			continue
+		}
+		stat, running := stats[name]
+		if !running {
+			if _, ok := m.downSince[name]; !ok {
+				m.downSince[name] = now
+			}
+			continue
+		}
+		delete(m.downSince, name)
+		samples := append(m.restarts[name], restartSample{at: now, count: stat.Restarts})
+		cutoff := now.Add(-maxAlertWindow(stack, name))
+		for len(samples) > 1 && samples[1].at.Before(cutoff) {
+			samples = samples[1:]
+		}
+		m.restarts[name] = samples
+	}
+}
+
+// evaluate returns why rule fires, or "" when it does not.
+func (m *AlertMonitor) evaluate(rule manifest.AlertRule, now time.Time) string {
+	if rule.DownFor != "" {
+		downFor, _ := time.ParseDuration(rule.DownFor)
+		if since, ok := m.downSince[rule.Service]; ok && now.Sub(since) >= downFor {
+			return fmt.Sprintf("service %s not running for %s", rule.Service, now.Sub(since).Round(time.Second))
+		}
+	}
+	if rule.Restarts > 0 {
+		window := alertWindow(rule)
+		if restarts := restartsWithin(m.restarts[rule.Service], now.Add(-window)); restarts > rule.Restarts {
+			return fmt.Sprintf("service %s restarted %d times in %s", rule.Service, restarts, window)
+		}
+	}
+	return ""
+}
+
+// restartsWithin sums the restart count increases between samples taken
+// since cutoff. A count that drops means the container was recreated; the
+// new count is taken as the increase.
+func restartsWithin(samples []restartSample, cutoff time.Time) int {
+	total := 0
+	for i := 1; i < len(samples); i++ {
+		if samples[i].at.Before(cutoff) {
+			continue
+		}
+		delta := samples[i].count - samples[i-1].count
+		if delta < 0 {
+			delta = samples[i].count
+		}
+		total += delta
+	}
+	return total
+}
+
+func alertWindow(rule manifest.AlertRule) time.Duration {
+	if window, err := time.ParseDuration(rule.Within); err == nil && window > 0 {
+		return window
+	}
+	return defaultAlertWindow
+}
+
+func maxAlertWindow(stack *manifest.Stack, service string) time.Duration {
+	window := defaultAlertWindow
+	for _, rule := range stack.Operator.Alerts {
+		if rule.Service == service {
+			window = max(window, alertWindow(rule))
+		}
+	}
+	return window
+}
+
+type alertState struct {
+	mu     sync.Mutex
+	active map[string][]string
+}
+
+func (p *Platform) setActiveAlerts(active map[string][]string) {
+	p.alerts.mu.Lock()
+	defer p.alerts.mu.Unlock()
+	p.alerts.active = active
+}
+
+// applyAlerts marks services with firing alerts as degraded. Alerts are only
+// evaluated inside the operator, so other processes never see any.
+func (p *Platform) applyAlerts(services map[string]api.ServiceState) {
+	p.alerts.mu.Lock()
+	defer p.alerts.mu.Unlock()
+	for name, rules := range p.alerts.active {
+		state, ok := services[name]
+		if !ok {
+			continue
+		}
+		state.Status = "degraded"
+		state.Alerts = slices.Clone(rules)
+		services[name] = state
+	}
+}
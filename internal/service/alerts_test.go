@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fyltr/angee/api"
+	"github.com/fyltr/angee/internal/manifest"
+	"github.com/fyltr/angee/internal/runtime"
+)
+
+type statsBackend struct {
+	runtime.Backend
+	samples []runtime.ServiceStats
+	calls   int
+}
+
+func (b *statsBackend) Stats(context.Context, string) ([]runtime.ServiceStats, error) {
+	b.calls++
+	return b.samples, nil
+}
+
+func TestServiceStatsReusesRecentSample(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "docker-compose.yaml"), []byte("services: {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	backend := &statsBackend{samples: []runtime.ServiceStats{{Name: "web", Restarts: 1}}}
+	platform, err := NewWithBackends(root, backend, nil)
+	if err != nil {
+		t.Fatalf("NewWithBackends() error = %v", err)
+	}
+	start := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	first := platform.serviceStatsAt(context.Background(), start)
+	first["web"] = api.ServiceStats{Restarts: 99}
+
+	backend.samples = []runtime.ServiceStats{{Name: "web", Restarts: 2}}
+	if got := platform.serviceStatsAt(context.Background(), start.Add(time.Second)); got["web"].Restarts != 1 || backend.calls != 1 {
+		t.Fatalf("stats within TTL = %+v after %d samples, want cached restarts 1", got, backend.calls)
+	}
+	if got := platform.serviceStatsAt(context.Background(), start.Add(statsTTL)); got["web"].Restarts != 2 || backend.calls != 2 {
+		t.Fatalf("stats after TTL = %+v after %d samples, want fresh restarts 2", got, backend.calls)
+	}
+}
+
+func TestAlertMonitorFiresAndResolvesRules(t *testing.T) {
+	var mu sync.Mutex
+	var events []api.Notification
+	hooks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event api.Notification
+		_ = json.NewDecoder(r.Body).Decode(&event)
+		mu.Lock()
+		events = append(events, event)
+		mu.Unlock()
+	}))
+	defer hooks.Close()
+
+	root := t.TempDir()
+	stack := &manifest.Stack{
+		Version: manifest.VersionCurrent,
+		Kind:    manifest.KindStack,
+		Name:    "notes",
+		Operator: manifest.Operator{
+			Notifications: map[string]manifest.Notification{"ops": {URL: hooks.URL}},
+			Alerts: map[string]manifest.AlertRule{
+				"web-crashloop": {Service: "web", Restarts: 2, Within: "10m"},
+				"db-down":       {Service: "db", DownFor: "5m"},
+			},
+		},
+		Services: map[string]manifest.Service{
+			"web": {Runtime: manifest.RuntimeContainer, Image: "nginx:1"},
+			"db":  {Runtime: manifest.RuntimeContainer, Image: "postgres:16"},
+		},
+	}
+	if err := manifest.SaveFile(manifest.Path(root), stack); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "docker-compose.yaml"), []byte("services: {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	backend := &statsBackend{}
+	platform, err := NewWithBackends(root, backend, nil)
+	if err != nil {
+		t.Fatalf("NewWithBackends() error = %v", err)
+	}
+	monitor := NewAlertMonitor(platform)
+	start := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	sample := func(minute, restarts int) {
+		backend.samples = []runtime.ServiceStats{{Name: "web", Restarts: restarts}}
+		monitor.check(context.Background(), start.Add(time.Duration(minute)*time.Minute))
+	}
+
+	sample(0, 0)
+	sample(2, 1)
+	mu.Lock()
+	if len(events) != 0 {
+		t.Fatalf("events after one restart = %+v", events)
+	}
+	mu.Unlock()
+	sample(5, 3)
+	status, err := platform.StackStatus(context.Background())
+	if err != nil {
+		t.Fatalf("StackStatus() error = %v", err)
+	}
+	if web := status.Services["web"]; web.Status != "degraded" || len(web.Alerts) != 1 || web.Alerts[0] != "web-crashloop" {
+		t.Fatalf("web status = %+v", web)
+	}
+	if db := status.Services["db"]; db.Status != "degraded" {
+		t.Fatalf("db status = %+v, want degraded after 5m down", db)
+	}
+	sample(16, 3)
+	status, _ = platform.StackStatus(context.Background())
+	if web := status.Services["web"]; web.Status == "degraded" {
+		t.Fatalf("web status = %+v, want resolved once restarts leave the window", web)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	var firing, resolved int
+	for _, event := range events {
+		switch event.Event {
+		case manifest.EventAlertFiring:
+			firing++
+		case manifest.EventAlertResolved:
+			resolved++
+		}
+	}
+	if firing != 2 || resolved != 1 {
+		t.Fatalf("events = %+v, want 2 firing and 1 resolved", events)
+	}
+}
@@ -0,0 +1,283 @@
+package service
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fyltr/angee/api"
+	"github.com/fyltr/angee/internal/manifest"
+	"github.com/fyltr/angee/internal/runtime"
+)
+
+// backupSuffix names dump files; the base name is the UTC time the dump
+// started, to the millisecond, so names sort oldest first.
+const (
+	backupSuffix     = ".sql.gz"
+	backupTimeLayout = "20060102T150405.000Z"
+)
+
+// mysqlCredentials exports the root password as MYSQL_PWD, read from
+// MYSQL_ROOT_PASSWORD_FILE when the service uses a secret file, so it never
+// shows in the client's argv. Without a password, and without
+// MYSQL_ALLOW_EMPTY_PASSWORD, it stops with an error naming the variables.
+const mysqlCredentials = `if [ -n "${MYSQL_ROOT_PASSWORD_FILE:-}" ]; then MYSQL_PWD="$(cat "$MYSQL_ROOT_PASSWORD_FILE")" || exit 1; else MYSQL_PWD="${MYSQL_ROOT_PASSWORD:-}"; fi
+if [ -z "$MYSQL_PWD" ] && [ -z "${MYSQL_ALLOW_EMPTY_PASSWORD:-}" ]; then echo "no MySQL root password: set MYSQL_ROOT_PASSWORD or MYSQL_ROOT_PASSWORD_FILE in the service env" >&2; exit 1; fi
+export MYSQL_PWD
+`
+
+// Commands run inside the database container through `docker compose exec`.
+// They read credentials from the env the official images are configured
+// with, so no secret passes through angee.
+var (
+	backupDumpCommands = map[string][]string{
+		manifest.BackupPostgres: {"sh", "-c", `exec pg_dump --clean --if-exists -U "${POSTGRES_USER:-postgres}" "${POSTGRES_DB:-${POSTGRES_USER:-postgres}}"`},
+		manifest.BackupMySQL:    {"sh", "-c", mysqlCredentials + `exec mysqldump --single-transaction --routines -uroot "$MYSQL_DATABASE"`},
+	}
+	backupRestoreCommands = map[string][]string{
+		manifest.BackupPostgres: {"sh", "-c", `exec psql -q -v ON_ERROR_STOP=1 -U "${POSTGRES_USER:-postgres}" "${POSTGRES_DB:-${POSTGRES_USER:-postgres}}"`},
+		manifest.BackupMySQL:    {"sh", "-c", mysqlCredentials + `exec mysql -uroot "$MYSQL_DATABASE"`},
+	}
+)
+
+// BackupList returns the dumps of service, or of every service with a
+// backup declaration when service is empty, newest first.
+func (p *Platform) BackupList(ctx context.Context, service string) ([]api.Backup, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	stack, err := p.LoadStack()
+	if err != nil {
+		return nil, err
+	}
+	names := []string{service}
+	if service == "" {
+		names = names[:0]
+		for _, name := range sortedKeys(stack.Services) {
+			if stack.Services[name].Backup != nil {
+				names = append(names, name)
+			}
+		}
+	} else if _, err := backupSpec(stack, service); err != nil {
+		return nil, err
+	}
+	backups := []api.Backup{}
+	for _, name := range names {
+		entries, err := os.ReadDir(p.backupDir(name))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), backupSuffix) {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				return nil, err
+			}
+			backups = append(backups, backupInfo(name, stack.Services[name].Backup.Kind, info))
+		}
+	}
+	sort.SliceStable(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+	return backups, nil
+}
+
+// BackupRun dumps service's database now.
+func (p *Platform) BackupRun(ctx context.Context, service string) (api.Backup, error) {
+	return p.runBackup(ctx, service, JobTriggerManual)
+}
+
+// BackupRestore loads a dump taken by BackupRun back into service's
+// database. The service must be running.
+func (p *Platform) BackupRestore(ctx context.Context, service, name string) error {
+	stack, err := p.LoadStack()
+	if err != nil {
+		return err
+	}
+	spec, err := backupSpec(stack, service)
+	if err != nil {
+		return err
+	}
+	if name == "" || filepath.Base(name) != name || !strings.HasSuffix(name, backupSuffix) {
+		return &InvalidInputError{Field: "name", Reason: "must be a backup name from `angee backup list`"}
+	}
+	executor, err := p.backupExecutor()
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(filepath.Join(p.backupDir(service), name))
+	if errors.Is(err, os.ErrNotExist) {
+		return &NotFoundError{Kind: "backup", Name: service + "/" + name}
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	dump, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("read backup %s: %w", name, err)
+	}
+	defer dump.Close()
+	return executor.Exec(ctx, runtime.ExecRequest{
+		Root:    p.root,
+		Service: service,
+		EnvFile: p.runtimeEnvFile(stack),
+		Command: backupRestoreCommands[spec.Kind],
+		Stdin:   dump,
+		Stdout:  io.Discard,
+	})
+}
+
+// runBackup dumps service and, like a job, notifies job.failed subscribers
+// when the dump fails. The notification names the job backup:<service>.
+func (p *Platform) runBackup(ctx context.Context, service, trigger string) (api.Backup, error) {
+	started := time.Now().UTC()
+	backup, err := p.dumpBackup(ctx, service, started)
+	var notFound *NotFoundError
+	if err != nil && !errors.As(err, &notFound) {
+		p.notifyJobRun(ctx, api.JobRun{Job: "backup:" + service, Trigger: trigger, Status: "failed", StartedAt: started, FinishedAt: time.Now().UTC(), Error: err.Error(), RequestID: requestIDFromContext(ctx)})
+	}
+	return backup, err
+}
+
+// dumpBackup streams the dump through gzip into a temporary file that is
+// renamed into place only once the dump succeeded, then prunes dumps past
+// the retention.
+func (p *Platform) dumpBackup(ctx context.Context, service string, started time.Time) (api.Backup, error) {
+	stack, err := p.LoadStack()
+	if err != nil {
+		return api.Backup{}, err
+	}
+	spec, err := backupSpec(stack, service)
+	if err != nil {
+		return api.Backup{}, err
+	}
+	executor, err := p.backupExecutor()
+	if err != nil {
+		return api.Backup{}, err
+	}
+	dir := p.backupDir(service)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return api.Backup{}, err
+	}
+	tmp, err := os.CreateTemp(dir, ".dump-*")
+	if err != nil {
+		return api.Backup{}, err
+	}
+	defer os.Remove(tmp.Name())
+	gz := gzip.NewWriter(tmp)
+	err = executor.Exec(ctx, runtime.ExecRequest{
+		Root:    p.root,
+		Service: service,
+		EnvFile: p.runtimeEnvFile(stack),
+		Command: backupDumpCommands[spec.Kind],
+		Stdout:  gz,
+	})
+	if closeErr := gz.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return api.Backup{}, fmt.Errorf("backup %s: %w", service, err)
+	}
+	path, err := backupPath(dir, started)
+	if err != nil {
+		return api.Backup{}, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return api.Backup{}, err
+	}
+	if err := pruneBackups(dir, spec.Retention); err != nil {
+		return api.Backup{}, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return api.Backup{}, err
+	}
+	return backupInfo(service, spec.Kind, info), nil
+}
+
+// backupPath names a dump started at started, moving past the millisecond
+// of any dump already taken then so a second dump never replaces the first.
+func backupPath(dir string, started time.Time) (string, error) {
+	for {
+		path := filepath.Join(dir, started.Format(backupTimeLayout)+backupSuffix)
+		if _, err := os.Lstat(path); errors.Is(err, os.ErrNotExist) {
+			return path, nil
+		} else if err != nil {
+			return "", err
+		}
+		started = started.Add(time.Millisecond)
+	}
+}
+
+func pruneBackups(dir string, retention int) error {
+	if retention == 0 {
+		return nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), backupSuffix) {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	for len(names) > retention {
+		if err := os.Remove(filepath.Join(dir, names[0])); err != nil {
+			return err
+		}
+		names = names[1:]
+	}
+	return nil
+}
+
+func backupSpec(stack *manifest.Stack, service string) (*manifest.Backup, error) {
+	svc, ok := stack.Services[service]
+	if !ok {
+		return nil, &NotFoundError{Kind: "service", Name: service}
+	}
+	if svc.Backup == nil {
+		return nil, &InvalidInputError{Field: "service", Reason: fmt.Sprintf("service %s declares no backup", service)}
+	}
+	return svc.Backup, nil
+}
+
+func (p *Platform) backupExecutor() (runtime.Executor, error) {
+	executor, ok := p.composeBackend.(runtime.Executor)
+	if !ok {
+		return nil, errors.New("the container backend cannot run commands in services")
+	}
+	return executor, nil
+}
+
+func (p *Platform) backupDir(service string) string {
+	return filepath.Join(p.root, "backups", service)
+}
+
+func backupInfo(service, kind string, info os.FileInfo) api.Backup {
+	backup := api.Backup{Service: service, Name: info.Name(), Kind: kind, Size: info.Size(), CreatedAt: info.ModTime().UTC()}
+	// Parsing to the second accepts the milliseconds when a name has them;
+	// dumps taken by earlier releases do not.
+	if created, err := time.Parse("20060102T150405Z", strings.TrimSuffix(info.Name(), backupSuffix)); err == nil {
+		backup.CreatedAt = created
+	}
+	return backup
+}
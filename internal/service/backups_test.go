@@ -0,0 +1,171 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fyltr/angee/internal/manifest"
+	"github.com/fyltr/angee/internal/runtime"
+)
+
+type execBackend struct {
+	runtime.Backend
+	dumps    int
+	restored []byte
+	requests []runtime.ExecRequest
+}
+
+func (b *execBackend) Exec(_ context.Context, req runtime.ExecRequest) error {
+	b.requests = append(b.requests, req)
+	if req.Stdin != nil {
+		data, err := io.ReadAll(req.Stdin)
+		b.restored = data
+		return err
+	}
+	b.dumps++
+	_, err := fmt.Fprintf(req.Stdout, "dump %d\n", b.dumps)
+	return err
+}
+
+func TestBackupsDumpPruneAndRestore(t *testing.T) {
+	root := t.TempDir()
+	stack := &manifest.Stack{
+		Version: manifest.VersionCurrent,
+		Kind:    manifest.KindStack,
+		Name:    "notes",
+		Services: map[string]manifest.Service{
+			"db":  {Runtime: manifest.RuntimeContainer, Image: "postgres:16", Backup: &manifest.Backup{Kind: manifest.BackupPostgres, Retention: 2}},
+			"web": {Runtime: manifest.RuntimeContainer, Image: "nginx:1"},
+		},
+	}
+	if err := manifest.SaveFile(manifest.Path(root), stack); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+	backend := &execBackend{}
+	platform, err := NewWithBackends(root, backend, nil)
+	if err != nil {
+		t.Fatalf("NewWithBackends() error = %v", err)
+	}
+	start := time.Date(2026, 5, 10, 2, 0, 0, 0, time.UTC)
+	for i := range 3 {
+		if _, err := platform.dumpBackup(context.Background(), "db", start.Add(time.Duration(i)*24*time.Hour)); err != nil {
+			t.Fatalf("dumpBackup() error = %v", err)
+		}
+	}
+	if cmd := backend.requests[0]; cmd.Service != "db" || cmd.Command[0] != "sh" || !bytes.Contains([]byte(cmd.Command[2]), []byte("pg_dump")) {
+		t.Fatalf("dump request = %#v", cmd)
+	}
+
+	backups, err := platform.BackupList(context.Background(), "")
+	if err != nil {
+		t.Fatalf("BackupList() error = %v", err)
+	}
+	if len(backups) != 2 || backups[0].Name != "20260512T020000.000Z.sql.gz" || backups[1].Name != "20260511T020000.000Z.sql.gz" {
+		t.Fatalf("backups = %#v, want the two newest, newest first", backups)
+	}
+	if backups[0].Kind != manifest.BackupPostgres || !backups[0].CreatedAt.Equal(start.Add(48*time.Hour)) {
+		t.Fatalf("backup = %#v", backups[0])
+	}
+
+	if err := platform.BackupRestore(context.Background(), "db", backups[1].Name); err != nil {
+		t.Fatalf("BackupRestore() error = %v", err)
+	}
+	if string(backend.restored) != "dump 2\n" {
+		t.Fatalf("restored %q, want the second dump", backend.restored)
+	}
+
+	var invalid *InvalidInputError
+	if err := platform.BackupRestore(context.Background(), "db", "../angee.yaml"); !errors.As(err, &invalid) {
+		t.Fatalf("BackupRestore(../angee.yaml) error = %v, want invalid input", err)
+	}
+	if _, err := platform.BackupRun(context.Background(), "web"); !errors.As(err, &invalid) {
+		t.Fatalf("BackupRun(web) error = %v, want invalid input", err)
+	}
+	var notFound *NotFoundError
+	if err := platform.BackupRestore(context.Background(), "db", "20200101T000000Z.sql.gz"); !errors.As(err, &notFound) {
+		t.Fatalf("BackupRestore(missing) error = %v, want not found", err)
+	}
+}
+
+func TestBackupsInOneSecondKeepBothDumps(t *testing.T) {
+	root := t.TempDir()
+	stack := &manifest.Stack{
+		Version: manifest.VersionCurrent,
+		Kind:    manifest.KindStack,
+		Name:    "notes",
+		Services: map[string]manifest.Service{
+			"db": {Runtime: manifest.RuntimeContainer, Image: "postgres:16", Backup: &manifest.Backup{Kind: manifest.BackupPostgres}},
+		},
+	}
+	if err := manifest.SaveFile(manifest.Path(root), stack); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+	platform, err := NewWithBackends(root, &execBackend{}, nil)
+	if err != nil {
+		t.Fatalf("NewWithBackends() error = %v", err)
+	}
+	started := time.Date(2026, 5, 10, 2, 0, 0, 250*int(time.Millisecond), time.UTC)
+	first, err := platform.dumpBackup(context.Background(), "db", started)
+	if err != nil {
+		t.Fatalf("dumpBackup() error = %v", err)
+	}
+	second, err := platform.dumpBackup(context.Background(), "db", started)
+	if err != nil {
+		t.Fatalf("dumpBackup() error = %v", err)
+	}
+	if first.Name != "20260510T020000.250Z.sql.gz" || second.Name != "20260510T020000.251Z.sql.gz" {
+		t.Fatalf("names = %q, %q, want distinct names in the same second", first.Name, second.Name)
+	}
+	backups, err := platform.BackupList(context.Background(), "db")
+	if err != nil {
+		t.Fatalf("BackupList() error = %v", err)
+	}
+	if len(backups) != 2 || backups[0].Name != second.Name {
+		t.Fatalf("backups = %#v, want both dumps, newest first", backups)
+	}
+}
+
+func TestMySQLBackupCommandPassesPasswordInEnv(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+	bin := t.TempDir()
+	// The stand-in client prints the password it sees and its arguments.
+	client := "#!/bin/sh\necho \"pwd=$MYSQL_PWD args=$*\"\n"
+	if err := os.WriteFile(filepath.Join(bin, "mysqldump"), []byte(client), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	secret := filepath.Join(t.TempDir(), "root-password")
+	if err := os.WriteFile(secret, []byte("from-file"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	run := func(env ...string) (string, error) {
+		command := backupDumpCommands[manifest.BackupMySQL]
+		cmd := exec.Command(command[0], command[1:]...)
+		cmd.Env = append([]string{"PATH=" + bin + string(os.PathListSeparator) + os.Getenv("PATH"), "MYSQL_DATABASE=notes"}, env...)
+		out, err := cmd.CombinedOutput()
+		return strings.TrimSpace(string(out)), err
+	}
+
+	out, err := run("MYSQL_ROOT_PASSWORD=s3cret")
+	if err != nil || out != "pwd=s3cret args=--single-transaction --routines -uroot notes" {
+		t.Fatalf("dump with MYSQL_ROOT_PASSWORD = %q, %v", out, err)
+	}
+	out, err = run("MYSQL_ROOT_PASSWORD_FILE=" + secret)
+	if err != nil || out != "pwd=from-file args=--single-transaction --routines -uroot notes" {
+		t.Fatalf("dump with MYSQL_ROOT_PASSWORD_FILE = %q, %v", out, err)
+	}
+	out, err = run()
+	if err == nil || !strings.Contains(out, "set MYSQL_ROOT_PASSWORD or MYSQL_ROOT_PASSWORD_FILE") {
+		t.Fatalf("dump without a password = %q, %v, want a credential error", out, err)
+	}
+}
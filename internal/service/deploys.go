@@ -0,0 +1,262 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fyltr/angee/api"
+	"github.com/fyltr/angee/internal/git"
+	"github.com/fyltr/angee/internal/manifest"
+)
+
+type (
+	callerKey    struct{}
+	requestIDKey struct{}
+)
+
+// WithCaller attributes the platform calls made with ctx to caller in the
+// deploy ledger. The operator sets it per request; calls without one are
+// attributed to LocalCaller.
+func WithCaller(ctx context.Context, caller string) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// LocalCaller names the user running this process, as "cli:<username>".
+func LocalCaller() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return "cli:" + u.Username
+	}
+	return "cli"
+}
+
+func callerFromContext(ctx context.Context) string {
+	if caller, ok := ctx.Value(callerKey{}).(string); ok && caller != "" {
+		return caller
+	}
+	return LocalCaller()
+}
+
+// WithRequestID tags the platform calls made with ctx with a request ID, so
+// the deploy ledger and job runs can be matched to operator logs. Calls
+// without one get a fresh ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// NewRequestID returns a random request ID.
+func NewRequestID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+func requestIDFromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(requestIDKey{}).(string); ok && id != "" {
+		return id
+	}
+	return NewRequestID()
+}
+
+// Deploys returns the deploy ledger, newest first.
+func (p *Platform) Deploys(ctx context.Context) ([]api.Deploy, error) {
+	p.deploysMu.Lock()
+	defer p.deploysMu.Unlock()
+	data, err := os.ReadFile(p.deploysPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return []api.Deploy{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	deploys := []api.Deploy{}
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var deploy api.Deploy
+		if err := json.Unmarshal(line, &deploy); err != nil {
+			return nil, fmt.Errorf("read deploy ledger: %w", err)
+		}
+		deploys = append(deploys, deploy)
+	}
+	slices.Reverse(deploys)
+	return deploys, nil
+}
+
+// recordDeploy appends an `up` to the ledger, linking the manifest digest
+// and the commit of the repository holding the root (when there is one) to
+// who applied it and how it went, and notifies subscribed webhooks. Like job
+// history it is best-effort.
+func (p *Platform) recordDeploy(ctx context.Context, services []string, message string, started time.Time, scans []api.ImageScan, err error) {
+	finished := time.Now().UTC()
+	deploy := api.Deploy{
+		Services:   services,
+		Message:    strings.TrimSpace(message),
+		Caller:     callerFromContext(ctx),
+		Status:     "succeeded",
+		StartedAt:  started,
+		FinishedAt: finished,
+		DurationMS: finished.Sub(started).Milliseconds(),
+		RequestID:  requestIDFromContext(ctx),
+		Scans:      scans,
+	}
+	if err != nil {
+		deploy.Status = "failed"
+		deploy.Error = err.Error()
+	}
+	if data, readErr := os.ReadFile(manifest.Path(p.root)); readErr == nil {
+		deploy.Manifest = revisionOf(data)
+		if err == nil {
+			_ = p.saveManifestSnapshot(deploy.Manifest, data)
+		}
+	}
+	if commit, gitErr := git.New().HeadCommit(ctx, p.root); gitErr == nil {
+		deploy.Commit = commit
+	}
+	p.deploysMu.Lock()
+	if appendJSONLine(p.deploysPath(), deploy) == nil {
+		p.compactDeploys()
+	}
+	p.deploysMu.Unlock()
+	p.notifyDeploy(ctx, deploy)
+}
+
+// deploysKept is how many deploys the ledger keeps. Rollback only walks a
+// few successful deploys back, so older ones are just history.
+const deploysKept = 500
+
+// compactDeploys trims the ledger to its last deploysKept entries and drops
+// the manifest snapshots no kept deploy refers to. Callers hold deploysMu.
+func (p *Platform) compactDeploys() {
+	var kept [][]byte
+	dropped := false
+	err := compactJSONLines(p.deploysPath(), func(lines [][]byte) [][]byte {
+		kept = lines[max(len(lines)-deploysKept, 0):]
+		dropped = len(kept) < len(lines)
+		return kept
+	})
+	if err != nil || !dropped {
+		return
+	}
+	revisions := map[string]bool{}
+	for _, line := range kept {
+		var deploy struct {
+			Manifest string `json:"manifest"`
+		}
+		if json.Unmarshal(line, &deploy) == nil && deploy.Manifest != "" {
+			revisions[filepath.Base(p.manifestSnapshotPath(deploy.Manifest))] = true
+		}
+	}
+	dir := filepath.Join(p.root, "run", "manifests")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if !revisions[entry.Name()] {
+			_ = os.Remove(filepath.Join(dir, entry.Name()))
+		}
+	}
+}
+
+// saveManifestSnapshot keeps the angee.yaml a deploy succeeded with, so a
+// later failed deploy can roll back to it.
+func (p *Platform) saveManifestSnapshot(revision string, data []byte) error {
+	path := p.manifestSnapshotPath(revision)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func (p *Platform) manifestSnapshotPath(revision string) string {
+	return filepath.Join(p.root, "run", "manifests", strings.TrimPrefix(revision, "sha256:")+".yaml")
+}
+
+// rollbackDeploy answers a failed deploy by restoring the angee.yaml of the
+// last successful one and deploying it again with redeploy, which waits for
+// health as the failed deploy did. When that manifest fails too, it walks
+// further back through at most depth distinct earlier ones. The rejected
+// file is kept as angee.yaml.rejected. The returned error always wraps
+// cause, since the change that was asked for did not land.
+func (p *Platform) rollbackDeploy(ctx context.Context, cause error, depth int, redeploy func() error) error {
+	current, err := p.manifestRevision()
+	if err != nil {
+		return fmt.Errorf("%w; not rolled back: %v", cause, err)
+	}
+	deploys, err := p.Deploys(ctx)
+	if err != nil {
+		return fmt.Errorf("%w; not rolled back: %v", cause, err)
+	}
+	targets := rollbackTargets(deploys, current, depth)
+	if len(targets) == 0 {
+		return fmt.Errorf("%w; not rolled back: no earlier successful deploy with a different angee.yaml", cause)
+	}
+	path := manifest.Path(p.root)
+	rejected, err := os.ReadFile(path)
+	if err == nil {
+		err = os.WriteFile(path+".rejected", rejected, 0o644)
+	}
+	if err != nil {
+		return fmt.Errorf("%w; not rolled back: %v", cause, err)
+	}
+	var failures []string
+	for _, target := range targets {
+		previous, err := os.ReadFile(p.manifestSnapshotPath(target))
+		if err == nil {
+			p.manifestMu.Lock()
+			err = os.WriteFile(path, previous, 0o644)
+			p.manifestMu.Unlock()
+		}
+		if err == nil {
+			err = redeploy()
+		}
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", target, err))
+			continue
+		}
+		return &RolledBackError{Err: cause, Revision: target}
+	}
+	return fmt.Errorf("%w; rollback failed for each of the last %d good manifests, and angee.yaml is now the oldest of them (%s); the rejected file is angee.yaml.rejected", cause, len(targets), strings.Join(failures, "; "))
+}
+
+// rollbackTargets returns the manifests of successful deploys to try, newest
+// first and without repeats, at most depth of them. There are none when the
+// last successful deploy used the current manifest: the failure then lies
+// outside angee.yaml, and older configuration will not fix it.
+func rollbackTargets(deploys []api.Deploy, current string, depth int) []string {
+	var targets []string
+	seen := map[string]bool{current: true}
+	for _, deploy := range deploys {
+		if deploy.Status != "succeeded" || deploy.Manifest == "" {
+			continue
+		}
+		if deploy.Manifest == current && len(targets) == 0 {
+			return nil
+		}
+		if seen[deploy.Manifest] {
+			continue
+		}
+		seen[deploy.Manifest] = true
+		targets = append(targets, deploy.Manifest)
+		if len(targets) == depth {
+			break
+		}
+	}
+	return targets
+}
+
+func (p *Platform) deploysPath() string {
+	return filepath.Join(p.root, "run", "deploys.jsonl")
+}
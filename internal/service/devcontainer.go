@@ -0,0 +1,210 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/fyltr/angee/internal/manifest"
+	"github.com/fyltr/angee/internal/runtime/compose"
+)
+
+// devcontainerHeader marks a devcontainer.json written by angee. Only files
+// that start with it are rewritten or removed, so a hand-written
+// configuration next to them is left alone.
+const devcontainerHeader = "// Generated by angee from angee.yaml. Edits are overwritten when the stack is compiled.\n"
+
+type devcontainer struct {
+	Name            string             `json:"name"`
+	Image           string             `json:"image,omitempty"`
+	Build           *devcontainerBuild `json:"build,omitempty"`
+	WorkspaceMount  string             `json:"workspaceMount"`
+	WorkspaceFolder string             `json:"workspaceFolder"`
+	Mounts          []string           `json:"mounts,omitempty"`
+	ContainerEnv    map[string]string  `json:"containerEnv,omitempty"`
+	RemoteEnv       map[string]string  `json:"remoteEnv,omitempty"`
+	RunArgs         []string           `json:"runArgs,omitempty"`
+}
+
+type devcontainerBuild struct {
+	Context    string            `json:"context"`
+	Dockerfile string            `json:"dockerfile,omitempty"`
+	Args       map[string]string `json:"args,omitempty"`
+}
+
+// writeDevcontainers keeps .devcontainer/<service>/devcontainer.json in step
+// with every container service that mounts a workspace, which is how an
+// agent runs in v2. Opening the stack root in VS Code and picking the
+// service's configuration gives a human the agent's image, mounts and env.
+// Configurations angee generated for services that no longer qualify are
+// removed.
+func (p *Platform) writeDevcontainers(stack *manifest.Stack, compiled *CompiledStack) error {
+	dir := filepath.Join(p.root, ".devcontainer")
+	secretEnv := map[string]bool{}
+	for _, env := range compiled.SecretEnvVars {
+		secretEnv[env] = true
+	}
+	wanted := map[string]bool{}
+	for _, name := range sortedKeys(compiled.Compose.Services) {
+		config, ok := p.devcontainerFor(stack, compiled.Compose, name, secretEnv)
+		if !ok {
+			continue
+		}
+		wanted[name] = true
+		data, err := json.MarshalIndent(config, "", "  ")
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(dir, name, "devcontainer.json")
+		if current, err := os.ReadFile(path); err == nil && !bytes.HasPrefix(current, []byte(devcontainerHeader)) {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		if err := writeGenerated(path, append([]byte(devcontainerHeader), append(data, '\n')...), 0o644); err != nil {
+			return err
+		}
+	}
+	return p.removeDevcontainers(wanted)
+}
+
+// removeDevcontainers deletes the configurations angee generated for
+// services not in keep.
+func (p *Platform) removeDevcontainers(keep map[string]bool) error {
+	dir := filepath.Join(p.root, ".devcontainer")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || keep[entry.Name()] {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name(), "devcontainer.json")
+		current, err := os.ReadFile(path)
+		if err != nil || !bytes.HasPrefix(current, []byte(devcontainerHeader)) {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		_ = os.Remove(filepath.Dir(path))
+	}
+	_ = os.Remove(dir)
+	return nil
+}
+
+func (p *Platform) devcontainerFor(stack *manifest.Stack, file compose.File, name string, secretEnv map[string]bool) (devcontainer, bool) {
+	service := file.Services[name]
+	workspaces := filepath.Join(p.root, "workspaces") + string(filepath.Separator)
+	config := devcontainer{Name: file.Name + "-" + name, Image: service.Image}
+	for _, spec := range service.Volumes {
+		parts := strings.Split(spec, ":")
+		readOnly := len(parts) > 2 && parts[len(parts)-1] == "ro"
+		if readOnly {
+			parts = parts[:len(parts)-1]
+		}
+		source, target := parts[0], strings.Join(parts[1:], ":")
+		mount := "type=bind,source=" + manifest.ResolvePath(p.root, source)
+		if _, ok := file.Volumes[source]; ok {
+			mount = "type=volume,source=" + file.Name + "_" + source
+		}
+		mount += ",target=" + target
+		if readOnly {
+			mount += ",readonly"
+		}
+		if config.WorkspaceMount == "" && strings.HasPrefix(source, workspaces) {
+			config.WorkspaceMount = mount
+			config.WorkspaceFolder = target
+			continue
+		}
+		config.Mounts = append(config.Mounts, mount)
+	}
+	if config.WorkspaceMount == "" {
+		return devcontainer{}, false
+	}
+	if service.WorkingDir != "" {
+		config.WorkspaceFolder = service.WorkingDir
+	}
+	if service.Build != nil {
+		config.Image = ""
+		config.Build = p.devcontainerBuild(name, service.Build)
+	}
+	// Secret values reach compose through the runtime env file and are
+	// interpolated into ${ANGEE_SECRET_*} placeholders. The devcontainer
+	// loads the same file and resolves the placeholders inside the
+	// container, so no secret is written into the configuration.
+	for key, value := range service.Environment {
+		resolved := envPlaceholderRE.ReplaceAllStringFunc(value, func(match string) string {
+			if env := match[2 : len(match)-1]; secretEnv[env] {
+				return "${containerEnv:" + env + "}"
+			}
+			return match
+		})
+		if resolved != value {
+			if config.RemoteEnv == nil {
+				config.RemoteEnv = map[string]string{}
+			}
+			config.RemoteEnv[key] = resolved
+			continue
+		}
+		if config.ContainerEnv == nil {
+			config.ContainerEnv = map[string]string{}
+		}
+		config.ContainerEnv[key] = value
+	}
+	if config.RemoteEnv != nil {
+		config.RunArgs = append(config.RunArgs, "--env-file", p.runtimeEnvFile(stack))
+	}
+	config.RunArgs = append(config.RunArgs, "--network", file.Name+"_default")
+	return config, true
+}
+
+// devcontainerBuild translates a compose build spec, with paths written
+// relative to the devcontainer.json that refers to them.
+func (p *Platform) devcontainerBuild(name string, spec any) *devcontainerBuild {
+	build := &devcontainerBuild{}
+	buildContext := "."
+	switch spec := spec.(type) {
+	case string:
+		buildContext = spec
+	case map[string]any:
+		if value, ok := spec["context"].(string); ok {
+			buildContext = value
+		}
+		if value, ok := spec["dockerfile"].(string); ok {
+			build.Dockerfile = value
+		}
+		if args, ok := spec["args"].(map[string]any); ok && len(args) > 0 {
+			build.Args = map[string]string{}
+			for key, value := range args {
+				build.Args[key] = fmt.Sprint(value)
+			}
+		}
+	}
+	configDir := filepath.Join(p.root, ".devcontainer", name)
+	contextDir := manifest.ResolvePath(p.root, buildContext)
+	build.Context = contextDir
+	if rel, err := filepath.Rel(configDir, contextDir); err == nil {
+		build.Context = filepath.ToSlash(rel)
+	}
+	// Compose reads dockerfile relative to the context, devcontainers
+	// relative to the configuration file.
+	if build.Dockerfile != "" {
+		dockerfile := manifest.ResolvePath(contextDir, build.Dockerfile)
+		if rel, err := filepath.Rel(configDir, dockerfile); err == nil {
+			build.Dockerfile = filepath.ToSlash(rel)
+		}
+	}
+	return build
+}
+
+var envPlaceholderRE = regexp.MustCompile(`\$\{[A-Za-z_][A-Za-z0-9_]*\}`)
@@ -1,6 +1,15 @@
 package service
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/fyltr/angee/api"
+	"github.com/fyltr/angee/internal/manifest"
+	"github.com/fyltr/angee/internal/runtime"
+	"github.com/fyltr/angee/internal/secrets"
+	"github.com/fyltr/angee/internal/substitute"
+)
 
 type NotFoundError struct {
 	Kind string
@@ -42,3 +51,99 @@ func (e *InvalidInputError) Error() string {
 	}
 	return fmt.Sprintf("%s: %s", e.Field, e.Reason)
 }
+
+// PolicyError reports a manifest change rejected by the stack's `policy:`.
+type PolicyError struct {
+	Rule   string
+	Reason string
+}
+
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("policy %s: %s", e.Rule, e.Reason)
+}
+
+// RolledBackError reports a deploy that failed and was rolled back: the
+// stack is running again, but on an earlier angee.yaml than the one asked
+// for.
+type RolledBackError struct {
+	Err      error
+	Revision string
+}
+
+func (e *RolledBackError) Error() string {
+	return fmt.Sprintf("%v; rolled back to the angee.yaml of an earlier successful deploy (%s), and the rejected file is angee.yaml.rejected", e.Err, e.Revision)
+}
+
+func (e *RolledBackError) Unwrap() error { return e.Err }
+
+// ErrorCode classifies err for API and CLI clients: one of the api
+// ErrorCode values and, when there is an obvious next step, a hint for the
+// person reading it. Errors it does not recognize are internal.
+func ErrorCode(err error) (code, hint string) {
+	var (
+		rolledBack  *RolledBackError
+		notFound    *NotFoundError
+		conflict    *ConflictError
+		invalid     *InvalidInputError
+		manifestErr *manifest.InvalidError
+		policy      *PolicyError
+		scan        *ScanError
+		missing     *secrets.MissingError
+		port        *runtime.PortConflictError
+		runtimeDown *runtime.UnreachableError
+		secretsDown *secrets.UnreachableError
+		failed      *runtime.CommandError
+	)
+	switch {
+	case errors.As(err, &rolledBack):
+		return api.ErrorCodeRolledBack, "fix angee.yaml.rejected, copy it back to angee.yaml, and deploy again"
+	case errors.As(err, &notFound):
+		return api.ErrorCodeNotFound, notFoundHint(notFound.Kind)
+	case errors.As(err, &conflict):
+		if conflict.Kind == "manifest" {
+			return api.ErrorCodeConflict, "angee.yaml was changed by someone else; read the current revision from GET /stack/status, reapply the change, and retry"
+		}
+		if conflict.Kind == "plan" {
+			return api.ErrorCodeConflict, "run `angee plan` again, review the new output, approve it with `angee plan approve <id>`, and deploy with the token"
+		}
+		return api.ErrorCodeConflict, ""
+	case errors.As(err, &invalid):
+		return api.ErrorCodeInvalidInput, ""
+	case errors.As(err, &manifestErr):
+		return api.ErrorCodeInvalidInput, "fix angee.yaml; `angee stack validate` checks it without deploying"
+	case errors.As(err, &policy):
+		if policy.Rule == PolicyPlan {
+			return api.ErrorCodePolicy, "run `angee plan`, have the output reviewed, approve it with `angee plan approve <id>` on the stack's host, and deploy with `angee up --plan <token>`"
+		}
+		return api.ErrorCodePolicy, "the policy: block in angee.yaml rejects this change"
+	case errors.As(err, &scan):
+		return api.ErrorCodePolicy, "update the images, or loosen operator.scan in angee.yaml (severity, ignore_unfixed, or warn_only)"
+	case errors.Is(err, ErrHookSignature):
+		return api.ErrorCodeUnauthorized, "set the webhook secret in GitHub to the value of operator.hooks.github.secret"
+	case errors.As(err, &missing):
+		return api.ErrorCodeMissingSecret, fmt.Sprintf("store a value for %s in the secrets backend (for env-file, a %s= line in .env), or declare it with generated: true or import: env:NAME", missing.Name, substitute.SecretEnvName(missing.Name))
+	case errors.As(err, &port):
+		if port.Port == 0 {
+			return api.ErrorCodePortConflict, "stop what holds the port or change it under ports: in angee.yaml; `angee doctor` lists ports in use"
+		}
+		return api.ErrorCodePortConflict, fmt.Sprintf("stop what holds port %d or change it under ports: in angee.yaml; `angee doctor` lists ports in use", port.Port)
+	case errors.As(err, &runtimeDown):
+		if runtimeDown.Runtime == "docker" {
+			return api.ErrorCodeBackendUnreachable, "start Docker (or Docker Desktop) and check that `docker info` answers"
+		}
+		return api.ErrorCodeBackendUnreachable, fmt.Sprintf("install %s or add it to PATH", runtimeDown.Runtime)
+	case errors.As(err, &secretsDown):
+		return api.ErrorCodeBackendUnreachable, fmt.Sprintf("check that %s is running at secrets_backend.address (%s)", secretsDown.Backend, secretsDown.Address)
+	case errors.As(err, &failed):
+		return api.ErrorCodeRuntimeFailed, "`angee logs` shows what the failing service printed"
+	}
+	return api.ErrorCodeInternal, ""
+}
+
+func notFoundHint(kind string) string {
+	switch kind {
+	case "service", "job", "source", "workspace", "template":
+		return fmt.Sprintf("`angee %s list` shows what is declared", kind)
+	}
+	return ""
+}
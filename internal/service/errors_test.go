@@ -0,0 +1,38 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/fyltr/angee/api"
+	"github.com/fyltr/angee/internal/manifest"
+	"github.com/fyltr/angee/internal/runtime"
+	"github.com/fyltr/angee/internal/secrets"
+)
+
+func TestErrorCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		code string
+		hint string
+	}{
+		{&NotFoundError{Kind: "service", Name: "web"}, api.ErrorCodeNotFound, "angee service list"},
+		{&InvalidInputError{Field: "inputs.db", Reason: "required"}, api.ErrorCodeInvalidInput, ""},
+		{fmt.Errorf("compile: %w", &secrets.MissingError{Name: "db-password"}), api.ErrorCodeMissingSecret, "DB_PASSWORD="},
+		{fmt.Errorf("up: %w", &runtime.PortConflictError{Port: 8080, Err: errors.New("exit status 1")}), api.ErrorCodePortConflict, "port 8080"},
+		{&runtime.UnreachableError{Runtime: "docker", Err: errors.New("exit status 1")}, api.ErrorCodeBackendUnreachable, "docker info"},
+		{fmt.Errorf("hook: %w", ErrHookSignature), api.ErrorCodeUnauthorized, "operator.hooks.github.secret"},
+		{&manifest.InvalidError{Path: "angee.yaml", Err: errors.New("manifest name is required")}, api.ErrorCodeInvalidInput, "angee stack validate"},
+		{&runtime.CommandError{Runtime: "docker", Err: errors.New("exit status 1")}, api.ErrorCodeRuntimeFailed, "angee logs"},
+		{&RolledBackError{Err: &runtime.CommandError{Runtime: "docker", Err: errors.New("exit status 1")}, Revision: "sha256:ab"}, api.ErrorCodeRolledBack, "angee.yaml.rejected"},
+		{errors.New("boom"), api.ErrorCodeInternal, ""},
+	}
+	for _, tt := range tests {
+		code, hint := ErrorCode(tt.err)
+		if code != tt.code || !strings.Contains(hint, tt.hint) || tt.hint == "" && hint != "" {
+			t.Fatalf("ErrorCode(%v) = %q, %q; want %q with hint containing %q", tt.err, code, hint, tt.code, tt.hint)
+		}
+	}
+}
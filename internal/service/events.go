@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"sync"
+
+	"github.com/fyltr/angee/api"
+)
+
+// eventBuffer is how many events a subscriber may fall behind by before
+// further events are dropped for it, so a slow reader never delays the
+// deploy or job that raised them.
+const eventBuffer = 64
+
+// eventHub fans the platform's notifications out to live subscribers,
+// such as the operator's GET /events stream.
+type eventHub struct {
+	mu          sync.Mutex
+	subscribers map[chan api.Notification]struct{}
+}
+
+// Events follows the notifications this platform raises, the same events
+// operator.notifications posts to webhooks, whether or not any webhook is
+// declared. Only events raised after the call are delivered, and only by
+// this process, so it is useful inside the operator. The channel closes
+// when ctx is done.
+func (p *Platform) Events(ctx context.Context) <-chan api.Notification {
+	ch := make(chan api.Notification, eventBuffer)
+	p.events.mu.Lock()
+	if p.events.subscribers == nil {
+		p.events.subscribers = map[chan api.Notification]struct{}{}
+	}
+	p.events.subscribers[ch] = struct{}{}
+	p.events.mu.Unlock()
+	context.AfterFunc(ctx, func() {
+		p.events.mu.Lock()
+		defer p.events.mu.Unlock()
+		delete(p.events.subscribers, ch)
+		close(ch)
+	})
+	return ch
+}
+
+func (p *Platform) publish(event api.Notification) {
+	p.events.mu.Lock()
+	defer p.events.mu.Unlock()
+	for ch := range p.events.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
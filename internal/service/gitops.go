@@ -7,7 +7,6 @@ import (
 	"strings"
 
 	"github.com/fyltr/angee/api"
-	"github.com/fyltr/angee/internal/git"
 	"github.com/fyltr/angee/internal/manifest"
 )
 
@@ -133,7 +132,12 @@ func (p *Platform) WorkspaceSourceFetch(ctx context.Context, workspaceName, slot
 	if _, err := os.Stat(path); err != nil {
 		return api.WorkspaceSourceStatus{}, err
 	}
-	if err := git.New().Fetch(ctx, path); err != nil {
+	client, cleanup, err := p.sourceGitClient(ctx, source)
+	defer cleanup()
+	if err != nil {
+		return api.WorkspaceSourceStatus{}, err
+	}
+	if err := client.Fetch(ctx, path); err != nil {
 		return api.WorkspaceSourceStatus{}, err
 	}
 	return p.workspaceSourceStatus(ctx, workspaceName, slot, wsSource, stack), nil
@@ -150,7 +154,11 @@ func (p *Platform) WorkspaceSourcePull(ctx context.Context, workspaceName, slot
 	if err := p.ensureWorkspaceGitSourceOnExpectedBranch(ctx, workspaceName, slot, source, wsSource); err != nil {
 		return api.WorkspaceSourceStatus{}, err
 	}
-	client := git.New()
+	client, cleanup, err := p.sourceGitClient(ctx, source)
+	defer cleanup()
+	if err != nil {
+		return api.WorkspaceSourceStatus{}, err
+	}
 	dirty, err := client.Dirty(ctx, path)
 	if err != nil {
 		return api.WorkspaceSourceStatus{}, err
@@ -175,7 +183,11 @@ func (p *Platform) WorkspaceSourcePush(ctx context.Context, workspaceName, slot,
 	if err := p.ensureWorkspaceGitSourceOnExpectedBranch(ctx, workspaceName, slot, source, wsSource); err != nil {
 		return api.WorkspaceSourceStatus{}, err
 	}
-	client := git.New()
+	client, cleanup, err := p.sourceGitClient(ctx, source)
+	defer cleanup()
+	if err != nil {
+		return api.WorkspaceSourceStatus{}, err
+	}
 	dirty, err := client.Dirty(ctx, path)
 	if err != nil {
 		return api.WorkspaceSourceStatus{}, err
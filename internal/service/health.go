@@ -0,0 +1,83 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/fyltr/angee/internal/manifest"
+	"github.com/fyltr/angee/internal/runtime/compose"
+	"github.com/fyltr/angee/internal/runtime/proccompose"
+	"github.com/fyltr/angee/internal/substitute"
+)
+
+// composeHealthcheck renders a container readiness probe as a Docker
+// healthcheck; Docker has no success threshold, so only failures count.
+func composeHealthcheck(probe *manifest.Probe, ctx substitute.Context) (*compose.Healthcheck, error) {
+	if probe == nil {
+		return nil, nil
+	}
+	command, err := substitute.ResolveSlice(probe.Command, ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &compose.Healthcheck{
+		Test:        append([]string{"CMD"}, command...),
+		Interval:    probe.Interval,
+		Timeout:     probe.Timeout,
+		Retries:     probe.FailureThreshold,
+		StartPeriod: probe.StartPeriod,
+	}, nil
+}
+
+// processProbe renders a local service probe for process-compose, which
+// counts in whole seconds.
+func processProbe(probe *manifest.Probe, ctx substitute.Context, workdir string) (*proccompose.Probe, error) {
+	if probe == nil {
+		return nil, nil
+	}
+	out := &proccompose.Probe{
+		InitialDelaySeconds: durationSeconds(probe.StartPeriod),
+		PeriodSeconds:       durationSeconds(probe.Interval),
+		TimeoutSeconds:      durationSeconds(probe.Timeout),
+		SuccessThreshold:    probe.SuccessThreshold,
+		FailureThreshold:    probe.FailureThreshold,
+	}
+	if probe.HTTP != "" {
+		raw, err := substitute.Resolve(probe.HTTP, ctx)
+		if err != nil {
+			return nil, err
+		}
+		target, err := url.Parse(raw)
+		if err != nil || target.Hostname() == "" {
+			return nil, fmt.Errorf("invalid probe url %q", raw)
+		}
+		get := &proccompose.HTTPProbe{Host: target.Hostname(), Scheme: target.Scheme, Path: target.RequestURI()}
+		if port := target.Port(); port != "" {
+			get.Port, _ = strconv.Atoi(port)
+		}
+		out.HTTPGet = get
+		return out, nil
+	}
+	command, err := substitute.ResolveSlice(probe.Command, ctx)
+	if err != nil {
+		return nil, err
+	}
+	out.Exec = &proccompose.ExecProbe{Command: shellCommand(command), WorkingDir: workdir}
+	return out, nil
+}
+
+func durationSeconds(value string) int {
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0
+	}
+	return int(math.Ceil(d.Seconds()))
+}
+
+func hasReadiness(stack *manifest.Stack, name string) bool {
+	service, ok := stack.Services[name]
+	return ok && service.Health != nil && service.Health.Readiness != nil
+}
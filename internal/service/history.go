@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/fyltr/angee/api"
+)
+
+// HistoryQuery selects a page of a history such as the deploy ledger:
+// entries started in [Since, Until), newest first, beginning after Cursor
+// and at most Limit of them. Zero values do not filter.
+type HistoryQuery struct {
+	Limit  int
+	Cursor string
+	Since  time.Time
+	Until  time.Time
+}
+
+// DeployPage returns the page of the deploy ledger q selects and the
+// cursor of the next page, "" on the last one.
+func (p *Platform) DeployPage(ctx context.Context, q HistoryQuery) ([]api.Deploy, string, error) {
+	deploys, err := p.Deploys(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	return historyPage(deploys, func(d api.Deploy) time.Time { return d.StartedAt }, q)
+}
+
+// JobRunPage returns the page of a job's run history q selects and the
+// cursor of the next page, "" on the last one.
+func (p *Platform) JobRunPage(ctx context.Context, name string, q HistoryQuery) ([]api.JobRun, string, error) {
+	runs, err := p.JobRuns(ctx, name)
+	if err != nil {
+		return nil, "", err
+	}
+	return historyPage(runs, func(r api.JobRun) time.Time { return r.StartedAt }, q)
+}
+
+// historyPage pages entries, which are newest first. A cursor is the
+// position of an entry counted from the oldest, which appending newer
+// entries does not change, so paging stays stable while history grows.
+func historyPage[T any](entries []T, startedAt func(T) time.Time, q HistoryQuery) ([]T, string, error) {
+	if q.Limit < 0 {
+		return nil, "", &InvalidInputError{Field: "limit", Reason: "must not be negative"}
+	}
+	before := len(entries)
+	if q.Cursor != "" {
+		n, err := strconv.Atoi(q.Cursor)
+		if err != nil || n < 0 {
+			return nil, "", &InvalidInputError{Field: "cursor", Reason: "is not a cursor from a previous page"}
+		}
+		before = min(n, len(entries))
+	}
+	page := []T{}
+	for i := len(entries) - before; i < len(entries); i++ {
+		started := startedAt(entries[i])
+		// Entries are recorded when they finish, so an overlapping one can
+		// be out of start order; every entry is checked.
+		if !q.Until.IsZero() && !started.Before(q.Until) || !q.Since.IsZero() && started.Before(q.Since) {
+			continue
+		}
+		if q.Limit > 0 && len(page) == q.Limit {
+			return page, strconv.Itoa(len(entries) - i), nil
+		}
+		page = append(page, entries[i])
+	}
+	return page, "", nil
+}
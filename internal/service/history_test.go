@@ -0,0 +1,50 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestHistoryPage(t *testing.T) {
+	base := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	// Newest first, as Deploys returns them: hours 4, 3, 2, 1, 0.
+	var entries []time.Time
+	for hour := 4; hour >= 0; hour-- {
+		entries = append(entries, base.Add(time.Duration(hour)*time.Hour))
+	}
+	started := func(t time.Time) time.Time { return t }
+	hours := func(page []time.Time) []int {
+		var out []int
+		for _, t := range page {
+			out = append(out, t.Hour())
+		}
+		return out
+	}
+
+	page, cursor, err := historyPage(entries, started, HistoryQuery{Limit: 2})
+	if err != nil || !slices.Equal(hours(page), []int{4, 3}) || cursor == "" {
+		t.Fatalf("first page = %v, %q, %v", hours(page), cursor, err)
+	}
+	// A newer entry arriving between pages does not shift the next one.
+	grown := append([]time.Time{base.Add(5 * time.Hour)}, entries...)
+	page, cursor, err = historyPage(grown, started, HistoryQuery{Limit: 2, Cursor: cursor})
+	if err != nil || !slices.Equal(hours(page), []int{2, 1}) || cursor == "" {
+		t.Fatalf("second page = %v, %q, %v", hours(page), cursor, err)
+	}
+	page, cursor, err = historyPage(grown, started, HistoryQuery{Limit: 2, Cursor: cursor})
+	if err != nil || !slices.Equal(hours(page), []int{0}) || cursor != "" {
+		t.Fatalf("last page = %v, %q, %v", hours(page), cursor, err)
+	}
+
+	page, _, err = historyPage(entries, started, HistoryQuery{Since: base.Add(time.Hour), Until: base.Add(3 * time.Hour)})
+	if err != nil || !slices.Equal(hours(page), []int{2, 1}) {
+		t.Fatalf("since/until page = %v, %v", hours(page), err)
+	}
+
+	var invalid *InvalidInputError
+	if _, _, err := historyPage(entries, started, HistoryQuery{Cursor: "next"}); !errors.As(err, &invalid) || invalid.Field != "cursor" {
+		t.Fatalf("bad cursor error = %v", err)
+	}
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/fyltr/angee/api"
+	"github.com/fyltr/angee/internal/manifest"
+	"github.com/fyltr/angee/internal/substitute"
+)
+
+// ErrHookSignature rejects a webhook delivery whose signature does not
+// match the configured secret.
+var ErrHookSignature = errors.New("webhook signature does not match")
+
+type githubPush struct {
+	Ref        string `json:"ref"`
+	Deleted    bool   `json:"deleted"`
+	Repository struct {
+		FullName string `json:"full_name"`
+		CloneURL string `json:"clone_url"`
+		SSHURL   string `json:"ssh_url"`
+		HTMLURL  string `json:"html_url"`
+	} `json:"repository"`
+}
+
+// HookGitHub handles a GitHub webhook delivery. signature is the
+// X-Hub-Signature-256 header and event the X-GitHub-Event header. A push to
+// the configured branch of a repository that is a git source of the stack
+// starts an operation that pulls those sources, rebuilds the services built
+// from them when the hook sets build, and brings the stack up. The deploy
+// is recorded with the caller hook:github.
+func (p *Platform) HookGitHub(ctx context.Context, event, signature string, body []byte) (api.HookResponse, error) {
+	stack, err := p.LoadStack()
+	if err != nil {
+		return api.HookResponse{}, err
+	}
+	hook := stack.Operator.Hooks.GitHub
+	if hook == nil {
+		return api.HookResponse{}, &NotFoundError{Kind: "hook", Name: "github"}
+	}
+	secret, err := p.declaredSecret(ctx, hook.Secret)
+	if err != nil {
+		return api.HookResponse{}, err
+	}
+	if !validGitHubSignature(secret, signature, body) {
+		return api.HookResponse{}, ErrHookSignature
+	}
+	if event != "push" {
+		return api.HookResponse{Status: api.HookIgnored, Reason: fmt.Sprintf("event %q is not handled", event)}, nil
+	}
+	var push githubPush
+	if err := json.Unmarshal(body, &push); err != nil {
+		return api.HookResponse{}, &InvalidInputError{Field: "body", Reason: err.Error()}
+	}
+	branch := hook.Branch
+	if branch == "" {
+		branch = "main"
+	}
+	if push.Ref != "refs/heads/"+branch || push.Deleted {
+		return api.HookResponse{Status: api.HookIgnored, Reason: fmt.Sprintf("push to %s, not refs/heads/%s", push.Ref, branch)}, nil
+	}
+	sources := pushedSources(stack, push)
+	if len(sources) == 0 {
+		return api.HookResponse{Status: api.HookIgnored, Reason: fmt.Sprintf("repository %s is not a git source", push.Repository.FullName)}, nil
+	}
+	op := p.operations.start("hook", "github")
+	go func() {
+		ctx := WithCaller(context.WithoutCancel(ctx), "hook:github")
+		out, err := p.deployPush(ctx, sources, hook.Build)
+		p.operations.finish(op.ID, out, err)
+	}()
+	return api.HookResponse{Status: api.HookAccepted, Sources: sources, Operation: &op}, nil
+}
+
+func (p *Platform) deployPush(ctx context.Context, sources []string, build bool) ([]byte, error) {
+	var out bytes.Buffer
+	for _, name := range sources {
+		state, err := p.SourcePull(ctx, name)
+		if err != nil {
+			return out.Bytes(), err
+		}
+		fmt.Fprintf(&out, "pulled %s at %s\n", name, state.CurrentRef)
+	}
+	if build {
+		stack, err := p.LoadStack()
+		if err != nil {
+			return out.Bytes(), err
+		}
+		if services := servicesBuiltFrom(stack, p.root, sources); len(services) > 0 {
+			if err := p.StackBuild(ctx, services, BuildOptions{}); err != nil {
+				return out.Bytes(), err
+			}
+			fmt.Fprintf(&out, "built %s\n", strings.Join(services, ", "))
+		}
+	}
+	if err := p.StackUp(ctx, nil, UpOptions{Message: "push to " + strings.Join(sources, ", ")}); err != nil {
+		return out.Bytes(), err
+	}
+	out.WriteString("stack up\n")
+	return out.Bytes(), nil
+}
+
+func validGitHubSignature(secret, signature string, body []byte) bool {
+	got, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return false
+	}
+	sum, err := hex.DecodeString(got)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(sum, mac.Sum(nil))
+}
+
+// pushedSources returns the git sources cloned from the pushed repository,
+// whichever of its URLs the source uses.
+func pushedSources(stack *manifest.Stack, push githubPush) []string {
+	repos := map[string]bool{}
+	for _, url := range []string{push.Repository.CloneURL, push.Repository.SSHURL, push.Repository.HTMLURL} {
+		if url != "" {
+			repos[repoKey(url)] = true
+		}
+	}
+	var names []string
+	for _, name := range sortedKeys(stack.Sources) {
+		source := stack.Sources[name]
+		if source.Kind == "git" && repos[repoKey(source.Repo)] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// repoKey reduces a git URL to host/path, so the https, ssh, and scp-like
+// forms of one repository compare equal.
+func repoKey(url string) string {
+	key := strings.ToLower(strings.TrimSpace(url))
+	if _, rest, ok := strings.Cut(key, "://"); ok {
+		key = rest
+	} else if user, rest, ok := strings.Cut(key, "@"); ok && !strings.Contains(user, "/") {
+		key = strings.Replace(rest, ":", "/", 1)
+	}
+	if _, rest, ok := strings.Cut(key, "@"); ok {
+		key = rest
+	}
+	key = strings.TrimSuffix(strings.TrimSuffix(key, "/"), ".git")
+	return key
+}
+
+// servicesBuiltFrom returns the container services whose build context
+// lies in the checkout of one of sources, the images a push to those
+// sources changes. Contexts are resolved the way compile resolves them, so
+// `${source.app}/web` and `./sources/app` both count as built from app.
+func servicesBuiltFrom(stack *manifest.Stack, root string, sources []string) []string {
+	ctx := baseSubstitutionContext(stack, root, nil, nil)
+	var services []string
+	for _, name := range sortedKeys(stack.Services) {
+		service := stack.Services[name]
+		if service.Runtime != manifest.RuntimeContainer || service.Build == nil {
+			continue
+		}
+		contexts := buildContexts(service.Build)
+		for _, source := range sources {
+			checkout, ok := ctx.Sources[source]
+			if ok && slices.ContainsFunc(contexts, func(dir string) bool {
+				return buildContextIn(dir, checkout, root, ctx)
+			}) {
+				services = append(services, name)
+				break
+			}
+		}
+	}
+	return services
+}
+
+// buildContexts returns the directories a build spec reads from: the
+// string form, or the context and additional_contexts of the long form.
+func buildContexts(build any) []string {
+	switch build := build.(type) {
+	case string:
+		return []string{build}
+	case map[string]any:
+		var contexts []string
+		if dir, ok := build["context"].(string); ok {
+			contexts = append(contexts, dir)
+		}
+		if additional, ok := build["additional_contexts"].(map[string]any); ok {
+			for _, value := range additional {
+				if dir, ok := value.(string); ok {
+					contexts = append(contexts, dir)
+				}
+			}
+		}
+		return contexts
+	}
+	return nil
+}
+
+// buildContextIn reports whether the build context buildDir, resolved
+// against root, is dir or lies below it.
+func buildContextIn(buildDir, dir, root string, ctx substitute.Context) bool {
+	resolved, err := substitute.Resolve(buildDir, ctx)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(dir, manifest.ResolvePath(root, resolved))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/fyltr/angee/api"
+	"github.com/fyltr/angee/internal/manifest"
+)
+
+func TestHookGitHubVerifiesSignatureAndFiltersPushes(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("GITHUB_HOOK_SECRET", "hook-secret")
+	stack := &manifest.Stack{
+		Version:  manifest.VersionCurrent,
+		Kind:     manifest.KindStack,
+		Name:     "notes",
+		Secrets:  map[string]manifest.Secret{"github-hook": {Required: true, Import: "env:GITHUB_HOOK_SECRET"}},
+		Operator: manifest.Operator{Hooks: manifest.Hooks{GitHub: &manifest.GitHubHook{Secret: "github-hook", Branch: "release"}}},
+		Sources:  map[string]manifest.Source{"app": {Kind: "git", Repo: "git@github.com:example/app.git"}},
+	}
+	if err := manifest.SaveFile(manifest.Path(root), stack); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+	platform, err := New(root)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	sign := func(body string) string {
+		mac := hmac.New(sha256.New, []byte("hook-secret"))
+		mac.Write([]byte(body))
+		return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+	ctx := context.Background()
+
+	body := `{"ref":"refs/heads/release","repository":{"full_name":"example/app","clone_url":"https://github.com/example/app.git"}}`
+	if _, err := platform.HookGitHub(ctx, "push", "sha256=00", []byte(body)); !errors.Is(err, ErrHookSignature) {
+		t.Fatalf("HookGitHub(bad signature) error = %v, want ErrHookSignature", err)
+	}
+	for _, tc := range []struct{ event, body, reason string }{
+		{"ping", `{"zen":"hi"}`, `event "ping"`},
+		{"push", strings.Replace(body, "release", "main", 1), "not refs/heads/release"},
+		{"push", strings.Replace(body, "example/app", "example/other", 2), "not a git source"},
+	} {
+		resp, err := platform.HookGitHub(ctx, tc.event, sign(tc.body), []byte(tc.body))
+		if err != nil {
+			t.Fatalf("HookGitHub(%s) error = %v", tc.event, err)
+		}
+		if resp.Status != api.HookIgnored || !strings.Contains(resp.Reason, tc.reason) {
+			t.Fatalf("HookGitHub(%s) = %+v, want ignored with %q", tc.event, resp, tc.reason)
+		}
+	}
+}
+
+func TestRepoKeyMatchesGitURLForms(t *testing.T) {
+	want := "github.com/example/app"
+	for _, url := range []string{
+		"https://github.com/example/app.git",
+		"https://github.com/Example/app",
+		"git@github.com:example/app.git",
+		"ssh://git@github.com/example/app.git",
+		"https://token@github.com/example/app/",
+	} {
+		if got := repoKey(url); got != want {
+			t.Fatalf("repoKey(%q) = %q, want %q", url, got, want)
+		}
+	}
+}
+
+func TestServicesBuiltFromSource(t *testing.T) {
+	stack := &manifest.Stack{
+		Sources: map[string]manifest.Source{
+			"app":     {Kind: "git"},
+			"app-api": {Kind: "git"},
+			"docs":    {Kind: "git"},
+		},
+		Services: map[string]manifest.Service{
+			"web":    {Runtime: manifest.RuntimeContainer, Build: "${source.app}/web"},
+			"worker": {Runtime: manifest.RuntimeContainer, Build: map[string]any{"context": "${source.app.path}"}},
+			"cron":   {Runtime: manifest.RuntimeContainer, Build: "./sources/app"},
+			"assets": {Runtime: manifest.RuntimeContainer, Build: map[string]any{"context": ".", "additional_contexts": map[string]any{"app": "${source.app}"}}},
+			"api":    {Runtime: manifest.RuntimeContainer, Build: "${source.app-api}"},
+			"docs":   {Runtime: manifest.RuntimeContainer, Build: "${source.docs}", Image: "${source.app}"},
+			"db":     {Runtime: manifest.RuntimeContainer, Image: "postgres:16"},
+		},
+	}
+	if got := strings.Join(servicesBuiltFrom(stack, "/srv/stack", []string{"app"}), ","); got != "assets,cron,web,worker" {
+		t.Fatalf("servicesBuiltFrom() = %q, want assets,cron,web,worker", got)
+	}
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"strings"
+
+	"github.com/fyltr/angee/api"
+	"github.com/fyltr/angee/internal/fslock"
+	"github.com/fyltr/angee/internal/manifest"
+	"github.com/fyltr/angee/internal/runtime"
+)
+
+// StackLockImages resolves the `image:` of each container service to a
+// digest and records it in angee.lock, which every later compile pins the
+// image to. Without refresh only images not yet locked are resolved; with
+// it every image is resolved again, moving each to what its tag points at
+// now. Images the stack no longer uses are dropped from the lock.
+func (p *Platform) StackLockImages(ctx context.Context, refresh bool) (api.ImageLockResponse, error) {
+	resp := api.ImageLockResponse{Images: []api.ImageLock{}}
+	resolver, ok := p.composeBackend.(runtime.ImageResolver)
+	if !ok {
+		return resp, errors.New("the container backend cannot resolve image digests")
+	}
+	err := fslock.RootLock(p.root).With(ctx, func() error {
+		stack, err := p.LoadStack()
+		if err != nil {
+			return err
+		}
+		path := manifest.LockPath(p.root)
+		lock, err := manifest.LoadLock(path)
+		if err != nil {
+			return err
+		}
+		images := lockableImages(stack)
+		locked := make(map[string]string, len(images))
+		for _, image := range sortedKeys(images) {
+			digest := lock.Images[image]
+			if digest == "" || refresh {
+				if digest, err = resolver.ResolveImage(ctx, image); err != nil {
+					return err
+				}
+			}
+			locked[image] = digest
+			resp.Images = append(resp.Images, api.ImageLock{Image: image, Digest: digest, Services: images[image]})
+		}
+		if maps.Equal(locked, lock.Images) {
+			return nil
+		}
+		lock.Images = locked
+		return manifest.SaveLock(path, lock)
+	})
+	return resp, err
+}
+
+// lockableImages maps each image the stack pulls to the services using
+// it. Images a service builds, and references already naming a digest,
+// are left out. A stack with container services also pulls the
+// port-forward relay, listed as used by port-forward.
+func lockableImages(stack *manifest.Stack) map[string][]string {
+	images := map[string][]string{}
+	for _, name := range sortedKeys(stack.Services) {
+		service := stack.Services[name]
+		if service.Runtime != manifest.RuntimeContainer || service.Image == "" || service.Build != nil || strings.Contains(service.Image, "@") {
+			continue
+		}
+		images[service.Image] = append(images[service.Image], name)
+	}
+	if relay := relayImage(stack); hasContainerService(stack) && !strings.Contains(relay, "@") {
+		images[relay] = append(images[relay], "port-forward")
+	}
+	return images
+}
+
+func hasContainerService(stack *manifest.Stack) bool {
+	for _, service := range stack.Services {
+		if service.Runtime == manifest.RuntimeContainer {
+			return true
+		}
+	}
+	return false
+}
+
+// pinImages rewrites the compiled images angee.lock records to their
+// digests.
+func (p *Platform) pinImages(compiled *CompiledStack) error {
+	lock, err := manifest.LoadLock(manifest.LockPath(p.root))
+	if err != nil {
+		return err
+	}
+	for name, service := range compiled.Compose.Services {
+		if digest := lock.Images[service.Image]; digest != "" {
+			service.Image = manifest.PinnedImage(service.Image, digest)
+			compiled.Compose.Services[name] = service
+		}
+	}
+	return nil
+}
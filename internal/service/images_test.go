@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/fyltr/angee/internal/manifest"
+	"github.com/fyltr/angee/internal/runtime"
+)
+
+type resolveBackend struct {
+	runtime.Backend
+	digests  map[string]string
+	local    map[string]string
+	resolved *[]string
+}
+
+func (b resolveBackend) ResolveImage(_ context.Context, image string) (string, error) {
+	*b.resolved = append(*b.resolved, image)
+	return b.digests[image], nil
+}
+
+func (b resolveBackend) LocalImageDigest(_ context.Context, image string) (string, error) {
+	return b.local[image], nil
+}
+
+func TestStackLockImagesPinsCompiledImages(t *testing.T) {
+	root := t.TempDir()
+	stack := &manifest.Stack{
+		Version: manifest.VersionCurrent,
+		Kind:    manifest.KindStack,
+		Name:    "notes",
+		Services: map[string]manifest.Service{
+			"web":    {Runtime: manifest.RuntimeContainer, Image: "nginx:1"},
+			"proxy":  {Runtime: manifest.RuntimeContainer, Image: "nginx:1"},
+			"db":     {Runtime: manifest.RuntimeContainer, Image: "postgres:16@sha256:fixed"},
+			"worker": {Runtime: manifest.RuntimeContainer, Image: "notes-worker", Build: "."},
+		},
+	}
+	if err := manifest.SaveFile(manifest.Path(root), stack); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+	var resolved []string
+	backend := resolveBackend{digests: map[string]string{"nginx:1": "sha256:one", "alpine/socat": "sha256:relay"}, resolved: &resolved}
+	platform, err := NewWithBackends(root, backend, nil)
+	if err != nil {
+		t.Fatalf("NewWithBackends() error = %v", err)
+	}
+
+	resp, err := platform.StackLockImages(context.Background(), false)
+	if err != nil {
+		t.Fatalf("StackLockImages() error = %v", err)
+	}
+	if len(resp.Images) != 2 || resp.Images[1].Digest != "sha256:one" || strings.Join(resp.Images[1].Services, ",") != "proxy,web" {
+		t.Fatalf("StackLockImages() = %+v, want nginx:1 for proxy and web", resp)
+	}
+	if resp.Images[0].Image != "alpine/socat" || strings.Join(resp.Images[0].Services, ",") != "port-forward" {
+		t.Fatalf("StackLockImages() = %+v, want the port-forward relay locked", resp)
+	}
+	compiled, err := platform.StackPrepare(context.Background())
+	if err != nil {
+		t.Fatalf("StackPrepare() error = %v", err)
+	}
+	if got := compiled.Compose.Services["web"].Image; got != "nginx:1@sha256:one" {
+		t.Fatalf("web image = %q, want the locked digest", got)
+	}
+	if got := compiled.Compose.Services["db"].Image; got != "postgres:16@sha256:fixed" {
+		t.Fatalf("db image = %q, want it unchanged", got)
+	}
+
+	backend.digests["nginx:1"] = "sha256:two"
+	if _, err := platform.StackLockImages(context.Background(), false); err != nil {
+		t.Fatalf("second StackLockImages() error = %v", err)
+	}
+	if len(resolved) != 2 {
+		t.Fatalf("resolved %v, want locked images left alone without refresh", resolved)
+	}
+	if _, err := platform.StackLockImages(context.Background(), true); err != nil {
+		t.Fatalf("StackLockImages(refresh) error = %v", err)
+	}
+	data, err := os.ReadFile(manifest.LockPath(root))
+	if err != nil {
+		t.Fatalf("ReadFile(angee.lock) error = %v", err)
+	}
+	if !strings.Contains(string(data), "nginx:1: sha256:two") {
+		t.Fatalf("angee.lock = %s, want the refreshed digest", data)
+	}
+}
+
+func TestStackOutdatedComparesDeployedDigests(t *testing.T) {
+	root := t.TempDir()
+	stack := &manifest.Stack{
+		Version: manifest.VersionCurrent,
+		Kind:    manifest.KindStack,
+		Name:    "notes",
+		Services: map[string]manifest.Service{
+			"web":   {Runtime: manifest.RuntimeContainer, Image: "nginx:1"},
+			"db":    {Runtime: manifest.RuntimeContainer, Image: "postgres:16"},
+			"cache": {Runtime: manifest.RuntimeContainer, Image: "ghcr.io/acme/cache:2"},
+		},
+	}
+	if err := manifest.SaveFile(manifest.Path(root), stack); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+	if err := manifest.SaveLock(manifest.LockPath(root), &manifest.Lock{Images: map[string]string{"nginx:1": "sha256:old"}}); err != nil {
+		t.Fatalf("SaveLock() error = %v", err)
+	}
+	var resolved []string
+	backend := resolveBackend{
+		digests:  map[string]string{"nginx:1": "sha256:new", "postgres:16": "sha256:same"},
+		local:    map[string]string{"nginx:1": "sha256:new", "postgres:16": "sha256:same"},
+		resolved: &resolved,
+	}
+	platform, err := NewWithBackends(root, backend, nil)
+	if err != nil {
+		t.Fatalf("NewWithBackends() error = %v", err)
+	}
+	resp, err := platform.StackOutdated(context.Background())
+	if err != nil {
+		t.Fatalf("StackOutdated() error = %v", err)
+	}
+	if len(resp.Updates) != 1 {
+		t.Fatalf("StackOutdated() = %+v, want only the locked nginx image", resp.Updates)
+	}
+	update := resp.Updates[0]
+	if update.Name != "nginx:1" || update.Current != "sha256:old" || update.Latest != "sha256:new" || update.Link != "https://hub.docker.com/_/nginx/tags" {
+		t.Fatalf("update = %+v", update)
+	}
+	if strings.Join(resolved, ",") != "nginx:1,postgres:16" {
+		t.Fatalf("resolved %v, want the unpulled ghcr image skipped", resolved)
+	}
+}
+
+func TestImageLink(t *testing.T) {
+	for image, want := range map[string]string{
+		"postgres:16":                  "https://hub.docker.com/_/postgres/tags",
+		"grafana/grafana:latest":       "https://hub.docker.com/r/grafana/grafana/tags",
+		"docker.io/library/redis:7":    "https://hub.docker.com/_/redis/tags",
+		"ghcr.io/acme/app:1@sha256:ab": "https://ghcr.io/acme/app",
+		"localhost:5000/app:dev":       "https://localhost:5000/app",
+	} {
+		if got := imageLink(image); got != want {
+			t.Errorf("imageLink(%q) = %q, want %q", image, got, want)
+		}
+	}
+}
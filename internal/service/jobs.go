@@ -1,15 +1,21 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"time"
 
 	"github.com/fyltr/angee/api"
 	"github.com/fyltr/angee/internal/manifest"
 	mountx "github.com/fyltr/angee/internal/mount"
+	"github.com/fyltr/angee/internal/schedule"
 	"github.com/fyltr/angee/internal/secrets"
 	"github.com/fyltr/angee/internal/substitute"
 )
@@ -27,6 +33,169 @@ func (p *Platform) JobList(ctx context.Context) ([]api.JobState, error) {
 }
 
 func (p *Platform) JobRun(ctx context.Context, name string, inputs map[string]string) ([]byte, error) {
+	return p.runJob(ctx, name, inputs, JobTriggerManual)
+}
+
+// JobRuns returns the recorded runs of a job, newest first.
+func (p *Platform) JobRuns(ctx context.Context, name string) ([]api.JobRun, error) {
+	stack, err := p.LoadStack()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := stack.Jobs[name]; !ok {
+		return nil, &NotFoundError{Kind: "job", Name: name}
+	}
+	p.jobRunsMu.Lock()
+	defer p.jobRunsMu.Unlock()
+	data, err := os.ReadFile(p.jobRunsPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return []api.JobRun{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	runs := []api.JobRun{}
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var run api.JobRun
+		if err := json.Unmarshal(line, &run); err != nil {
+			return nil, fmt.Errorf("read job run history: %w", err)
+		}
+		if run.Job == name {
+			runs = append(runs, run)
+		}
+	}
+	slices.Reverse(runs)
+	return runs, nil
+}
+
+func (p *Platform) runJob(ctx context.Context, name string, inputs map[string]string, trigger string) ([]byte, error) {
+	started := time.Now().UTC()
+	out, err := p.execJob(ctx, name, inputs)
+	var notFound *NotFoundError
+	if errors.As(err, &notFound) {
+		return out, err
+	}
+	run := api.JobRun{Job: name, Trigger: trigger, Status: "succeeded", StartedAt: started, FinishedAt: time.Now().UTC(), RequestID: requestIDFromContext(ctx)}
+	if err != nil {
+		run.Status = "failed"
+		run.Error = err.Error()
+	}
+	// History is best-effort: a job that ran must not be reported as failed
+	// because its record could not be written.
+	_ = p.recordJobRun(run)
+	p.notifyJobRun(ctx, run)
+	return out, err
+}
+
+// jobRunsKept is how many runs of each job the history keeps.
+const jobRunsKept = 100
+
+func (p *Platform) recordJobRun(run api.JobRun) error {
+	p.jobRunsMu.Lock()
+	defer p.jobRunsMu.Unlock()
+	if err := appendJSONLine(p.jobRunsPath(), run); err != nil {
+		return err
+	}
+	return compactJSONLines(p.jobRunsPath(), keepJobRuns)
+}
+
+// keepJobRuns keeps the last jobRunsKept runs of each job.
+func keepJobRuns(lines [][]byte) [][]byte {
+	counts := map[string]int{}
+	kept := make([][]byte, 0, len(lines))
+	for i := len(lines) - 1; i >= 0; i-- {
+		var run struct {
+			Job string `json:"job"`
+		}
+		if err := json.Unmarshal(lines[i], &run); err == nil {
+			counts[run.Job]++
+			if counts[run.Job] > jobRunsKept {
+				continue
+			}
+		}
+		kept = append(kept, lines[i])
+	}
+	slices.Reverse(kept)
+	return kept
+}
+
+// appendJSONLine appends value as one line of a JSON Lines history file.
+// Callers hold the lock guarding path.
+func appendJSONLine(path string, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// compactJSONLines rewrites a JSON Lines history file with only the lines
+// keep returns, through a temporary file renamed into place, so a reader
+// never sees it half written. The file is left alone when keep drops
+// nothing. Callers hold the lock guarding path.
+func compactJSONLines(path string, keep func(lines [][]byte) [][]byte) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var lines [][]byte
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) > 0 {
+			lines = append(lines, line)
+		}
+	}
+	kept := keep(lines)
+	if len(kept) == len(lines) {
+		return nil
+	}
+	var buf bytes.Buffer
+	for _, line := range kept {
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+func (p *Platform) jobRunsPath() string {
+	return filepath.Join(p.root, "run", "job-runs.jsonl")
+}
+
+func jobState(name string, job manifest.Job, now time.Time) api.JobState {
+	state := api.JobState{Name: name, Runtime: string(job.Runtime), Schedule: job.Schedule}
+	if job.Schedule == "" {
+		return state
+	}
+	if sched, err := schedule.Parse(job.Schedule); err == nil {
+		if next := sched.Next(now); !next.IsZero() {
+			state.NextRun = &next
+		}
+	}
+	return state
+}
+
+func (p *Platform) execJob(ctx context.Context, name string, inputs map[string]string) ([]byte, error) {
 	stack, err := p.LoadStack()
 	if err != nil {
 		return nil, err
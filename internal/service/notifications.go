@@ -0,0 +1,111 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/fyltr/angee/api"
+	"github.com/fyltr/angee/internal/manifest"
+)
+
+// notificationTimeout bounds each webhook post, so an unreachable endpoint
+// delays the deploy or job that triggered it by at most this much.
+const notificationTimeout = 5 * time.Second
+
+func (p *Platform) notifyDeploy(ctx context.Context, deploy api.Deploy) {
+	event := manifest.EventDeploySucceeded
+	detail := fmt.Sprintf("in %s", (time.Duration(deploy.DurationMS) * time.Millisecond).Round(100*time.Millisecond))
+	if deploy.Status == "failed" {
+		event = manifest.EventDeployFailed
+		detail = deploy.Error
+	}
+	message := fmt.Sprintf("deploy %s by %s: %s", deploy.Status, deploy.Caller, detail)
+	if deploy.Commit != "" {
+		message += fmt.Sprintf(" (commit %s)", shortCommit(deploy.Commit))
+	}
+	p.notify(ctx, api.Notification{Event: event, Message: message, Deploy: &deploy})
+}
+
+func (p *Platform) notifyJobRun(ctx context.Context, run api.JobRun) {
+	if run.Status != "failed" {
+		return
+	}
+	message := fmt.Sprintf("job %s failed (%s): %s", run.Job, run.Trigger, run.Error)
+	p.notify(ctx, api.Notification{Event: manifest.EventJobFailed, Message: message, JobRun: &run})
+}
+
+// notify publishes event to Events subscribers and posts it to every
+// notification in operator.notifications that subscribes to it. Like the
+// histories it is best-effort: a webhook that fails or times out is skipped.
+func (p *Platform) notify(ctx context.Context, event api.Notification) {
+	stack, err := p.LoadStack()
+	if err != nil {
+		return
+	}
+	event.Stack = stack.Name
+	event.Message = stack.Name + ": " + event.Message
+	event.Time = time.Now().UTC()
+	p.publish(event)
+	if len(stack.Operator.Notifications) == 0 {
+		return
+	}
+	// The event's own context may already be cancelled, e.g. a failed deploy
+	// interrupted by the caller; delivery still goes ahead.
+	ctx = context.WithoutCancel(ctx)
+	for _, name := range sortedKeys(stack.Operator.Notifications) {
+		notification := stack.Operator.Notifications[name]
+		if len(notification.Events) > 0 && !slices.Contains(notification.Events, event.Event) {
+			continue
+		}
+		_ = p.postNotification(ctx, notification, event)
+	}
+}
+
+func (p *Platform) postNotification(ctx context.Context, notification manifest.Notification, event api.Notification) error {
+	url := notification.URL
+	if notification.URLSecret != "" {
+		var err error
+		if url, err = p.declaredSecret(ctx, notification.URLSecret); err != nil {
+			return err
+		}
+	}
+	var body any = event
+	switch notification.Format {
+	case manifest.NotificationSlack:
+		body = map[string]string{"text": event.Message}
+	case manifest.NotificationDiscord:
+		body = map[string]string{"content": event.Message}
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, notificationTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("notification webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
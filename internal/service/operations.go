@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/fyltr/angee/api"
+)
+
+// maxFinishedOperations bounds how many completed operations are kept for
+// polling. Running operations are never evicted.
+const maxFinishedOperations = 100
+
+type operationStore struct {
+	mu       sync.Mutex
+	items    map[string]*api.Operation
+	finished []string
+}
+
+func newOperationStore() *operationStore {
+	return &operationStore{items: map[string]*api.Operation{}}
+}
+
+func (s *operationStore) start(kind, name string) api.Operation {
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+	op := &api.Operation{
+		ID:        hex.EncodeToString(id),
+		Kind:      kind,
+		Name:      name,
+		Status:    api.OperationRunning,
+		StartedAt: time.Now().UTC(),
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items[op.ID] = op
+	return *op
+}
+
+func (s *operationStore) finish(id string, output []byte, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	op, ok := s.items[id]
+	if !ok {
+		return
+	}
+	ended := time.Now().UTC()
+	op.EndedAt = &ended
+	op.Output = string(output)
+	op.Status = api.OperationSucceeded
+	if err != nil {
+		op.Status = api.OperationFailed
+		op.Message = err.Error()
+	}
+	s.finished = append(s.finished, id)
+	if len(s.finished) > maxFinishedOperations {
+		delete(s.items, s.finished[0])
+		s.finished = s.finished[1:]
+	}
+}
+
+func (s *operationStore) get(id string) (api.Operation, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	op, ok := s.items[id]
+	if !ok {
+		return api.Operation{}, false
+	}
+	return *op, true
+}
+
+// JobStart runs a job in the background and returns an operation that can be
+// polled with OperationGet. The run is detached from ctx so it outlives the
+// request that started it; ctx only bounds the existence check.
+func (p *Platform) JobStart(ctx context.Context, name string, inputs map[string]string) (api.Operation, error) {
+	stack, err := p.LoadStack()
+	if err != nil {
+		return api.Operation{}, err
+	}
+	if _, ok := stack.Jobs[name]; !ok {
+		return api.Operation{}, &NotFoundError{Kind: "job", Name: name}
+	}
+	op := p.operations.start("job", name)
+	go func() {
+		out, err := p.runJob(context.WithoutCancel(ctx), name, inputs, JobTriggerManual)
+		p.operations.finish(op.ID, out, err)
+	}()
+	return op, nil
+}
+
+func (p *Platform) OperationGet(ctx context.Context, id string) (api.Operation, error) {
+	op, ok := p.operations.get(id)
+	if !ok {
+		return api.Operation{}, &NotFoundError{Kind: "operation", Name: id}
+	}
+	return op, nil
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/fyltr/angee/api"
+	"github.com/fyltr/angee/internal/manifest"
+	"github.com/fyltr/angee/internal/runtime"
+)
+
+// StackOutdated reports what in the stack has moved on upstream: images
+// whose tag now points at a different digest than the one deployed, the
+// stack template when commits have landed in it, and git sources behind
+// their upstream as of their last fetch. Anything that could not be
+// checked, such as an image in a registry that refuses the lookup, is
+// listed with its error instead of failing the report.
+func (p *Platform) StackOutdated(ctx context.Context) (api.OutdatedResponse, error) {
+	resp := api.OutdatedResponse{Updates: []api.Update{}}
+	stack, err := p.LoadStack()
+	if err != nil {
+		return resp, err
+	}
+	images, err := p.outdatedImages(ctx, stack)
+	if err != nil {
+		return resp, err
+	}
+	resp.Updates = append(resp.Updates, images...)
+
+	template, err := p.TemplateStatus(ctx)
+	var notFound *NotFoundError
+	switch {
+	case errors.As(err, &notFound):
+	case err != nil:
+		resp.Updates = append(resp.Updates, api.Update{Kind: api.UpdateTemplate, Name: stack.Template.Source, Error: err.Error()})
+	case template.State == api.TemplateOutdated:
+		resp.Updates = append(resp.Updates, api.Update{
+			Kind:    api.UpdateTemplate,
+			Name:    template.Source,
+			Current: template.Commit,
+			Latest:  template.Latest,
+			Behind:  template.Behind,
+			Link:    templateLink(template.Source),
+		})
+	}
+
+	sources, err := p.SourceList(ctx)
+	if err != nil {
+		return resp, err
+	}
+	for _, source := range sources {
+		switch {
+		case source.Error != "":
+			resp.Updates = append(resp.Updates, api.Update{Kind: api.UpdateSource, Name: source.Name, Error: source.Error})
+		case source.Behind > 0:
+			resp.Updates = append(resp.Updates, api.Update{
+				Kind:    api.UpdateSource,
+				Name:    source.Name,
+				Current: source.CurrentRef,
+				Latest:  source.Upstream,
+				Behind:  source.Behind,
+				Link:    "https://" + repoKey(stack.Sources[source.Name].Repo),
+			})
+		}
+	}
+	return resp, nil
+}
+
+// outdatedImages compares the digest each image is deployed at, the one in
+// angee.lock or else the one pulled locally, with the digest its tag
+// points at now. Images that are neither locked nor pulled are skipped.
+func (p *Platform) outdatedImages(ctx context.Context, stack *manifest.Stack) ([]api.Update, error) {
+	resolver, ok := p.composeBackend.(runtime.ImageResolver)
+	if !ok {
+		return nil, nil
+	}
+	lock, err := manifest.LoadLock(manifest.LockPath(p.root))
+	if err != nil {
+		return nil, err
+	}
+	var updates []api.Update
+	images := lockableImages(stack)
+	for _, image := range sortedKeys(images) {
+		update := api.Update{Kind: api.UpdateImage, Name: image, Services: images[image], Link: imageLink(image)}
+		current := lock.Images[image]
+		if current == "" {
+			if current, err = resolver.LocalImageDigest(ctx, image); err != nil {
+				update.Error = err.Error()
+				updates = append(updates, update)
+				continue
+			}
+		}
+		if current == "" {
+			continue
+		}
+		latest, err := resolver.ResolveImage(ctx, image)
+		if err != nil {
+			update.Error = err.Error()
+			updates = append(updates, update)
+			continue
+		}
+		if latest != current {
+			update.Current, update.Latest = current, latest
+			updates = append(updates, update)
+		}
+	}
+	return updates, nil
+}
+
+// imageLink points at the page listing an image's tags: Docker Hub for
+// images without a registry host, otherwise the repository on its host,
+// which for ghcr.io and quay.io is the image's page.
+func imageLink(image string) string {
+	repository, _, _ := strings.Cut(image, "@")
+	if colon := strings.LastIndex(repository, ":"); colon > strings.LastIndex(repository, "/") {
+		repository = repository[:colon]
+	}
+	host, rest, ok := strings.Cut(repository, "/")
+	switch {
+	case !ok:
+		return "https://hub.docker.com/_/" + repository + "/tags"
+	case !strings.ContainsAny(host, ".:") && host != "localhost":
+		return "https://hub.docker.com/r/" + repository + "/tags"
+	case host == "docker.io" || host == "index.docker.io":
+		return imageLink(strings.TrimPrefix(rest, "library/"))
+	default:
+		return "https://" + repository
+	}
+}
+
+// templateLink points at the repository of a remote stack template, ""
+// for a local one.
+func templateLink(source string) string {
+	if strings.HasPrefix(source, "github:") {
+		if repoURL, _, _, err := parseGitHubShorthand(source); err == nil {
+			return "https://" + repoKey(repoURL)
+		}
+		return ""
+	}
+	if strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://") {
+		return source
+	}
+	return ""
+}
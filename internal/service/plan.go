@@ -0,0 +1,280 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fyltr/angee/api"
+	"github.com/fyltr/angee/internal/manifest"
+	"github.com/fyltr/angee/internal/runtime/compose"
+	"github.com/fyltr/angee/internal/runtime/proccompose"
+)
+
+// PlanTTL is how long a plan can be approved and deployed after it is
+// made.
+const PlanTTL = 24 * time.Hour
+
+// planClaims is what a plan ID encodes and its approval signs: the
+// angee.yaml revision and the digest of the compiled files it was planned
+// from.
+type planClaims struct {
+	Manifest  string `json:"manifest"`
+	Compiled  string `json:"compiled"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// StackPlan compiles the stack without applying it and returns the
+// compiled files with an ID naming exactly them. The ID does not deploy
+// anything by itself: ApprovePlan turns it into a token for `up`, so
+// whoever proposes a change through the API cannot also approve it.
+func (p *Platform) StackPlan(ctx context.Context) (api.Plan, error) {
+	compiled, err := p.StackCompile(ctx)
+	if err != nil {
+		return api.Plan{}, err
+	}
+	revision, err := p.manifestRevision()
+	if err != nil {
+		return api.Plan{}, err
+	}
+	expires := time.Now().UTC().Add(PlanTTL).Truncate(time.Second)
+	plan, err := planOf(compiled, planClaims{Manifest: revision, ExpiresAt: expires.Unix()})
+	if err != nil {
+		return api.Plan{}, err
+	}
+	plan.ID, err = encodePlan(planClaims{Manifest: plan.Manifest, Compiled: plan.Compiled, ExpiresAt: expires.Unix()})
+	if err != nil {
+		return api.Plan{}, err
+	}
+	return plan, nil
+}
+
+// ApprovePlan signs the plan id from StackPlan with the root's plan key and
+// returns it with the token `up --plan` deploys. The stack must still
+// compile to what was planned. Only the CLI on the stack's host offers it,
+// since it needs run/plan.key; the operator does not, so an API caller
+// cannot approve the plans it makes.
+func (p *Platform) ApprovePlan(ctx context.Context, id string) (api.Plan, error) {
+	claims, err := decodePlan(id)
+	if err != nil {
+		return api.Plan{}, err
+	}
+	compiled, err := p.StackCompile(ctx)
+	if err != nil {
+		return api.Plan{}, err
+	}
+	if err := p.matchPlan(claims, compiled); err != nil {
+		return api.Plan{}, err
+	}
+	plan, err := planOf(compiled, claims)
+	if err != nil {
+		return api.Plan{}, err
+	}
+	plan.ID = id
+	if plan.Token, err = p.signPlan(id); err != nil {
+		return api.Plan{}, err
+	}
+	return plan, nil
+}
+
+// planOf describes compiled as a plan made from claims' manifest revision
+// and expiring with them.
+func planOf(compiled *CompiledStack, claims planClaims) (api.Plan, error) {
+	composeData, processData, err := compiledFiles(compiled)
+	if err != nil {
+		return api.Plan{}, err
+	}
+	return api.Plan{
+		Manifest:       claims.Manifest,
+		Compiled:       compiledDigest(composeData, processData),
+		Services:       sortedKeys(compiled.Compose.Services),
+		Processes:      sortedKeys(compiled.ProcessCompose.Processes),
+		Compose:        string(composeData),
+		ProcessCompose: string(processData),
+		ExpiresAt:      time.Unix(claims.ExpiresAt, 0).UTC(),
+	}, nil
+}
+
+// checkPlan admits a deploy of compiled. With a token, it must carry an
+// approval, and angee.yaml and the compiled files must be the ones it was
+// issued for; without one, the deploy is refused when the stack's policy
+// requires a plan.
+func (p *Platform) checkPlan(stack *manifest.Stack, compiled *CompiledStack, token string) error {
+	if token == "" {
+		if stack.Policy != nil && stack.Policy.RequirePlan {
+			return &PolicyError{Rule: PolicyPlan, Reason: "deploys need an approved plan token"}
+		}
+		return nil
+	}
+	claims, err := p.verifyPlan(token)
+	if err != nil {
+		return err
+	}
+	return p.matchPlan(claims, compiled)
+}
+
+// matchPlan checks that claims have not expired and that angee.yaml and
+// compiled are still what they were planned from.
+func (p *Platform) matchPlan(claims planClaims, compiled *CompiledStack) error {
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return &InvalidInputError{Field: "plan", Reason: "expired at " + time.Unix(claims.ExpiresAt, 0).UTC().Format(time.RFC3339)}
+	}
+	revision, err := p.manifestRevision()
+	if err != nil {
+		return err
+	}
+	if revision != claims.Manifest {
+		return &ConflictError{Kind: "plan", Name: "angee.yaml", Reason: "changed since the plan (planned " + claims.Manifest + ", now " + revision + ")"}
+	}
+	composeData, processData, err := compiledFiles(compiled)
+	if err != nil {
+		return err
+	}
+	if digest := compiledDigest(composeData, processData); digest != claims.Compiled {
+		return &ConflictError{Kind: "plan", Name: "compiled stack", Reason: "compiles differently than when planned, for example after a source or angee.lock changed"}
+	}
+	return nil
+}
+
+func compiledFiles(compiled *CompiledStack) (composeData, processData []byte, err error) {
+	if len(compiled.Compose.Services) > 0 {
+		if composeData, err = compose.Marshal(compiled.Compose); err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(compiled.ProcessCompose.Processes) > 0 {
+		if processData, err = proccompose.Marshal(compiled.ProcessCompose); err != nil {
+			return nil, nil, err
+		}
+	}
+	return composeData, processData, nil
+}
+
+func compiledDigest(composeData, processData []byte) string {
+	sum := sha256.New()
+	sum.Write(composeData)
+	sum.Write([]byte{0})
+	sum.Write(processData)
+	return "sha256:" + hex.EncodeToString(sum.Sum(nil))
+}
+
+// encodePlan encodes claims as base64url JSON, the plan ID.
+func encodePlan(claims planClaims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(payload), nil
+}
+
+func decodePlan(id string) (planClaims, error) {
+	payload, err := base64.RawURLEncoding.DecodeString(id)
+	if err != nil {
+		return planClaims{}, &InvalidInputError{Field: "plan", Reason: "is not a plan ID from `angee plan`"}
+	}
+	var claims planClaims
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Manifest == "" || claims.Compiled == "" {
+		return planClaims{}, &InvalidInputError{Field: "plan", Reason: "is not a plan ID from `angee plan`"}
+	}
+	return claims, nil
+}
+
+// signPlan approves the plan id: the token is the ID followed by its
+// HMAC-SHA256 under the root's plan key.
+func (p *Platform) signPlan(id string) (string, error) {
+	key, err := p.planKey()
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(id))
+	return id + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+func (p *Platform) verifyPlan(token string) (planClaims, error) {
+	invalid := &InvalidInputError{Field: "plan", Reason: "is not a plan token issued for this stack"}
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		if _, err := decodePlan(token); err == nil {
+			return planClaims{}, &InvalidInputError{Field: "plan", Reason: "is a plan ID that has not been approved; approve it with `angee plan approve` on the stack's host"}
+		}
+		return planClaims{}, invalid
+	}
+	sum, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		return planClaims{}, invalid
+	}
+	key, err := p.planKey()
+	if err != nil {
+		return planClaims{}, err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(encoded))
+	if !hmac.Equal(sum, mac.Sum(nil)) {
+		return planClaims{}, invalid
+	}
+	claims, err := decodePlan(encoded)
+	if err != nil {
+		return planClaims{}, invalid
+	}
+	return claims, nil
+}
+
+// planKey returns the key plan tokens are signed with, kept in
+// run/plan.key and created on first use. Removing the file revokes every
+// outstanding plan.
+func (p *Platform) planKey() ([]byte, error) {
+	path := filepath.Join(p.root, "run", "plan.key")
+	data, err := os.ReadFile(path)
+	if err == nil {
+		key := bytes.TrimSpace(data)
+		if _, decodeErr := hex.DecodeString(string(key)); decodeErr != nil || len(key) != 2*sha256.Size {
+			return nil, fmt.Errorf("%s is not a plan key; remove it to create a new one, which revokes every outstanding plan", path)
+		}
+		return key, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	var key [sha256.Size]byte
+	if _, err := rand.Read(key[:]); err != nil {
+		return nil, err
+	}
+	encoded := []byte(hex.EncodeToString(key[:]))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	// Write the key in full before linking it into place, so a concurrent
+	// first use never reads a partial key; the link fails if another one
+	// got there first.
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".plan.key-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(encoded); err != nil {
+		tmp.Close()
+		return nil, fmt.Errorf("write plan key: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, fmt.Errorf("write plan key: %w", err)
+	}
+	if err := os.Link(tmp.Name(), path); errors.Is(err, os.ErrExist) {
+		// Another plan created it first.
+		return p.planKey()
+	} else if err != nil {
+		return nil, fmt.Errorf("write plan key: %w", err)
+	}
+	return encoded, nil
+}
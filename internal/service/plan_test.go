@@ -0,0 +1,116 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/fyltr/angee/internal/manifest"
+)
+
+func TestStackUpDeploysOnlyThePlannedStack(t *testing.T) {
+	root := t.TempDir()
+	stack := &manifest.Stack{
+		Version: manifest.VersionCurrent,
+		Kind:    manifest.KindStack,
+		Name:    "notes",
+		Policy:  &manifest.Policy{RequirePlan: true},
+		Services: map[string]manifest.Service{
+			"web": {Runtime: manifest.RuntimeContainer, Image: "nginx:1"},
+		},
+	}
+	if err := manifest.SaveFile(manifest.Path(root), stack); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+	platform, err := NewWithBackends(root, upBackend{}, nil)
+	if err != nil {
+		t.Fatalf("NewWithBackends() error = %v", err)
+	}
+	ctx := context.Background()
+
+	var policyErr *PolicyError
+	if err := platform.StackUp(ctx, nil, UpOptions{}); !errors.As(err, &policyErr) || policyErr.Rule != PolicyPlan {
+		t.Fatalf("StackUp() without a plan error = %v, want the plan policy", err)
+	}
+	plan, err := platform.StackPlan(ctx)
+	if err != nil {
+		t.Fatalf("StackPlan() error = %v", err)
+	}
+	if !strings.Contains(plan.Compose, "nginx:1") || len(plan.Services) != 1 || plan.Services[0] != "web" || plan.ID == "" || plan.Token != "" {
+		t.Fatalf("StackPlan() = %+v", plan)
+	}
+	var invalid *InvalidInputError
+	if err := platform.StackUp(ctx, nil, UpOptions{Plan: plan.ID}); !errors.As(err, &invalid) || invalid.Field != "plan" {
+		t.Fatalf("StackUp() with an unapproved plan error = %v, want invalid plan", err)
+	}
+	approved, err := platform.ApprovePlan(ctx, plan.ID)
+	if err != nil {
+		t.Fatalf("ApprovePlan() error = %v", err)
+	}
+	if approved.ID != plan.ID || approved.Compiled != plan.Compiled || approved.Token == "" {
+		t.Fatalf("ApprovePlan() = %+v, want %+v with a token", approved, plan)
+	}
+	if err := platform.StackUp(ctx, nil, UpOptions{Plan: approved.Token + "x"}); !errors.As(err, &invalid) || invalid.Field != "plan" {
+		t.Fatalf("StackUp() with a tampered plan error = %v, want invalid plan", err)
+	}
+	if err := platform.StackUp(ctx, nil, UpOptions{Plan: approved.Token}); err != nil {
+		t.Fatalf("StackUp() with the plan error = %v", err)
+	}
+
+	stack.Services["web"] = manifest.Service{Runtime: manifest.RuntimeContainer, Image: "nginx:2"}
+	if err := manifest.SaveFile(manifest.Path(root), stack); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+	var conflict *ConflictError
+	if err := platform.StackUp(ctx, nil, UpOptions{Plan: approved.Token}); !errors.As(err, &conflict) || conflict.Kind != "plan" {
+		t.Fatalf("StackUp() after angee.yaml changed error = %v, want a plan conflict", err)
+	}
+	if _, err := platform.ApprovePlan(ctx, plan.ID); !errors.As(err, &conflict) || conflict.Kind != "plan" {
+		t.Fatalf("ApprovePlan() after angee.yaml changed error = %v, want a plan conflict", err)
+	}
+}
+
+func TestPolicyKeepsRequirePlan(t *testing.T) {
+	platform := &Platform{root: t.TempDir()}
+	current := &manifest.Stack{Policy: &manifest.Policy{RequirePlan: true}}
+	var policyErr *PolicyError
+	if err := platform.checkPolicy(context.Background(), current, &manifest.Stack{}); !errors.As(err, &policyErr) || policyErr.Rule != PolicyPlan {
+		t.Fatalf("checkPolicy() error = %v, want the plan rule", err)
+	}
+	if err := platform.checkPolicy(context.Background(), current, current); err != nil {
+		t.Fatalf("checkPolicy() error = %v", err)
+	}
+}
+
+func TestPlanKeyIsCreatedOnceAndChecked(t *testing.T) {
+	platform := &Platform{root: t.TempDir()}
+	keys := make([][]byte, 8)
+	errs := make([]error, len(keys))
+	var wg sync.WaitGroup
+	for i := range keys {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			keys[i], errs[i] = platform.planKey()
+		}()
+	}
+	wg.Wait()
+	for i := range keys {
+		if errs[i] != nil || len(keys[i]) != 64 || !bytes.Equal(keys[i], keys[0]) {
+			t.Fatalf("planKey() = %q, %v; want one 64-character key for every caller", keys[i], errs[i])
+		}
+	}
+
+	path := filepath.Join(platform.root, "run", "plan.key")
+	if err := os.WriteFile(path, []byte("abc"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := platform.planKey(); err == nil || !strings.Contains(err.Error(), "is not a plan key") {
+		t.Fatalf("planKey() error = %v, want a short key rejected", err)
+	}
+}
@@ -1,12 +1,15 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/fyltr/angee/api"
 	"github.com/fyltr/angee/internal/fslock"
@@ -24,6 +27,17 @@ type Platform struct {
 	root           string
 	composeBackend runtime.Backend
 	procBackend    runtime.Backend
+
+	jobRunsMu  sync.Mutex
+	deploysMu  sync.Mutex
+	manifestMu sync.Mutex
+	operations *operationStore
+	alerts     alertState
+	stats      statsCache
+	events     eventHub
+
+	refreshTemplates bool
+	templateFetches  templateFetches
 }
 
 type CompiledStack struct {
@@ -44,7 +58,7 @@ func New(root string) (*Platform, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &Platform{root: abs, composeBackend: compose.NewBackend(), procBackend: proccompose.NewBackend()}, nil
+	return &Platform{root: abs, composeBackend: compose.NewBackend(), procBackend: proccompose.NewBackend(), operations: newOperationStore()}, nil
 }
 
 func NewWithBackends(root string, composeBackend, procBackend runtime.Backend) (*Platform, error) {
@@ -95,7 +109,13 @@ func (p *Platform) StackPrepare(ctx context.Context) (*CompiledStack, error) {
 		if err != nil {
 			return err
 		}
-		return p.writeCompiled(compiled)
+		if err := p.pinImages(compiled); err != nil {
+			return err
+		}
+		if err := p.writeCompiled(compiled); err != nil {
+			return err
+		}
+		return p.writeDevcontainers(stack, compiled)
 	})
 	return compiled, err
 }
@@ -122,7 +142,7 @@ func (p *Platform) writeRuntimeEnv(stack *manifest.Stack, resolved map[string]st
 		out.WriteString(resolved[key])
 		out.WriteByte('\n')
 	}
-	return os.WriteFile(path, []byte(out.String()), 0o600)
+	return writeGenerated(path, []byte(out.String()), 0o600)
 }
 
 func (p *Platform) StackCompile(ctx context.Context) (*CompiledStack, error) {
@@ -141,7 +161,29 @@ func (p *Platform) StackCompile(ctx context.Context) (*CompiledStack, error) {
 	if err := p.materializeReferencedSources(ctx, stack); err != nil {
 		return nil, err
 	}
-	return Compile(stack, p.root, resolvedSecrets)
+	compiled, err := Compile(stack, p.root, resolvedSecrets)
+	if err != nil {
+		return nil, err
+	}
+	return compiled, p.pinImages(compiled)
+}
+
+// StackValidate loads angee.yaml and compiles it without reading secret
+// values or fetching sources, so it runs anywhere the root is checked out,
+// such as CI. Declared secrets compile to their env placeholders as usual.
+func (p *Platform) StackValidate(ctx context.Context) (*CompiledStack, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	stack, err := p.LoadStack()
+	if err != nil {
+		return nil, err
+	}
+	placeholders := make(map[string]string, len(stack.Secrets))
+	for name := range stack.Secrets {
+		placeholders[name] = ""
+	}
+	return Compile(stack, p.root, placeholders)
 }
 
 func (p *Platform) StackStatus(ctx context.Context) (api.StackStatusResponse, error) {
@@ -152,9 +194,14 @@ func (p *Platform) StackStatus(ctx context.Context) (api.StackStatusResponse, er
 	if err != nil {
 		return api.StackStatusResponse{}, err
 	}
+	revision, err := p.manifestRevision()
+	if err != nil {
+		return api.StackStatusResponse{}, err
+	}
 	resp := api.StackStatusResponse{
 		Root:       p.root,
 		Name:       stack.Name,
+		Revision:   revision,
 		Services:   map[string]api.ServiceState{},
 		Jobs:       map[string]api.JobState{},
 		Workspaces: map[string]api.WorkspaceRef{},
@@ -165,7 +212,7 @@ func (p *Platform) StackStatus(ctx context.Context) (api.StackStatusResponse, er
 	}
 	for _, name := range sortedKeys(stack.Jobs) {
 		job := stack.Jobs[name]
-		resp.Jobs[name] = api.JobState{Name: name, Runtime: string(job.Runtime)}
+		resp.Jobs[name] = jobState(name, job, time.Now())
 	}
 	for _, name := range sortedKeys(stack.Workspaces) {
 		workspace := stack.Workspaces[name]
@@ -177,6 +224,7 @@ func (p *Platform) StackStatus(ctx context.Context) (api.StackStatusResponse, er
 			TTLExpiresAt: workspace.TTLExpiresAt,
 		}
 	}
+	p.applyAlerts(resp.Services)
 	return resp, nil
 }
 
@@ -192,6 +240,9 @@ func Compile(stack *manifest.Stack, root string, resolvedSecrets map[string]stri
 		Compose: compose.File{
 			Name:     stack.Name,
 			Services: map[string]compose.Service{},
+			// Volumes and the default network carry the stack label, like
+			// containers, so `angee prune` can find the ones left behind.
+			Networks: map[string]compose.Network{"default": {Labels: map[string]string{LabelStack: stack.Name}}},
 			Volumes:  map[string]compose.Volume{},
 		},
 		ProcessCompose: proccompose.File{
@@ -202,7 +253,11 @@ func Compile(stack *manifest.Stack, root string, resolvedSecrets map[string]stri
 	}
 
 	for name, volume := range stack.Volumes {
-		compiled.Compose.Volumes[name] = compose.Volume{Driver: composeVolumeDriver(volume.Driver)}
+		compiled.Compose.Volumes[name] = compose.Volume{Driver: composeVolumeDriver(volume.Driver), Labels: map[string]string{LabelStack: stack.Name}}
+	}
+	logging, err := composeLogging(stack.Logging, ctx)
+	if err != nil {
+		return nil, err
 	}
 
 	for _, name := range sortedKeys(stack.Services) {
@@ -235,15 +290,46 @@ func Compile(stack *manifest.Stack, root string, resolvedSecrets map[string]stri
 			if err != nil {
 				return nil, fmt.Errorf("service %s mounts: %w", name, err)
 			}
+			var healthcheck *compose.Healthcheck
+			if service.Health != nil {
+				if healthcheck, err = composeHealthcheck(service.Health.Readiness, svcCtx); err != nil {
+					return nil, fmt.Errorf("service %s readiness: %w", name, err)
+				}
+			}
+			develop, err := composeDevelop(service.Develop, svcCtx, root)
+			if err != nil {
+				return nil, fmt.Errorf("service %s develop: %w", name, err)
+			}
+			build, buildSecrets, err := composeBuild(service.Build, svcCtx)
+			if err != nil {
+				return nil, fmt.Errorf("service %s build: %w", name, err)
+			}
+			resources, err := serviceResources(name, service.Resources, stack.Operator.Resources)
+			if err != nil {
+				return nil, err
+			}
+			for _, secret := range buildSecrets {
+				if compiled.Compose.Secrets == nil {
+					compiled.Compose.Secrets = map[string]compose.Secret{}
+				}
+				compiled.Compose.Secrets[secret] = compose.Secret{Environment: substitute.SecretEnvName(secret)}
+			}
 			compiled.Compose.Services[name] = compose.Service{
 				Image:       service.Image,
-				Build:       service.Build,
+				Build:       build,
+				Platform:    service.Platform,
 				Command:     command,
 				Environment: env,
 				Ports:       ports,
 				Volumes:     containerMounts,
 				WorkingDir:  workdir,
 				DependsOn:   composeDependsOn(append(service.After, service.DependsOn...), stack),
+				Labels:      serviceLabels(stack.Name, name),
+				Logging:     logging,
+				Healthcheck: healthcheck,
+				Develop:     develop,
+				CPUs:        resources.CPUs,
+				MemLimit:    resources.Memory,
 			}
 		case manifest.RuntimeLocal:
 			localEnv, err := localMountEnv(mounts, mountResolver)
@@ -263,12 +349,24 @@ func Compile(stack *manifest.Stack, root string, resolvedSecrets map[string]stri
 			if workdir != "" && !filepath.IsAbs(workdir) {
 				workdir = filepath.Join(root, workdir)
 			}
-			compiled.ProcessCompose.Processes[name] = proccompose.Process{
+			process := proccompose.Process{
 				Command:     shellCommand(command),
 				Environment: envList(env),
 				WorkingDir:  workdir,
 				DependsOn:   processDependsOn(append(service.After, service.DependsOn...), stack),
 			}
+			if service.Health != nil {
+				if process.Readiness, err = processProbe(service.Health.Readiness, svcCtx, workdir); err != nil {
+					return nil, fmt.Errorf("service %s readiness: %w", name, err)
+				}
+				if process.Liveness, err = processProbe(service.Health.Liveness, svcCtx, workdir); err != nil {
+					return nil, fmt.Errorf("service %s liveness: %w", name, err)
+				}
+				if process.Liveness != nil {
+					process.Availability = &proccompose.Availability{Restart: "on_failure"}
+				}
+			}
+			compiled.ProcessCompose.Processes[name] = process
 		}
 	}
 
@@ -344,6 +442,8 @@ func processDependsOn(names []string, stack *manifest.Stack) map[string]proccomp
 		condition := "process_started"
 		if _, ok := stack.Jobs[name]; ok {
 			condition = "process_completed_successfully"
+		} else if hasReadiness(stack, name) {
+			condition = "process_healthy"
 		}
 		deps[name] = proccompose.ProcessDependency{Condition: condition}
 	}
@@ -359,6 +459,8 @@ func composeDependsOn(names []string, stack *manifest.Stack) map[string]compose.
 		condition := "service_started"
 		if _, ok := stack.Jobs[name]; ok {
 			condition = "service_completed_successfully"
+		} else if hasReadiness(stack, name) {
+			condition = "service_healthy"
 		}
 		deps[name] = compose.ServiceDependency{Condition: condition}
 	}
@@ -463,7 +565,7 @@ func (p *Platform) writeCompiled(compiled *CompiledStack) error {
 		if err != nil {
 			return err
 		}
-		if err := os.WriteFile(filepath.Join(p.root, "docker-compose.yaml"), data, 0o644); err != nil {
+		if err := writeGenerated(filepath.Join(p.root, "docker-compose.yaml"), data, 0o644); err != nil {
 			return err
 		}
 	}
@@ -472,13 +574,22 @@ func (p *Platform) writeCompiled(compiled *CompiledStack) error {
 		if err != nil {
 			return err
 		}
-		if err := os.WriteFile(filepath.Join(p.root, "process-compose.yaml"), data, 0o644); err != nil {
+		if err := writeGenerated(filepath.Join(p.root, "process-compose.yaml"), data, 0o644); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// writeGenerated leaves path untouched when it already holds data, so an
+// unchanged compile keeps the file's mtime and watchers see no change.
+func writeGenerated(path string, data []byte, perm os.FileMode) error {
+	if current, err := os.ReadFile(path); err == nil && bytes.Equal(current, data) {
+		return nil
+	}
+	return os.WriteFile(path, data, perm)
+}
+
 func (c *CompiledStack) Text() (string, error) {
 	var out strings.Builder
 	if len(c.Compose.Services) > 0 {
@@ -509,6 +620,36 @@ func (c *CompiledStack) Text() (string, error) {
 	return out.String(), nil
 }
 
+// serviceResources is the CPU and memory a container service runs with:
+// its own, else the operator default, within the operator maximum.
+func serviceResources(name string, own *manifest.Resources, policy *manifest.ResourcePolicy) (manifest.Resources, error) {
+	var resources manifest.Resources
+	if own != nil {
+		resources = *own
+	}
+	if policy == nil {
+		return resources, nil
+	}
+	// Without a default, a value left out is capped at the maximum, so
+	// leaving it out never runs a service uncapped.
+	if resources.CPUs == "" {
+		resources.CPUs = policy.Default.CPUs
+	}
+	if resources.CPUs == "" {
+		resources.CPUs = policy.Max.CPUs
+	}
+	if resources.Memory == "" {
+		resources.Memory = policy.Default.Memory
+	}
+	if resources.Memory == "" {
+		resources.Memory = policy.Max.Memory
+	}
+	if err := policy.Check(resources); err != nil {
+		return resources, &InvalidInputError{Field: "services." + name + ".resources", Reason: err.Error()}
+	}
+	return resources, nil
+}
+
 func baseSubstitutionContext(stack *manifest.Stack, root string, resolvedSecrets, secretEnvVars map[string]string) substitute.Context {
 	ports := make(map[string]int, len(stack.Ports))
 	for name, port := range stack.Ports {
@@ -539,6 +680,92 @@ func baseSubstitutionContext(stack *manifest.Stack, root string, resolvedSecrets
 	}
 }
 
+// composeBuild resolves substitutions in a service build spec. Build
+// secrets are passed to BuildKit as compose secrets read from the same
+// ANGEE_SECRET_* variables as the environment, so a Dockerfile reads them
+// with RUN --mount=type=secret and they never land in an image layer.
+func composeBuild(build any, ctx substitute.Context) (any, []string, error) {
+	spec, ok := build.(map[string]any)
+	if !ok {
+		resolved, err := resolveBuildValue(build, ctx)
+		return resolved, nil, err
+	}
+	out := make(map[string]any, len(spec))
+	var secrets []string
+	for key, value := range spec {
+		if key == "secrets" {
+			list, _ := value.([]any)
+			for _, item := range list {
+				if secret, ok := item.(string); ok {
+					secrets = append(secrets, secret)
+				}
+			}
+			out[key] = secrets
+			continue
+		}
+		resolved, err := resolveBuildValue(value, ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", key, err)
+		}
+		out[key] = resolved
+	}
+	return out, secrets, nil
+}
+
+func resolveBuildValue(value any, ctx substitute.Context) (any, error) {
+	switch value := value.(type) {
+	case string:
+		return substitute.Resolve(value, ctx)
+	case []any:
+		out := make([]any, len(value))
+		for i, item := range value {
+			resolved, err := resolveBuildValue(item, ctx)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = resolved
+		}
+		return out, nil
+	case map[string]any:
+		out := make(map[string]any, len(value))
+		for key, item := range value {
+			resolved, err := resolveBuildValue(item, ctx)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = resolved
+		}
+		return out, nil
+	}
+	return value, nil
+}
+
+// composeDevelop resolves watch paths against the stack root, the
+// directory compose resolves relative paths from as well.
+func composeDevelop(develop *manifest.Develop, ctx substitute.Context, root string) (*compose.Develop, error) {
+	if develop == nil {
+		return nil, nil
+	}
+	out := &compose.Develop{}
+	for _, rule := range develop.Watch {
+		path, err := substitute.Resolve(rule.Path, ctx)
+		if err != nil {
+			return nil, fmt.Errorf("watch path: %w", err)
+		}
+		target, err := substitute.Resolve(rule.Target, ctx)
+		if err != nil {
+			return nil, fmt.Errorf("watch target: %w", err)
+		}
+		out.Watch = append(out.Watch, compose.Watch{
+			Action: rule.Action,
+			Path:   manifest.ResolvePath(root, path),
+			Target: target,
+			Ignore: rule.Ignore,
+		})
+	}
+	return out, nil
+}
+
 func composeVolumeDriver(driver string) string {
 	if driver == "" || driver == "local-fs" {
 		return "local"
@@ -558,3 +785,35 @@ func sortedKeys[T any](m map[string]T) []string {
 func MarshalYAML(v any) ([]byte, error) {
 	return yaml.Marshal(v)
 }
+
+// Labels on every container service, so log shippers and `docker ps
+// --filter` can select a stack's containers without knowing compose's own
+// project naming.
+const (
+	LabelStack   = "ai.angee.stack"
+	LabelService = "ai.angee.service"
+)
+
+func serviceLabels(stack, service string) map[string]string {
+	return map[string]string{LabelStack: stack, LabelService: service}
+}
+
+// composeLogging resolves the stack logging block. Unless the options say
+// otherwise, drivers that support it attach the angee labels to each log
+// entry.
+func composeLogging(logging *manifest.Logging, ctx substitute.Context) (*compose.Logging, error) {
+	if logging == nil {
+		return nil, nil
+	}
+	options, err := substitute.ResolveMap(logging.Options, ctx)
+	if err != nil {
+		return nil, fmt.Errorf("logging options: %w", err)
+	}
+	if options == nil {
+		options = map[string]string{}
+	}
+	if _, ok := options["labels"]; !ok {
+		options["labels"] = LabelStack + "," + LabelService
+	}
+	return &compose.Logging{Driver: logging.Driver, Options: options}, nil
+}
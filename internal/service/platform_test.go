@@ -2,12 +2,22 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/fyltr/angee/api"
 	"github.com/fyltr/angee/internal/manifest"
+	"github.com/fyltr/angee/internal/runtime"
+	"github.com/fyltr/angee/internal/runtime/compose"
 )
 
 func TestStackPrepareWritesSecretSafeGeneratedFiles(t *testing.T) {
@@ -68,3 +78,864 @@ func TestStackPrepareWritesSecretSafeGeneratedFiles(t *testing.T) {
 		t.Fatalf("env file does not contain runtime secret env var: %s", envData)
 	}
 }
+
+func TestStackPrepareKeepsUnchangedGeneratedFiles(t *testing.T) {
+	root := t.TempDir()
+	stack := &manifest.Stack{
+		Version: manifest.VersionCurrent,
+		Kind:    manifest.KindStack,
+		Name:    "notes",
+		Services: map[string]manifest.Service{
+			"web": {Runtime: manifest.RuntimeContainer, Image: "nginx:1"},
+		},
+	}
+	if err := manifest.SaveFile(manifest.Path(root), stack); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+	platform, err := New(root)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := platform.StackPrepare(context.Background()); err != nil {
+		t.Fatalf("StackPrepare() error = %v", err)
+	}
+	composePath := filepath.Join(root, "docker-compose.yaml")
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(composePath, past, past); err != nil {
+		t.Fatalf("Chtimes() error = %v", err)
+	}
+	if _, err := platform.StackPrepare(context.Background()); err != nil {
+		t.Fatalf("second StackPrepare() error = %v", err)
+	}
+	info, err := os.Stat(composePath)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if !info.ModTime().Equal(past) {
+		t.Fatalf("docker-compose.yaml mtime = %v, want unchanged %v", info.ModTime(), past)
+	}
+
+	stack.Services["web"] = manifest.Service{Runtime: manifest.RuntimeContainer, Image: "nginx:2"}
+	if err := manifest.SaveFile(manifest.Path(root), stack); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+	if _, err := platform.StackPrepare(context.Background()); err != nil {
+		t.Fatalf("third StackPrepare() error = %v", err)
+	}
+	data, err := os.ReadFile(composePath)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if !strings.Contains(string(data), "nginx:2") {
+		t.Fatalf("docker-compose.yaml not rewritten after manifest change:\n%s", data)
+	}
+}
+
+func TestStackPrepareWritesDevcontainersForWorkspaceServices(t *testing.T) {
+	root := t.TempDir()
+	stack := &manifest.Stack{
+		Version:        manifest.VersionCurrent,
+		Kind:           manifest.KindStack,
+		Name:           "notes",
+		SecretsBackend: manifest.SecretsBackend{Type: "env-file", Path: ".env"},
+		Secrets: map[string]manifest.Secret{
+			"api-key": {Required: true, Import: "env:API_KEY"},
+		},
+		Volumes: map[string]manifest.Volume{"cache": {}},
+		Workspaces: map[string]manifest.Workspace{
+			"fix-1": {Template: "workspaces/dev"},
+		},
+		Services: map[string]manifest.Service{
+			"agent": {
+				Runtime: manifest.RuntimeContainer,
+				Image:   "ghcr.io/example/agent:1",
+				Mounts:  manifest.StringList{"workspace://fix-1:/workspace", "volume://cache:/cache"},
+				Env: map[string]string{
+					"API_KEY":  "${secret.api-key}",
+					"LOG_MODE": "debug",
+				},
+			},
+			"web": {Runtime: manifest.RuntimeContainer, Image: "nginx:1"},
+		},
+	}
+	if err := manifest.SaveFile(manifest.Path(root), stack); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+	t.Setenv("API_KEY", "super-secret")
+	handWritten := filepath.Join(root, ".devcontainer", "web", "devcontainer.json")
+	if err := os.MkdirAll(filepath.Dir(handWritten), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(handWritten, []byte("{}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	platform, err := New(root)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := platform.StackPrepare(context.Background()); err != nil {
+		t.Fatalf("StackPrepare() error = %v", err)
+	}
+
+	path := filepath.Join(root, ".devcontainer", "agent", "devcontainer.json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile(devcontainer.json) error = %v", err)
+	}
+	if strings.Contains(string(data), "super-secret") {
+		t.Fatalf("devcontainer.json contains resolved secret:\n%s", data)
+	}
+	var config devcontainer
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(string(data), devcontainerHeader)), &config); err != nil {
+		t.Fatalf("Unmarshal() error = %v\n%s", err, data)
+	}
+	if config.Image != "ghcr.io/example/agent:1" || config.WorkspaceFolder != "/workspace" {
+		t.Fatalf("config = %#v", config)
+	}
+	if want := "type=bind,source=" + filepath.Join(root, "workspaces", "fix-1") + ",target=/workspace"; config.WorkspaceMount != want {
+		t.Fatalf("workspaceMount = %q, want %q", config.WorkspaceMount, want)
+	}
+	if len(config.Mounts) != 1 || config.Mounts[0] != "type=volume,source=notes_cache,target=/cache" {
+		t.Fatalf("mounts = %#v", config.Mounts)
+	}
+	if config.ContainerEnv["LOG_MODE"] != "debug" || config.RemoteEnv["API_KEY"] != "${containerEnv:ANGEE_SECRET_API_KEY}" {
+		t.Fatalf("env = %#v / %#v", config.ContainerEnv, config.RemoteEnv)
+	}
+	if data, err := os.ReadFile(handWritten); err != nil || string(data) != "{}\n" {
+		t.Fatalf("hand-written devcontainer.json = %q, %v", data, err)
+	}
+
+	agent := stack.Services["agent"]
+	agent.Mounts = nil
+	stack.Services["agent"] = agent
+	if err := manifest.SaveFile(manifest.Path(root), stack); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+	if _, err := platform.StackPrepare(context.Background()); err != nil {
+		t.Fatalf("second StackPrepare() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Dir(path)); !os.IsNotExist(err) {
+		t.Fatalf("stale devcontainer directory still present: %v", err)
+	}
+}
+
+func TestCompileLabelsContainersAndAppliesStackLogging(t *testing.T) {
+	stack := &manifest.Stack{
+		Version: manifest.VersionCurrent,
+		Kind:    manifest.KindStack,
+		Name:    "notes",
+		Ports:   map[string]manifest.Port{"loki": {Value: 3100}},
+		Logging: &manifest.Logging{
+			Driver:  "loki",
+			Options: map[string]string{"loki-url": "http://127.0.0.1:${ports.loki}/loki/api/v1/push"},
+		},
+		Services: map[string]manifest.Service{
+			"web": {Runtime: manifest.RuntimeContainer, Image: "nginx:1"},
+		},
+	}
+	compiled, err := Compile(stack, t.TempDir(), nil)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	web := compiled.Compose.Services["web"]
+	if web.Labels[LabelStack] != "notes" || web.Labels[LabelService] != "web" {
+		t.Fatalf("labels = %#v", web.Labels)
+	}
+	if web.Logging == nil || web.Logging.Driver != "loki" {
+		t.Fatalf("logging = %#v", web.Logging)
+	}
+	if got := web.Logging.Options["loki-url"]; got != "http://127.0.0.1:3100/loki/api/v1/push" {
+		t.Fatalf("loki-url = %q", got)
+	}
+	if got := web.Logging.Options["labels"]; got != "ai.angee.stack,ai.angee.service" {
+		t.Fatalf("labels option = %q", got)
+	}
+}
+
+func TestCompilePassesBuildSecretsToBuildKit(t *testing.T) {
+	stack := &manifest.Stack{
+		Version: manifest.VersionCurrent,
+		Kind:    manifest.KindStack,
+		Name:    "notes",
+		Ports:   map[string]manifest.Port{"web": {Value: 8080}},
+		Secrets: map[string]manifest.Secret{"npm-token": {Required: true}},
+		Services: map[string]manifest.Service{
+			"web": {Runtime: manifest.RuntimeContainer, Platform: "linux/amd64", Build: map[string]any{
+				"context":    ".",
+				"args":       map[string]any{"PORT": "${ports.web}"},
+				"cache_from": []any{"type=local,src=.cache/buildx"},
+				"platforms":  []any{"linux/amd64"},
+				"secrets":    []any{"npm-token"},
+			}},
+		},
+	}
+	compiled, err := Compile(stack, t.TempDir(), map[string]string{"npm-token": "s3cret"})
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	build, ok := compiled.Compose.Services["web"].Build.(map[string]any)
+	if !ok {
+		t.Fatalf("build = %#v", compiled.Compose.Services["web"].Build)
+	}
+	if got := build["args"].(map[string]any)["PORT"]; got != "8080" {
+		t.Fatalf("build arg PORT = %v", got)
+	}
+	if got := build["secrets"].([]string); len(got) != 1 || got[0] != "npm-token" {
+		t.Fatalf("build secrets = %#v", got)
+	}
+	if got := build["platforms"].([]any); len(got) != 1 || got[0] != "linux/amd64" {
+		t.Fatalf("build platforms = %#v", got)
+	}
+	if got := compiled.Compose.Services["web"].Platform; got != "linux/amd64" {
+		t.Fatalf("platform = %q", got)
+	}
+	if got := compiled.Compose.Secrets["npm-token"].Environment; got != "ANGEE_SECRET_NPM_TOKEN" {
+		t.Fatalf("compose secret environment = %q", got)
+	}
+	data, err := compose.Marshal(compiled.Compose)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "s3cret") {
+		t.Fatalf("compose file contains the secret value:\n%s", data)
+	}
+}
+
+func TestCompileResolvesWatchPathsAgainstRoot(t *testing.T) {
+	root := t.TempDir()
+	stack := &manifest.Stack{
+		Version: manifest.VersionCurrent,
+		Kind:    manifest.KindStack,
+		Name:    "notes",
+		Services: map[string]manifest.Service{
+			"web": {Runtime: manifest.RuntimeContainer, Build: ".", Develop: &manifest.Develop{Watch: []manifest.WatchRule{
+				{Path: "src", Action: manifest.WatchSync, Target: "/app/src", Ignore: []string{"node_modules/"}},
+			}}},
+		},
+	}
+	compiled, err := Compile(stack, root, nil)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	develop := compiled.Compose.Services["web"].Develop
+	if develop == nil || len(develop.Watch) != 1 {
+		t.Fatalf("develop = %#v", develop)
+	}
+	want := compose.Watch{Action: "sync", Path: filepath.Join(root, "src"), Target: "/app/src", Ignore: []string{"node_modules/"}}
+	if got := develop.Watch[0]; got.Action != want.Action || got.Path != want.Path || got.Target != want.Target || len(got.Ignore) != 1 {
+		t.Fatalf("watch = %#v, want %#v", got, want)
+	}
+}
+
+func TestCompileRendersHealthProbes(t *testing.T) {
+	stack := &manifest.Stack{
+		Version: manifest.VersionCurrent,
+		Kind:    manifest.KindStack,
+		Name:    "notes",
+		Ports:   map[string]manifest.Port{"api": {Value: 8001}},
+		Services: map[string]manifest.Service{
+			"db": {
+				Runtime: manifest.RuntimeContainer,
+				Image:   "postgres:16",
+				Health: &manifest.Health{Readiness: &manifest.Probe{
+					Command: []string{"pg_isready"}, Interval: "5s", FailureThreshold: 5, StartPeriod: "30s",
+				}},
+			},
+			"web": {Runtime: manifest.RuntimeContainer, Image: "nginx:1", DependsOn: []string{"db"}},
+			"api": {
+				Runtime:   manifest.RuntimeLocal,
+				Command:   []string{"serve"},
+				DependsOn: []string{"worker"},
+				Health: &manifest.Health{
+					Readiness: &manifest.Probe{HTTP: "http://127.0.0.1:${ports.api}/ready", Interval: "1500ms", SuccessThreshold: 2},
+					Liveness:  &manifest.Probe{Command: []string{"pgrep", "serve"}, FailureThreshold: 3},
+				},
+			},
+			"worker": {
+				Runtime: manifest.RuntimeLocal,
+				Command: []string{"work"},
+				Health:  &manifest.Health{Readiness: &manifest.Probe{Command: []string{"true"}}},
+			},
+		},
+	}
+	compiled, err := Compile(stack, t.TempDir(), nil)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	db := compiled.Compose.Services["db"].Healthcheck
+	if db == nil || strings.Join(db.Test, " ") != "CMD pg_isready" || db.Interval != "5s" || db.Retries != 5 || db.StartPeriod != "30s" {
+		t.Fatalf("db healthcheck = %#v", db)
+	}
+	if got := compiled.Compose.Services["web"].DependsOn["db"].Condition; got != "service_healthy" {
+		t.Fatalf("web depends_on db condition = %q, want service_healthy", got)
+	}
+	api := compiled.ProcessCompose.Processes["api"]
+	if api.Readiness == nil || api.Readiness.HTTPGet == nil || api.Readiness.HTTPGet.Port != 8001 || api.Readiness.HTTPGet.Path != "/ready" || api.Readiness.PeriodSeconds != 2 || api.Readiness.SuccessThreshold != 2 {
+		t.Fatalf("api readiness = %#v", api.Readiness)
+	}
+	if api.Liveness == nil || api.Liveness.Exec == nil || api.Liveness.Exec.Command != "pgrep serve" || api.Availability == nil || api.Availability.Restart != "on_failure" {
+		t.Fatalf("api liveness = %#v, availability = %#v", api.Liveness, api.Availability)
+	}
+	if got := api.DependsOn["worker"].Condition; got != "process_healthy" {
+		t.Fatalf("api depends_on worker condition = %q, want process_healthy", got)
+	}
+}
+
+func TestMaterializeSourcesClonesConcurrentlyAndReportsEachFailure(t *testing.T) {
+	upstream := t.TempDir()
+	runGit(t, upstream, "init", "-q", "-b", "main")
+	runGit(t, upstream, "config", "user.email", "test@example.com")
+	runGit(t, upstream, "config", "user.name", "Test")
+	runGit(t, upstream, "commit", "-q", "--allow-empty", "-m", "init")
+
+	root := t.TempDir()
+	sources := map[string]manifest.Source{}
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		sources[name] = manifest.Source{Kind: "git", Repo: upstream, DefaultRef: "main", CachePath: "sources/" + name}
+	}
+	sources["broken"] = manifest.Source{Kind: "git", Repo: filepath.Join(root, "missing"), DefaultRef: "main", CachePath: "sources/broken"}
+	sources["gone"] = manifest.Source{Kind: "local", Path: "nowhere"}
+	platform, err := New(root)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	err = platform.materializeSources(context.Background(), sources, sortedKeys(sources))
+	if err == nil || !strings.Contains(err.Error(), "source broken:") || !strings.Contains(err.Error(), "source gone:") {
+		t.Fatalf("materializeSources() error = %v, want both failures named", err)
+	}
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		if _, err := os.Stat(filepath.Join(root, "sources", name, ".git")); err != nil {
+			t.Fatalf("source %s not cloned: %v", name, err)
+		}
+	}
+}
+
+func TestCompileAppliesResourceDefaultsAndCaps(t *testing.T) {
+	stack := &manifest.Stack{
+		Version: manifest.VersionCurrent,
+		Kind:    manifest.KindStack,
+		Name:    "notes",
+		Operator: manifest.Operator{Resources: &manifest.ResourcePolicy{
+			Default: manifest.Resources{CPUs: "0.5", Memory: "512m"},
+			Max:     manifest.Resources{CPUs: "2", Memory: "4g"},
+		}},
+		Services: map[string]manifest.Service{
+			"web": {Runtime: manifest.RuntimeContainer, Image: "nginx:1"},
+			"db":  {Runtime: manifest.RuntimeContainer, Image: "postgres:16", Resources: &manifest.Resources{Memory: "2g"}},
+		},
+	}
+	compiled, err := Compile(stack, t.TempDir(), nil)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	if web := compiled.Compose.Services["web"]; web.CPUs != "0.5" || web.MemLimit != "512m" {
+		t.Fatalf("web cpus, mem_limit = %q, %q, want the defaults", web.CPUs, web.MemLimit)
+	}
+	if db := compiled.Compose.Services["db"]; db.CPUs != "0.5" || db.MemLimit != "2g" {
+		t.Fatalf("db cpus, mem_limit = %q, %q, want its memory and the default cpus", db.CPUs, db.MemLimit)
+	}
+
+	stack.Services["db"] = manifest.Service{Runtime: manifest.RuntimeContainer, Image: "postgres:16", Resources: &manifest.Resources{CPUs: "8"}}
+	_, err = Compile(stack, t.TempDir(), nil)
+	var invalid *InvalidInputError
+	if !errors.As(err, &invalid) || invalid.Field != "services.db.resources" || !strings.Contains(invalid.Reason, "exceeds the operator maximum of 2") {
+		t.Fatalf("Compile() error = %v, want the cap enforced", err)
+	}
+}
+
+func TestCompileCapsServicesWithoutResourcesAtMax(t *testing.T) {
+	stack := &manifest.Stack{
+		Version: manifest.VersionCurrent,
+		Kind:    manifest.KindStack,
+		Name:    "notes",
+		Operator: manifest.Operator{Resources: &manifest.ResourcePolicy{
+			Max: manifest.Resources{CPUs: "2", Memory: "4g"},
+		}},
+		Services: map[string]manifest.Service{
+			"web": {Runtime: manifest.RuntimeContainer, Image: "nginx:1"},
+			"db":  {Runtime: manifest.RuntimeContainer, Image: "postgres:16", Resources: &manifest.Resources{Memory: "1g"}},
+		},
+	}
+	compiled, err := Compile(stack, t.TempDir(), nil)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	if web := compiled.Compose.Services["web"]; web.CPUs != "2" || web.MemLimit != "4g" {
+		t.Fatalf("web cpus, mem_limit = %q, %q, want the maximum", web.CPUs, web.MemLimit)
+	}
+	if db := compiled.Compose.Services["db"]; db.CPUs != "2" || db.MemLimit != "1g" {
+		t.Fatalf("db cpus, mem_limit = %q, %q, want its memory and the maximum cpus", db.CPUs, db.MemLimit)
+	}
+}
+
+type upBackend struct {
+	runtime.Backend
+	err error
+}
+
+func (b upBackend) Up(context.Context, runtime.Target) error { return b.err }
+
+func TestStackUpRecordsDeployLedger(t *testing.T) {
+	root := t.TempDir()
+	stack := &manifest.Stack{
+		Version: manifest.VersionCurrent,
+		Kind:    manifest.KindStack,
+		Name:    "notes",
+		Services: map[string]manifest.Service{
+			"web": {Runtime: manifest.RuntimeContainer, Image: "nginx:1"},
+		},
+	}
+	if err := manifest.SaveFile(manifest.Path(root), stack); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+	runGit(t, root, "init", "-q")
+	runGit(t, root, "config", "user.email", "test@example.com")
+	runGit(t, root, "config", "user.name", "Test")
+	runGit(t, root, "add", "angee.yaml")
+	runGit(t, root, "commit", "-q", "-m", "stack")
+	platform, err := NewWithBackends(root, upBackend{}, nil)
+	if err != nil {
+		t.Fatalf("NewWithBackends() error = %v", err)
+	}
+	ctx := WithRequestID(WithCaller(context.Background(), "cli:alice"), "req-1")
+	if err := platform.StackUp(ctx, nil, UpOptions{Message: " bump nginx to 1.27\n"}); err != nil {
+		t.Fatalf("StackUp() error = %v", err)
+	}
+	platform.composeBackend = upBackend{err: errors.New("pull failed")}
+	if err := platform.StackUp(context.Background(), []string{"web"}, UpOptions{}); err == nil {
+		t.Fatal("StackUp() error is nil, want backend failure")
+	}
+
+	deploys, err := platform.Deploys(context.Background())
+	if err != nil {
+		t.Fatalf("Deploys() error = %v", err)
+	}
+	if len(deploys) != 2 {
+		t.Fatalf("Deploys() = %+v, want 2 entries", deploys)
+	}
+	failed, succeeded := deploys[0], deploys[1]
+	if succeeded.Status != "succeeded" || succeeded.Caller != "cli:alice" || succeeded.RequestID != "req-1" || succeeded.Message != "bump nginx to 1.27" || len(succeeded.Commit) != 40 || !strings.HasPrefix(succeeded.Manifest, "sha256:") {
+		t.Fatalf("succeeded deploy = %+v", succeeded)
+	}
+	if failed.Status != "failed" || failed.Error != "pull failed" || failed.Message != "" || failed.Caller != LocalCaller() || failed.RequestID == "" || strings.Join(failed.Services, ",") != "web" {
+		t.Fatalf("failed deploy = %+v", failed)
+	}
+}
+
+func TestDeployLedgerKeepsLastDeploys(t *testing.T) {
+	root := t.TempDir()
+	stack := &manifest.Stack{
+		Version: manifest.VersionCurrent,
+		Kind:    manifest.KindStack,
+		Name:    "notes",
+		Services: map[string]manifest.Service{
+			"web": {Runtime: manifest.RuntimeContainer, Image: "nginx:1"},
+		},
+	}
+	if err := manifest.SaveFile(manifest.Path(root), stack); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+	platform, err := NewWithBackends(root, upBackend{}, nil)
+	if err != nil {
+		t.Fatalf("NewWithBackends() error = %v", err)
+	}
+	// The oldest deploy is the only one using sha256:old.
+	var ledger strings.Builder
+	for i := range deploysKept {
+		revision := "sha256:kept"
+		if i == 0 {
+			revision = "sha256:old"
+		}
+		fmt.Fprintf(&ledger, "{\"manifest\":%q,\"status\":\"succeeded\",\"request_id\":\"seed-%d\"}\n", revision, i)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "run"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(platform.deploysPath(), []byte(ledger.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, revision := range []string{"sha256:old", "sha256:kept"} {
+		if err := platform.saveManifestSnapshot(revision, []byte("name: notes\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := platform.StackUp(context.Background(), nil, UpOptions{}); err != nil {
+		t.Fatalf("StackUp() error = %v", err)
+	}
+
+	deploys, err := platform.Deploys(context.Background())
+	if err != nil {
+		t.Fatalf("Deploys() error = %v", err)
+	}
+	if len(deploys) != deploysKept || deploys[len(deploys)-1].RequestID != "seed-1" {
+		t.Fatalf("Deploys() kept %d entries, oldest %q; want %d from seed-1", len(deploys), deploys[len(deploys)-1].RequestID, deploysKept)
+	}
+	if _, err := os.Stat(platform.manifestSnapshotPath("sha256:old")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("snapshot of dropped deploy: Stat() error = %v, want not exist", err)
+	}
+	for _, revision := range []string{"sha256:kept", deploys[0].Manifest} {
+		if _, err := os.Stat(platform.manifestSnapshotPath(revision)); err != nil {
+			t.Fatalf("snapshot %s: %v", revision, err)
+		}
+	}
+}
+
+type verifyBackend struct {
+	targetBackend
+	err error
+}
+
+func (b verifyBackend) Verify(context.Context, string, string) error { return b.err }
+
+func TestStackUpReportsComposeRejectionAgainstService(t *testing.T) {
+	root := t.TempDir()
+	stack := &manifest.Stack{
+		Version: manifest.VersionCurrent,
+		Kind:    manifest.KindStack,
+		Name:    "notes",
+		Services: map[string]manifest.Service{
+			"web": {Runtime: manifest.RuntimeContainer, Image: "nginx:1"},
+		},
+	}
+	if err := manifest.SaveFile(manifest.Path(root), stack); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+	var targets []runtime.Target
+	backend := verifyBackend{targetBackend: targetBackend{targets: &targets}, err: &runtime.ConfigError{Service: "web", Reason: "services.web.ports.0 Does not match format 'ports'"}}
+	platform, err := NewWithBackends(root, backend, nil)
+	if err != nil {
+		t.Fatalf("NewWithBackends() error = %v", err)
+	}
+	err = platform.StackUp(context.Background(), nil, UpOptions{})
+	var invalid *InvalidInputError
+	if !errors.As(err, &invalid) || invalid.Field != "services.web" || !strings.Contains(invalid.Reason, "Does not match format") {
+		t.Fatalf("StackUp() error = %v, want an invalid services.web", err)
+	}
+	if len(targets) != 0 {
+		t.Fatalf("Up() called with %v after verification failed", targets)
+	}
+
+	platform.composeBackend = verifyBackend{targetBackend: targetBackend{targets: &targets}, err: &runtime.ConfigError{Reason: "networks must be a mapping"}}
+	if err := platform.StackUp(context.Background(), nil, UpOptions{}); !errors.As(err, &invalid) || invalid.Field != "" || !strings.Contains(err.Error(), "docker-compose.yaml") {
+		t.Fatalf("StackUp() error = %v, want the generated file named", err)
+	}
+}
+
+type targetBackend struct {
+	runtime.Backend
+	targets *[]runtime.Target
+}
+
+func (b targetBackend) Up(_ context.Context, target runtime.Target) error {
+	*b.targets = append(*b.targets, target)
+	return nil
+}
+
+func TestStackUpIncludesDependents(t *testing.T) {
+	root := t.TempDir()
+	stack := &manifest.Stack{
+		Version: manifest.VersionCurrent,
+		Kind:    manifest.KindStack,
+		Name:    "notes",
+		Services: map[string]manifest.Service{
+			"db":     {Runtime: manifest.RuntimeContainer, Image: "postgres:16"},
+			"web":    {Runtime: manifest.RuntimeContainer, Image: "nginx:1", DependsOn: []string{"db"}},
+			"worker": {Runtime: manifest.RuntimeContainer, Image: "busybox:1", DependsOn: []string{"web"}},
+			"proxy":  {Runtime: manifest.RuntimeContainer, Image: "caddy:2", DependsOn: []string{"worker"}},
+			"cache":  {Runtime: manifest.RuntimeContainer, Image: "redis:7"},
+		},
+	}
+	if err := manifest.SaveFile(manifest.Path(root), stack); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+	var targets []runtime.Target
+	platform, err := NewWithBackends(root, targetBackend{targets: &targets}, nil)
+	if err != nil {
+		t.Fatalf("NewWithBackends() error = %v", err)
+	}
+	if err := platform.StackUp(context.Background(), []string{"web"}, UpOptions{}); err != nil {
+		t.Fatalf("StackUp() error = %v", err)
+	}
+	if len(targets) != 1 || strings.Join(targets[0].Services, ",") != "web,worker,proxy" || !targets[0].NoDeps {
+		t.Fatalf("StackUp(web) targets = %+v, want web and its dependents without db", targets)
+	}
+	deploys, err := platform.Deploys(context.Background())
+	if err != nil || len(deploys) != 1 || strings.Join(deploys[0].Services, ",") != "web,worker,proxy" {
+		t.Fatalf("Deploys() = %+v, %v", deploys, err)
+	}
+	if err := platform.StackUp(context.Background(), nil, UpOptions{}); err != nil {
+		t.Fatalf("StackUp() error = %v", err)
+	}
+	if len(targets) != 2 || targets[1].NoDeps {
+		t.Fatalf("StackUp() targets = %+v, want the whole stack with dependencies", targets)
+	}
+}
+
+// imageGateBackend fails Up while the compiled compose file uses a
+// rejected image, the way a crash-looping release fails `up --wait`.
+type imageGateBackend struct {
+	runtime.Backend
+	rejected map[string]bool
+	waits    *[]time.Duration
+}
+
+func (b imageGateBackend) Up(_ context.Context, target runtime.Target) error {
+	*b.waits = append(*b.waits, target.Wait)
+	data, err := os.ReadFile(filepath.Join(target.Root, "docker-compose.yaml"))
+	if err != nil {
+		return err
+	}
+	for image := range b.rejected {
+		if strings.Contains(string(data), image) {
+			return errors.New("container notes-web-1 is unhealthy")
+		}
+	}
+	return nil
+}
+
+func TestStackUpAutoRollbackWalksBackToHealthyManifest(t *testing.T) {
+	root := t.TempDir()
+	rejected := map[string]bool{}
+	var waits []time.Duration
+	platform, err := NewWithBackends(root, imageGateBackend{rejected: rejected, waits: &waits}, nil)
+	if err != nil {
+		t.Fatalf("NewWithBackends() error = %v", err)
+	}
+	deploy := func(image string, opts UpOptions) error {
+		stack := &manifest.Stack{
+			Version:  manifest.VersionCurrent,
+			Kind:     manifest.KindStack,
+			Name:     "notes",
+			Services: map[string]manifest.Service{"web": {Runtime: manifest.RuntimeContainer, Image: image}},
+		}
+		if err := manifest.SaveFile(manifest.Path(root), stack); err != nil {
+			t.Fatalf("SaveFile() error = %v", err)
+		}
+		return platform.StackUp(context.Background(), nil, opts)
+	}
+	for _, image := range []string{"nginx:1", "nginx:2"} {
+		if err := deploy(image, UpOptions{}); err != nil {
+			t.Fatalf("StackUp(%s) error = %v", image, err)
+		}
+	}
+	// nginx:2 was healthy when it shipped but no longer is, so the
+	// rollback from nginx:3 has to go one step further.
+	rejected["nginx:2"], rejected["nginx:3"] = true, true
+
+	err = deploy("nginx:3", UpOptions{AutoRollback: true})
+	if err == nil || !strings.Contains(err.Error(), "unhealthy") || !strings.Contains(err.Error(), "rolled back") {
+		t.Fatalf("StackUp(nginx:3) error = %v, want the failure and a rollback", err)
+	}
+	if len(waits) != 5 || waits[2] != DefaultUpWait || waits[3] != DefaultUpWait || waits[4] != DefaultUpWait {
+		t.Fatalf("Up waits = %v, want the rollbacks to wait for health", waits)
+	}
+	current, err := manifest.LoadFile(manifest.Path(root))
+	if err != nil || current.Services["web"].Image != "nginx:1" {
+		t.Fatalf("angee.yaml after rollback = %+v, %v", current, err)
+	}
+	kept, err := os.ReadFile(manifest.Path(root) + ".rejected")
+	if err != nil || !strings.Contains(string(kept), "nginx:3") {
+		t.Fatalf("angee.yaml.rejected = %q, %v", kept, err)
+	}
+	deploys, err := platform.Deploys(context.Background())
+	if err != nil || len(deploys) != 5 {
+		t.Fatalf("Deploys() = %+v, %v", deploys, err)
+	}
+	var statuses []string
+	for _, deploy := range deploys {
+		statuses = append(statuses, deploy.Status)
+	}
+	if strings.Join(statuses, ",") != "succeeded,failed,failed,succeeded,succeeded" || deploys[0].Manifest != deploys[4].Manifest {
+		t.Fatalf("Deploys() = %+v", deploys)
+	}
+
+	rejected["nginx:1"] = true
+	if err := deploy("nginx:3", UpOptions{AutoRollback: true, RollbackDepth: 1}); err == nil || !strings.Contains(err.Error(), "rollback failed") {
+		t.Fatalf("StackUp(nginx:3, depth 1) error = %v, want the rollback to give up", err)
+	}
+}
+
+func TestRollbackTargets(t *testing.T) {
+	deploys := []api.Deploy{
+		{Status: "failed", Manifest: "sha256:c"},
+		{Status: "succeeded", Manifest: "sha256:b"},
+		{Status: "succeeded", Manifest: "sha256:b"},
+		{Status: "succeeded", Manifest: "sha256:a"},
+		{Status: "succeeded", Manifest: "sha256:0"},
+	}
+	if got := rollbackTargets(deploys, "sha256:c", 2); strings.Join(got, ",") != "sha256:b,sha256:a" {
+		t.Fatalf("rollbackTargets() = %v", got)
+	}
+	if got := rollbackTargets(deploys, "sha256:b", 3); got != nil {
+		t.Fatalf("rollbackTargets(current is last good) = %v, want none", got)
+	}
+}
+
+func TestDeployAndJobFailuresNotifyWebhooks(t *testing.T) {
+	var mu sync.Mutex
+	var slack []map[string]string
+	var generic []api.Notification
+	hooks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/slack":
+			var body map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			slack = append(slack, body)
+		case "/generic":
+			var body api.Notification
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			generic = append(generic, body)
+		}
+	}))
+	defer hooks.Close()
+
+	root := t.TempDir()
+	stack := &manifest.Stack{
+		Version: manifest.VersionCurrent,
+		Kind:    manifest.KindStack,
+		Name:    "notes",
+		Operator: manifest.Operator{Notifications: map[string]manifest.Notification{
+			"ops":   {URL: hooks.URL + "/slack", Format: manifest.NotificationSlack, Events: []string{manifest.EventDeployFailed, manifest.EventJobFailed}},
+			"audit": {URL: hooks.URL + "/generic"},
+		}},
+		Services: map[string]manifest.Service{
+			"web": {Runtime: manifest.RuntimeContainer, Image: "nginx:1"},
+		},
+		Jobs: map[string]manifest.Job{
+			"check": {Runtime: manifest.RuntimeLocal, Command: []string{"false"}},
+		},
+	}
+	if err := manifest.SaveFile(manifest.Path(root), stack); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+	platform, err := NewWithBackends(root, upBackend{}, nil)
+	if err != nil {
+		t.Fatalf("NewWithBackends() error = %v", err)
+	}
+	if err := platform.StackUp(context.Background(), nil, UpOptions{}); err != nil {
+		t.Fatalf("StackUp() error = %v", err)
+	}
+	platform.composeBackend = upBackend{err: errors.New("pull failed")}
+	if err := platform.StackUp(context.Background(), nil, UpOptions{}); err == nil {
+		t.Fatal("StackUp() error is nil, want backend failure")
+	}
+	if _, err := platform.JobRun(context.Background(), "check", nil); err == nil {
+		t.Fatal("JobRun() error is nil, want job failure")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(generic) != 3 || generic[0].Event != manifest.EventDeploySucceeded || generic[0].Deploy == nil || generic[2].JobRun == nil {
+		t.Fatalf("generic notifications = %+v", generic)
+	}
+	if len(slack) != 2 || !strings.Contains(slack[0]["text"], "notes: deploy failed") || !strings.Contains(slack[0]["text"], "pull failed") || !strings.Contains(slack[1]["text"], "job check failed") {
+		t.Fatalf("slack notifications = %+v", slack)
+	}
+}
+
+func TestEventsFollowNotificationsWithoutWebhooks(t *testing.T) {
+	root := t.TempDir()
+	stack := &manifest.Stack{
+		Version:  manifest.VersionCurrent,
+		Kind:     manifest.KindStack,
+		Name:     "notes",
+		Services: map[string]manifest.Service{"web": {Runtime: manifest.RuntimeContainer, Image: "nginx:1"}},
+	}
+	if err := manifest.SaveFile(manifest.Path(root), stack); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+	platform, err := NewWithBackends(root, upBackend{}, nil)
+	if err != nil {
+		t.Fatalf("NewWithBackends() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	events := platform.Events(ctx)
+	if err := platform.StackUp(context.Background(), nil, UpOptions{Message: "first"}); err != nil {
+		t.Fatalf("StackUp() error = %v", err)
+	}
+	event := <-events
+	if event.Event != manifest.EventDeploySucceeded || event.Stack != "notes" || event.Deploy == nil || event.Deploy.Message != "first" {
+		t.Fatalf("event = %+v", event)
+	}
+	cancel()
+	if _, ok := <-events; ok {
+		t.Fatal("Events() channel is open after ctx is done")
+	}
+}
+
+func TestManifestChangesAreCheckedAgainstPolicy(t *testing.T) {
+	root := t.TempDir()
+	stack := &manifest.Stack{
+		Version: manifest.VersionCurrent,
+		Kind:    manifest.KindStack,
+		Name:    "notes",
+		Policy:  &manifest.Policy{ProtectedServices: []string{"postgres"}, DenyPublicPorts: true},
+		Services: map[string]manifest.Service{
+			"postgres": {Runtime: manifest.RuntimeContainer, Image: "postgres:16"},
+		},
+	}
+	if err := manifest.SaveFile(manifest.Path(root), stack); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+	platform, err := New(root)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ctx := context.Background()
+
+	var policyErr *PolicyError
+	err = platform.ServiceDestroy(ctx, "postgres", false)
+	if !errors.As(err, &policyErr) || policyErr.Rule != PolicyProtectedService {
+		t.Fatalf("ServiceDestroy(protected) error = %v, want %s violation", err, PolicyProtectedService)
+	}
+	err = platform.ServiceInit(ctx, api.ServiceInitRequest{Name: "web", Runtime: "container", Image: "nginx:1", Ports: []string{"8080:80"}})
+	if !errors.As(err, &policyErr) || policyErr.Rule != PolicyPublicPort {
+		t.Fatalf("ServiceInit(public port) error = %v, want %s violation", err, PolicyPublicPort)
+	}
+	if err := platform.ServiceInit(ctx, api.ServiceInitRequest{Name: "web", Runtime: "container", Image: "nginx:1", Ports: []string{"127.0.0.1:8080:80"}}); err != nil {
+		t.Fatalf("ServiceInit(loopback port) error = %v", err)
+	}
+
+	loaded, err := platform.LoadStack()
+	if err != nil {
+		t.Fatalf("LoadStack() error = %v", err)
+	}
+	loaded.Policy.Command = []string{"sh", "-c", "grep -q '\"worker\"' && { echo 'workers need review'; exit 1; }; exit 0"}
+	if err := manifest.SaveFile(manifest.Path(root), loaded); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+	err = platform.ServiceInit(ctx, api.ServiceInitRequest{Name: "worker", Runtime: "container", Image: "worker:1"})
+	if !errors.As(err, &policyErr) || policyErr.Rule != PolicyCommand || policyErr.Reason != "workers need review" {
+		t.Fatalf("ServiceInit(worker) error = %v, want command violation", err)
+	}
+	loaded, err = platform.LoadStack()
+	if err != nil {
+		t.Fatalf("LoadStack() error = %v", err)
+	}
+	if _, ok := loaded.Services["postgres"]; !ok {
+		t.Fatal("protected service was removed")
+	}
+	if _, ok := loaded.Services["worker"]; ok {
+		t.Fatal("rejected service was saved")
+	}
+}
+
+func TestPublishesOnAllInterfaces(t *testing.T) {
+	for port, want := range map[string]bool{
+		"80":                        false,
+		"8080:80":                   true,
+		"0.0.0.0:8080:80":           true,
+		"127.0.0.1:8080:80/tcp":     false,
+		"127.0.0.1:${ports.web}:80": false,
+		"${ports.web}:80":           true,
+		"[::1]:8080:80":             false,
+		"[::]:8080:80":              true,
+	} {
+		if got := publishesOnAllInterfaces(port); got != want {
+			t.Errorf("publishesOnAllInterfaces(%q) = %v, want %v", port, got, want)
+		}
+	}
+}
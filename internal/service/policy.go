@@ -0,0 +1,153 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/fyltr/angee/internal/manifest"
+)
+
+// Built-in policy rules, reported in PolicyError.Rule.
+const (
+	PolicyProtectedService = "protected-service"
+	PolicyPublicPort       = "public-port"
+	PolicyCommand          = "command"
+	PolicyPlan             = "plan"
+)
+
+// saveStack writes a manifest change made through the platform API after
+// checking it against the policy of the manifest currently on disk, so a
+// change cannot loosen the policy that governs it.
+func (p *Platform) saveStack(ctx context.Context, next *manifest.Stack) error {
+	current, err := p.LoadStack()
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		// A first write, such as a workspace created without a host stack,
+		// has no policy yet.
+	case err != nil:
+		return err
+	default:
+		if err := p.checkPolicy(ctx, current, next); err != nil {
+			return err
+		}
+	}
+	return p.writeStack(ctx, next)
+}
+
+func (p *Platform) checkPolicy(ctx context.Context, current, next *manifest.Stack) error {
+	policy := current.Policy
+	if policy == nil {
+		return nil
+	}
+	if policy.RequirePlan && (next.Policy == nil || !next.Policy.RequirePlan) {
+		return &PolicyError{Rule: PolicyPlan, Reason: "require_plan cannot be turned off through angee; edit angee.yaml"}
+	}
+	for _, name := range policy.ProtectedServices {
+		if _, had := current.Services[name]; !had {
+			continue
+		}
+		if _, has := next.Services[name]; !has {
+			return &PolicyError{Rule: PolicyProtectedService, Reason: fmt.Sprintf("service %q is protected and cannot be removed", name)}
+		}
+	}
+	if policy.DenyPublicPorts {
+		for _, name := range sortedKeys(next.Services) {
+			service := next.Services[name]
+			if previous, ok := current.Services[name]; ok && reflect.DeepEqual(previous.Ports, service.Ports) {
+				continue
+			}
+			for _, port := range service.Ports {
+				if publishesOnAllInterfaces(port) {
+					return &PolicyError{Rule: PolicyPublicPort, Reason: fmt.Sprintf("service %q publishes %q on all interfaces; bind a host address such as 127.0.0.1", name, port)}
+				}
+			}
+		}
+	}
+	if len(policy.Command) > 0 {
+		return p.runPolicyCommand(ctx, policy.Command, current, next)
+	}
+	return nil
+}
+
+// runPolicyCommand passes {"current": ..., "next": ...} as JSON on stdin and
+// rejects the change when the command exits non-zero, with its output as
+// the reason. This is where OPA, conftest, or a script plugs in.
+func (p *Platform) runPolicyCommand(ctx context.Context, command []string, current, next *manifest.Stack) error {
+	input, err := json.Marshal(map[string]*manifest.Stack{"current": current, "next": next})
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Dir = p.root
+	cmd.Stdin = bytes.NewReader(input)
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		return nil
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return fmt.Errorf("policy command %s: %w", command[0], err)
+	}
+	reason := strings.TrimSpace(string(out))
+	if reason == "" {
+		reason = fmt.Sprintf("%s rejected the change", command[0])
+	}
+	return &PolicyError{Rule: PolicyCommand, Reason: reason}
+}
+
+// publishesOnAllInterfaces reports whether a compose short-syntax port
+// ([IP:]HOST:CONTAINER[/proto]) publishes without a host address, or on a
+// wildcard one. A bare container port is not published.
+func publishesOnAllInterfaces(port string) bool {
+	spec, _, _ := strings.Cut(port, "/")
+	var ip string
+	if strings.HasPrefix(spec, "[") {
+		end := strings.Index(spec, "]")
+		if end < 0 {
+			return false
+		}
+		ip, spec = spec[1:end], strings.TrimPrefix(spec[end+1:], ":")
+		return slices.Contains([]string{"", "::"}, ip) && spec != ""
+	}
+	parts := splitOutsideBraces(spec)
+	switch len(parts) {
+	case 1:
+		return false
+	case 2:
+		return true
+	default:
+		ip = parts[0]
+		return ip == "" || ip == "0.0.0.0" || ip == "::"
+	}
+}
+
+// splitOutsideBraces splits on ':' except inside ${...}, so substitutions
+// such as ${ports.web} count as one segment.
+func splitOutsideBraces(value string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range value {
+		switch r {
+		case '{':
+			depth++
+		case '}':
+			if depth > 0 {
+				depth--
+			}
+		case ':':
+			if depth == 0 {
+				parts = append(parts, value[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, value[start:])
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/fyltr/angee/internal/manifest"
+	"github.com/fyltr/angee/internal/runtime"
+	"github.com/fyltr/angee/internal/runtime/compose"
+)
+
+// PortForward listens on 127.0.0.1:local, or a free port when local is 0,
+// and relays each connection to port remote inside the running container
+// service name, until ctx is cancelled. Unpublished ports such as a
+// database's become reachable from host tools without editing angee.yaml.
+// The listening address is written to stdout and failed connections to
+// stderr.
+func (p *Platform) PortForward(ctx context.Context, name string, local, remote int, stdout, stderr io.Writer) error {
+	stack, err := p.LoadStack()
+	if err != nil {
+		return err
+	}
+	service, ok := stack.Services[name]
+	if !ok {
+		return &NotFoundError{Kind: "service", Name: name}
+	}
+	if service.Runtime != manifest.RuntimeContainer {
+		return &InvalidInputError{Field: "service", Reason: fmt.Sprintf("service %s is not a container service; its ports are already on the host", name)}
+	}
+	if remote < 1 || remote > 65535 {
+		return &InvalidInputError{Field: "remote", Reason: fmt.Sprintf("port %d is not between 1 and 65535", remote)}
+	}
+	if local < 0 || local > 65535 {
+		return &InvalidInputError{Field: "local", Reason: fmt.Sprintf("port %d is not between 0 and 65535", local)}
+	}
+	forwarder, ok := p.composeBackend.(runtime.Forwarder)
+	if !ok {
+		return fmt.Errorf("the container backend cannot forward ports")
+	}
+	image, err := p.forwardImage(stack)
+	if err != nil {
+		return err
+	}
+	var lc net.ListenConfig
+	listener, err := lc.Listen(ctx, "tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(local)))
+	if err != nil {
+		return &runtime.PortConflictError{Port: local, Err: err}
+	}
+	stop := context.AfterFunc(ctx, func() { listener.Close() })
+	defer stop()
+	defer listener.Close()
+	fmt.Fprintf(stdout, "forwarding %s -> %s:%d\n", listener.Addr(), name, remote)
+	req := runtime.ForwardRequest{Root: p.root, Service: name, EnvFile: p.runtimeEnvFile(stack), Port: remote, Image: image}
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			forward := req
+			forward.Conn = conn
+			if err := forwarder.Forward(ctx, forward); err != nil && ctx.Err() == nil {
+				fmt.Fprintf(stderr, "%s: %v\n", conn.RemoteAddr(), err)
+			}
+		}()
+	}
+}
+
+// forwardImage returns the relay image pinned to its angee.lock digest. The
+// relay runs inside the service's network namespace, so a stack whose
+// images are locked refuses to run it unpinned.
+func (p *Platform) forwardImage(stack *manifest.Stack) (string, error) {
+	image := relayImage(stack)
+	if strings.Contains(image, "@") {
+		return image, nil
+	}
+	lock, err := manifest.LoadLock(manifest.LockPath(p.root))
+	if err != nil {
+		return "", err
+	}
+	if digest := lock.Images[image]; digest != "" {
+		return manifest.PinnedImage(image, digest), nil
+	}
+	if len(lock.Images) > 0 {
+		return "", &InvalidInputError{Field: "operator.forward_image", Reason: image + " is not in angee.lock; run `angee lock images`"}
+	}
+	return image, nil
+}
+
+// relayImage is operator.forward_image, or the backend's default.
+func relayImage(stack *manifest.Stack) string {
+	if stack.Operator.ForwardImage != "" {
+		return stack.Operator.ForwardImage
+	}
+	return compose.ForwardImage
+}
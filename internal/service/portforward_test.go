@@ -0,0 +1,123 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fyltr/angee/internal/manifest"
+	"github.com/fyltr/angee/internal/runtime"
+)
+
+// echoForwarder answers the first line of each forwarded connection with
+// the service name and that line.
+type echoForwarder struct {
+	runtime.Backend
+}
+
+func (echoForwarder) Forward(_ context.Context, req runtime.ForwardRequest) error {
+	line, err := bufio.NewReader(req.Conn).ReadString('\n')
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(req.Conn, req.Service+":"+strings.TrimSpace(line)+"\n")
+	return err
+}
+
+func TestPortForwardRelaysConnections(t *testing.T) {
+	root := t.TempDir()
+	stack := &manifest.Stack{
+		Version: manifest.VersionCurrent,
+		Kind:    manifest.KindStack,
+		Name:    "notes",
+		Services: map[string]manifest.Service{
+			"db":  {Runtime: manifest.RuntimeContainer, Image: "postgres:16"},
+			"web": {Runtime: manifest.RuntimeLocal, Command: []string{"npm", "start"}},
+		},
+	}
+	if err := manifest.SaveFile(manifest.Path(root), stack); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+	platform, err := NewWithBackends(root, echoForwarder{}, nil)
+	if err != nil {
+		t.Fatalf("NewWithBackends() error = %v", err)
+	}
+	var invalid *InvalidInputError
+	if err := platform.PortForward(context.Background(), "web", 0, 3000, io.Discard, io.Discard); !errors.As(err, &invalid) {
+		t.Fatalf("PortForward(web) error = %v, want invalid input for a local service", err)
+	}
+
+	free, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := free.Addr().(*net.TCPAddr).Port
+	free.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- platform.PortForward(ctx, "db", port, 5432, io.Discard, io.Discard) }()
+
+	var conn net.Conn
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		if conn, err = net.Dial("tcp", free.Addr().String()); err == nil || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+	if _, err := io.WriteString(conn, "ping\n"); err != nil {
+		t.Fatal(err)
+	}
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil || reply != "db:ping\n" {
+		t.Fatalf("reply = %q, %v", reply, err)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("PortForward() error = %v", err)
+	}
+}
+
+func TestPortForwardPinsRelayImage(t *testing.T) {
+	root := t.TempDir()
+	stack := &manifest.Stack{
+		Version:  manifest.VersionCurrent,
+		Kind:     manifest.KindStack,
+		Name:     "notes",
+		Services: map[string]manifest.Service{"db": {Runtime: manifest.RuntimeContainer, Image: "postgres:16"}},
+	}
+	if err := manifest.SaveFile(manifest.Path(root), stack); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+	platform, err := NewWithBackends(root, echoForwarder{}, nil)
+	if err != nil {
+		t.Fatalf("NewWithBackends() error = %v", err)
+	}
+	if image, err := platform.forwardImage(stack); err != nil || image != "alpine/socat" {
+		t.Fatalf("forwardImage(no lock) = %q, %v", image, err)
+	}
+
+	lock := &manifest.Lock{Images: map[string]string{"postgres:16": "sha256:db"}}
+	if err := manifest.SaveLock(manifest.LockPath(root), lock); err != nil {
+		t.Fatalf("SaveLock() error = %v", err)
+	}
+	var invalid *InvalidInputError
+	if err := platform.PortForward(context.Background(), "db", 0, 5432, io.Discard, io.Discard); !errors.As(err, &invalid) || invalid.Field != "operator.forward_image" {
+		t.Fatalf("PortForward(unlocked relay) error = %v, want the relay missing from angee.lock", err)
+	}
+	lock.Images["alpine/socat"] = "sha256:relay"
+	if err := manifest.SaveLock(manifest.LockPath(root), lock); err != nil {
+		t.Fatalf("SaveLock() error = %v", err)
+	}
+	if image, err := platform.forwardImage(stack); err != nil || image != "alpine/socat@sha256:relay" {
+		t.Fatalf("forwardImage(locked) = %q, %v", image, err)
+	}
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/fyltr/angee/api"
+	"github.com/fyltr/angee/internal/runtime"
+)
+
+// Labels compose puts on the volumes and networks it creates, naming them
+// as the compose file does.
+const (
+	composeVolumeLabel  = "com.docker.compose.volume"
+	composeNetworkLabel = "com.docker.compose.network"
+)
+
+// PruneOptions chooses whether StackPrune removes what it finds.
+type PruneOptions struct {
+	// DryRun lists the resources and removes nothing.
+	DryRun bool
+	// Only, when set, is the list a person confirmed, as returned by an
+	// earlier dry run. Prune refuses unless it finds exactly these, so a
+	// resource orphaned after the listing is never removed unseen.
+	Only []api.PruneResource
+}
+
+// StackPrune finds the containers, volumes, and networks labeled for this
+// stack that the compiled stack no longer declares, such as those of a
+// removed service, and removes them unless opts.DryRun. It returns what it
+// found either way.
+func (p *Platform) StackPrune(ctx context.Context, opts PruneOptions) (api.PruneResponse, error) {
+	pruner, ok := p.composeBackend.(runtime.Pruner)
+	if !ok {
+		return api.PruneResponse{}, errors.New("the container backend cannot list the resources it created")
+	}
+	compiled, err := p.StackValidate(ctx)
+	if err != nil {
+		return api.PruneResponse{}, err
+	}
+	resources, err := pruner.Resources(ctx, LabelStack+"="+compiled.Compose.Name)
+	if err != nil {
+		return api.PruneResponse{}, err
+	}
+	resp := api.PruneResponse{Resources: []api.PruneResource{}, DryRun: opts.DryRun}
+	var orphans []runtime.Resource
+	for _, resource := range resources {
+		reason := orphanReason(resource, compiled)
+		if reason == "" {
+			continue
+		}
+		orphans = append(orphans, resource)
+		resp.Resources = append(resp.Resources, api.PruneResource{Kind: resource.Kind, Name: resource.Name, Reason: reason})
+	}
+	if opts.DryRun {
+		return resp, nil
+	}
+	if opts.Only != nil && !samePruneResources(opts.Only, resp.Resources) {
+		return resp, &ConflictError{Kind: "stack", Name: compiled.Compose.Name, Reason: "the resources to prune changed since they were listed; list them again"}
+	}
+	if len(orphans) == 0 {
+		return resp, nil
+	}
+	if err := pruner.Remove(ctx, orphans); err != nil {
+		return api.PruneResponse{}, err
+	}
+	return resp, nil
+}
+
+// samePruneResources reports whether confirmed and found name the same
+// resources, in any order.
+func samePruneResources(confirmed, found []api.PruneResource) bool {
+	if len(confirmed) != len(found) {
+		return false
+	}
+	names := map[string]bool{}
+	for _, resource := range confirmed {
+		names[resource.Kind+"/"+resource.Name] = true
+	}
+	for _, resource := range found {
+		if !names[resource.Kind+"/"+resource.Name] {
+			return false
+		}
+	}
+	return true
+}
+
+// orphanReason says why resource is no longer referenced by compiled, or
+// returns "" when it still is.
+func orphanReason(resource runtime.Resource, compiled *CompiledStack) string {
+	switch resource.Kind {
+	case runtime.ResourceContainer:
+		name := resource.Labels[LabelService]
+		if _, ok := compiled.Compose.Services[name]; !ok {
+			return fmt.Sprintf("container service %q is no longer declared", name)
+		}
+	case runtime.ResourceVolume:
+		name := resource.Labels[composeVolumeLabel]
+		if _, ok := compiled.Compose.Volumes[name]; !ok {
+			return fmt.Sprintf("volume %q is no longer declared", name)
+		}
+	case runtime.ResourceNetwork:
+		name := resource.Labels[composeNetworkLabel]
+		if _, ok := compiled.Compose.Networks[name]; !ok {
+			return fmt.Sprintf("network %q is no longer used", name)
+		}
+	}
+	return ""
+}
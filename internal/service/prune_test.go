@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fyltr/angee/api"
+
+	"github.com/fyltr/angee/internal/manifest"
+	"github.com/fyltr/angee/internal/runtime"
+)
+
+type pruneBackend struct {
+	runtime.Backend
+	resources []runtime.Resource
+	removed   *[]runtime.Resource
+}
+
+func (b pruneBackend) Resources(context.Context, string) ([]runtime.Resource, error) {
+	return b.resources, nil
+}
+
+func (b pruneBackend) Remove(_ context.Context, resources []runtime.Resource) error {
+	*b.removed = append(*b.removed, resources...)
+	return nil
+}
+
+func TestStackPruneRemovesUndeclaredResources(t *testing.T) {
+	root := t.TempDir()
+	stack := &manifest.Stack{
+		Version:  manifest.VersionCurrent,
+		Kind:     manifest.KindStack,
+		Name:     "notes",
+		Services: map[string]manifest.Service{"web": {Runtime: manifest.RuntimeContainer, Image: "nginx:1"}},
+		Volumes:  map[string]manifest.Volume{"data": {}},
+	}
+	if err := manifest.SaveFile(manifest.Path(root), stack); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+	var removed []runtime.Resource
+	backend := pruneBackend{removed: &removed, resources: []runtime.Resource{
+		{Kind: runtime.ResourceContainer, Name: "notes-web-1", Labels: map[string]string{LabelService: "web"}},
+		{Kind: runtime.ResourceContainer, Name: "notes-worker-1", Labels: map[string]string{LabelService: "worker"}},
+		{Kind: runtime.ResourceVolume, Name: "notes_data", Labels: map[string]string{composeVolumeLabel: "data"}},
+		{Kind: runtime.ResourceVolume, Name: "notes_cache", Labels: map[string]string{composeVolumeLabel: "cache"}},
+		{Kind: runtime.ResourceNetwork, Name: "notes_default", Labels: map[string]string{composeNetworkLabel: "default"}},
+	}}
+	platform, err := NewWithBackends(root, backend, nil)
+	if err != nil {
+		t.Fatalf("NewWithBackends() error = %v", err)
+	}
+	found, err := platform.StackPrune(context.Background(), PruneOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("StackPrune(dry run) error = %v", err)
+	}
+	if len(found.Resources) != 2 || found.Resources[0].Name != "notes-worker-1" || found.Resources[1].Name != "notes_cache" || len(removed) != 0 {
+		t.Fatalf("StackPrune(dry run) = %+v, removed %+v", found, removed)
+	}
+	if _, err := platform.StackPrune(context.Background(), PruneOptions{Only: found.Resources}); err != nil {
+		t.Fatalf("StackPrune() error = %v", err)
+	}
+	if len(removed) != 2 || removed[0].Name != "notes-worker-1" || removed[1].Name != "notes_cache" {
+		t.Fatalf("removed = %+v", removed)
+	}
+}
+
+func TestStackPruneRefusesWhenConfirmedResourcesChanged(t *testing.T) {
+	root := t.TempDir()
+	stack := &manifest.Stack{
+		Version:  manifest.VersionCurrent,
+		Kind:     manifest.KindStack,
+		Name:     "notes",
+		Services: map[string]manifest.Service{"web": {Runtime: manifest.RuntimeContainer, Image: "nginx:1"}},
+	}
+	if err := manifest.SaveFile(manifest.Path(root), stack); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+	var removed []runtime.Resource
+	backend := pruneBackend{removed: &removed, resources: []runtime.Resource{
+		{Kind: runtime.ResourceContainer, Name: "notes-worker-1", Labels: map[string]string{LabelService: "worker"}},
+		{Kind: runtime.ResourceVolume, Name: "notes_cache", Labels: map[string]string{composeVolumeLabel: "cache"}},
+	}}
+	platform, err := NewWithBackends(root, backend, nil)
+	if err != nil {
+		t.Fatalf("NewWithBackends() error = %v", err)
+	}
+	// Only the container was listed and confirmed; the volume appeared
+	// after, for example because a service was removed meanwhile.
+	confirmed := []api.PruneResource{{Kind: runtime.ResourceContainer, Name: "notes-worker-1"}}
+	var conflict *ConflictError
+	if _, err := platform.StackPrune(context.Background(), PruneOptions{Only: confirmed}); !errors.As(err, &conflict) {
+		t.Fatalf("StackPrune(changed set) error = %v, want conflict", err)
+	}
+	if len(removed) != 0 {
+		t.Fatalf("removed = %+v, want nothing", removed)
+	}
+}
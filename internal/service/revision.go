@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+
+	"github.com/fyltr/angee/internal/manifest"
+)
+
+type baseRevisionKey struct{}
+
+// WithBaseRevision makes the manifest writes made with ctx conditional: a
+// write fails with a ConflictError unless angee.yaml still has revision,
+// as reported in StackStatus. The operator sets it from If-Match, so two
+// clients editing the same stack cannot silently overwrite each other.
+func WithBaseRevision(ctx context.Context, revision string) context.Context {
+	return context.WithValue(ctx, baseRevisionKey{}, revision)
+}
+
+// manifestRevision returns the revision of angee.yaml, the sha256 digest of
+// its bytes, or "" when it does not exist yet.
+func (p *Platform) manifestRevision() (string, error) {
+	data, err := os.ReadFile(manifest.Path(p.root))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return revisionOf(data), nil
+}
+
+func revisionOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// writeStack writes next to angee.yaml, first checking the base revision
+// carried by ctx, if any. Writes are serialized so the check and the write
+// see the same file.
+func (p *Platform) writeStack(ctx context.Context, next *manifest.Stack) error {
+	p.manifestMu.Lock()
+	defer p.manifestMu.Unlock()
+	if err := p.checkBaseRevision(ctx); err != nil {
+		return err
+	}
+	return manifest.SaveFile(manifest.Path(p.root), next)
+}
+
+// checkBaseRevision fails with a conflict when ctx carries a base revision
+// and angee.yaml has moved on from it. writeStack checks it again; writes
+// with side effects beforehand check it first, so a stale request changes
+// nothing.
+func (p *Platform) checkBaseRevision(ctx context.Context) error {
+	base, ok := ctx.Value(baseRevisionKey{}).(string)
+	if !ok || base == "" {
+		return nil
+	}
+	current, err := p.manifestRevision()
+	if err != nil {
+		return err
+	}
+	if current != base {
+		return &ConflictError{Kind: "manifest", Name: "angee.yaml", Reason: "changed since revision " + base + " (now " + current + ")"}
+	}
+	return nil
+}
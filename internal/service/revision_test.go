@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fyltr/angee/internal/manifest"
+	"github.com/fyltr/angee/internal/runtime"
+)
+
+func TestSaveStackRejectsStaleBaseRevision(t *testing.T) {
+	root := t.TempDir()
+	platform, err := NewWithBackends(root, stoppedBackend{}, stoppedBackend{})
+	if err != nil {
+		t.Fatalf("NewWithBackends() error = %v", err)
+	}
+	stack := platform.EmptyStack("notes")
+	if err := platform.saveStack(context.Background(), stack); err != nil {
+		t.Fatalf("saveStack() error = %v", err)
+	}
+	base, err := platform.manifestRevision()
+	if err != nil || base == "" {
+		t.Fatalf("manifestRevision() = %q, %v", base, err)
+	}
+
+	stack.Services = map[string]manifest.Service{"web": {Runtime: manifest.RuntimeContainer, Image: "nginx:1"}}
+	if err := platform.saveStack(WithBaseRevision(context.Background(), base), stack); err != nil {
+		t.Fatalf("saveStack(current base) error = %v", err)
+	}
+	updated, _ := platform.manifestRevision()
+
+	stack.Services["worker"] = manifest.Service{Runtime: manifest.RuntimeContainer, Image: "busybox:1"}
+	err = platform.saveStack(WithBaseRevision(context.Background(), base), stack)
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) || conflict.Kind != "manifest" {
+		t.Fatalf("saveStack(stale base) error = %v, want a manifest ConflictError", err)
+	}
+	if after, _ := platform.manifestRevision(); after != updated {
+		t.Fatalf("stale write changed angee.yaml: revision %s, want %s", after, updated)
+	}
+}
+
+type stopRecordingBackend struct {
+	stoppedBackend
+	stops *int
+}
+
+func (b stopRecordingBackend) Stop(context.Context, runtime.Target) error {
+	*b.stops++
+	return nil
+}
+
+func TestServiceDestroyWithStaleBaseRevisionDoesNotStop(t *testing.T) {
+	root := t.TempDir()
+	var stops int
+	platform, err := NewWithBackends(root, stopRecordingBackend{stops: &stops}, stoppedBackend{})
+	if err != nil {
+		t.Fatalf("NewWithBackends() error = %v", err)
+	}
+	stack := platform.EmptyStack("notes")
+	stack.Services = map[string]manifest.Service{"web": {Runtime: manifest.RuntimeContainer, Image: "nginx:1"}}
+	if err := platform.saveStack(context.Background(), stack); err != nil {
+		t.Fatalf("saveStack() error = %v", err)
+	}
+	base, _ := platform.manifestRevision()
+	stack.Services["worker"] = manifest.Service{Runtime: manifest.RuntimeContainer, Image: "busybox:1"}
+	if err := platform.saveStack(context.Background(), stack); err != nil {
+		t.Fatalf("saveStack() error = %v", err)
+	}
+
+	err = platform.ServiceDestroy(WithBaseRevision(context.Background(), base), "web", true)
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) || conflict.Kind != "manifest" {
+		t.Fatalf("ServiceDestroy(stale base) error = %v, want a manifest ConflictError", err)
+	}
+	if stops != 0 {
+		t.Fatalf("ServiceDestroy(stale base) stopped the service %d times", stops)
+	}
+	reloaded, err := platform.LoadStack()
+	if err != nil || len(reloaded.Services) != 2 {
+		t.Fatalf("LoadStack() = %+v, %v, want web still declared", reloaded, err)
+	}
+}
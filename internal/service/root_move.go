@@ -0,0 +1,263 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/fyltr/angee/internal/git"
+	"github.com/fyltr/angee/internal/manifest"
+	"github.com/fyltr/angee/internal/runtime"
+)
+
+// RootMoveResult reports what `angee root move` changed.
+type RootMoveResult struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Rewritten lists the files, relative to the new root, whose absolute
+	// paths into the old root were rewritten.
+	Rewritten []string `json:"rewritten,omitempty"`
+	// Restarted is set when services were running before the move and were
+	// brought back up from the new root.
+	Restarted bool `json:"restarted,omitempty"`
+}
+
+// RootMove relocates the stack root to target. Running services are
+// stopped first, since their bind mounts name the old path. After the
+// rename, absolute paths into the old root in angee.yaml and in workspace
+// git worktree links are rewritten, the generated compose, process-compose,
+// and devcontainer files are regenerated, and the result is checked for
+// leftover references to the old path before services are started again.
+// When one of those steps fails, the root is moved back.
+func (p *Platform) RootMove(ctx context.Context, target string) (RootMoveResult, error) {
+	from := p.root
+	to, err := filepath.Abs(target)
+	if err != nil {
+		return RootMoveResult{}, err
+	}
+	result := RootMoveResult{From: from, To: to}
+	if to == from {
+		return result, &InvalidInputError{Field: "path", Reason: "is already the stack root"}
+	}
+	if rel, err := filepath.Rel(from, to); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return result, &InvalidInputError{Field: "path", Reason: "is inside the current stack root"}
+	}
+	if nonEmpty, err := pathExistsNonEmpty(to); err != nil {
+		return result, err
+	} else if nonEmpty {
+		return result, &ConflictError{Kind: "stack-root", Name: to, Reason: "already exists and is non-empty"}
+	}
+	if _, err := p.LoadStack(); err != nil {
+		return result, err
+	}
+
+	running := p.servicesRunning(ctx)
+	if running {
+		if err := p.StackDown(ctx); err != nil {
+			return result, fmt.Errorf("stop services before the move: %w", err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(to), 0o755); err != nil {
+		return result, err
+	}
+	// An empty directory at the target is replaced by the root itself.
+	_ = os.Remove(to)
+	if err := os.Rename(from, to); err != nil {
+		if errors.Is(err, syscall.EXDEV) {
+			return result, &InvalidInputError{Field: "path", Reason: "is on another filesystem; copy the root there instead and run `angee stack prepare` in the copy"}
+		}
+		return result, err
+	}
+
+	moved, err := p.finishRootMove(ctx, from, to, &result)
+	if err != nil {
+		return result, p.undoRootMove(ctx, from, to, running, err)
+	}
+	p.root = to
+	if running {
+		if err := moved.StackUp(ctx, nil, UpOptions{}); err != nil {
+			return result, fmt.Errorf("restart services from %s: %w", to, err)
+		}
+		result.Restarted = true
+	}
+	return result, nil
+}
+
+// finishRootMove does the part of a move that follows the rename: it
+// rewrites paths into the old root, regenerates the runtime files in the
+// new one, and checks that nothing still names the old root.
+func (p *Platform) finishRootMove(ctx context.Context, from, to string, result *RootMoveResult) (*Platform, error) {
+	var err error
+	if result.Rewritten, err = rewriteRootPaths(ctx, from, to); err != nil {
+		return nil, err
+	}
+	moved, err := NewWithBackends(to, p.composeBackend, p.procBackend)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := moved.StackPrepare(ctx); err != nil {
+		return nil, fmt.Errorf("regenerate files in %s: %w", to, err)
+	}
+	if err := checkNoOldRoot(to, from); err != nil {
+		return nil, err
+	}
+	return moved, nil
+}
+
+// undoRootMove puts the root back at from after a move failed past the
+// rename: the directory is renamed back, the rewritten paths are pointed
+// at from again, the runtime files are regenerated, and services that were
+// running are started again. It returns cause, annotated when the root
+// could not be put back.
+func (p *Platform) undoRootMove(ctx context.Context, from, to string, running bool, cause error) error {
+	if err := os.Rename(to, from); err != nil {
+		return fmt.Errorf("%w; the root is left at %s: %v", cause, to, err)
+	}
+	// Rewriting back is best-effort: it ends with the same worktree repair
+	// that may be what failed the move.
+	_, _ = rewriteRootPaths(ctx, to, from)
+	if _, err := p.StackPrepare(ctx); err != nil {
+		return fmt.Errorf("%w; the root was moved back to %s, but its files were not regenerated: %v", cause, from, err)
+	}
+	if running {
+		if err := p.StackUp(ctx, nil, UpOptions{}); err != nil {
+			return fmt.Errorf("%w; the root was moved back to %s, but its services did not restart: %v", cause, from, err)
+		}
+	}
+	return fmt.Errorf("%w; the root was moved back to %s", cause, from)
+}
+
+// servicesRunning reports whether any container or local service is
+// running. A runtime that cannot answer, such as one with no generated
+// file yet, counts as stopped.
+func (p *Platform) servicesRunning(ctx context.Context) bool {
+	for _, backend := range []runtime.Backend{p.composeBackend, p.procBackend} {
+		statuses, err := backend.Status(ctx, p.root)
+		if err != nil {
+			continue
+		}
+		for _, status := range statuses {
+			if status.State == "running" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// rewriteRootPaths replaces absolute paths under from with the same paths
+// under to in angee.yaml and in the .git files of workspace worktrees, then
+// has git repair each repository's link back to its worktree. It returns
+// the rewritten files relative to to.
+func rewriteRootPaths(ctx context.Context, from, to string) ([]string, error) {
+	var rewritten []string
+	rewrite := func(path string) error {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		next := replaceRoot(data, from, to)
+		if bytes.Equal(next, data) {
+			return nil
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, next, info.Mode().Perm()); err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(to, path)
+		rewritten = append(rewritten, filepath.ToSlash(rel))
+		return nil
+	}
+	if err := rewrite(manifest.Path(to)); err != nil {
+		return nil, err
+	}
+	// A worktree has a .git file naming its repository's gitdir. Once one
+	// is found, nothing below it is another workspace source.
+	var worktrees []string
+	err := filepath.WalkDir(filepath.Join(to, "workspaces"), func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrPermission) {
+				return nil
+			}
+			return err
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		info, err := os.Lstat(filepath.Join(path, ".git"))
+		switch {
+		case err == nil && info.Mode().IsRegular():
+			worktrees = append(worktrees, path)
+			if err := rewrite(filepath.Join(path, ".git")); err != nil {
+				return err
+			}
+			return filepath.SkipDir
+		case err == nil:
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	client := git.New()
+	for _, dir := range worktrees {
+		if _, err := client.Run(ctx, dir, "worktree", "repair"); err != nil {
+			return nil, fmt.Errorf("repair worktree %s: %w", dir, err)
+		}
+	}
+	return rewritten, nil
+}
+
+// replaceRoot replaces from with to wherever from appears as a whole path
+// or a path prefix, so /srv/angee does not match /srv/angee2.
+func replaceRoot(data []byte, from, to string) []byte {
+	var out bytes.Buffer
+	old := []byte(from)
+	for {
+		i := bytes.Index(data, old)
+		if i < 0 {
+			out.Write(data)
+			return out.Bytes()
+		}
+		end := i + len(old)
+		out.Write(data[:i])
+		if end == len(data) || data[end] == '/' || data[end] == filepath.Separator || !isPathByte(data[end]) {
+			out.WriteString(to)
+		} else {
+			out.Write(old)
+		}
+		data = data[end:]
+	}
+}
+
+func isPathByte(b byte) bool {
+	return b == '/' || b == '\\' || b == '.' || b == '-' || b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// checkNoOldRoot fails when a generated file still names the old root, so
+// a mount that would silently point at a missing directory is caught now.
+func checkNoOldRoot(root, old string) error {
+	for _, name := range []string{"docker-compose.yaml", "process-compose.yaml"} {
+		data, err := os.ReadFile(filepath.Join(root, name))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(replaceRoot(data, old, root), data) {
+			return fmt.Errorf("%s still refers to %s after the move; check absolute paths in angee.yaml", name, old)
+		}
+	}
+	return nil
+}
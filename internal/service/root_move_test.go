@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fyltr/angee/internal/manifest"
+	"github.com/fyltr/angee/internal/runtime"
+)
+
+type stoppedBackend struct {
+	runtime.Backend
+}
+
+func (stoppedBackend) Status(context.Context, string) ([]runtime.ServiceStatus, error) {
+	return nil, nil
+}
+
+func TestRootMoveRewritesAbsolutePaths(t *testing.T) {
+	parent := t.TempDir()
+	from := filepath.Join(parent, "angee")
+	lib := filepath.Join(from, "vendor", "lib")
+	if err := os.MkdirAll(lib, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	stack := &manifest.Stack{
+		Version: manifest.VersionCurrent,
+		Kind:    manifest.KindStack,
+		Name:    "notes",
+		Sources: map[string]manifest.Source{"lib": {Kind: "local", Path: lib}},
+		Services: map[string]manifest.Service{
+			"web": {Runtime: manifest.RuntimeContainer, Image: "nginx:1", Mounts: manifest.StringList{"source://lib:/lib"}},
+		},
+	}
+	if err := manifest.SaveFile(manifest.Path(from), stack); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+	platform, err := NewWithBackends(from, stoppedBackend{}, stoppedBackend{})
+	if err != nil {
+		t.Fatalf("NewWithBackends() error = %v", err)
+	}
+	to := filepath.Join(parent, "moved")
+	result, err := platform.RootMove(context.Background(), to)
+	if err != nil {
+		t.Fatalf("RootMove() error = %v", err)
+	}
+	if result.Restarted || len(result.Rewritten) != 1 || result.Rewritten[0] != "angee.yaml" {
+		t.Fatalf("RootMove() = %+v", result)
+	}
+	if _, err := os.Stat(from); !os.IsNotExist(err) {
+		t.Fatalf("old root still exists, err = %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(to, "docker-compose.yaml"))
+	if err != nil {
+		t.Fatalf("read docker-compose.yaml: %v", err)
+	}
+	if !strings.Contains(string(data), filepath.Join(to, "vendor", "lib")+":/lib") {
+		t.Fatalf("docker-compose.yaml = %s, want the mount under the new root", data)
+	}
+}
+
+func TestRootMoveMovesBackWhenALaterStepFails(t *testing.T) {
+	parent := t.TempDir()
+	from := filepath.Join(parent, "angee")
+	lib := filepath.Join(from, "vendor", "lib")
+	if err := os.MkdirAll(lib, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	stack := &manifest.Stack{
+		Version: manifest.VersionCurrent,
+		Kind:    manifest.KindStack,
+		Name:    "notes",
+		Sources: map[string]manifest.Source{"lib": {Kind: "local", Path: lib}},
+		Services: map[string]manifest.Service{
+			"web": {Runtime: manifest.RuntimeContainer, Image: "nginx:1", Mounts: manifest.StringList{"source://lib:/lib"}},
+		},
+	}
+	if err := manifest.SaveFile(manifest.Path(from), stack); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+	// A worktree link to a repository that does not exist fails the
+	// `git worktree repair` that follows the rename.
+	worktree := filepath.Join(from, "workspaces", "feat", "app")
+	if err := os.MkdirAll(worktree, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	link := "gitdir: " + filepath.Join(from, "missing") + "\n"
+	if err := os.WriteFile(filepath.Join(worktree, ".git"), []byte(link), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	platform, err := NewWithBackends(from, stoppedBackend{}, stoppedBackend{})
+	if err != nil {
+		t.Fatalf("NewWithBackends() error = %v", err)
+	}
+
+	to := filepath.Join(parent, "moved")
+	if _, err := platform.RootMove(context.Background(), to); err == nil || !strings.Contains(err.Error(), "moved back to "+from) {
+		t.Fatalf("RootMove() error = %v, want the root moved back", err)
+	}
+	if _, err := os.Stat(to); !os.IsNotExist(err) {
+		t.Fatalf("new root exists after the failed move, err = %v", err)
+	}
+	data, err := os.ReadFile(manifest.Path(from))
+	if err != nil || !strings.Contains(string(data), lib) {
+		t.Fatalf("angee.yaml = %s, %v; want the source path under the old root", data, err)
+	}
+	data, err = os.ReadFile(filepath.Join(worktree, ".git"))
+	if err != nil || string(data) != link {
+		t.Fatalf(".git = %q, %v; want %q", data, err, link)
+	}
+	if platform.root != from {
+		t.Fatalf("platform root = %s, want %s", platform.root, from)
+	}
+}
+
+func TestReplaceRootMatchesWholePaths(t *testing.T) {
+	got := string(replaceRoot([]byte("path: /srv/angee/src\nother: /srv/angee2\nroot: /srv/angee\n"), "/srv/angee", "/data/angee"))
+	want := "path: /data/angee/src\nother: /srv/angee2\nroot: /data/angee\n"
+	if got != want {
+		t.Fatalf("replaceRoot() = %q, want %q", got, want)
+	}
+}
@@ -2,17 +2,28 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
+	"github.com/fyltr/angee/api"
 	"github.com/fyltr/angee/internal/manifest"
 	"github.com/fyltr/angee/internal/runtime"
+	"github.com/fyltr/angee/internal/runtime/compose"
 )
 
 const defaultProcessComposeControlPort = 8080
 
-func (p *Platform) StackBuild(ctx context.Context, services []string) error {
+// BuildOptions tune StackBuild.
+type BuildOptions struct {
+	// Push pushes the built images to their registries, as a build for
+	// more than one of build.platforms requires.
+	Push bool
+}
+
+func (p *Platform) StackBuild(ctx context.Context, services []string, opts BuildOptions) error {
 	stack, err := p.LoadStack()
 	if err != nil {
 		return err
@@ -31,40 +42,154 @@ func (p *Platform) StackBuild(ctx context.Context, services []string) error {
 	if len(compiled.Compose.Services) == 0 || len(selected) == 0 && len(services) > 0 {
 		return nil
 	}
-	return p.composeBackend.Build(ctx, runtime.Target{Root: p.root, Services: selected, EnvFile: p.runtimeEnvFile(stack)})
+	if err := p.verifyCompose(ctx, stack); err != nil {
+		return err
+	}
+	return p.composeBackend.Build(ctx, runtime.Target{Root: p.root, Services: selected, Push: opts.Push, EnvFile: p.runtimeEnvFile(stack)})
+}
+
+// UpOptions tune StackUp.
+type UpOptions struct {
+	Build bool
+	// Wait, when positive, reports success only once the started
+	// containers are running and those with a readiness probe are healthy,
+	// and fails the deploy when they are not within Wait.
+	Wait time.Duration
+	// AutoRollback restores the angee.yaml of the last successful deploy
+	// and deploys it again when this one fails. It implies a Wait of
+	// DefaultUpWait unless one is set.
+	AutoRollback bool
+	// RollbackDepth bounds how many earlier manifests a rollback tries
+	// when the first ones fail as well; DefaultRollbackDepth when unset.
+	RollbackDepth int
+	// LockImages resolves images not yet in angee.lock and records them
+	// before the stack is compiled, so this deploy and later ones of the
+	// same angee.yaml run the same images.
+	LockImages bool
+	// Message names the deploy in the ledger, such as "bump django to
+	// 5.1".
+	Message string
+	// Plan is a token from ApprovePlan; the deploy goes ahead only if the
+	// stack still compiles to what was planned.
+	Plan string
+
+	// rollback marks the redeploy of an earlier manifest, which was
+	// approved when it was first deployed.
+	rollback bool
 }
 
-func (p *Platform) StackUp(ctx context.Context, services []string, build bool) error {
+const (
+	// DefaultUpWait is how long a deploy waits for health when it rolls
+	// back on failure and no wait was given.
+	DefaultUpWait = 2 * time.Minute
+	// DefaultRollbackDepth is how many earlier manifests a rollback tries.
+	DefaultRollbackDepth = 3
+)
+
+func (p *Platform) StackUp(ctx context.Context, services []string, opts UpOptions) error {
+	return p.stackUp(ctx, services, opts, func(target runtime.Target) error {
+		return p.composeBackend.Up(ctx, target)
+	}, nil, nil)
+}
+
+func (p *Platform) StackUpForeground(ctx context.Context, services []string, opts UpOptions, stdout io.Writer, stderr io.Writer) error {
+	return p.stackUp(ctx, services, opts, func(target runtime.Target) error {
+		return p.composeBackend.UpForeground(ctx, target, stdout, stderr)
+	}, stdout, stderr)
+}
+
+// stackUp prepares the stack and hands the container services to up. The
+// attempt is recorded in the deploy ledger, and when the backend ran and
+// failed, AutoRollback redeploys the last good manifest the same way.
+func (p *Platform) stackUp(ctx context.Context, services []string, opts UpOptions, up func(runtime.Target) error, stdout, stderr io.Writer) (err error) {
+	if opts.AutoRollback && opts.Wait <= 0 {
+		opts.Wait = DefaultUpWait
+	}
+	if opts.RollbackDepth <= 0 {
+		opts.RollbackDepth = DefaultRollbackDepth
+	}
+	started := time.Now().UTC()
+	applied := false
+	var scans []api.ImageScan
+	defer func() {
+		p.recordDeploy(ctx, services, opts.Message, started, scans, err)
+		if err != nil && applied && opts.AutoRollback {
+			err = p.rollbackDeploy(ctx, err, opts.RollbackDepth, func() error {
+				return p.stackUp(ctx, services, UpOptions{Wait: opts.Wait, rollback: true}, up, stdout, stderr)
+			})
+		}
+	}()
 	stack, err := p.LoadStack()
 	if err != nil {
 		return err
 	}
-	if err := p.bootstrapOpenBao(ctx, stack, nil, nil); err != nil {
+	if err := p.bootstrapOpenBao(ctx, stack, stdout, stderr); err != nil {
 		return err
 	}
+	if opts.LockImages {
+		if _, err := p.StackLockImages(ctx, false); err != nil {
+			return err
+		}
+	}
 	compiled, err := p.StackPrepare(ctx)
 	if err != nil {
 		return err
 	}
+	if !opts.rollback {
+		if err := p.checkPlan(stack, compiled, opts.Plan); err != nil {
+			return err
+		}
+	}
 	selected, err := selectRuntimeServices(stack, services, manifest.RuntimeContainer)
 	if err != nil {
 		return err
 	}
+	if len(services) > 0 {
+		selected = withDependents(compiled.Compose, selected)
+		services = selected
+	}
 	if len(compiled.Compose.Services) == 0 || len(selected) == 0 && len(services) > 0 {
 		return nil
 	}
-	return p.composeBackend.Up(ctx, runtime.Target{Root: p.root, Services: selected, Build: build, EnvFile: p.runtimeEnvFile(stack)})
+	if err := p.verifyCompose(ctx, stack); err != nil {
+		return err
+	}
+	if scans, err = p.scanImages(ctx, stack, compiled, selected); err != nil {
+		return err
+	}
+	applied = true
+	// A partial deploy already includes the dependents; with --no-deps
+	// Compose also leaves the dependencies, such as the database, as they
+	// are instead of recreating them when their configuration drifted.
+	return up(runtime.Target{Root: p.root, Services: selected, Build: opts.Build, EnvFile: p.runtimeEnvFile(stack), Wait: opts.Wait, NoDeps: len(services) > 0})
 }
 
-func (p *Platform) StackUpForeground(ctx context.Context, services []string, build bool, stdout io.Writer, stderr io.Writer) error {
-	stack, err := p.LoadStack()
-	if err != nil {
-		return err
+// verifyCompose has the container backend check the docker-compose.yaml
+// StackPrepare wrote before anything is built or started from it. A
+// rejection is reported against the angee.yaml service it came from, since
+// the file itself is generated and not the one to edit.
+func (p *Platform) verifyCompose(ctx context.Context, stack *manifest.Stack) error {
+	verifier, ok := p.composeBackend.(runtime.Verifier)
+	if !ok {
+		return nil
 	}
-	if err := p.bootstrapOpenBao(ctx, stack, stdout, stderr); err != nil {
+	err := verifier.Verify(ctx, p.root, p.runtimeEnvFile(stack))
+	var configErr *runtime.ConfigError
+	if !errors.As(err, &configErr) {
 		return err
 	}
-	compiled, err := p.StackPrepare(ctx)
+	if _, ok := stack.Services[configErr.Service]; ok {
+		return &InvalidInputError{Field: "services." + configErr.Service, Reason: "docker compose rejected the compiled service: " + configErr.Reason}
+	}
+	return &InvalidInputError{Reason: "docker compose rejected the generated docker-compose.yaml: " + configErr.Reason}
+}
+
+// StackWatch syncs or rebuilds running container services as the paths in
+// their develop.watch rules change, until ctx is cancelled. Without names
+// it watches every service that declares rules. The services must already
+// be up.
+func (p *Platform) StackWatch(ctx context.Context, services []string, stdout io.Writer, stderr io.Writer) error {
+	stack, err := p.LoadStack()
 	if err != nil {
 		return err
 	}
@@ -72,10 +197,22 @@ func (p *Platform) StackUpForeground(ctx context.Context, services []string, bui
 	if err != nil {
 		return err
 	}
-	if len(compiled.Compose.Services) == 0 || len(selected) == 0 && len(services) > 0 {
-		return nil
+	watched := selected[:0]
+	for _, name := range selected {
+		if develop := stack.Services[name].Develop; develop != nil && len(develop.Watch) > 0 {
+			watched = append(watched, name)
+		} else if len(services) > 0 {
+			return &InvalidInputError{Field: "services", Reason: fmt.Sprintf("service %s declares no develop.watch rules", name)}
+		}
+	}
+	if len(watched) == 0 {
+		return &InvalidInputError{Field: "services", Reason: "no service declares develop.watch rules"}
 	}
-	return p.composeBackend.UpForeground(ctx, runtime.Target{Root: p.root, Services: selected, Build: build, EnvFile: p.runtimeEnvFile(stack)}, stdout, stderr)
+	watcher, ok := p.composeBackend.(runtime.Watcher)
+	if !ok {
+		return fmt.Errorf("the container backend cannot watch services")
+	}
+	return watcher.Watch(ctx, runtime.Target{Root: p.root, Services: watched, EnvFile: p.runtimeEnvFile(stack)}, stdout, stderr)
 }
 
 func (p *Platform) StackDev(ctx context.Context, build bool) error {
@@ -91,6 +228,9 @@ func (p *Platform) StackDev(ctx context.Context, build bool) error {
 		return err
 	}
 	if len(compiled.Compose.Services) > 0 {
+		if err := p.verifyCompose(ctx, stack); err != nil {
+			return err
+		}
 		if err := p.composeBackend.Up(ctx, runtime.Target{Root: p.root, Build: build, EnvFile: p.runtimeEnvFile(stack)}); err != nil {
 			return err
 		}
@@ -116,6 +256,9 @@ func (p *Platform) StackDevForeground(ctx context.Context, build bool, stdout io
 		return err
 	}
 	if len(compiled.Compose.Services) > 0 {
+		if err := p.verifyCompose(ctx, stack); err != nil {
+			return err
+		}
 		if err := p.composeBackend.UpForeground(ctx, runtime.Target{Root: p.root, Build: build, EnvFile: p.runtimeEnvFile(stack)}, stdout, stderr); err != nil {
 			return err
 		}
@@ -178,11 +321,19 @@ func (p *Platform) ServiceRestart(ctx context.Context, names []string) error {
 	return p.serviceRuntimeAction(ctx, "restart", names)
 }
 
+// LogLimits bounds a log read. Tail keeps the last lines of each service and
+// MaxBytes caps the output, which then ends with a "[truncated]" marker. Zero
+// means no limit.
+type LogLimits struct {
+	Tail     int
+	MaxBytes int
+}
+
 func (p *Platform) StackLogs(ctx context.Context, services []string, follow bool) (<-chan string, error) {
-	return p.StackLogsLimited(ctx, services, follow, 0)
+	return p.StackLogsLimited(ctx, services, follow, LogLimits{})
 }
 
-func (p *Platform) StackLogsLimited(ctx context.Context, services []string, follow bool, maxBytes int) (<-chan string, error) {
+func (p *Platform) StackLogsLimited(ctx context.Context, services []string, follow bool, limits LogLimits) (<-chan string, error) {
 	stack, err := p.LoadStack()
 	if err != nil {
 		return nil, err
@@ -210,14 +361,14 @@ func (p *Platform) StackLogsLimited(ctx context.Context, services []string, foll
 	}
 	var channels []<-chan string
 	if len(compiled.Compose.Services) > 0 && len(container) > 0 {
-		ch, err := p.composeBackend.Logs(ctx, runtime.LogsRequest{Root: p.root, Services: container, Follow: follow, EnvFile: p.runtimeEnvFile(stack), MaxBytes: maxBytes})
+		ch, err := p.composeBackend.Logs(ctx, runtime.LogsRequest{Root: p.root, Services: container, Follow: follow, EnvFile: p.runtimeEnvFile(stack), Tail: limits.Tail, MaxBytes: limits.MaxBytes})
 		if err != nil {
 			return nil, err
 		}
 		channels = append(channels, ch)
 	}
 	if len(compiled.ProcessCompose.Processes) > 0 && len(local) > 0 {
-		ch, err := p.procBackend.Logs(ctx, runtime.LogsRequest{Root: p.root, Services: local, Follow: follow, EnvFile: p.runtimeEnvFile(stack), MaxBytes: maxBytes, ControlPort: processComposeControlPort(stack)})
+		ch, err := p.procBackend.Logs(ctx, runtime.LogsRequest{Root: p.root, Services: local, Follow: follow, EnvFile: p.runtimeEnvFile(stack), Tail: limits.Tail, MaxBytes: limits.MaxBytes, ControlPort: processComposeControlPort(stack)})
 		if err != nil {
 			return nil, err
 		}
@@ -327,6 +478,33 @@ func splitRuntimeServices(stack *manifest.Stack, names []string) ([]string, []st
 	return container, local, nil
 }
 
+// withDependents adds to selected every compiled service that depends on
+// one of them, directly or through others, so a partial deploy also
+// recreates what would otherwise keep talking to the old container. The
+// dependencies of selected services are not added and are left untouched.
+func withDependents(file compose.File, selected []string) []string {
+	included := map[string]bool{}
+	for _, name := range selected {
+		included[name] = true
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, name := range sortedKeys(file.Services) {
+			if included[name] {
+				continue
+			}
+			for dep := range file.Services[name].DependsOn {
+				if included[dep] {
+					included[name], changed = true, true
+					selected = append(selected, name)
+					break
+				}
+			}
+		}
+	}
+	return selected
+}
+
 func selectRuntimeServices(stack *manifest.Stack, names []string, runtimeKind manifest.Runtime) ([]string, error) {
 	if len(names) == 0 {
 		selected := make([]string, 0)
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/fyltr/angee/api"
+	"github.com/fyltr/angee/internal/manifest"
+	"github.com/fyltr/angee/internal/runtime"
+)
+
+// scanSeverities are the severities operator.scan.severity takes, lowest
+// first.
+var scanSeverities = []string{"low", "medium", "high", "critical"}
+
+const defaultScanSeverity = "high"
+
+// ScanError reports a deploy operator.scan blocked: images with known
+// vulnerabilities at or above Severity.
+type ScanError struct {
+	Severity string
+	Scans    []api.ImageScan
+}
+
+func (e *ScanError) Error() string {
+	images := make([]string, len(e.Scans))
+	for i, scan := range e.Scans {
+		ids := make([]string, 0, 3)
+		for _, v := range scan.Vulnerabilities[:min(3, len(scan.Vulnerabilities))] {
+			ids = append(ids, v.ID)
+		}
+		if len(scan.Vulnerabilities) > len(ids) {
+			ids = append(ids, "…")
+		}
+		images[i] = fmt.Sprintf("%s has %d (%s)", scan.Image, len(scan.Vulnerabilities), strings.Join(ids, ", "))
+	}
+	return fmt.Sprintf("image scan found vulnerabilities of severity %s or higher: %s", e.Severity, strings.Join(images, "; "))
+}
+
+// scanImages scans the images of the compiled services in selected, or of
+// every container service when selected is empty, as operator.scan
+// configures. Images a service builds are not scanned, since they may not
+// exist until the deploy builds them. It returns the images with findings
+// and, unless the scan is warn-only, a ScanError when there are any.
+func (p *Platform) scanImages(ctx context.Context, stack *manifest.Stack, compiled *CompiledStack, selected []string) ([]api.ImageScan, error) {
+	config := stack.Operator.Scan
+	if config == nil {
+		return nil, nil
+	}
+	scanner, ok := p.composeBackend.(runtime.ImageScanner)
+	if !ok {
+		return nil, errors.New("the container backend cannot scan images")
+	}
+	severity := config.Severity
+	if severity == "" {
+		severity = defaultScanSeverity
+	}
+	severities := scanSeverities[slices.Index(scanSeverities, severity):]
+	names := selected
+	if len(names) == 0 {
+		names = sortedKeys(compiled.Compose.Services)
+	}
+	var scans []api.ImageScan
+	scanned := map[string]bool{}
+	for _, name := range names {
+		image := compiled.Compose.Services[name].Image
+		if image == "" || stack.Services[name].Build != nil || scanned[image] {
+			continue
+		}
+		scanned[image] = true
+		found, err := scanner.ScanImage(ctx, image, severities, config.IgnoreUnfixed)
+		if err != nil {
+			return scans, err
+		}
+		if len(found) == 0 {
+			continue
+		}
+		scan := api.ImageScan{Image: image}
+		for _, v := range found {
+			scan.Vulnerabilities = append(scan.Vulnerabilities, api.Vulnerability(v))
+		}
+		scans = append(scans, scan)
+	}
+	if len(scans) > 0 && !config.WarnOnly {
+		return scans, &ScanError{Severity: severity, Scans: scans}
+	}
+	return scans, nil
+}
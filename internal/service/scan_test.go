@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/fyltr/angee/api"
+	"github.com/fyltr/angee/internal/manifest"
+	"github.com/fyltr/angee/internal/runtime"
+)
+
+type scanBackend struct {
+	targetBackend
+	found      map[string][]runtime.Vulnerability
+	severities *[]string
+}
+
+func (b scanBackend) ScanImage(_ context.Context, image string, severities []string, _ bool) ([]runtime.Vulnerability, error) {
+	*b.severities = severities
+	return b.found[image], nil
+}
+
+func TestStackUpScanGate(t *testing.T) {
+	root := t.TempDir()
+	stack := &manifest.Stack{
+		Version:  manifest.VersionCurrent,
+		Kind:     manifest.KindStack,
+		Name:     "notes",
+		Operator: manifest.Operator{Scan: &manifest.ImageScan{Severity: "critical"}},
+		Services: map[string]manifest.Service{
+			"web": {Runtime: manifest.RuntimeContainer, Image: "nginx:1"},
+			"db":  {Runtime: manifest.RuntimeContainer, Image: "postgres:16"},
+		},
+	}
+	if err := manifest.SaveFile(manifest.Path(root), stack); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+	var targets []runtime.Target
+	var severities []string
+	backend := scanBackend{
+		targetBackend: targetBackend{targets: &targets},
+		found:         map[string][]runtime.Vulnerability{"nginx:1": {{ID: "CVE-2026-1", Package: "openssl", Severity: "critical"}}},
+		severities:    &severities,
+	}
+	platform, err := NewWithBackends(root, backend, nil)
+	if err != nil {
+		t.Fatalf("NewWithBackends() error = %v", err)
+	}
+	err = platform.StackUp(context.Background(), nil, UpOptions{})
+	var scanErr *ScanError
+	if !errors.As(err, &scanErr) || !strings.Contains(err.Error(), "nginx:1 has 1 (CVE-2026-1)") {
+		t.Fatalf("StackUp() error = %v, want the scan to block", err)
+	}
+	if code, _ := ErrorCode(err); code != api.ErrorCodePolicy {
+		t.Fatalf("ErrorCode() = %s, want policy", code)
+	}
+	if len(targets) != 0 || strings.Join(severities, ",") != "critical" {
+		t.Fatalf("Up() targets = %v, severities = %v, want no deploy and a critical-only scan", targets, severities)
+	}
+
+	stack.Operator.Scan.WarnOnly = true
+	if err := manifest.SaveFile(manifest.Path(root), stack); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+	if err := platform.StackUp(context.Background(), nil, UpOptions{}); err != nil {
+		t.Fatalf("StackUp(warn_only) error = %v", err)
+	}
+	deploys, err := platform.Deploys(context.Background())
+	if err != nil {
+		t.Fatalf("Deploys() error = %v", err)
+	}
+	if len(deploys) != 2 || deploys[0].Status != "succeeded" || len(deploys[0].Scans) != 1 || deploys[0].Scans[0].Vulnerabilities[0].ID != "CVE-2026-1" {
+		t.Fatalf("Deploys() = %+v, want the warn-only findings recorded", deploys)
+	}
+	if deploys[1].Status != "failed" || len(deploys[1].Scans) != 1 {
+		t.Fatalf("blocked deploy = %+v, want it failed with its findings", deploys[1])
+	}
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/fyltr/angee/internal/schedule"
+)
+
+const (
+	JobTriggerManual   = "manual"
+	JobTriggerSchedule = "schedule"
+	JobTriggerInit     = "init"
+)
+
+// JobScheduler runs jobs that declare a cron `schedule:`, and the backups of
+// services whose `backup:` has one. It re-reads angee.yaml every minute, so
+// schedule edits apply without a restart. A job or backup whose previous
+// scheduled run is still going is skipped rather than overlapped.
+type JobScheduler struct {
+	platform *Platform
+
+	mu      sync.Mutex
+	running map[string]bool
+	wg      sync.WaitGroup
+}
+
+func NewJobScheduler(platform *Platform) *JobScheduler {
+	return &JobScheduler{platform: platform, running: map[string]bool{}}
+}
+
+// Run blocks until ctx is cancelled, then waits for in-flight runs.
+func (s *JobScheduler) Run(ctx context.Context) {
+	defer s.wg.Wait()
+	for {
+		now := time.Now()
+		next := now.Truncate(time.Minute).Add(time.Minute)
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		s.tick(ctx, next)
+	}
+}
+
+func (s *JobScheduler) tick(ctx context.Context, now time.Time) {
+	stack, err := s.platform.LoadStack()
+	if err != nil {
+		return
+	}
+	for _, name := range sortedKeys(stack.Jobs) {
+		job := stack.Jobs[name]
+		if job.Schedule == "" {
+			continue
+		}
+		sched, err := schedule.Parse(job.Schedule)
+		if err != nil || !sched.Matches(now) {
+			continue
+		}
+		if !s.claim(name) {
+			continue
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer s.release(name)
+			_, _ = s.platform.runJob(ctx, name, nil, JobTriggerSchedule)
+		}()
+	}
+	for _, name := range sortedKeys(stack.Services) {
+		backup := stack.Services[name].Backup
+		if backup == nil || backup.Schedule == "" {
+			continue
+		}
+		sched, err := schedule.Parse(backup.Schedule)
+		if err != nil || !sched.Matches(now) {
+			continue
+		}
+		if !s.claim("backup:" + name) {
+			continue
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer s.release("backup:" + name)
+			_, _ = s.platform.runBackup(ctx, name, JobTriggerSchedule)
+		}()
+	}
+}
+
+func (s *JobScheduler) claim(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running[name] {
+		return false
+	}
+	s.running[name] = true
+	return true
+}
+
+func (s *JobScheduler) release(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.running, name)
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/fyltr/angee/api"
+	"github.com/fyltr/angee/internal/manifest"
+)
+
+func TestJobSchedulerRunsDueJobsAndRecordsHistory(t *testing.T) {
+	root := t.TempDir()
+	stack := &manifest.Stack{
+		Version: manifest.VersionCurrent,
+		Kind:    manifest.KindStack,
+		Name:    "cron",
+		Jobs: map[string]manifest.Job{
+			"nightly": {Runtime: manifest.RuntimeLocal, Command: []string{"sh", "-c", "echo ok"}, Schedule: "0 3 * * *"},
+			"hourly":  {Runtime: manifest.RuntimeLocal, Command: []string{"sh", "-c", "exit 3"}, Schedule: "@hourly"},
+		},
+	}
+	if err := manifest.SaveFile(manifest.Path(root), stack); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+	platform, err := New(root)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	scheduler := NewJobScheduler(platform)
+	scheduler.tick(context.Background(), time.Date(2026, 5, 10, 3, 0, 0, 0, time.Local))
+	scheduler.wg.Wait()
+
+	nightly, err := platform.JobRuns(context.Background(), "nightly")
+	if err != nil {
+		t.Fatalf("JobRuns(nightly) error = %v", err)
+	}
+	if len(nightly) != 1 || nightly[0].Trigger != JobTriggerSchedule || nightly[0].Status != "succeeded" {
+		t.Fatalf("JobRuns(nightly) = %#v, want one succeeded scheduled run", nightly)
+	}
+	hourly, err := platform.JobRuns(context.Background(), "hourly")
+	if err != nil {
+		t.Fatalf("JobRuns(hourly) error = %v", err)
+	}
+	if len(hourly) != 1 || hourly[0].Status != "failed" || hourly[0].Error == "" {
+		t.Fatalf("JobRuns(hourly) = %#v, want one failed run", hourly)
+	}
+
+	scheduler.tick(context.Background(), time.Date(2026, 5, 10, 3, 30, 0, 0, time.Local))
+	scheduler.wg.Wait()
+	if _, err := platform.JobRun(context.Background(), "nightly", nil); err != nil {
+		t.Fatalf("JobRun() error = %v", err)
+	}
+	nightly, err = platform.JobRuns(context.Background(), "nightly")
+	if err != nil {
+		t.Fatalf("JobRuns(nightly) error = %v", err)
+	}
+	if len(nightly) != 2 || nightly[0].Trigger != JobTriggerManual {
+		t.Fatalf("JobRuns(nightly) = %#v, want manual run first", nightly)
+	}
+}
+
+func TestJobRunHistoryKeepsLastRunsPerJob(t *testing.T) {
+	root := t.TempDir()
+	stack := &manifest.Stack{
+		Version: manifest.VersionCurrent,
+		Kind:    manifest.KindStack,
+		Name:    "cron",
+		Jobs: map[string]manifest.Job{
+			"busy":  {Runtime: manifest.RuntimeLocal, Command: []string{"true"}},
+			"quiet": {Runtime: manifest.RuntimeLocal, Command: []string{"true"}},
+		},
+	}
+	if err := manifest.SaveFile(manifest.Path(root), stack); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+	platform, err := New(root)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if err := platform.recordJobRun(api.JobRun{Job: "quiet", Status: "succeeded"}); err != nil {
+		t.Fatalf("recordJobRun(quiet) error = %v", err)
+	}
+	for i := range jobRunsKept + 5 {
+		run := api.JobRun{Job: "busy", Status: "succeeded", RequestID: strconv.Itoa(i)}
+		if err := platform.recordJobRun(run); err != nil {
+			t.Fatalf("recordJobRun(busy) error = %v", err)
+		}
+	}
+
+	busy, err := platform.JobRuns(context.Background(), "busy")
+	if err != nil {
+		t.Fatalf("JobRuns(busy) error = %v", err)
+	}
+	if len(busy) != jobRunsKept || busy[0].RequestID != strconv.Itoa(jobRunsKept+4) || busy[len(busy)-1].RequestID != "5" {
+		t.Fatalf("JobRuns(busy) kept %d runs from %q to %q, want the last %d", len(busy), busy[len(busy)-1].RequestID, busy[0].RequestID, jobRunsKept)
+	}
+	quiet, err := platform.JobRuns(context.Background(), "quiet")
+	if err != nil {
+		t.Fatalf("JobRuns(quiet) error = %v", err)
+	}
+	if len(quiet) != 1 {
+		t.Fatalf("JobRuns(quiet) = %#v, want its one run kept", quiet)
+	}
+}
@@ -3,9 +3,15 @@ package service
 import (
 	"context"
 	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/fyltr/angee/api"
 	"github.com/fyltr/angee/internal/manifest"
+	"github.com/fyltr/angee/internal/runtime"
 )
 
 func (p *Platform) ServiceInit(ctx context.Context, req api.ServiceInitRequest) error {
@@ -24,7 +30,7 @@ func (p *Platform) ServiceInit(ctx context.Context, req api.ServiceInitRequest)
 		return err
 	}
 	stack.Services[req.Name] = service
-	if err := manifest.SaveFile(manifest.Path(p.root), stack); err != nil {
+	if err := p.saveStack(ctx, stack); err != nil {
 		return err
 	}
 	if _, err := p.StackPrepare(ctx); err != nil {
@@ -36,6 +42,42 @@ func (p *Platform) ServiceInit(ctx context.Context, req api.ServiceInitRequest)
 	return nil
 }
 
+// ServiceImport adds services and named volumes, such as those read from a
+// docker-compose file, to angee.yaml. Names already declared are conflicts,
+// and nothing is written unless every entry is new.
+func (p *Platform) ServiceImport(ctx context.Context, services map[string]manifest.Service, volumes map[string]manifest.Volume) error {
+	stack, err := p.LoadStack()
+	if err != nil {
+		return err
+	}
+	next := *stack
+	next.Services = maps.Clone(stack.Services)
+	if next.Services == nil {
+		next.Services = map[string]manifest.Service{}
+	}
+	for _, name := range sortedKeys(services) {
+		if _, exists := next.Services[name]; exists {
+			return &ConflictError{Kind: "service", Name: name, Reason: "already exists"}
+		}
+		next.Services[name] = services[name]
+	}
+	next.Volumes = maps.Clone(stack.Volumes)
+	if next.Volumes == nil && len(volumes) > 0 {
+		next.Volumes = map[string]manifest.Volume{}
+	}
+	for _, name := range sortedKeys(volumes) {
+		if _, exists := next.Volumes[name]; exists {
+			return &ConflictError{Kind: "volume", Name: name, Reason: "already exists"}
+		}
+		next.Volumes[name] = volumes[name]
+	}
+	if err := p.saveStack(ctx, &next); err != nil {
+		return err
+	}
+	_, err = p.StackPrepare(ctx)
+	return err
+}
+
 func (p *Platform) ServiceUpdate(ctx context.Context, req api.ServiceInitRequest) error {
 	if req.Name == "" {
 		return &InvalidInputError{Field: "name", Reason: "service name is required"}
@@ -74,7 +116,7 @@ func (p *Platform) ServiceUpdate(ctx context.Context, req api.ServiceInitRequest
 		return err
 	}
 	stack.Services[req.Name] = updated
-	if err := manifest.SaveFile(manifest.Path(p.root), stack); err != nil {
+	if err := p.saveStack(ctx, stack); err != nil {
 		return err
 	}
 	_, err = p.StackPrepare(ctx)
@@ -90,11 +132,21 @@ func (p *Platform) ServiceDestroy(ctx context.Context, name string, stop bool) e
 	if !exists {
 		return &NotFoundError{Kind: "service", Name: name}
 	}
+	next := *stack
+	next.Services = maps.Clone(stack.Services)
+	delete(next.Services, name)
+	// Check policy and the base revision before stopping, so a rejected
+	// destroy leaves the service running.
+	if err := p.checkPolicy(ctx, stack, &next); err != nil {
+		return err
+	}
+	if err := p.checkBaseRevision(ctx); err != nil {
+		return err
+	}
 	if stop && service.Runtime == manifest.RuntimeContainer {
 		_ = p.ServiceStop(ctx, []string{name})
 	}
-	delete(stack.Services, name)
-	if err := manifest.SaveFile(manifest.Path(p.root), stack); err != nil {
+	if err := p.writeStack(ctx, &next); err != nil {
 		return err
 	}
 	_, err = p.StackPrepare(ctx)
@@ -106,13 +158,84 @@ func (p *Platform) ServiceList(ctx context.Context) ([]api.ServiceState, error)
 	if err != nil {
 		return nil, err
 	}
+	stats := p.serviceStats(ctx)
 	services := make([]api.ServiceState, 0, len(status.Services))
 	for _, name := range sortedKeys(status.Services) {
-		services = append(services, status.Services[name])
+		state := status.Services[name]
+		if sample, ok := stats[name]; ok {
+			state.Stats = &sample
+			if sample.Health != "" && state.Status != "degraded" {
+				state.Status = sample.Health
+			}
+		}
+		services = append(services, state)
 	}
 	return services, nil
 }
 
+// statsTTL is how long a stats sample is reused. Each sample runs three
+// docker commands; the dashboard, polling clients, and the alert monitor
+// share one sample instead of each spawning their own.
+const statsTTL = 3 * time.Second
+
+type statsCache struct {
+	mu    sync.Mutex
+	at    time.Time
+	stats map[string]api.ServiceStats
+}
+
+// serviceStats samples running container services. Stats are advisory: a
+// stack that was never compiled, or a host without docker, yields none.
+// Concurrent callers within statsTTL share one sample.
+func (p *Platform) serviceStats(ctx context.Context) map[string]api.ServiceStats {
+	return p.serviceStatsAt(ctx, time.Now())
+}
+
+func (p *Platform) serviceStatsAt(ctx context.Context, now time.Time) map[string]api.ServiceStats {
+	p.stats.mu.Lock()
+	defer p.stats.mu.Unlock()
+	if age := now.Sub(p.stats.at); p.stats.stats != nil && age >= 0 && age < statsTTL {
+		return maps.Clone(p.stats.stats)
+	}
+	stats := p.sampleServiceStats(ctx)
+	if stats != nil {
+		p.stats.at, p.stats.stats = now, stats
+	}
+	return maps.Clone(stats)
+}
+
+func (p *Platform) sampleServiceStats(ctx context.Context) map[string]api.ServiceStats {
+	reporter, ok := p.composeBackend.(runtime.StatsReporter)
+	if !ok {
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(p.root, "docker-compose.yaml")); err != nil {
+		return nil
+	}
+	samples, err := reporter.Stats(ctx, p.root)
+	if err != nil {
+		return nil
+	}
+	now := time.Now()
+	stats := make(map[string]api.ServiceStats, len(samples))
+	for _, sample := range samples {
+		stat := api.ServiceStats{
+			CPUPercent:       sample.CPUPercent,
+			MemoryBytes:      sample.MemoryBytes,
+			MemoryLimitBytes: sample.MemoryLimitBytes,
+			Restarts:         sample.Restarts,
+			Health:           sample.Health,
+		}
+		if !sample.StartedAt.IsZero() {
+			started := sample.StartedAt
+			stat.StartedAt = &started
+			stat.UptimeSeconds = int64(now.Sub(started).Seconds())
+		}
+		stats[sample.Name] = stat
+	}
+	return stats
+}
+
 func serviceFromRequest(req api.ServiceInitRequest) (manifest.Service, error) {
 	runtimeKind := manifest.Runtime(req.Runtime)
 	if runtimeKind == "" {
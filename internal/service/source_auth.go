@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fyltr/angee/internal/git"
+	"github.com/fyltr/angee/internal/manifest"
+	"github.com/fyltr/angee/internal/secrets"
+	"github.com/fyltr/angee/internal/substitute"
+)
+
+// sourceGitClient returns the git client for network operations on source,
+// carrying the credentials its `auth:` block names. An SSH key is written to
+// a 0600 file under run/git-auth for the duration of the command; the
+// returned cleanup removes it and must always be called.
+func (p *Platform) sourceGitClient(ctx context.Context, source manifest.Source) (git.Client, func(), error) {
+	client := git.New()
+	noop := func() {}
+	switch source.Auth.Mode {
+	case "", manifest.SourceAuthHost:
+		return client, noop, nil
+	case manifest.SourceAuthSSH:
+		key, err := p.declaredSecret(ctx, source.Auth.SSHKeySecret)
+		if err != nil {
+			return client, noop, err
+		}
+		dir := filepath.Join(p.root, "run", "git-auth")
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return client, noop, err
+		}
+		file, err := os.CreateTemp(dir, "key-*")
+		if err != nil {
+			return client, noop, err
+		}
+		cleanup := func() { _ = os.Remove(file.Name()) }
+		// ssh rejects a private key without a trailing newline, which is
+		// easy to lose when a key is stored as a single secret value.
+		if _, err := file.WriteString(strings.TrimRight(key, "\n") + "\n"); err != nil {
+			file.Close()
+			cleanup()
+			return client, noop, err
+		}
+		if err := file.Close(); err != nil {
+			cleanup()
+			return client, noop, err
+		}
+		return client.WithSSHKey(file.Name()), cleanup, nil
+	case manifest.SourceAuthHTTPSToken:
+		token, err := p.declaredSecret(ctx, source.Auth.TokenSecret)
+		if err != nil {
+			return client, noop, err
+		}
+		return client.WithToken(source.Repo, token), noop, nil
+	default:
+		return client, noop, &InvalidInputError{Field: "auth.mode", Reason: fmt.Sprintf("unsupported source auth mode %q", source.Auth.Mode)}
+	}
+}
+
+func (p *Platform) declaredSecret(ctx context.Context, name string) (string, error) {
+	stack, err := p.LoadStack()
+	if err != nil {
+		return "", err
+	}
+	spec, ok := stack.Secrets[name]
+	if !ok {
+		return "", &NotFoundError{Kind: "secret", Name: name}
+	}
+	backend, err := secrets.FromManifest(p.root, stack.SecretsBackend, substitute.SecretEnvName)
+	if err != nil {
+		return "", err
+	}
+	resolved, err := secrets.ResolveDeclarations(ctx, backend, map[string]manifest.Secret{name: spec}, os.LookupEnv)
+	if err != nil {
+		return "", err
+	}
+	value := resolved[name]
+	if value == "" {
+		return "", fmt.Errorf("secret %q has no value", name)
+	}
+	return value, nil
+}
@@ -2,10 +2,12 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/fyltr/angee/api"
 	"github.com/fyltr/angee/internal/git"
@@ -45,16 +47,37 @@ func (p *Platform) materializeReferencedSources(ctx context.Context, stack *mani
 		}
 		collect(job.Workdir)
 	}
-	for name := range seen {
-		source, ok := stack.Sources[name]
-		if !ok {
+	for _, name := range sortedKeys(seen) {
+		if _, ok := stack.Sources[name]; !ok {
 			return fmt.Errorf("source %q is referenced but not declared", name)
 		}
-		if err := p.materializeSource(ctx, name, source); err != nil {
-			return err
-		}
 	}
-	return nil
+	return p.materializeSources(ctx, stack.Sources, sortedKeys(seen))
+}
+
+// sourceParallelism bounds concurrent clones and fetches, so a stack with
+// many sources does not open a connection per repository at once.
+const sourceParallelism = 4
+
+// materializeSources clones or fetches names concurrently. Every source is
+// attempted; the error names each one that failed.
+func (p *Platform) materializeSources(ctx context.Context, sources map[string]manifest.Source, names []string) error {
+	errs := make([]error, len(names))
+	slots := make(chan struct{}, sourceParallelism)
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			if err := p.materializeSource(ctx, name, sources[name]); err != nil {
+				errs[i] = fmt.Errorf("source %s: %w", name, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
 }
 
 func (p *Platform) SourceList(ctx context.Context) ([]api.SourceState, error) {
@@ -115,7 +138,12 @@ func (p *Platform) SourcePull(ctx context.Context, name string) (api.SourceState
 	if err := p.materializeSource(ctx, name, source); err != nil {
 		return api.SourceState{}, err
 	}
-	if err := git.New().Pull(ctx, p.sourcePath(name, source)); err != nil {
+	client, cleanup, err := p.sourceGitClient(ctx, source)
+	defer cleanup()
+	if err != nil {
+		return api.SourceState{}, err
+	}
+	if err := client.Pull(ctx, p.sourcePath(name, source)); err != nil {
 		return api.SourceState{}, err
 	}
 	return p.sourceState(ctx, name, source)
@@ -141,7 +169,12 @@ func (p *Platform) SourcePush(ctx context.Context, name, ref string) (api.Source
 	if dirty {
 		return api.SourceState{}, fmt.Errorf("source %q has uncommitted changes", name)
 	}
-	if err := git.New().Push(ctx, path, ref); err != nil {
+	client, cleanup, err := p.sourceGitClient(ctx, source)
+	defer cleanup()
+	if err != nil {
+		return api.SourceState{}, err
+	}
+	if err := client.Push(ctx, path, ref); err != nil {
 		return api.SourceState{}, err
 	}
 	return p.sourceState(ctx, name, source)
@@ -151,14 +184,18 @@ func (p *Platform) materializeSource(ctx context.Context, name string, source ma
 	path := p.sourcePath(name, source)
 	switch source.Kind {
 	case "git":
-		client := git.New()
+		client, cleanup, err := p.sourceGitClient(ctx, source)
+		defer cleanup()
+		if err != nil {
+			return err
+		}
 		if _, err := os.Stat(filepath.Join(path, ".git")); err == nil {
 			return client.Fetch(ctx, path)
 		}
 		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 			return err
 		}
-		return client.CloneRef(ctx, source.Repo, path, source.DefaultRef)
+		return client.CloneWith(ctx, source.Repo, path, sourceCloneOptions(source, source.DefaultRef))
 	case "local":
 		if _, err := os.Stat(path); err != nil {
 			return fmt.Errorf("local source %q path %s: %w", name, path, err)
@@ -169,6 +206,10 @@ func (p *Platform) materializeSource(ctx context.Context, name string, source ma
 	}
 }
 
+func sourceCloneOptions(source manifest.Source, ref string) git.CloneOptions {
+	return git.CloneOptions{Ref: ref, Depth: source.Depth, SingleBranch: source.SingleBranch, Sparse: source.Sparse}
+}
+
 func (p *Platform) sourceState(ctx context.Context, name string, source manifest.Source) (api.SourceState, error) {
 	path := p.sourcePath(name, source)
 	state := api.SourceState{Name: name, Kind: source.Kind, Path: path, State: "missing", Pushed: true}
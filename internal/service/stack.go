@@ -2,19 +2,29 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 
 	"github.com/fyltr/angee/internal/copierx"
 	"github.com/fyltr/angee/internal/manifest"
+	"github.com/fyltr/angee/internal/secrets"
+	"github.com/fyltr/angee/internal/substitute"
 )
 
 type StackInitResult struct {
-	Template string `json:"template"`
-	Root     string `json:"root"`
+	Template string   `json:"template"`
+	Root     string   `json:"root"`
+	InitJobs []string `json:"init_jobs,omitempty"`
 }
 
-func (p *Platform) StackInit(ctx context.Context, template string, targetPath string, inputs map[string]string, force bool) (StackInitResult, error) {
+// StackInit renders template and its addons into targetPath. secretValues
+// are stored in the new stack's secrets backend before its sources are
+// fetched and its init jobs run, so an unattended init can supply what
+// they need.
+func (p *Platform) StackInit(ctx context.Context, template string, addons []string, targetPath string, inputs, secretValues map[string]string, force bool) (StackInitResult, error) {
 	if template == "" {
 		return StackInitResult{}, &InvalidInputError{Field: "template", Reason: "stack template is required"}
 	}
@@ -24,14 +34,31 @@ func (p *Platform) StackInit(ctx context.Context, template string, targetPath st
 	if !filepath.IsAbs(targetPath) {
 		targetPath = filepath.Join(p.root, targetPath)
 	}
-	templatePath, _, err := p.resolveTemplate(ctx, template, "stack")
+	templatePath, templateRef, err := p.resolveTemplate(ctx, template, "stack")
 	if err != nil {
 		return StackInitResult{}, err
 	}
-	if _, err := copierx.ValidateMetadata(templatePath, "stack"); err != nil {
+	layers, err := p.stackTemplateLayers(ctx, templatePath)
+	if err != nil {
 		return StackInitResult{}, err
 	}
-	mergedInputs, err := copierx.TemplateInputs(templatePath, copierx.Inputs(inputs))
+	addonLayers, err := p.addonTemplateLayers(ctx, addons)
+	if err != nil {
+		return StackInitResult{}, err
+	}
+	layers = append(layers, addonLayers...)
+	questions, defaults, err := layerQuestions(layers)
+	if err != nil {
+		return StackInitResult{}, err
+	}
+	if err := copierx.ValidateInputs(questions, defaults, copierx.Inputs(inputs)); err != nil {
+		var inputErr *copierx.InputError
+		if errors.As(err, &inputErr) {
+			return StackInitResult{}, &InvalidInputError{Field: "inputs." + inputErr.Name, Reason: inputErr.Reason}
+		}
+		return StackInitResult{}, err
+	}
+	mergedInputs, err := layerInputs(layers, copierx.Inputs(inputs))
 	if err != nil {
 		return StackInitResult{}, err
 	}
@@ -49,14 +76,31 @@ func (p *Platform) StackInit(ctx context.Context, template string, targetPath st
 			}
 		}
 	}
+	createdRoot := false
+	if _, err := os.Stat(preparedRoot); os.IsNotExist(err) {
+		createdRoot = true
+	}
 	if err := os.MkdirAll(targetPath, 0o755); err != nil {
 		return StackInitResult{}, err
 	}
-	resolvedInputs, err := copierx.ResolvePathInputs(templatePath, mergedInputs, targetPath, mergedInputs["ANGEE_ROOT"])
+	result, err := p.initStackRoot(ctx, layers, targetPath, preparedRoot, mergedInputs, inputs, secretValues, templateRef, templatePath, addons)
 	if err != nil {
+		// Do not leave a half-initialized root behind: a root this call
+		// created is removed so init can simply be retried.
+		if createdRoot {
+			_ = os.RemoveAll(preparedRoot)
+		}
 		return StackInitResult{}, err
 	}
-	if err := (copierx.LocalRenderer{}).Copy(ctx, copierx.CopyRequest{Template: templatePath, Dest: targetPath, Inputs: resolvedInputs}); err != nil {
+	result.Template = template
+	return result, nil
+}
+
+// initStackRoot renders the template layers and brings the new root to a
+// usable state: template record, provided secrets, referenced sources, and
+// `run_on: [init]` jobs.
+func (p *Platform) initStackRoot(ctx context.Context, layers []string, targetPath, preparedRoot string, mergedInputs copierx.Inputs, inputs, secretValues map[string]string, templateRef, templatePath string, addons []string) (StackInitResult, error) {
+	if err := renderStackLayers(ctx, layers, targetPath, preparedRoot, mergedInputs); err != nil {
 		return StackInitResult{}, err
 	}
 	if _, err := os.Stat(manifest.Path(preparedRoot)); err != nil {
@@ -72,6 +116,9 @@ func (p *Platform) StackInit(ctx context.Context, template string, targetPath st
 			}
 		}
 	}
+	if err := recordStackTemplate(ctx, preparedRoot, templateRef, templatePath, addons); err != nil {
+		return StackInitResult{}, err
+	}
 	initialized, err := New(preparedRoot)
 	if err != nil {
 		return StackInitResult{}, err
@@ -80,21 +127,103 @@ func (p *Platform) StackInit(ctx context.Context, template string, targetPath st
 	if err != nil {
 		return StackInitResult{}, err
 	}
+	if err := storeInitSecrets(ctx, preparedRoot, stack, secretValues); err != nil {
+		return StackInitResult{}, err
+	}
 	if err := initialized.materializeReferencedSources(ctx, stack); err != nil {
 		return StackInitResult{}, err
 	}
-	return StackInitResult{Template: template, Root: preparedRoot}, nil
+	jobs, err := initJobOrder(stack)
+	if err != nil {
+		return StackInitResult{}, err
+	}
+	for _, name := range jobs {
+		if _, err := initialized.runJob(ctx, name, nil, JobTriggerInit); err != nil {
+			return StackInitResult{}, fmt.Errorf("init job %s: %w", name, err)
+		}
+	}
+	return StackInitResult{Root: preparedRoot, InitJobs: jobs}, nil
 }
 
-func (p *Platform) StackTemplateQuestions(ctx context.Context, template string) (map[string]copierx.Input, copierx.Inputs, error) {
+// storeInitSecrets writes values for secrets the rendered stack declares.
+// A name the stack does not declare is an error rather than a silent drop,
+// since it usually means the answers were written for another template.
+func storeInitSecrets(ctx context.Context, root string, stack *manifest.Stack, values map[string]string) error {
+	if len(values) == 0 {
+		return nil
+	}
+	for _, name := range sortedKeys(values) {
+		if _, ok := stack.Secrets[name]; !ok {
+			return &InvalidInputError{Field: "secrets." + name, Reason: "is not declared by the stack template"}
+		}
+	}
+	backend, err := secrets.FromManifest(root, stack.SecretsBackend, substitute.SecretEnvName)
+	if err != nil {
+		return err
+	}
+	for _, name := range sortedKeys(values) {
+		if err := backend.Set(ctx, name, values[name]); err != nil {
+			return fmt.Errorf("store secret %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// initJobOrder returns the jobs marked `run_on: [init]`, ordered so a job
+// runs after any other init job it lists in depends_on, then by name.
+func initJobOrder(stack *manifest.Stack) ([]string, error) {
+	init := map[string]bool{}
+	for name, job := range stack.Jobs {
+		if slices.Contains(job.RunOn, manifest.RunOnInit) {
+			init[name] = true
+		}
+	}
+	order := []string{}
+	state := map[string]int{}
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case 1:
+			return &InvalidInputError{Field: "jobs." + name + ".depends_on", Reason: "init jobs depend on each other in a cycle"}
+		case 2:
+			return nil
+		}
+		state[name] = 1
+		deps := slices.Clone(stack.Jobs[name].DependsOn)
+		slices.Sort(deps)
+		for _, dep := range deps {
+			if init[dep] {
+				if err := visit(dep); err != nil {
+					return err
+				}
+			}
+		}
+		state[name] = 2
+		order = append(order, name)
+		return nil
+	}
+	for _, name := range sortedKeys(init) {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+func (p *Platform) StackTemplateQuestions(ctx context.Context, template string, addons []string) (map[string]copierx.Input, copierx.Inputs, error) {
 	templatePath, _, err := p.resolveTemplate(ctx, template, "stack")
 	if err != nil {
 		return nil, nil, err
 	}
-	if _, err := copierx.ValidateMetadata(templatePath, "stack"); err != nil {
+	layers, err := p.stackTemplateLayers(ctx, templatePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	addonLayers, err := p.addonTemplateLayers(ctx, addons)
+	if err != nil {
 		return nil, nil, err
 	}
-	return copierx.TemplateQuestions(templatePath)
+	return layerQuestions(append(layers, addonLayers...))
 }
 
 func expectedStackRoot(targetPath string, inputs map[string]string) string {
@@ -129,6 +258,9 @@ func (p *Platform) StackDestroy(ctx context.Context, purge bool) error {
 			return err
 		}
 	}
+	if err := p.removeDevcontainers(nil); err != nil {
+		return err
+	}
 	if purge {
 		for _, name := range []string{"workspaces", "sources", "volumes", "run"} {
 			if err := os.RemoveAll(filepath.Join(p.root, name)); err != nil {
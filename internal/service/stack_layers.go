@@ -0,0 +1,192 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/fyltr/angee/internal/copierx"
+	"github.com/fyltr/angee/internal/manifest"
+)
+
+// maxTemplateLayers bounds an `_angee.extends` chain so a misconfigured
+// catalog fails fast instead of resolving forever.
+const maxTemplateLayers = 8
+
+// stackTemplateLayers returns templatePath preceded by the templates it
+// extends, base first. Each layer must be a stack template.
+func (p *Platform) stackTemplateLayers(ctx context.Context, templatePath string) ([]string, error) {
+	layers := []string{}
+	seen := map[string]bool{}
+	path := filepath.Clean(templatePath)
+	for {
+		if seen[path] {
+			return nil, &InvalidInputError{Field: "template", Reason: fmt.Sprintf("template %s extends itself", path)}
+		}
+		if len(layers) == maxTemplateLayers {
+			return nil, &InvalidInputError{Field: "template", Reason: fmt.Sprintf("extends chain deeper than %d templates", maxTemplateLayers)}
+		}
+		seen[path] = true
+		metadata, err := copierx.ValidateMetadata(path, "stack")
+		if err != nil {
+			return nil, err
+		}
+		layers = append([]string{path}, layers...)
+		if metadata.Extends == "" {
+			return layers, nil
+		}
+		next, err := p.resolveExtendedTemplate(ctx, path, metadata.Extends)
+		if err != nil {
+			return nil, fmt.Errorf("template %s extends %s: %w", filepath.Base(path), metadata.Extends, err)
+		}
+		path = filepath.Clean(next)
+	}
+}
+
+// templateParallelism bounds concurrent addon resolution, which may clone
+// or download a remote template per addon.
+const templateParallelism = 4
+
+// addonTemplateLayers resolves `--with` addons, in the order given. An addon
+// is a single `kind: addon` template found under `addons/` in the search
+// roots; it renders after the stack template and its manifest is merged
+// like an extending layer. Addons resolve concurrently; the first failure in
+// the given order is reported.
+func (p *Platform) addonTemplateLayers(ctx context.Context, addons []string) ([]string, error) {
+	unique := make([]string, 0, len(addons))
+	seen := map[string]bool{}
+	for _, addon := range addons {
+		if !seen[addon] {
+			seen[addon] = true
+			unique = append(unique, addon)
+		}
+	}
+	layers := make([]string, len(unique))
+	errs := make([]error, len(unique))
+	slots := make(chan struct{}, templateParallelism)
+	var wg sync.WaitGroup
+	for i, addon := range unique {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			path, _, err := p.resolveTemplate(ctx, addon, "addon")
+			if err != nil {
+				errs[i] = &NotFoundError{Kind: "addon template", Name: addon}
+				return
+			}
+			if _, err := copierx.ValidateMetadata(path, "addon"); err != nil {
+				errs[i] = err
+				return
+			}
+			layers[i] = path
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return layers, nil
+}
+
+// resolveExtendedTemplate resolves an extends ref relative to the extending
+// template first, so a catalog can say `extends: ../base`, then through the
+// normal template search roots and remote refs.
+func (p *Platform) resolveExtendedTemplate(ctx context.Context, from, ref string) (string, error) {
+	if !filepath.IsAbs(ref) && !isRemoteTemplateRef(ref) {
+		candidate := filepath.Join(from, filepath.FromSlash(ref))
+		if _, err := os.Stat(filepath.Join(candidate, "copier.yml")); err == nil {
+			return candidate, nil
+		}
+	}
+	path, _, err := p.resolveTemplate(ctx, ref, "stack")
+	return path, err
+}
+
+// layerQuestions merges the questions and defaults of every layer; a later
+// layer redefines a question wholesale.
+func layerQuestions(layers []string) (map[string]copierx.Input, copierx.Inputs, error) {
+	questions := map[string]copierx.Input{}
+	defaults := copierx.Inputs{}
+	for _, path := range layers {
+		layerQuestions, layerDefaults, err := copierx.TemplateQuestions(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		for key, question := range layerQuestions {
+			questions[key] = question
+		}
+		for key, value := range layerDefaults {
+			defaults[key] = value
+		}
+	}
+	return questions, defaults, nil
+}
+
+// layerInputs merges each layer's defaults and generated values, later
+// layers winning, and applies the provided inputs on top.
+func layerInputs(layers []string, provided copierx.Inputs) (copierx.Inputs, error) {
+	merged := copierx.Inputs{}
+	for _, path := range layers {
+		layer, err := copierx.TemplateInputs(path, nil)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range layer {
+			merged[key] = value
+		}
+	}
+	for key, value := range provided {
+		merged[key] = value
+	}
+	return merged, nil
+}
+
+// renderStackLayers renders each layer into targetPath in order. Files from
+// a later layer replace earlier ones, except the stack manifest, which is
+// deep-merged with manifest.Overlay so a layer only states what it adds or
+// changes.
+func renderStackLayers(ctx context.Context, layers []string, targetPath, stackRoot string, inputs copierx.Inputs) error {
+	manifestPath := manifest.Path(stackRoot)
+	for i, path := range layers {
+		var base []byte
+		if i > 0 {
+			data, err := os.ReadFile(manifestPath)
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			base = data
+		}
+		resolved, err := copierx.ResolvePathInputs(path, inputs, targetPath, inputs["ANGEE_ROOT"])
+		if err != nil {
+			return err
+		}
+		if err := (copierx.LocalRenderer{}).Copy(ctx, copierx.CopyRequest{Template: path, Dest: targetPath, Inputs: resolved}); err != nil {
+			return err
+		}
+		if len(base) == 0 {
+			continue
+		}
+		rendered, err := os.ReadFile(manifestPath)
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(rendered, base) {
+			continue
+		}
+		merged, err := manifest.Overlay(base, rendered)
+		if err != nil {
+			return fmt.Errorf("template %s: %w", filepath.Base(path), err)
+		}
+		if err := os.WriteFile(manifestPath, merged, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// maxTemplateArchiveBytes bounds a template tarball download, well above
+// the size of a template repository's archive.
+const maxTemplateArchiveBytes = 64 << 20
+
+// isTarballTemplateRef reports whether ref is an http(s) URL of a gzipped
+// tarball, such as a release asset or a forge's archive download.
+func isTarballTemplateRef(ref string) bool {
+	u, err := url.Parse(ref)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return false
+	}
+	return strings.HasSuffix(u.Path, ".tar.gz") || strings.HasSuffix(u.Path, ".tgz")
+}
+
+// resolveTarballTemplate downloads and unpacks a tarball template into the
+// user cache. The URL fragment selects a subdirectory of the archive. A
+// tarball URL names a fixed release, so the cached copy is reused until
+// template refresh is on.
+func (p *Platform) resolveTarballTemplate(ctx context.Context, ref, kind string) (string, string, error) {
+	archiveURL, subpath, _ := strings.Cut(ref, "#")
+	cacheRoot, err := templateCacheRoot(archiveURL)
+	if err != nil {
+		return "", "", err
+	}
+	treeDir := filepath.Join(cacheRoot, "tree")
+	defer p.templateFetches.lock(cacheRoot)()
+	if _, err := os.Stat(treeDir); err != nil || p.refreshTemplates && !p.templateFetches.fetched(cacheRoot) {
+		if err := downloadTemplateArchive(ctx, archiveURL, cacheRoot); err != nil {
+			return "", "", fmt.Errorf("download template %s: %w", archiveURL, err)
+		}
+		p.templateFetches.mark(cacheRoot)
+	}
+	root := archiveRoot(treeDir)
+	templatePath := filepath.Join(root, filepath.FromSlash(strings.Trim(subpath, "/")))
+	if _, err := os.Stat(filepath.Join(templatePath, "copier.yml")); err != nil {
+		if alt := alternateTemplatePath(root, subpath, kind); alt != "" {
+			templatePath = alt
+		} else {
+			return "", "", fmt.Errorf("template %q was not found in downloaded archive", ref)
+		}
+	}
+	return templatePath, ref, nil
+}
+
+// downloadTemplateArchive unpacks the archive into a staging directory and
+// swaps it in only once extraction succeeded, so an interrupted download
+// never replaces a good cached tree.
+func downloadTemplateArchive(ctx context.Context, archiveURL, cacheRoot string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, archiveURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	if err := os.MkdirAll(cacheRoot, 0o755); err != nil {
+		return err
+	}
+	staging, err := os.MkdirTemp(cacheRoot, "tree-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+	body := &io.LimitedReader{R: resp.Body, N: maxTemplateArchiveBytes + 1}
+	err = extractTarGz(body, staging)
+	if body.N <= 0 {
+		return fmt.Errorf("archive is larger than %d bytes", maxTemplateArchiveBytes)
+	}
+	if err != nil {
+		return err
+	}
+	treeDir := filepath.Join(cacheRoot, "tree")
+	if err := os.RemoveAll(treeDir); err != nil {
+		return err
+	}
+	if err := os.Rename(staging, treeDir); err != nil {
+		return err
+	}
+	markTemplateCacheFetched(cacheRoot)
+	return nil
+}
+
+// extractTarGz unpacks regular files and directories. Entries that would
+// land outside dest are rejected; links and special files are skipped.
+func extractTarGz(r io.Reader, dest string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name := path.Clean(strings.TrimPrefix(header.Name, "./"))
+		if name == "." {
+			continue
+		}
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("archive entry %q escapes the template directory", header.Name)
+		}
+		target := filepath.Join(dest, filepath.FromSlash(name))
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode)&0o755|0o644)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(file, tr); err != nil {
+				file.Close()
+				return err
+			}
+			if err := file.Close(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// archiveRoot skips the single top-level directory that release and forge
+// archives wrap their contents in (repo-v1.2.0/...), so `#subdir` is written
+// relative to the repository, not the archive.
+func archiveRoot(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 || !entries[0].IsDir() {
+		return dir
+	}
+	return filepath.Join(dir, entries[0].Name())
+}
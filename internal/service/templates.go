@@ -8,34 +8,66 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/fyltr/angee/api"
+	"github.com/fyltr/angee/internal/copierx"
 	"github.com/fyltr/angee/internal/git"
+	"github.com/fyltr/angee/internal/manifest"
+	"gopkg.in/yaml.v3"
 )
 
+// isRemoteTemplateRef reports whether ref names a template fetched over the
+// network: a GitHub URL, a `github:owner/repo@ref#subdir` shorthand, an
+// http(s) tarball, or an oci:// artifact.
 func isRemoteTemplateRef(ref string) bool {
+	if strings.HasPrefix(ref, "github:") {
+		return true
+	}
 	u, err := url.Parse(ref)
-	return err == nil && (u.Scheme == "https" || u.Scheme == "http")
+	return err == nil && (u.Scheme == "https" || u.Scheme == "http" || u.Scheme == "oci")
 }
 
-func (p *Platform) resolveRemoteTemplate(ctx context.Context, ref, kind string) (string, string, error) {
-	repoURL, branch, subpath, err := parseGitHubTemplateRef(ref)
+// resolveRemoteTemplate clones a remote template into the user cache, or
+// reuses the cached clone. The clone is fetched when forceFetch is set, when
+// template refresh is on, or when the last fetch is older than
+// templateFetchInterval; a failed fetch falls back to the cached copy unless
+// refresh was requested.
+func (p *Platform) resolveRemoteTemplate(ctx context.Context, ref, kind string, forceFetch bool) (string, string, error) {
+	if strings.HasPrefix(ref, "oci://") {
+		return "", "", fmt.Errorf("oci template refs are not supported yet; publish %s as a tarball or git repository", ref)
+	}
+	if isTarballTemplateRef(ref) {
+		return p.resolveTarballTemplate(ctx, ref, kind)
+	}
+	var repoURL, branch, subpath string
+	var err error
+	if strings.HasPrefix(ref, "github:") {
+		repoURL, branch, subpath, err = parseGitHubShorthand(ref)
+	} else {
+		repoURL, branch, subpath, err = parseGitHubTemplateRef(ref)
+	}
 	if err != nil {
 		return "", "", err
 	}
-	cacheRoot, err := templateCacheRoot(ref)
+	cacheRoot, err := templateCacheRoot(repoURL + "@" + branch)
 	if err != nil {
 		return "", "", err
 	}
 	repoDir := filepath.Join(cacheRoot, "repo")
 	client := git.New()
+	defer p.templateFetches.lock(cacheRoot)()
 	if _, err := os.Stat(filepath.Join(repoDir, ".git")); err == nil {
-		if err := client.Fetch(ctx, repoDir); err != nil {
-			return "", "", err
-		}
-		if branch != "" {
-			if _, err := client.Run(ctx, repoDir, "checkout", branch); err != nil {
-				return "", "", err
+		if forceFetch || p.refreshTemplates && !p.templateFetches.fetched(cacheRoot) || templateCacheStale(cacheRoot) {
+			if err := p.refreshTemplateCache(ctx, client, cacheRoot, repoDir, branch); err != nil {
+				if p.refreshTemplates {
+					return "", "", err
+				}
+				fmt.Fprintf(os.Stderr, "warning: could not refresh template %s, using cached copy: %v\n", ref, err)
 			}
 		}
 	} else {
@@ -45,6 +77,8 @@ func (p *Platform) resolveRemoteTemplate(ctx context.Context, ref, kind string)
 		if err := client.CloneRef(ctx, repoURL, repoDir, branch); err != nil {
 			return "", "", err
 		}
+		p.templateFetches.mark(cacheRoot)
+		markTemplateCacheFetched(cacheRoot)
 	}
 	templatePath := filepath.Join(repoDir, filepath.FromSlash(subpath))
 	if _, err := os.Stat(filepath.Join(templatePath, "copier.yml")); err != nil {
@@ -85,6 +119,99 @@ func parseGitHubTemplateRef(ref string) (repoURL string, branch string, subpath
 	return fmt.Sprintf("https://github.com/%s/%s.git", owner, repo), branch, strings.Join(rest, "/"), nil
 }
 
+// parseGitHubShorthand parses `github:owner/repo[@ref][#subdir]`. The ref
+// is a branch or tag, which the clone checks out; without #subdir the
+// repository root is the template.
+func parseGitHubShorthand(ref string) (repoURL string, branch string, subpath string, err error) {
+	rest := strings.TrimPrefix(ref, "github:")
+	rest, subpath, _ = strings.Cut(rest, "#")
+	rest, branch, _ = strings.Cut(rest, "@")
+	parts := strings.Split(strings.Trim(rest, "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", "", fmt.Errorf("GitHub template ref %q must be github:owner/repo[@ref][#subdir]", ref)
+	}
+	repo := strings.TrimSuffix(parts[1], ".git")
+	return fmt.Sprintf("https://github.com/%s/%s.git", parts[0], repo), branch, strings.Trim(subpath, "/"), nil
+}
+
+// templateFetchInterval is how long a cached clone is used without fetching.
+// One init resolves the same template several times (questions, layers,
+// render); the interval keeps that to one network round trip.
+const templateFetchInterval = 10 * time.Minute
+
+// SetTemplateRefresh makes remote template resolution fetch every time and
+// fail instead of falling back to the cached clone when the fetch fails.
+func (p *Platform) SetTemplateRefresh(refresh bool) {
+	p.refreshTemplates = refresh
+	p.templateFetches.reset()
+}
+
+// templateFetches serializes work on each template cache directory and
+// remembers which caches were fetched since refresh was turned on, so an
+// extends chain or several addons from one repository fetch it only once.
+type templateFetches struct {
+	mu    sync.Mutex
+	done  map[string]bool
+	locks map[string]*sync.Mutex
+}
+
+func (f *templateFetches) lock(cacheRoot string) func() {
+	f.mu.Lock()
+	if f.locks == nil {
+		f.locks = map[string]*sync.Mutex{}
+	}
+	lock := f.locks[cacheRoot]
+	if lock == nil {
+		lock = &sync.Mutex{}
+		f.locks[cacheRoot] = lock
+	}
+	f.mu.Unlock()
+	lock.Lock()
+	return lock.Unlock
+}
+
+func (f *templateFetches) fetched(cacheRoot string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.done[cacheRoot]
+}
+
+func (f *templateFetches) mark(cacheRoot string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.done == nil {
+		f.done = map[string]bool{}
+	}
+	f.done[cacheRoot] = true
+}
+
+func (f *templateFetches) reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.done = nil
+}
+
+func (p *Platform) refreshTemplateCache(ctx context.Context, client git.Client, cacheRoot, repoDir, branch string) error {
+	if err := client.Fetch(ctx, repoDir); err != nil {
+		return err
+	}
+	if err := client.CheckoutLatest(ctx, repoDir, branch); err != nil {
+		return err
+	}
+	p.templateFetches.mark(cacheRoot)
+	markTemplateCacheFetched(cacheRoot)
+	return nil
+}
+
+func templateCacheStale(cacheRoot string) bool {
+	info, err := os.Stat(filepath.Join(cacheRoot, "fetched-at"))
+	return err != nil || time.Since(info.ModTime()) > templateFetchInterval
+}
+
+func markTemplateCacheFetched(cacheRoot string) {
+	_ = os.WriteFile(filepath.Join(cacheRoot, "fetched-at"), []byte(time.Now().UTC().Format(time.RFC3339)+"\n"), 0o644)
+}
+
 func templateCacheRoot(ref string) (string, error) {
 	base, err := os.UserCacheDir()
 	if err != nil || base == "" {
@@ -113,3 +240,188 @@ func alternateTemplatePath(repoDir string, subpath string, kind string) string {
 	}
 	return ""
 }
+
+// TemplateList discovers local templates of the given kind ("stack",
+// "workspace", "addon", or "" for all) across the resolver's search roots. When the
+// same name appears in several roots the one resolution would pick wins.
+func (p *Platform) TemplateList(ctx context.Context, kind string) ([]api.TemplateInfo, error) {
+	kinds := []string{"stack", "workspace", "addon"}
+	if kind != "" {
+		if !slices.Contains(kinds, kind) {
+			return nil, &InvalidInputError{Field: "kind", Reason: fmt.Sprintf("unsupported template kind %q", kind)}
+		}
+		kinds = []string{kind}
+	}
+	templates := []api.TemplateInfo{}
+	for _, kind := range kinds {
+		seen := map[string]bool{}
+		for _, dir := range p.templateSearchRoots() {
+			entries, err := os.ReadDir(filepath.Join(dir, kind+"s"))
+			if err != nil {
+				continue
+			}
+			for _, entry := range entries {
+				if !entry.IsDir() || seen[entry.Name()] {
+					continue
+				}
+				path := filepath.Join(dir, kind+"s", entry.Name())
+				info, err := templateInfo(path, kind+"s/"+entry.Name(), kind)
+				if err != nil {
+					continue
+				}
+				seen[entry.Name()] = true
+				templates = append(templates, info)
+			}
+		}
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		if templates[i].Kind != templates[j].Kind {
+			return templates[i].Kind < templates[j].Kind
+		}
+		return templates[i].Name < templates[j].Name
+	})
+	return templates, nil
+}
+
+// TemplateInfo describes one template, resolved exactly as init or
+// workspace create would resolve it.
+func (p *Platform) TemplateInfo(ctx context.Context, ref string, kind string) (api.TemplateInfo, error) {
+	if kind == "" {
+		kind = "stack"
+	}
+	path, resolvedRef, err := p.resolveTemplate(ctx, ref, kind)
+	if err != nil {
+		return api.TemplateInfo{}, &NotFoundError{Kind: kind + " template", Name: ref}
+	}
+	info, err := templateInfo(path, resolvedRef, kind)
+	if err != nil || info.Extends == "" {
+		return info, err
+	}
+	layers, err := p.stackTemplateLayers(ctx, path)
+	if err != nil {
+		return api.TemplateInfo{}, err
+	}
+	questions, defaults, err := layerQuestions(layers)
+	if err != nil {
+		return api.TemplateInfo{}, err
+	}
+	info.Inputs = templateInputs(questions, defaults)
+	return info, nil
+}
+
+func templateInfo(path, ref, kind string) (api.TemplateInfo, error) {
+	metadata, err := copierx.ValidateMetadata(path, kind)
+	if err != nil {
+		return api.TemplateInfo{}, err
+	}
+	questions, defaults, err := copierx.TemplateQuestions(path)
+	if err != nil {
+		return api.TemplateInfo{}, err
+	}
+	info := api.TemplateInfo{
+		Name:        filepath.Base(path),
+		Kind:        kind,
+		Ref:         ref,
+		Path:        path,
+		Description: metadata.Description,
+		Extends:     metadata.Extends,
+		Inputs:      templateInputs(questions, defaults),
+	}
+	return info, nil
+}
+
+func templateInputs(questions map[string]copierx.Input, defaults copierx.Inputs) []api.TemplateInput {
+	var inputs []api.TemplateInput
+	for _, key := range sortedKeys(questions) {
+		question := questions[key]
+		if question.Generated {
+			continue
+		}
+		inputs = append(inputs, api.TemplateInput{
+			Name:     key,
+			Type:     question.Type,
+			Default:  defaults[key],
+			Required: question.Required,
+			Help:     question.Help,
+			Choices:  question.ChoiceValues(),
+		})
+	}
+	return inputs
+}
+
+// TemplateStatus compares the template recorded in angee.yaml with its
+// upstream. Only commits that touch the template's own directory count, so
+// unrelated changes elsewhere in a template catalog do not flag the stack.
+func (p *Platform) TemplateStatus(ctx context.Context) (api.TemplateStatus, error) {
+	stack, err := p.LoadStack()
+	if err != nil {
+		return api.TemplateStatus{}, err
+	}
+	if stack.Template == nil || stack.Template.Source == "" {
+		return api.TemplateStatus{}, &NotFoundError{Kind: "stack template record"}
+	}
+	status := api.TemplateStatus{
+		Source: stack.Template.Source,
+		Commit: stack.Template.Commit,
+		With:   stack.Template.With,
+		State:  api.TemplateUnknown,
+	}
+	var path string
+	if isRemoteTemplateRef(status.Source) {
+		path, _, err = p.resolveRemoteTemplate(ctx, status.Source, "stack", true)
+	} else {
+		path, _, err = p.resolveTemplate(ctx, status.Source, "stack")
+	}
+	if err != nil {
+		return api.TemplateStatus{}, &NotFoundError{Kind: "stack template", Name: status.Source}
+	}
+	client := git.New()
+	latest, err := client.HeadCommit(ctx, path)
+	if err != nil {
+		status.State = api.TemplateUntracked
+		return status, nil
+	}
+	if upstream, ok, err := client.Upstream(ctx, path); err == nil && ok {
+		if commit, err := client.ResolveCommit(ctx, path, upstream); err == nil {
+			latest = commit
+		}
+	}
+	status.Latest = latest
+	if status.Commit == "" {
+		return status, nil
+	}
+	behind, err := client.CommitsTouching(ctx, path, status.Commit, latest, ".")
+	if err != nil {
+		return status, nil
+	}
+	status.Behind = behind
+	status.State = api.TemplateCurrent
+	if behind > 0 {
+		status.State = api.TemplateOutdated
+	}
+	return status, nil
+}
+
+// recordStackTemplate writes the template block into a freshly rendered
+// manifest. It overlays rather than re-saves so the rendered file keeps its
+// comments and key order.
+func recordStackTemplate(ctx context.Context, stackRoot, source, templatePath string, addons []string) error {
+	record := manifest.Template{Source: source, With: addons}
+	if commit, err := git.New().HeadCommit(ctx, templatePath); err == nil {
+		record.Commit = commit
+	}
+	overlay, err := yaml.Marshal(map[string]manifest.Template{"template": record})
+	if err != nil {
+		return err
+	}
+	path := manifest.Path(stackRoot)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	merged, err := manifest.Overlay(data, overlay)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, merged, 0o644)
+}
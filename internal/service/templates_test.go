@@ -1,10 +1,20 @@
 package service
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
 	"context"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
+
+	"github.com/fyltr/angee/api"
+	"github.com/fyltr/angee/internal/manifest"
 )
 
 func TestParseGitHubTemplateRefWithSubpath(t *testing.T) {
@@ -38,6 +48,140 @@ func TestParseGitHubTemplateRefWithTreeBranch(t *testing.T) {
 	}
 }
 
+func TestParseGitHubShorthand(t *testing.T) {
+	repo, branch, subpath, err := parseGitHubShorthand("github:fyltr/angee-django@v2#templates/stacks/dev")
+	if err != nil {
+		t.Fatalf("parseGitHubShorthand() error = %v", err)
+	}
+	if repo != "https://github.com/fyltr/angee-django.git" || branch != "v2" || subpath != "templates/stacks/dev" {
+		t.Fatalf("parseGitHubShorthand() = %q, %q, %q", repo, branch, subpath)
+	}
+	if _, branch, subpath, err := parseGitHubShorthand("github:fyltr/templates"); err != nil || branch != "" || subpath != "" {
+		t.Fatalf("parseGitHubShorthand(bare) = %q, %q, %v", branch, subpath, err)
+	}
+	if _, _, _, err := parseGitHubShorthand("github:fyltr"); err == nil {
+		t.Fatal("parseGitHubShorthand(owner only) error is nil")
+	}
+}
+
+func TestTarballTemplateDownloadsOnceAndSelectsSubdir(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	archive := templateArchive(t, map[string]string{
+		"templates-1.0.0/stacks/dev/copier.yml":       "_angee:\n  kind: stack\n",
+		"templates-1.0.0/stacks/dev/angee.yaml.jinja": "version: 1\n",
+		"templates-1.0.0/workspaces/pr/copier.yml":    "_angee:\n  kind: workspace\n",
+	})
+	downloads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads++
+		_, _ = w.Write(archive)
+	}))
+	defer server.Close()
+
+	platform, err := New(t.TempDir())
+	if err != nil {
+		t.Fatalf("New() = %v", err)
+	}
+	ref := server.URL + "/templates-1.0.0.tar.gz#stacks/dev"
+	for range 2 {
+		path, _, err := platform.resolveTemplate(context.Background(), ref, "stack")
+		if err != nil {
+			t.Fatalf("resolveTemplate() = %v", err)
+		}
+		if filepath.Base(path) != "dev" || filepath.Base(filepath.Dir(path)) != "stacks" {
+			t.Fatalf("resolveTemplate() = %q, want the archive's stacks/dev", path)
+		}
+	}
+	if downloads != 1 {
+		t.Fatalf("downloads = %d, want 1 cached download", downloads)
+	}
+	platform.SetTemplateRefresh(true)
+	if _, _, err := platform.resolveTemplate(context.Background(), ref, "stack"); err != nil {
+		t.Fatalf("resolveTemplate() with refresh = %v", err)
+	}
+	if downloads != 2 {
+		t.Fatalf("downloads = %d, want refresh to download again", downloads)
+	}
+	if _, _, err := platform.resolveTemplate(context.Background(), server.URL+"/templates-1.0.0.tar.gz#workspaces/pr", "workspace"); err != nil {
+		t.Fatalf("resolveTemplate(workspaces/pr) with refresh = %v", err)
+	}
+	if downloads != 2 {
+		t.Fatalf("downloads = %d, want one refresh per archive per invocation", downloads)
+	}
+}
+
+func TestAddonTemplateLayersResolveConcurrentlyInOrder(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	archive := templateArchive(t, map[string]string{
+		"addons-1.0.0/redis/copier.yml":  "_angee:\n  kind: addon\n",
+		"addons-1.0.0/celery/copier.yml": "_angee:\n  kind: addon\n",
+	})
+	var downloads atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads.Add(1)
+		_, _ = w.Write(archive)
+	}))
+	defer server.Close()
+
+	platform, err := New(t.TempDir())
+	if err != nil {
+		t.Fatalf("New() = %v", err)
+	}
+	platform.SetTemplateRefresh(true)
+	ref := server.URL + "/addons-1.0.0.tar.gz"
+	layers, err := platform.addonTemplateLayers(context.Background(), []string{ref + "#celery", ref + "#redis", ref + "#celery"})
+	if err != nil {
+		t.Fatalf("addonTemplateLayers() = %v", err)
+	}
+	if len(layers) != 2 || filepath.Base(layers[0]) != "celery" || filepath.Base(layers[1]) != "redis" {
+		t.Fatalf("addonTemplateLayers() = %q, want celery then redis", layers)
+	}
+	if got := downloads.Load(); got != 1 {
+		t.Fatalf("downloads = %d, want the shared archive fetched once", got)
+	}
+	if _, err := platform.addonTemplateLayers(context.Background(), []string{ref + "#redis", ref + "#missing"}); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Fatalf("addonTemplateLayers() error = %v, want missing addon", err)
+	}
+}
+
+func templateArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	for name, body := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(body)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatalf("WriteHeader(%s) = %v", name, err)
+		}
+		if _, err := tw.Write([]byte(body)); err != nil {
+			t.Fatalf("Write(%s) = %v", name, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("tar Close() = %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("gzip Close() = %v", err)
+	}
+	return archive.Bytes()
+}
+
+func TestExtractTarGzRejectsEscapingEntries(t *testing.T) {
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{Name: "../evil", Mode: 0o644, Typeflag: tar.TypeReg}); err != nil {
+		t.Fatalf("WriteHeader() = %v", err)
+	}
+	_ = tw.Close()
+	_ = gz.Close()
+	if err := extractTarGz(&archive, t.TempDir()); err == nil {
+		t.Fatal("extractTarGz() error is nil, want escaping entry rejected")
+	}
+}
+
 func TestResolveTemplateWalksUpFromRoot(t *testing.T) {
 	repoRoot := t.TempDir()
 	templateDir := filepath.Join(repoRoot, ".templates", "stacks", "dev")
@@ -110,3 +254,146 @@ func TestAncestorTemplatePathsTerminatesAtRoot(t *testing.T) {
 		}
 	}
 }
+
+func TestTemplateListIncludesOperatorTemplatePaths(t *testing.T) {
+	root := t.TempDir()
+	shared := filepath.Join(t.TempDir(), "catalog")
+	templateDir := filepath.Join(shared, "stacks", "fastapi")
+	if err := os.MkdirAll(templateDir, 0o755); err != nil {
+		t.Fatalf("MkdirAll(template) = %v", err)
+	}
+	copierYAML := "_angee:\n  kind: stack\n  description: FastAPI service\nport:\n  type: int\n  default: 8000\n"
+	if err := os.WriteFile(filepath.Join(templateDir, "copier.yml"), []byte(copierYAML), 0o644); err != nil {
+		t.Fatalf("write copier.yml: %v", err)
+	}
+	stack := &manifest.Stack{Name: "catalog", Operator: manifest.Operator{TemplatePaths: []string{shared}}}
+	if err := manifest.SaveFile(manifest.Path(root), stack); err != nil {
+		t.Fatalf("SaveFile() = %v", err)
+	}
+	platform, err := New(root)
+	if err != nil {
+		t.Fatalf("New() = %v", err)
+	}
+	templates, err := platform.TemplateList(context.Background(), "stack")
+	if err != nil {
+		t.Fatalf("TemplateList() = %v", err)
+	}
+	if len(templates) != 1 || templates[0].Name != "fastapi" || templates[0].Description != "FastAPI service" {
+		t.Fatalf("TemplateList() = %#v", templates)
+	}
+	if inputs := templates[0].Inputs; len(inputs) != 1 || inputs[0].Name != "port" || inputs[0].Default != "8000" {
+		t.Fatalf("inputs = %#v", inputs)
+	}
+	if _, _, err := platform.resolveTemplate(context.Background(), "fastapi", "stack"); err != nil {
+		t.Fatalf("resolveTemplate(fastapi) = %v", err)
+	}
+}
+
+func TestTemplateStatusCountsTemplateCommitsSinceInit(t *testing.T) {
+	repoRoot := t.TempDir()
+	templateDir := filepath.Join(repoRoot, ".templates", "stacks", "dev")
+	manifestDir := filepath.Join(templateDir, "template", "{{ ANGEE_ROOT }}")
+	if err := os.MkdirAll(manifestDir, 0o755); err != nil {
+		t.Fatalf("MkdirAll(template) = %v", err)
+	}
+	copierYAML := "_subdirectory: template\n_angee:\n  kind: stack\n  name: dev\nANGEE_ROOT:\n  default: .angee\n"
+	if err := os.WriteFile(filepath.Join(templateDir, "copier.yml"), []byte(copierYAML), 0o644); err != nil {
+		t.Fatalf("write copier.yml: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(manifestDir, "angee.yaml.jinja"), []byte("# dev stack\nversion: 1\nkind: stack\nname: dev\n"), 0o644); err != nil {
+		t.Fatalf("write angee.yaml.jinja: %v", err)
+	}
+	runGit(t, repoRoot, "init", "-q")
+	runGit(t, repoRoot, "config", "user.email", "test@example.com")
+	runGit(t, repoRoot, "config", "user.name", "Test")
+	runGit(t, repoRoot, "add", ".")
+	runGit(t, repoRoot, "commit", "-q", "-m", "template")
+
+	platform, err := New(repoRoot)
+	if err != nil {
+		t.Fatalf("New() = %v", err)
+	}
+	result, err := platform.StackInit(context.Background(), "dev", nil, filepath.Join(repoRoot, "app"), nil, nil, false)
+	if err != nil {
+		t.Fatalf("StackInit() = %v", err)
+	}
+	data, err := os.ReadFile(manifest.Path(result.Root))
+	if err != nil {
+		t.Fatalf("read angee.yaml: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "# dev stack\n") || !strings.Contains(string(data), "source: stacks/dev") {
+		t.Fatalf("angee.yaml = %q, want rendered comment kept and template recorded", data)
+	}
+	initialized, err := New(result.Root)
+	if err != nil {
+		t.Fatalf("New(initialized) = %v", err)
+	}
+	status, err := initialized.TemplateStatus(context.Background())
+	if err != nil {
+		t.Fatalf("TemplateStatus() = %v", err)
+	}
+	if status.State != api.TemplateCurrent || status.Commit == "" || status.Commit != status.Latest {
+		t.Fatalf("status = %+v, want current", status)
+	}
+
+	if err := os.WriteFile(filepath.Join(repoRoot, "README.md"), []byte("catalog\n"), 0o644); err != nil {
+		t.Fatalf("write README.md: %v", err)
+	}
+	runGit(t, repoRoot, "add", "README.md")
+	runGit(t, repoRoot, "commit", "-q", "-m", "unrelated")
+	if status, err = initialized.TemplateStatus(context.Background()); err != nil || status.State != api.TemplateCurrent {
+		t.Fatalf("TemplateStatus() after unrelated commit = %+v, %v, want current", status, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(manifestDir, "angee.yaml.jinja"), []byte("version: 1\nkind: stack\nname: dev2\n"), 0o644); err != nil {
+		t.Fatalf("write angee.yaml.jinja: %v", err)
+	}
+	runGit(t, repoRoot, "commit", "-q", "-am", "template change")
+	status, err = initialized.TemplateStatus(context.Background())
+	if err != nil {
+		t.Fatalf("TemplateStatus() after template commit = %v", err)
+	}
+	if status.State != api.TemplateOutdated || status.Behind != 1 {
+		t.Fatalf("status = %+v, want outdated by 1", status)
+	}
+}
+
+func TestRemoteTemplateFallsBackToCacheWhenFetchFails(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	ref := "https://github.com/example/templates/tree/main/stacks/dev"
+	repoURL, branch, _, err := parseGitHubTemplateRef(ref)
+	if err != nil {
+		t.Fatalf("parseGitHubTemplateRef() = %v", err)
+	}
+	cacheRoot, err := templateCacheRoot(repoURL + "@" + branch)
+	if err != nil {
+		t.Fatalf("templateCacheRoot() = %v", err)
+	}
+	repoDir := filepath.Join(cacheRoot, "repo")
+	templateDir := filepath.Join(repoDir, "stacks", "dev")
+	if err := os.MkdirAll(templateDir, 0o755); err != nil {
+		t.Fatalf("MkdirAll(template) = %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(templateDir, "copier.yml"), []byte("_angee:\n  kind: stack\n"), 0o644); err != nil {
+		t.Fatalf("write copier.yml: %v", err)
+	}
+	runGit(t, repoDir, "init", "-q")
+	runGit(t, repoDir, "remote", "add", "origin", filepath.Join(t.TempDir(), "missing.git"))
+
+	platform, err := New(t.TempDir())
+	if err != nil {
+		t.Fatalf("New() = %v", err)
+	}
+	path, _, err := platform.resolveTemplate(context.Background(), ref, "stack")
+	if err != nil {
+		t.Fatalf("resolveTemplate() offline = %v, want cached copy", err)
+	}
+	if path != templateDir {
+		t.Fatalf("resolveTemplate() = %q, want %q", path, templateDir)
+	}
+	platform.SetTemplateRefresh(true)
+	if _, _, err := platform.resolveTemplate(context.Background(), ref, "stack"); err == nil {
+		t.Fatal("resolveTemplate() with refresh error is nil, want fetch failure")
+	}
+}
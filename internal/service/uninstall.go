@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fyltr/angee/api"
+	"github.com/fyltr/angee/internal/git"
+	"github.com/fyltr/angee/internal/manifest"
+	"github.com/fyltr/angee/internal/runtime"
+	"gopkg.in/yaml.v3"
+)
+
+// UninstallOptions chooses what StackUninstall may delete beyond the
+// stack's containers, networks, and root directory.
+type UninstallOptions struct {
+	// Volumes allows deleting the stack's Docker volumes and the root's
+	// volumes/ directory, which hold the services' persistent data.
+	// Without it, uninstall refuses while there are any.
+	Volumes bool
+	// Archive, when set, is a file outside the root that a git bundle of
+	// the root's history is written to before anything is removed.
+	Archive string
+	// DryRun reports what would be removed, volumes included, and changes
+	// nothing.
+	DryRun bool
+}
+
+// UninstallResult reports what `angee uninstall` removed, or would
+// remove with DryRun.
+type UninstallResult struct {
+	Stack string `json:"stack"`
+	Root  string `json:"root"`
+	// Resources lists the Docker objects labeled for the stack and, when it
+	// has any content, the root's volumes/ directory.
+	Resources []api.PruneResource `json:"resources"`
+	Archive   string              `json:"archive,omitempty"`
+	DryRun    bool                `json:"dry_run,omitempty"`
+	// Invalid is why angee.yaml did not validate, when it did not. The
+	// stack is then found by the name the file declares, or the one in the
+	// generated compose file, and torn down without compiling it.
+	Invalid string `json:"invalid,omitempty"`
+}
+
+// Persistent reports whether the result includes volume data, which
+// needs UninstallOptions.Volumes to be removed.
+func (r UninstallResult) Persistent() bool {
+	for _, resource := range r.Resources {
+		if resource.Kind == runtime.ResourceVolume || resource.Kind == "directory" {
+			return true
+		}
+	}
+	return false
+}
+
+// StackUninstall tears the stack down for good: services are stopped, the
+// containers, networks, and volumes labeled for it are removed, and the
+// root directory is deleted with everything in it, including sources,
+// workspaces, histories, and secrets kept in the root. An operator serving
+// the root is not stopped. A root whose angee.yaml no longer validates can
+// still be uninstalled by its stack name.
+func (p *Platform) StackUninstall(ctx context.Context, opts UninstallOptions) (UninstallResult, error) {
+	result := UninstallResult{Root: p.root, DryRun: opts.DryRun}
+	pruner, ok := p.composeBackend.(runtime.Pruner)
+	if !ok {
+		return result, errors.New("the container backend cannot list the resources it created")
+	}
+	compiled, err := p.StackValidate(ctx)
+	if err == nil {
+		result.Stack = compiled.Compose.Name
+	} else if result.Stack = p.declaredStackName(); result.Stack != "" {
+		result.Invalid = err.Error()
+	} else {
+		return result, err
+	}
+	label := LabelStack + "=" + compiled.Compose.Name
+	resources, err := pruner.Resources(ctx, label)
+	if err != nil {
+		return result, err
+	}
+	result.Resources = uninstallResources(resources)
+	volumes := filepath.Join(p.root, "volumes")
+	if nonEmpty, err := pathExistsNonEmpty(volumes); err != nil {
+		return result, err
+	} else if nonEmpty {
+		result.Resources = append(result.Resources, api.PruneResource{Kind: "directory", Name: volumes, Reason: "bind-mounted service data"})
+	}
+	if opts.Archive != "" {
+		if result.Archive, err = p.checkArchive(opts.Archive); err != nil {
+			return result, err
+		}
+	}
+	if opts.DryRun {
+		return result, nil
+	}
+	if !opts.Volumes && result.Persistent() {
+		return result, &ConflictError{Kind: "stack", Name: result.Stack, Reason: "has volumes of persistent service data; confirm deleting them with --volumes"}
+	}
+	if result.Archive != "" {
+		if _, err := git.New().Run(ctx, p.root, "bundle", "create", result.Archive, "--all"); err != nil {
+			return result, fmt.Errorf("archive git history: %w", err)
+		}
+	}
+	if result.Invalid == "" {
+		if err := p.StackDestroy(ctx, false); err != nil {
+			return result, err
+		}
+	} else if _, err := os.Stat(filepath.Join(p.root, "process-compose.yaml")); err == nil {
+		// Without a stack to compile, local processes are stopped through
+		// the file the last prepare left; containers go with the labeled
+		// resources below.
+		_ = p.procBackend.Down(ctx, runtime.Target{Root: p.root, ControlPort: processComposeControlPort(nil)})
+	}
+	// Down removed what the compose file still declares; the listing also
+	// finds containers of removed services and every volume.
+	if resources, err = pruner.Resources(ctx, label); err != nil {
+		return result, err
+	}
+	if len(resources) > 0 {
+		if err := pruner.Remove(ctx, resources); err != nil {
+			return result, err
+		}
+	}
+	return result, os.RemoveAll(p.root)
+}
+
+// declaredStackName reads the stack name from angee.yaml without validating
+// it, falling back to the project name of the generated compose file. It
+// returns "" when neither names one.
+func (p *Platform) declaredStackName() string {
+	for _, path := range []string{manifest.Path(p.root), filepath.Join(p.root, "docker-compose.yaml")} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var doc struct {
+			Name string `yaml:"name"`
+		}
+		if yaml.Unmarshal(data, &doc) == nil && strings.TrimSpace(doc.Name) != "" {
+			return strings.TrimSpace(doc.Name)
+		}
+	}
+	return ""
+}
+
+func uninstallResources(resources []runtime.Resource) []api.PruneResource {
+	out := []api.PruneResource{}
+	for _, resource := range resources {
+		var reason string
+		switch resource.Kind {
+		case runtime.ResourceContainer:
+			reason = fmt.Sprintf("container of service %q", resource.Labels[LabelService])
+		case runtime.ResourceVolume:
+			reason = fmt.Sprintf("volume %q and its data", resource.Labels[composeVolumeLabel])
+		case runtime.ResourceNetwork:
+			reason = fmt.Sprintf("network %q", resource.Labels[composeNetworkLabel])
+		}
+		out = append(out, api.PruneResource{Kind: resource.Kind, Name: resource.Name, Reason: reason})
+	}
+	return out
+}
+
+// checkArchive resolves the bundle path for --archive. The root must be a
+// git repository of its own, and the bundle must be a new file outside it.
+func (p *Platform) checkArchive(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if rel, err := filepath.Rel(p.root, abs); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", &InvalidInputError{Field: "archive", Reason: "is inside the stack root, which is removed"}
+	}
+	if _, err := os.Stat(abs); err == nil {
+		return "", &ConflictError{Kind: "archive", Name: abs, Reason: "already exists"}
+	}
+	if _, err := os.Stat(filepath.Join(p.root, ".git")); err != nil {
+		return "", &InvalidInputError{Field: "archive", Reason: "the stack root is not a git repository"}
+	}
+	return abs, nil
+}
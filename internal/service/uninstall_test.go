@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fyltr/angee/internal/manifest"
+	"github.com/fyltr/angee/internal/runtime"
+)
+
+type downBackend struct {
+	runtime.Backend
+	downs *int
+}
+
+func (b downBackend) Down(context.Context, runtime.Target) error {
+	*b.downs++
+	return nil
+}
+
+func TestStackUninstallNeedsVolumesAndRemovesRoot(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "stack")
+	stack := &manifest.Stack{
+		Version:  manifest.VersionCurrent,
+		Kind:     manifest.KindStack,
+		Name:     "notes",
+		Services: map[string]manifest.Service{"web": {Runtime: manifest.RuntimeContainer, Image: "nginx:1"}},
+		Volumes:  map[string]manifest.Volume{"data": {}},
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := manifest.SaveFile(manifest.Path(root), stack); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+	runGit(t, root, "init", "-q")
+	runGit(t, root, "add", "angee.yaml")
+	runGit(t, root, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "initial")
+	var removed []runtime.Resource
+	var downs int
+	backend := pruneBackend{Backend: downBackend{downs: &downs}, removed: &removed, resources: []runtime.Resource{
+		{Kind: runtime.ResourceContainer, Name: "notes-web-1", Labels: map[string]string{LabelService: "web"}},
+		{Kind: runtime.ResourceVolume, Name: "notes_data", Labels: map[string]string{composeVolumeLabel: "data"}},
+		{Kind: runtime.ResourceNetwork, Name: "notes_default", Labels: map[string]string{composeNetworkLabel: "default"}},
+	}}
+	platform, err := NewWithBackends(root, backend, nil)
+	if err != nil {
+		t.Fatalf("NewWithBackends() error = %v", err)
+	}
+	ctx := context.Background()
+	archive := filepath.Join(t.TempDir(), "notes.bundle")
+
+	var invalid *InvalidInputError
+	if _, err := platform.StackUninstall(ctx, UninstallOptions{Volumes: true, Archive: filepath.Join(root, "history.bundle")}); !errors.As(err, &invalid) || invalid.Field != "archive" {
+		t.Fatalf("StackUninstall(archive in root) error = %v, want invalid archive", err)
+	}
+	plan, err := platform.StackUninstall(ctx, UninstallOptions{DryRun: true, Archive: archive})
+	if err != nil {
+		t.Fatalf("StackUninstall(dry run) error = %v", err)
+	}
+	if len(plan.Resources) != 3 || !plan.Persistent() || plan.Archive != archive || len(removed) != 0 || downs != 0 {
+		t.Fatalf("StackUninstall(dry run) = %+v, removed %+v after %d downs", plan, removed, downs)
+	}
+	var conflict *ConflictError
+	if _, err := platform.StackUninstall(ctx, UninstallOptions{}); !errors.As(err, &conflict) {
+		t.Fatalf("StackUninstall() error = %v, want a conflict over volumes", err)
+	}
+	if _, err := os.Stat(manifest.Path(root)); err != nil || downs != 0 {
+		t.Fatalf("root after refused uninstall: %v, %d downs", err, downs)
+	}
+
+	if _, err := platform.StackUninstall(ctx, UninstallOptions{Volumes: true, Archive: archive}); err != nil {
+		t.Fatalf("StackUninstall(volumes) error = %v", err)
+	}
+	if len(removed) != 3 || downs != 1 {
+		t.Fatalf("removed %+v after %d downs, want every resource after one down", removed, downs)
+	}
+	if _, err := os.Stat(root); !os.IsNotExist(err) {
+		t.Fatalf("root still exists: %v", err)
+	}
+	if out := runGitOutput(t, "", "bundle", "list-heads", archive); out == "" {
+		t.Fatal("archive bundle has no refs")
+	}
+}
+
+func TestStackUninstallFallsBackToStackNameWhenManifestIsInvalid(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "stack")
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	// A field this version does not know fails validation.
+	if err := os.WriteFile(manifest.Path(root), []byte("version: 1\nkind: stack\nname: notes\nservices:\n  web:\n    runtime: container\n    image: nginx:1\n    replicas_max: 3\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var removed []runtime.Resource
+	var downs int
+	backend := pruneBackend{Backend: downBackend{downs: &downs}, removed: &removed, resources: []runtime.Resource{
+		{Kind: runtime.ResourceContainer, Name: "notes-web-1", Labels: map[string]string{LabelService: "web"}},
+		{Kind: runtime.ResourceNetwork, Name: "notes_default", Labels: map[string]string{composeNetworkLabel: "default"}},
+	}}
+	platform, err := NewWithBackends(root, backend, nil)
+	if err != nil {
+		t.Fatalf("NewWithBackends() error = %v", err)
+	}
+
+	result, err := platform.StackUninstall(context.Background(), UninstallOptions{})
+	if err != nil {
+		t.Fatalf("StackUninstall() error = %v", err)
+	}
+	if result.Stack != "notes" || result.Invalid == "" || len(removed) != 2 {
+		t.Fatalf("StackUninstall() = %+v, removed %+v; want notes removed by name", result, removed)
+	}
+	if _, err := os.Stat(root); !os.IsNotExist(err) {
+		t.Fatalf("root still exists: %v", err)
+	}
+}
+
+func TestStackUninstallNeedsAStackName(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(manifest.Path(root), []byte("kind: stack\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var removed []runtime.Resource
+	platform, err := NewWithBackends(root, pruneBackend{removed: &removed}, nil)
+	if err != nil {
+		t.Fatalf("NewWithBackends() error = %v", err)
+	}
+	if _, err := platform.StackUninstall(context.Background(), UninstallOptions{}); err == nil {
+		t.Fatal("StackUninstall() error is nil, want the validation error")
+	}
+	if _, err := os.Stat(manifest.Path(root)); err != nil || len(removed) != 0 {
+		t.Fatalf("root after refused uninstall: %v, removed %+v", err, removed)
+	}
+}
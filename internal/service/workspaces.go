@@ -112,7 +112,7 @@ func (p *Platform) WorkspaceCreate(ctx context.Context, req api.WorkspaceCreateR
 		stack.Workspaces = map[string]manifest.Workspace{}
 	}
 	stack.Workspaces[name] = workspace
-	if err := manifest.SaveFile(manifest.Path(p.root), stack); err != nil {
+	if err := p.saveStack(ctx, stack); err != nil {
 		return api.WorkspaceRef{}, err
 	}
 	ref := workspaceRef(name, workspacePath, workspace)
@@ -397,7 +397,7 @@ func (p *Platform) WorkspaceDestroy(ctx context.Context, name string, purge bool
 	}
 	delete(stack.Workspaces, name)
 	releaseWorkspacePorts(stack, name)
-	if err := manifest.SaveFile(manifest.Path(p.root), stack); err != nil {
+	if err := p.saveStack(ctx, stack); err != nil {
 		return err
 	}
 	if purge {
@@ -555,17 +555,17 @@ func (p *Platform) WorkspaceUpdate(ctx context.Context, name string, inputs map[
 		workspace.TTLExpiresAt = &expires
 	}
 	stack.Workspaces[name] = workspace
-	if err := manifest.SaveFile(manifest.Path(p.root), stack); err != nil {
+	if err := p.saveStack(ctx, stack); err != nil {
 		return api.WorkspaceRef{}, err
 	}
 	return workspaceRef(name, filepath.Join(p.root, "workspaces", name), workspace), nil
 }
 
 func (p *Platform) WorkspaceLogs(ctx context.Context, name string, follow bool) (<-chan string, error) {
-	return p.WorkspaceLogsLimited(ctx, name, follow, 0)
+	return p.WorkspaceLogsLimited(ctx, name, follow, LogLimits{})
 }
 
-func (p *Platform) WorkspaceLogsLimited(ctx context.Context, name string, follow bool, maxBytes int) (<-chan string, error) {
+func (p *Platform) WorkspaceLogsLimited(ctx context.Context, name string, follow bool, limits LogLimits) (<-chan string, error) {
 	stack, err := p.LoadStack()
 	if err != nil {
 		return nil, err
@@ -583,7 +583,7 @@ func (p *Platform) WorkspaceLogsLimited(ctx context.Context, name string, follow
 	if err != nil {
 		return nil, err
 	}
-	return inner.StackLogsLimited(ctx, nil, follow, maxBytes)
+	return inner.StackLogsLimited(ctx, nil, follow, limits)
 }
 
 func releaseWorkspacePorts(stack *manifest.Stack, workspaceName string) {
@@ -657,14 +657,14 @@ func startInnerStack(ctx context.Context, inner *Platform, innerStack *manifest.
 	case chainLifecycleDev:
 		return inner.StackDev(ctx, false)
 	case chainLifecycleUp:
-		return inner.StackUp(ctx, nil, false)
+		return inner.StackUp(ctx, nil, UpOptions{})
 	}
 	for _, service := range innerStack.Services {
 		if service.Runtime == manifest.RuntimeLocal {
 			return inner.StackDev(ctx, false)
 		}
 	}
-	return inner.StackUp(ctx, nil, false)
+	return inner.StackUp(ctx, nil, UpOptions{})
 }
 
 func resolveChainLifecycle(value string) string {
@@ -870,7 +870,6 @@ func (p *Platform) WorkspacePush(ctx context.Context, name, ref string) ([]api.S
 	if err := p.ensureWorkspaceGitSourcesOnExpectedBranches(ctx, name, workspace, stack); err != nil {
 		return nil, err
 	}
-	client := git.New()
 	states := []api.SourceState{}
 	for _, slot := range sortedKeys(workspace.Sources) {
 		wsSource := workspace.Sources[slot]
@@ -885,6 +884,11 @@ func (p *Platform) WorkspacePush(ctx context.Context, name, ref string) ([]api.S
 		if err != nil {
 			return nil, fmt.Errorf("workspace %q source %q: %w", name, slot, err)
 		}
+		client, cleanup, err := p.sourceGitClient(ctx, source)
+		defer cleanup()
+		if err != nil {
+			return nil, err
+		}
 		dirty, err := client.Dirty(ctx, path)
 		if err != nil {
 			return nil, err
@@ -939,7 +943,6 @@ func (p *Platform) WorkspaceSyncBase(ctx context.Context, name, method string) (
 	if err := p.ensureWorkspaceGitSourcesOnExpectedBranches(ctx, name, workspace, stack); err != nil {
 		return nil, err
 	}
-	client := git.New()
 	states := []api.SourceState{}
 	for _, slot := range sortedKeys(workspace.Sources) {
 		wsSource := workspace.Sources[slot]
@@ -954,6 +957,11 @@ func (p *Platform) WorkspaceSyncBase(ctx context.Context, name, method string) (
 		if err != nil {
 			return nil, fmt.Errorf("workspace %q source %q: %w", name, slot, err)
 		}
+		client, cleanup, err := p.sourceGitClient(ctx, source)
+		defer cleanup()
+		if err != nil {
+			return nil, err
+		}
 		dirty, err := client.Dirty(ctx, path)
 		if err != nil {
 			return nil, err
@@ -1200,15 +1208,27 @@ func (p *Platform) materializeWorkspaceSource(ctx context.Context, sourceName st
 			}
 			if ws.Branch != "" && client.RefExists(ctx, cachePath, "refs/heads/"+ws.Branch) {
 				fmt.Fprintf(os.Stderr, "warning: branch %q already exists in %s; checking it out into worktree without creating a new branch\n", ws.Branch, cachePath)
-				return client.WorktreeAdd(ctx, cachePath, dest, ws.Branch)
+				err = client.WorktreeAdd(ctx, cachePath, dest, ws.Branch)
+			} else {
+				err = client.WorktreeAddBranch(ctx, cachePath, dest, ws.Branch, ref)
+			}
+			if err != nil || len(source.Sparse) == 0 {
+				return err
 			}
-			return client.WorktreeAddBranch(ctx, cachePath, dest, ws.Branch, ref)
+			// Recent git copies the clone's sparse patterns into new
+			// worktrees; setting them again covers older versions.
+			return client.SparseCheckout(ctx, dest, source.Sparse)
 		}
 		ref := ws.Ref
 		if ref == "" {
 			ref = source.DefaultRef
 		}
-		return git.New().CloneRef(ctx, source.Repo, dest, ref)
+		client, cleanup, err := p.sourceGitClient(ctx, source)
+		defer cleanup()
+		if err != nil {
+			return err
+		}
+		return client.CloneWith(ctx, source.Repo, dest, sourceCloneOptions(source, ref))
 	case "local":
 		target, err := workspaceLocalSymlinkTarget(p.sourcePath(sourceName, source), dest)
 		if err != nil {
@@ -1455,7 +1475,7 @@ func (p *Platform) resolveTemplate(ctx context.Context, ref, kind string) (strin
 		return "", "", fmt.Errorf("template reference is empty")
 	}
 	if isRemoteTemplateRef(ref) {
-		return p.resolveRemoteTemplate(ctx, ref, kind)
+		return p.resolveRemoteTemplate(ctx, ref, kind, false)
 	}
 	if filepath.IsAbs(ref) {
 		if _, err := os.Stat(ref); err != nil {
@@ -1471,19 +1491,12 @@ func (p *Platform) resolveTemplate(ctx context.Context, ref, kind string) (strin
 	if !strings.HasPrefix(kindRef, family+"/") {
 		return "", "", fmt.Errorf("template %q does not match kind %q", ref, kind)
 	}
-	candidates := []string{
-		filepath.Join(p.root, ".templates", kindRef),
-		filepath.Join(p.root, "templates", kindRef),
-		filepath.Join(p.root, kindRef),
-		filepath.Join(p.root, ref),
-	}
-	candidates = append(candidates, ancestorTemplatePaths(p.root, kindRef)...)
-	if cwd, err := os.Getwd(); err == nil && cwd != p.root {
-		candidates = append(candidates,
-			filepath.Join(cwd, ".templates", kindRef),
-			filepath.Join(cwd, "templates", kindRef),
-		)
-		candidates = append(candidates, ancestorTemplatePaths(cwd, kindRef)...)
+	candidates := []string{}
+	for i, dir := range p.templateSearchRoots() {
+		candidates = append(candidates, filepath.Join(dir, kindRef))
+		if i == 2 {
+			candidates = append(candidates, filepath.Join(p.root, ref))
+		}
 	}
 	seen := map[string]bool{}
 	for _, candidate := range candidates {
@@ -1498,6 +1511,32 @@ func (p *Platform) resolveTemplate(ctx context.Context, ref, kind string) (strin
 	return "", "", fmt.Errorf("template %q was not found", ref)
 }
 
+// templateSearchRoots lists, in resolution order, the directories that hold
+// `stacks/` and `workspaces/` template families. The first three entries
+// are always $ANGEE_ROOT/.templates, $ANGEE_ROOT/templates and $ANGEE_ROOT;
+// `operator.template_paths` from angee.yaml follow, when a manifest exists.
+func (p *Platform) templateSearchRoots() []string {
+	roots := []string{
+		filepath.Join(p.root, ".templates"),
+		filepath.Join(p.root, "templates"),
+		p.root,
+	}
+	if stack, err := p.LoadStack(); err == nil {
+		for _, path := range stack.Operator.TemplatePaths {
+			roots = append(roots, manifest.ResolvePath(p.root, path))
+		}
+	}
+	roots = append(roots, ancestorTemplatePaths(p.root, "")...)
+	if cwd, err := os.Getwd(); err == nil && cwd != p.root {
+		roots = append(roots,
+			filepath.Join(cwd, ".templates"),
+			filepath.Join(cwd, "templates"),
+		)
+		roots = append(roots, ancestorTemplatePaths(cwd, "")...)
+	}
+	return roots
+}
+
 // ancestorTemplatePaths walks up from start (exclusive) and returns
 // "<ancestor>/.templates/<kindRef>" for each ancestor up to the
 // filesystem root, capped at 32 levels of nesting as a safety net.
@@ -4,6 +4,7 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
@@ -214,6 +215,12 @@ func resolvePath(path string, ctx Context) (string, error) {
 
 func applyFilter(value, filter string) (string, error) {
 	name, args := parseCall(filter)
+	return Filter(name, value, args...)
+}
+
+// Filter applies one named filter to value. It is the single filter library
+// for `${...}` substitutions and, through copierx, for Jinja templates.
+func Filter(name, value string, args ...string) (string, error) {
 	switch name {
 	case "slug":
 		return slug.Make(value), nil
@@ -252,8 +259,30 @@ func applyFilter(value, filter string) (string, error) {
 			return "", errors.New("required value is empty")
 		}
 		return value, nil
+	case "trim":
+		return strings.TrimSpace(value), nil
 	case "b64encode":
 		return base64.StdEncoding.EncodeToString([]byte(value)), nil
+	case "b64decode":
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return "", fmt.Errorf("b64decode: %w", err)
+		}
+		return string(decoded), nil
+	case "urlencode":
+		// Everything but unreserved characters is escaped, a space as %20
+		// rather than +, so the value reads back unchanged in a URL's
+		// userinfo, path, or query alike.
+		return strings.ReplaceAll(url.QueryEscape(value), "+", "%20"), nil
+	case "indent":
+		if len(args) != 1 {
+			return "", errors.New("indent requires one argument")
+		}
+		width, err := strconv.Atoi(args[0])
+		if err != nil || width < 0 {
+			return "", fmt.Errorf("invalid indent width %q", args[0])
+		}
+		return indent(value, width), nil
 	case "replace":
 		if len(args) != 2 {
 			return "", errors.New("replace requires two arguments")
@@ -336,6 +365,19 @@ func splitArgs(body string) []string {
 	return out
 }
 
+// indent prefixes every line after the first with width spaces, so a
+// multi-line value can follow a YAML key: `key: |\n  ${x | indent(2)}`.
+func indent(value string, width int) string {
+	pad := strings.Repeat(" ", width)
+	lines := strings.Split(value, "\n")
+	for i := 1; i < len(lines); i++ {
+		if lines[i] != "" {
+			lines[i] = pad + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
 func truncate(value string, limit int) string {
 	if limit == 0 {
 		return ""
@@ -1,6 +1,10 @@
 package substitute
 
-import "testing"
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
 
 func TestResolveSubstitutionsAndFilters(t *testing.T) {
 	ctx := Context{
@@ -71,3 +75,29 @@ func TestTruncateCountsRunes(t *testing.T) {
 		t.Fatalf("Resolve() = %q", got)
 	}
 }
+
+func TestFilterLibrary(t *testing.T) {
+	ctx := Context{Inputs: map[string]string{
+		"password": "p@ss word+1",
+		"cert":     "line1\nline2",
+		"padded":   "  value  ",
+		"encoded":  "aGVsbG8=",
+	}}
+	got, err := Resolve("${inputs.password | urlencode}|${inputs.padded | trim}|${inputs.encoded | b64decode}|${inputs.cert | indent(2)}", ctx)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if want := "p%40ss%20word%2B1|value|hello|line1\n  line2"; got != want {
+		t.Fatalf("Resolve() = %q, want %q", got, want)
+	}
+	dsn, err := url.Parse("postgres://app:" + got[:strings.Index(got, "|")] + "@db/app")
+	if err != nil {
+		t.Fatalf("Parse(DSN) error = %v", err)
+	}
+	if password, _ := dsn.User.Password(); password != "p@ss word+1" {
+		t.Fatalf("DSN password = %q, want the original", password)
+	}
+	if _, err := Filter("indent", "x"); err == nil {
+		t.Fatal("Filter(indent) without width error is nil")
+	}
+}
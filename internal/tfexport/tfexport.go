@@ -0,0 +1,353 @@
+// Package tfexport renders a compiled compose file as Terraform JSON for the
+// kreuzwerker/docker provider.
+//
+// The output mirrors what `docker compose up` creates for the stack: one
+// network, the named volumes, an image and a container per service. Names
+// follow compose's project naming, so volumes keep their data when a stack
+// moves from `angee up` to Terraform. Secret placeholders become sensitive
+// variables instead of values.
+package tfexport
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fyltr/angee/internal/runtime/compose"
+)
+
+// ProviderVersion is the docker provider constraint written to the output.
+const ProviderVersion = "~> 3.0"
+
+// Options carries what the compose file alone does not say. Root resolves
+// relative bind mounts and build contexts; SecretEnvVars lists the env
+// names compose would read from the runtime env file.
+type Options struct {
+	Root          string
+	SecretEnvVars []string
+}
+
+// Export returns the Terraform JSON document and a warning for each part of
+// the compose file it could not express.
+func Export(file compose.File, opts Options) ([]byte, []string, error) {
+	e := exporter{file: file, opts: opts, secrets: map[string]string{}}
+	for _, env := range opts.SecretEnvVars {
+		e.secrets[env] = identifier(strings.ToLower(env))
+	}
+	doc, err := e.document()
+	if err != nil {
+		return nil, nil, err
+	}
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return nil, nil, err
+	}
+	return out.Bytes(), e.warnings, nil
+}
+
+type exporter struct {
+	file     compose.File
+	opts     Options
+	secrets  map[string]string
+	warnings []string
+}
+
+type object = map[string]any
+
+func (e *exporter) document() (object, error) {
+	network := identifier(e.file.Name)
+	resources := object{
+		"docker_network": object{network: object{"name": e.file.Name + "_default"}},
+	}
+	if len(e.file.Volumes) > 0 {
+		volumes := object{}
+		for _, name := range sortedKeys(e.file.Volumes) {
+			volume := object{"name": e.file.Name + "_" + name}
+			if driver := e.file.Volumes[name].Driver; driver != "" {
+				volume["driver"] = driver
+			}
+			volumes[identifier(name)] = volume
+		}
+		resources["docker_volume"] = volumes
+	}
+	images, containers := object{}, object{}
+	for _, name := range sortedKeys(e.file.Services) {
+		image, err := e.image(name, e.file.Services[name])
+		if err != nil {
+			return nil, fmt.Errorf("service %s: %w", name, err)
+		}
+		images[identifier(name)] = image
+		container, err := e.container(name, e.file.Services[name], network)
+		if err != nil {
+			return nil, fmt.Errorf("service %s: %w", name, err)
+		}
+		containers[identifier(name)] = container
+	}
+	if len(containers) > 0 {
+		resources["docker_image"] = images
+		resources["docker_container"] = containers
+	}
+	doc := object{
+		"terraform": object{
+			"required_providers": object{
+				"docker": object{"source": "kreuzwerker/docker", "version": ProviderVersion},
+			},
+		},
+		"resource": resources,
+	}
+	if len(e.secrets) > 0 {
+		variables := object{}
+		for _, name := range sortedKeys(e.secrets) {
+			variables[e.secrets[name]] = object{
+				"type":        "string",
+				"sensitive":   true,
+				"description": "Value of " + name + " from the stack's secrets backend.",
+			}
+		}
+		doc["variable"] = variables
+	}
+	return doc, nil
+}
+
+func (e *exporter) image(name string, service compose.Service) (object, error) {
+	if service.Build == nil {
+		return object{"name": e.literal(service.Image), "keep_locally": true}, nil
+	}
+	build := object{}
+	switch spec := service.Build.(type) {
+	case string:
+		build["context"] = e.hostPath(spec)
+	case map[string]any:
+		for key, value := range spec {
+			switch key {
+			case "context":
+				build["context"] = e.hostPath(fmt.Sprint(value))
+			case "dockerfile":
+				build["dockerfile"] = e.literal(fmt.Sprint(value))
+			case "args":
+				args, ok := value.(map[string]any)
+				if !ok {
+					e.warn(name, "build args must be a mapping to be exported")
+					continue
+				}
+				out := object{}
+				for arg, argValue := range args {
+					out[arg] = e.literal(fmt.Sprint(argValue))
+				}
+				build["build_args"] = out
+			default:
+				e.warn(name, "build %q is not exported", key)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported build %T", service.Build)
+	}
+	if build["context"] == nil {
+		build["context"] = e.hostPath(".")
+	}
+	image := service.Image
+	if image == "" {
+		image = e.file.Name + "-" + name
+	}
+	return object{"name": e.literal(image), "build": []object{build}}, nil
+}
+
+func (e *exporter) container(name string, service compose.Service, network string) (object, error) {
+	container := object{
+		"name":  e.file.Name + "-" + name,
+		"image": "${docker_image." + identifier(name) + ".image_id}",
+		"networks_advanced": []object{{
+			"name":    "${docker_network." + network + ".name}",
+			"aliases": []string{name},
+		}},
+	}
+	if len(service.Command) > 0 {
+		container["command"] = e.literals(service.Command)
+	}
+	if len(service.Environment) > 0 {
+		env := make([]string, 0, len(service.Environment))
+		for _, key := range sortedKeys(service.Environment) {
+			env = append(env, e.literal(key+"="+service.Environment[key]))
+		}
+		container["env"] = env
+	}
+	if service.WorkingDir != "" {
+		container["working_dir"] = e.literal(service.WorkingDir)
+	}
+	if len(service.Ports) > 0 {
+		ports := make([]object, 0, len(service.Ports))
+		for _, spec := range service.Ports {
+			port, err := parsePort(spec)
+			if err != nil {
+				return nil, err
+			}
+			ports = append(ports, port)
+		}
+		container["ports"] = ports
+	}
+	if len(service.Volumes) > 0 {
+		volumes := make([]object, 0, len(service.Volumes))
+		for _, spec := range service.Volumes {
+			volumes = append(volumes, e.volume(spec))
+		}
+		container["volumes"] = volumes
+	}
+	if len(service.Labels) > 0 {
+		labels := make([]object, 0, len(service.Labels))
+		for _, key := range sortedKeys(service.Labels) {
+			labels = append(labels, object{"label": key, "value": e.literal(service.Labels[key])})
+		}
+		container["labels"] = labels
+	}
+	if service.Logging != nil {
+		container["log_driver"] = service.Logging.Driver
+		if len(service.Logging.Options) > 0 {
+			opts := object{}
+			for key, value := range service.Logging.Options {
+				opts[key] = e.literal(value)
+			}
+			container["log_opts"] = opts
+		}
+	}
+	if check := service.Healthcheck; check != nil {
+		healthcheck := object{"test": e.literals(check.Test)}
+		for key, value := range map[string]string{"interval": check.Interval, "timeout": check.Timeout, "start_period": check.StartPeriod} {
+			if value != "" {
+				healthcheck[key] = value
+			}
+		}
+		if check.Retries > 0 {
+			healthcheck["retries"] = check.Retries
+		}
+		container["healthcheck"] = []object{healthcheck}
+	}
+	if len(service.DependsOn) > 0 {
+		deps := make([]string, 0, len(service.DependsOn))
+		for _, dep := range sortedKeys(service.DependsOn) {
+			deps = append(deps, "docker_container."+identifier(dep))
+			if condition := service.DependsOn[dep].Condition; condition != "" && condition != "service_started" {
+				e.warn(name, "depends_on %s waits for %s under compose; Terraform only orders creation", dep, condition)
+			}
+		}
+		container["depends_on"] = deps
+	}
+	return container, nil
+}
+
+// volume renders a compiled compose volume, `source:target[:ro]`, where
+// source is a named volume or a host path.
+func (e *exporter) volume(spec string) object {
+	parts := strings.Split(spec, ":")
+	out := object{}
+	if n := len(parts); n > 2 && parts[n-1] == "ro" {
+		out["read_only"] = true
+		parts = parts[:n-1]
+	}
+	source, target := parts[0], strings.Join(parts[1:], ":")
+	out["container_path"] = target
+	if _, ok := e.file.Volumes[source]; ok {
+		out["volume_name"] = "${docker_volume." + identifier(source) + ".name}"
+	} else {
+		out["host_path"] = e.hostPath(source)
+	}
+	return out
+}
+
+func parsePort(spec string) (object, error) {
+	spec, protocol, _ := strings.Cut(spec, "/")
+	parts := strings.Split(spec, ":")
+	var ip, external, internal string
+	switch len(parts) {
+	case 1:
+		internal = parts[0]
+	case 2:
+		external, internal = parts[0], parts[1]
+	case 3:
+		ip, external, internal = parts[0], parts[1], parts[2]
+	default:
+		return nil, fmt.Errorf("port %q is not [ip:]published:target", spec)
+	}
+	port := object{}
+	internalPort, err := strconv.Atoi(internal)
+	if err != nil {
+		return nil, fmt.Errorf("port %q: only single ports can be exported", spec)
+	}
+	port["internal"] = internalPort
+	if external != "" {
+		externalPort, err := strconv.Atoi(external)
+		if err != nil {
+			return nil, fmt.Errorf("port %q: only single ports can be exported", spec)
+		}
+		port["external"] = externalPort
+	}
+	if ip != "" {
+		port["ip"] = ip
+	}
+	if protocol != "" {
+		port["protocol"] = protocol
+	}
+	return port, nil
+}
+
+func (e *exporter) hostPath(path string) string {
+	if !filepath.IsAbs(path) && e.opts.Root != "" {
+		path = filepath.Join(e.opts.Root, path)
+	}
+	return e.literal(filepath.Clean(path))
+}
+
+var placeholderRE = regexp.MustCompile(`\$\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// literal escapes Terraform template sequences, then turns secret env
+// placeholders back into references to their variables.
+func (e *exporter) literal(value string) string {
+	value = strings.ReplaceAll(value, "${", "$${")
+	value = strings.ReplaceAll(value, "%{", "%%{")
+	return placeholderRE.ReplaceAllStringFunc(value, func(match string) string {
+		env := match[3 : len(match)-1]
+		if variable, ok := e.secrets[env]; ok {
+			return "${var." + variable + "}"
+		}
+		return match
+	})
+}
+
+func (e *exporter) literals(values []string) []string {
+	out := make([]string, len(values))
+	for i, value := range values {
+		out[i] = e.literal(value)
+	}
+	return out
+}
+
+func (e *exporter) warn(service, format string, args ...any) {
+	e.warnings = append(e.warnings, "service "+service+": "+fmt.Sprintf(format, args...))
+}
+
+var invalidIdentifierRE = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// identifier makes a Terraform resource name from a stack or service name.
+func identifier(name string) string {
+	name = invalidIdentifierRE.ReplaceAllString(name, "_")
+	if name == "" || name[0] >= '0' && name[0] <= '9' || name[0] == '-' {
+		name = "_" + name
+	}
+	return name
+}
+
+func sortedKeys[T any](values map[string]T) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,89 @@
+package tfexport
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/fyltr/angee/internal/runtime/compose"
+)
+
+func TestExportRendersDockerResources(t *testing.T) {
+	file := compose.File{
+		Name: "demo",
+		Services: map[string]compose.Service{
+			"db": {
+				Image:       "postgres:16",
+				Environment: map[string]string{"POSTGRES_PASSWORD": "${ANGEE_SECRET_DB_PASSWORD}"},
+				Volumes:     []string{"pgdata:/var/lib/postgresql/data"},
+				Healthcheck: &compose.Healthcheck{Test: []string{"CMD", "pg_isready"}, Interval: "5s", Retries: 3},
+			},
+			"web": {
+				Build:       map[string]any{"context": "src/web", "dockerfile": "Dockerfile"},
+				Command:     []string{"sh", "-c", "echo ${HOME}"},
+				Ports:       []string{"127.0.0.1:8000:8000", "9000/udp"},
+				Volumes:     []string{"./config:/config:ro"},
+				DependsOn:   map[string]compose.ServiceDependency{"db": {Condition: "service_healthy"}},
+				Labels:      map[string]string{"ai.angee.service": "web"},
+				Environment: map[string]string{"DATABASE_URL": "postgres://app:${ANGEE_SECRET_DB_PASSWORD}@db/app"},
+			},
+		},
+		Volumes: map[string]compose.Volume{"pgdata": {Driver: "local"}},
+	}
+	data, warnings, err := Export(file, Options{Root: "/srv/demo", SecretEnvVars: []string{"ANGEE_SECRET_DB_PASSWORD"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Variable map[string]map[string]any `json:"variable"`
+		Resource struct {
+			Network   map[string]map[string]any `json:"docker_network"`
+			Volume    map[string]map[string]any `json:"docker_volume"`
+			Image     map[string]map[string]any `json:"docker_image"`
+			Container map[string]map[string]any `json:"docker_container"`
+		} `json:"resource"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, data)
+	}
+	if doc.Variable["angee_secret_db_password"]["sensitive"] != true {
+		t.Fatalf("secret variable = %#v", doc.Variable)
+	}
+	if doc.Resource.Network["demo"]["name"] != "demo_default" || doc.Resource.Volume["pgdata"]["name"] != "demo_pgdata" {
+		t.Fatalf("network/volume names = %#v %#v", doc.Resource.Network, doc.Resource.Volume)
+	}
+	build := doc.Resource.Image["web"]["build"].([]any)[0].(map[string]any)
+	if build["context"] != "/srv/demo/src/web" || doc.Resource.Image["web"]["name"] != "demo-web" {
+		t.Fatalf("web image = %#v", doc.Resource.Image["web"])
+	}
+
+	text := string(data)
+	for _, want := range []string{
+		`"DATABASE_URL=postgres://app:${var.angee_secret_db_password}@db/app"`,
+		`"POSTGRES_PASSWORD=${var.angee_secret_db_password}"`,
+		`"echo $${HOME}"`,
+		`"volume_name": "${docker_volume.pgdata.name}"`,
+		`"host_path": "/srv/demo/config"`,
+		`"docker_container.db"`,
+		`"protocol": "udp"`,
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("output missing %s:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "ANGEE_SECRET_DB_PASSWORD}") {
+		t.Fatalf("secret placeholder leaked into output:\n%s", text)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "service_healthy") {
+		t.Fatalf("warnings = %#v", warnings)
+	}
+}
+
+func TestExportRejectsPortRanges(t *testing.T) {
+	file := compose.File{Name: "demo", Services: map[string]compose.Service{
+		"web": {Image: "nginx", Ports: []string{"8000-8010:8000-8010"}},
+	}}
+	if _, _, err := Export(file, Options{}); err == nil || !strings.Contains(err.Error(), "single ports") {
+		t.Fatalf("err = %v", err)
+	}
+}
@@ -0,0 +1,206 @@
+// Package upgrade fetches angee release bundles and swaps the running
+// binary for one from them.
+package upgrade
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// DefaultBaseURL is where GitHub serves angee release assets, under
+// v<version>/.
+const DefaultBaseURL = "https://github.com/fyltr/angee/releases/download"
+
+// ReleaseKey is the base64 ed25519 public key that signs each release's
+// SHA256SUMS, set at build time with
+// -X github.com/fyltr/angee/internal/upgrade.ReleaseKey=<key>. A build
+// without it cannot self-upgrade.
+var ReleaseKey string
+
+// maxBundleBytes bounds a download, well above the size of a release
+// bundle.
+const maxBundleBytes = 256 << 20
+
+var versionPattern = regexp.MustCompile(`^v?[0-9]+\.[0-9]+\.[0-9]+(-[0-9A-Za-z.-]+)?$`)
+
+// ValidVersion reports whether version names a release, such as 1.4.0 or
+// v1.4.0-rc.1.
+func ValidVersion(version string) bool {
+	return versionPattern.MatchString(version)
+}
+
+// Fetcher downloads release bundles.
+type Fetcher struct {
+	Client  *http.Client
+	BaseURL string
+	// PublicKey verifies SHA256SUMS.sig; nil uses ReleaseKey.
+	PublicKey ed25519.PublicKey
+}
+
+// Binary downloads the release bundle of version for goos and goarch,
+// checks the ed25519 signature SHA256SUMS.sig over the release's
+// SHA256SUMS, checks the bundle against SHA256SUMS, and returns the binary
+// called name from it. A release without a valid signature is refused.
+func (f Fetcher) Binary(ctx context.Context, version, goos, goarch, name string) ([]byte, error) {
+	if !ValidVersion(version) {
+		return nil, fmt.Errorf("version %q is not a release version such as 1.4.0", version)
+	}
+	if goos == "windows" {
+		return nil, errors.New("self-upgrade is not supported on windows")
+	}
+	version = strings.TrimPrefix(version, "v")
+	bundle := fmt.Sprintf("angee-%s-%s.tar.gz", goos, goarch)
+	key, err := f.publicKey()
+	if err != nil {
+		return nil, err
+	}
+	sums, err := f.get(ctx, version, "SHA256SUMS")
+	if err != nil {
+		return nil, err
+	}
+	sig, err := f.get(ctx, version, "SHA256SUMS.sig")
+	if err != nil {
+		return nil, fmt.Errorf("release %s has no usable SHA256SUMS.sig, so it cannot be verified: %w", version, err)
+	}
+	if !ed25519.Verify(key, sums, sig) {
+		return nil, fmt.Errorf("release %s: SHA256SUMS.sig is not a valid signature of SHA256SUMS by the release key", version)
+	}
+	want, err := checksumFor(sums, bundle)
+	if err != nil {
+		return nil, err
+	}
+	data, err := f.get(ctx, version, bundle)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return nil, fmt.Errorf("%s: sha256 %s does not match SHA256SUMS (%s)", bundle, got, want)
+	}
+	return extract(data, name)
+}
+
+func (f Fetcher) publicKey() (ed25519.PublicKey, error) {
+	if f.PublicKey != nil {
+		return f.PublicKey, nil
+	}
+	if ReleaseKey == "" {
+		return nil, errors.New("this build has no release signing key, so it cannot verify a release; install the new version by hand")
+	}
+	key, err := base64.StdEncoding.DecodeString(ReleaseKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, errors.New("the release signing key built into this binary is not a base64 ed25519 public key")
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+func (f Fetcher) get(ctx context.Context, version, asset string) ([]byte, error) {
+	base := f.BaseURL
+	if base == "" {
+		base = DefaultBaseURL
+	}
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	url := strings.TrimRight(base, "/") + "/v" + version + "/" + asset
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBundleBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxBundleBytes {
+		return nil, fmt.Errorf("GET %s: larger than %d bytes", url, maxBundleBytes)
+	}
+	return data, nil
+}
+
+// checksumFor finds file in sha256sum output.
+func checksumFor(sums []byte, file string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(sums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == file {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("SHA256SUMS has no entry for %s", file)
+}
+
+func extract(bundle []byte, name string) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(bundle))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	archive := tar.NewReader(gz)
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("release bundle has no %s binary", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag == tar.TypeReg && filepath.Base(header.Name) == name {
+			return io.ReadAll(io.LimitReader(archive, maxBundleBytes))
+		}
+	}
+}
+
+// Replace swaps the executable at path for data. The new file is written
+// next to it and renamed over it, so a process starting it sees either
+// the old binary or the new one. The old one stays at path.previous.
+func Replace(path string, data []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	next, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".new-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(next.Name())
+	if _, err := next.Write(data); err != nil {
+		next.Close()
+		return err
+	}
+	if err := next.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(next.Name(), info.Mode().Perm()|0o111); err != nil {
+		return err
+	}
+	previous := path + ".previous"
+	_ = os.Remove(previous)
+	if err := os.Link(path, previous); err != nil {
+		return fmt.Errorf("keep the current binary as %s: %w", previous, err)
+	}
+	return os.Rename(next.Name(), path)
+}
@@ -0,0 +1,139 @@
+package upgrade
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func bundle(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	archive := tar.NewWriter(gz)
+	for name, body := range files {
+		if err := archive.WriteHeader(&tar.Header{Name: name, Mode: 0o755, Size: int64(len(body)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := archive.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// releaseServer serves a release whose SHA256SUMS lists sum and is signed
+// with key; a nil key serves no signature.
+func releaseServer(t *testing.T, data []byte, sum string, key ed25519.PrivateKey) *httptest.Server {
+	t.Helper()
+	sums := []byte(sum + "  angee-linux-amd64.tar.gz\n")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1.4.0/SHA256SUMS":
+			_, _ = w.Write(sums)
+		case "/v1.4.0/SHA256SUMS.sig":
+			if key == nil {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write(ed25519.Sign(key, sums))
+		case "/v1.4.0/angee-linux-amd64.tar.gz":
+			_, _ = w.Write(data)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func releaseKey(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return public, private
+}
+
+func TestFetcherBinaryVerifiesChecksum(t *testing.T) {
+	data := bundle(t, map[string]string{"angee": "cli", "angee-operator": "operator"})
+	sum := sha256.Sum256(data)
+	public, private := releaseKey(t)
+	server := releaseServer(t, data, hex.EncodeToString(sum[:]), private)
+	fetcher := Fetcher{Client: server.Client(), BaseURL: server.URL, PublicKey: public}
+
+	got, err := fetcher.Binary(context.Background(), "v1.4.0", "linux", "amd64", "angee-operator")
+	if err != nil || string(got) != "operator" {
+		t.Fatalf("Binary() = %q, %v", got, err)
+	}
+	if _, err := fetcher.Binary(context.Background(), "1.4.0/../x", "linux", "amd64", "angee"); err == nil {
+		t.Fatal("Binary() accepted a version that is not a release version")
+	}
+
+	tampered := releaseServer(t, data, strings.Repeat("0", 64), private)
+	_, err = Fetcher{Client: tampered.Client(), BaseURL: tampered.URL, PublicKey: public}.Binary(context.Background(), "1.4.0", "linux", "amd64", "angee")
+	if err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Fatalf("Binary(bad checksum) error = %v", err)
+	}
+}
+
+func TestFetcherBinaryRequiresReleaseSignature(t *testing.T) {
+	data := bundle(t, map[string]string{"angee": "cli"})
+	sum := sha256.Sum256(data)
+	public, _ := releaseKey(t)
+	_, other := releaseKey(t)
+
+	forged := releaseServer(t, data, hex.EncodeToString(sum[:]), other)
+	_, err := Fetcher{Client: forged.Client(), BaseURL: forged.URL, PublicKey: public}.Binary(context.Background(), "1.4.0", "linux", "amd64", "angee")
+	if err == nil || !strings.Contains(err.Error(), "not a valid signature") {
+		t.Fatalf("Binary(signed by another key) error = %v", err)
+	}
+
+	unsigned := releaseServer(t, data, hex.EncodeToString(sum[:]), nil)
+	_, err = Fetcher{Client: unsigned.Client(), BaseURL: unsigned.URL, PublicKey: public}.Binary(context.Background(), "1.4.0", "linux", "amd64", "angee")
+	if err == nil || !strings.Contains(err.Error(), "SHA256SUMS.sig") {
+		t.Fatalf("Binary(unsigned) error = %v", err)
+	}
+
+	_, err = Fetcher{Client: unsigned.Client(), BaseURL: unsigned.URL}.Binary(context.Background(), "1.4.0", "linux", "amd64", "angee")
+	if err == nil || !strings.Contains(err.Error(), "no release signing key") {
+		t.Fatalf("Binary(without a release key) error = %v", err)
+	}
+}
+
+func TestReplaceKeepsPreviousBinary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "angee")
+	if err := os.WriteFile(path, []byte("old"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := Replace(path, []byte("new")); err != nil {
+		t.Fatalf("Replace() error = %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new" {
+		t.Fatalf("binary = %q, want new", data)
+	}
+	if data, _ := os.ReadFile(path + ".previous"); string(data) != "old" {
+		t.Fatalf("previous binary = %q, want old", data)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm()&0o111 == 0 {
+		t.Fatalf("binary mode = %v, %v", info.Mode(), err)
+	}
+}